@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+
+	identitypb "github.com/Leganyst/appointment-platform/internal/api/identity/v1"
+	verificationpb "github.com/Leganyst/appointment-platform/internal/api/verification/v1"
+)
+
+// bot — разбирает входящие сообщения Telegram и водит их через
+// ConsumeVerificationCode -> RegisterUser (см. service.VerificationService,
+// service.IdentityService.RegisterUser). Сам бот не хранит состояние: вся
+// сессия умещается в одно сообщение "/start <code>".
+type bot struct {
+	tg           *telegramClient
+	identity     identitypb.IdentityServiceClient
+	verification verificationpb.VerificationServiceClient
+	namespaceID  string
+	logger       *log.Logger
+}
+
+func newBot(tg *telegramClient, identity identitypb.IdentityServiceClient, verification verificationpb.VerificationServiceClient, namespaceID string) *bot {
+	return &bot{tg: tg, identity: identity, verification: verification, namespaceID: namespaceID, logger: log.Default()}
+}
+
+// run — long-polling цикл: забирает апдейты пачками, обрабатывает message,
+// сдвигает offset. Останавливается по отмене ctx.
+func (b *bot) run(ctx context.Context) {
+	var offset int64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		updates, err := b.tg.getUpdates(offset, 30)
+		if err != nil {
+			b.logger.Printf("[IDENTITY-BOT][ERROR] getUpdates: %v", err)
+			continue
+		}
+
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil {
+				continue
+			}
+			b.handleMessage(ctx, u.Message)
+		}
+	}
+}
+
+func (b *bot) handleMessage(ctx context.Context, msg *tgMessage) {
+	text := strings.TrimSpace(msg.Text)
+	if !strings.HasPrefix(text, "/start") {
+		return
+	}
+	code := strings.TrimSpace(strings.TrimPrefix(text, "/start"))
+	if code == "" {
+		b.reply(msg.Chat.ID, "Отправьте код подтверждения: /start <код>")
+		return
+	}
+	if msg.From == nil || msg.From.ID == 0 {
+		b.reply(msg.Chat.ID, "Не удалось определить ваш Telegram ID, попробуйте ещё раз.")
+		return
+	}
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-namespace-id", b.namespaceID)
+
+	consumeResp, err := b.verification.ConsumeVerificationCode(ctx, &verificationpb.ConsumeVerificationCodeRequest{
+		Code:       code,
+		TelegramId: msg.From.ID,
+	})
+	if err != nil {
+		b.logger.Printf("[IDENTITY-BOT][ERROR] ConsumeVerificationCode: %v", err)
+		b.reply(msg.Chat.ID, "Код не найден или уже истёк. Запросите новый код и попробуйте снова.")
+		return
+	}
+
+	displayName := strings.TrimSpace(msg.From.FirstName + " " + msg.From.LastName)
+	_, err = b.identity.RegisterUser(ctx, &identitypb.RegisterUserRequest{
+		TelegramId:        msg.From.ID,
+		DisplayName:       displayName,
+		Username:          msg.From.Username,
+		VerificationToken: consumeResp.GetVerificationToken(),
+	})
+	if err != nil {
+		b.logger.Printf("[IDENTITY-BOT][ERROR] RegisterUser: %v", err)
+		b.reply(msg.Chat.ID, "Код подтверждён, но регистрация не удалась. Напишите в поддержку.")
+		return
+	}
+
+	b.logger.Printf("[IDENTITY-BOT][INFO] registered telegram_id=%d", msg.From.ID)
+	b.reply(msg.Chat.ID, "Готово! Аккаунт подтверждён, можно возвращаться в приложение.")
+}
+
+func (b *bot) reply(chatID int64, text string) {
+	if err := b.tg.sendMessage(chatID, text); err != nil {
+		b.logger.Printf("[IDENTITY-BOT][ERROR] sendMessage chat_id=%s: %v", strconv.FormatInt(chatID, 10), err)
+	}
+}
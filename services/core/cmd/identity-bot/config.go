@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Config — параметры процесса identity-bot: куда стучаться (Telegram Bot API,
+// gRPC core), какой арендатор использовать для x-namespace-id (см.
+// middleware.TenantUnaryInterceptor — бот не умеет резолвить арендатора
+// по чату/группе, поэтому у него один фиксированный namespace на процесс).
+type Config struct {
+	BotToken     string
+	CoreGRPCAddr string
+	NamespaceID  string
+}
+
+func LoadConfig() (*Config, error) {
+	cfg := &Config{
+		BotToken:     getEnv("TELEGRAM_BOT_TOKEN", ""),
+		CoreGRPCAddr: getEnv("CORE_GRPC_ADDR", "localhost:50051"),
+		NamespaceID:  getEnv("IDENTITY_BOT_NAMESPACE_ID", ""),
+	}
+	if cfg.BotToken == "" {
+		return nil, fmt.Errorf("invalid identity-bot config: TELEGRAM_BOT_TOKEN must not be empty")
+	}
+	if cfg.NamespaceID == "" {
+		return nil, fmt.Errorf("invalid identity-bot config: IDENTITY_BOT_NAMESPACE_ID must not be empty")
+	}
+	return cfg, nil
+}
+
+func getEnv(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,49 @@
+// Command identity-bot — отдельный процесс, не связанный с сервером core
+// (см. ../main.go): Telegram-бот, который принимает "/start <код>",
+// подтверждает его через VerificationService.ConsumeVerificationCode и
+// завершает регистрацию через IdentityService.RegisterUser (см.
+// internal/service/verification_service.go, internal/verification). Живёт
+// в своей директории, а не под тем же build tag'ом, что main_migrate.go,
+// потому что у него совсем другой набор зависимостей (long-polling HTTP к
+// Telegram, gRPC-клиент к core) и нет ничего общего с БД/gRPC-сервером.
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	identitypb "github.com/Leganyst/appointment-platform/internal/api/identity/v1"
+	verificationpb "github.com/Leganyst/appointment-platform/internal/api/verification/v1"
+)
+
+func main() {
+	cfg, err := LoadConfig()
+	if err != nil {
+		log.Fatalf("[IDENTITY-BOT][FATAL] %v", err)
+	}
+
+	conn, err := grpc.NewClient(cfg.CoreGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("[IDENTITY-BOT][FATAL] dial core grpc %s: %v", cfg.CoreGRPCAddr, err)
+	}
+	defer conn.Close()
+
+	b := newBot(
+		newTelegramClient(cfg.BotToken),
+		identitypb.NewIdentityServiceClient(conn),
+		verificationpb.NewVerificationServiceClient(conn),
+		cfg.NamespaceID,
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Println("[IDENTITY-BOT][INFO] started long-polling")
+	b.run(ctx)
+	log.Println("[IDENTITY-BOT][INFO] stopped")
+}
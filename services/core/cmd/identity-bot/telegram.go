@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// telegramClient — минимальный клиент к Telegram Bot API: только то, что
+// нужно identity-bot (long-polling getUpdates + sendMessage). Полноценная
+// SDK-обёртка здесь избыточна — бот понимает ровно одну команду.
+type telegramClient struct {
+	token      string
+	httpClient *http.Client
+}
+
+func newTelegramClient(token string) *telegramClient {
+	return &telegramClient{
+		token:      token,
+		httpClient: &http.Client{Timeout: 35 * time.Second},
+	}
+}
+
+func (c *telegramClient) apiURL(method string) string {
+	return fmt.Sprintf("https://api.telegram.org/bot%s/%s", c.token, method)
+}
+
+type tgUpdate struct {
+	UpdateID int64      `json:"update_id"`
+	Message  *tgMessage `json:"message"`
+}
+
+type tgMessage struct {
+	MessageID int64   `json:"message_id"`
+	Text      string  `json:"text"`
+	From      *tgUser `json:"from"`
+	Chat      tgChat  `json:"chat"`
+}
+
+type tgUser struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+type tgChat struct {
+	ID int64 `json:"id"`
+}
+
+type tgResponse[T any] struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+	Result      T      `json:"result"`
+}
+
+// getUpdates делает один long-polling запрос getUpdates с таймаутом timeout
+// секунд, начиная с offset (см. Telegram Bot API: "offset" — update_id+1
+// последнего обработанного апдейта).
+func (c *telegramClient) getUpdates(offset int64, timeout int) ([]tgUpdate, error) {
+	q := url.Values{}
+	q.Set("timeout", fmt.Sprintf("%d", timeout))
+	q.Set("offset", fmt.Sprintf("%d", offset))
+	q.Set("allowed_updates", `["message"]`)
+
+	resp, err := c.httpClient.Get(c.apiURL("getUpdates") + "?" + q.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("getUpdates: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out tgResponse[[]tgUpdate]
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("getUpdates: decode response: %w", err)
+	}
+	if !out.OK {
+		return nil, fmt.Errorf("getUpdates: %s", out.Description)
+	}
+	return out.Result, nil
+}
+
+func (c *telegramClient) sendMessage(chatID int64, text string) error {
+	body, err := json.Marshal(map[string]any{
+		"chat_id": chatID,
+		"text":    text,
+	})
+	if err != nil {
+		return fmt.Errorf("sendMessage: marshal body: %w", err)
+	}
+
+	resp, err := c.httpClient.Post(c.apiURL("sendMessage"), "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("sendMessage: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out tgResponse[json.RawMessage]
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return fmt.Errorf("sendMessage: decode response: %w", err)
+	}
+	if !out.OK {
+		return fmt.Errorf("sendMessage: %s", out.Description)
+	}
+	return nil
+}
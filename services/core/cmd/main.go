@@ -1,21 +1,37 @@
+//go:build !migration
+
 package main
 
 import (
+	"context"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 
+	authorizationpb "github.com/Leganyst/appointment-platform/internal/api/authorization/v1"
 	calendarpb "github.com/Leganyst/appointment-platform/internal/api/calendar/v1"
 	identitypb "github.com/Leganyst/appointment-platform/internal/api/identity/v1"
+	verificationpb "github.com/Leganyst/appointment-platform/internal/api/verification/v1"
 	"github.com/Leganyst/appointment-platform/internal/config"
 	"github.com/Leganyst/appointment-platform/internal/db"
+	"github.com/Leganyst/appointment-platform/internal/events"
+	"github.com/Leganyst/appointment-platform/internal/icalendar"
+	"github.com/Leganyst/appointment-platform/internal/integration/caldav"
+	"github.com/Leganyst/appointment-platform/internal/middleware"
+	appmigrate "github.com/Leganyst/appointment-platform/internal/migrate"
 	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/notify"
+	"github.com/Leganyst/appointment-platform/internal/outbox"
 	"github.com/Leganyst/appointment-platform/internal/repository"
+	"github.com/Leganyst/appointment-platform/internal/scheduler"
 	"github.com/Leganyst/appointment-platform/internal/service"
 )
 
@@ -31,9 +47,26 @@ func main() {
 	if err != nil {
 		log.Fatalf("init db: %v", err)
 	}
+	if err := db.Ping(gormDB); err != nil {
+		log.Fatalf("db ping: %v", err)
+	}
 
-	// 3. Миграции моделей.
-	if err := model.AutoMigrate(gormDB); err != nil {
+	// 3. Версия схемы. На Postgres (прод) схему накатывает отдельный
+	// бинарь (см. cmd -tags migration, main_migrate.go) — сервер только
+	// сверяет версию и отказывается стартовать при расхождении, а не
+	// докатывает её молча через AutoMigrate (см. internal/migrate). На
+	// MySQL/SQLite (тесты, небольшие self-hosted развёртывания, см.
+	// model.Dialect) отдельных SQL-миграций нет, поэтому там по-прежнему
+	// используется AutoMigrate.
+	if dbCfg.Driver == config.DriverPostgres || dbCfg.Driver == "" {
+		m, err := appmigrate.New(gormDB)
+		if err != nil {
+			log.Fatalf("init migrate: %v", err)
+		}
+		if err := appmigrate.VerifyVersion(m); err != nil {
+			log.Fatalf("schema check: %v", err)
+		}
+	} else if err := model.AutoMigrate(gormDB); err != nil {
 		log.Fatalf("auto migrate: %v", err)
 	}
 
@@ -51,15 +84,69 @@ func main() {
 	clientRepo := repository.NewGormClientRepository(gormDB)
 	providerRepo := repository.NewGormProviderRepository(gormDB)
 	serviceRepo := repository.NewGormServiceRepository(gormDB)
+	maintenanceRepo := repository.NewGormMaintenanceRepository(gormDB)
+	namespaceRepo := repository.NewGormNamespaceRepository(gormDB)
+	permissionRepo := repository.NewGormPermissionRepository(gormDB)
+	calendarIntegrationRepo := repository.NewGormCalendarIntegrationRepository(gormDB)
+	waitlistRepo := repository.NewGormWaitlistRepository(gormDB)
+	resourceRepo := repository.NewGormResourceRepository(gormDB)
+	notificationOutboxRepo := repository.NewGormNotificationOutboxRepository(gormDB)
+	unavailabilityRepo := repository.NewGormUnavailabilityRepository(gormDB)
+	scheduleTemplateRepo := repository.NewGormScheduleTemplateRepository(gormDB)
+	blackoutRepo := repository.NewGormBlackoutRepository(gormDB)
+	eventOutboxRepo := repository.NewGormEventOutboxRepository(gormDB)
+	verificationCodeRepo := repository.NewGormVerificationCodeRepository(gormDB)
+	roleRepo := repository.NewGormRoleRepository(gormDB)
+	identityEventRepo := repository.NewGormIdentityEventRepository(gormDB)
+	calendarFeedTokenRepo := repository.NewGormCalendarFeedTokenRepository(gormDB)
+
+	// 4.1. Сидируем базовые права домена записи на приём и группы, к которым
+	// они привязаны (см. repository.SeedBaselinePermissions). Идемпотентно,
+	// поэтому безопасно на каждом старте.
+	if err := repository.SeedBaselinePermissions(context.Background(), permissionRepo, userRepo); err != nil {
+		log.Fatalf("seed baseline permissions: %v", err)
+	}
+
+	// 4.2. Фоновый материализатор слотов и очередь его перестроек — нужны
+	// calendarSvc (см. CalendarService.UpdateProviderSchedule,
+	// RebuildProviderCalendar), поэтому собираются до него. Сами воркеры
+	// очереди и скользящего горизонта запускаются позже, в 7.2.
+	schedulerCfg := config.LoadSchedulerConfig()
+	materializationStateRepo := repository.NewGormScheduleMaterializationStateRepository(gormDB)
+	materializer := scheduler.NewSlotMaterializer(gormDB, scheduleRepo, maintenanceRepo, unavailabilityRepo, blackoutRepo, materializationStateRepo, scheduler.Config{
+		Horizon:   schedulerCfg.Horizon,
+		RunEvery:  schedulerCfg.RunEvery,
+		BatchSize: schedulerCfg.BatchSize,
+	})
+	materializationQueue := scheduler.NewMaterializationQueue(materializer, schedulerCfg.QueueWorkers)
+	workerCtx, cancelWorker := context.WithCancel(context.Background())
 
 	// 5. gRPC-сервис календаря.
-	calendarSvc := service.NewCalendarService(gormDB, slotRepo, bookingRepo, scheduleRepo, providerRepo, serviceRepo, userRepo)
-	identitySvc := service.NewIdentityService(userRepo, clientRepo, providerRepo)
+	// bookingEventBus — живой push-стрим бронирований/слотов для
+	// CalendarService.SubscribeBookingEvents (см. cmd/main.go userEventBus
+	// ниже — тот же generic Bus, другой ключ).
+	bookingEventBus := events.NewBus[string, events.BookingEvent](events.Config{})
+	calendarSvc := service.NewCalendarService(gormDB, slotRepo, bookingRepo, scheduleRepo, providerRepo, serviceRepo, userRepo, maintenanceRepo, calendarIntegrationRepo, waitlistRepo, resourceRepo, unavailabilityRepo, scheduleTemplateRepo, blackoutRepo, materializationQueueAdapter{materializationQueue}, permissionRepo, calendarFeedTokenRepo, bookingEventBus)
+	identityCfg := config.LoadIdentityConfig()
+	// userEventBus разносит события профиля (см. model.IdentityEvent) между
+	// identity-bot и фронтендами, подписанными на SubscribeUserEvents.
+	userEventBus := events.NewBus[int64, events.IdentityEvent](events.Config{})
+	identitySvc := service.NewIdentityService(userRepo, clientRepo, providerRepo, roleRepo, identityEventRepo, userEventBus, *identityCfg)
+	verificationSvc := service.NewVerificationService(verificationCodeRepo, service.VerificationServiceConfig{
+		TokenSecret: []byte(identityCfg.VerificationTokenSecret),
+	})
+	authorizationSvc := service.NewAuthorizationService(roleRepo, permissionRepo)
 
-	// 6. Настраиваем gRPC-сервер.
-	grpcServer := grpc.NewServer()
+	// 6. Настраиваем gRPC-сервер. Унарный интерцептор резолвит арендатора из
+	// метаданных запроса (см. internal/middleware) до того, как вызов дойдёт
+	// до namespace-скоупнутых репозиториев.
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(middleware.TenantUnaryInterceptor(namespaceRepo)),
+	)
 	calendarpb.RegisterCalendarServiceServer(grpcServer, calendarSvc)
 	identitypb.RegisterIdentityServiceServer(grpcServer, identitySvc)
+	verificationpb.RegisterVerificationServiceServer(grpcServer, verificationSvc)
+	authorizationpb.RegisterAuthorizationServiceServer(grpcServer, authorizationSvc)
 	reflection.Register(grpcServer)
 
 	addr := ":50051" // можно вынести в env, например CORE_GRPC_ADDR
@@ -77,11 +164,116 @@ func main() {
 		}
 	}()
 
+	// 7.1. HTTP-сервер для iCalendar-фидов провайдеров (/providers/{id}/calendar.ics).
+	icsCfg := config.LoadICSConfig()
+	icsHandler := icalendar.NewHandler([]byte(icsCfg.SigningSecret), providerRepo, slotRepo, bookingRepo, scheduleRepo, maintenanceRepo, calendarFeedTokenRepo)
+	outboxReplayHandler := scheduler.NewOutboxReplayHandler(providerRepo, notificationOutboxRepo)
+	eventOutboxAdminHandler := outbox.NewAdminHandler(eventOutboxRepo)
+	httpMux := http.NewServeMux()
+	httpMux.Handle("/providers/", icsHandler)
+	httpMux.Handle("/ops/outbox/replay", outboxReplayHandler)
+	httpMux.Handle("/admin/outbox", eventOutboxAdminHandler)
+	httpServer := &http.Server{Addr: icsCfg.Addr, Handler: httpMux}
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("ics http serve: %v", err)
+		}
+	}()
+
+	// 7.2. Запускаем воркеров материализации: периодический прогон по
+	// скользящему горизонту (materializer, собран в 4.2) и воркеров очереди
+	// точечных/форсированных перестроек (materializationQueue).
+	go materializer.Run(workerCtx)
+	materializationQueue.Start(workerCtx)
+
+	// 7.3. Фоновый реконсилятор CalDAV-интеграций (двусторонняя синхронизация
+	// с внешними календарями провайдеров, см. internal/integration/caldav).
+	caldavReconciler := caldav.NewReconciler(gormDB, calendarIntegrationRepo, bookingRepo, caldav.Config{})
+	go caldavReconciler.Run(workerCtx)
+
+	// 7.4. Фоновый промоутер листа ожидания: подстраховывает best-effort
+	// попытки продвижения из CancelBooking/материализации слотов (см.
+	// service.CalendarService.PromoteWaitlistForProvider) и истекает
+	// просроченные по ExpiresAt записи.
+	waitlistPromoter := scheduler.NewWaitlistPromoter(gormDB, waitlistRepo, calendarSvc, scheduler.WaitlistPromoterConfig{})
+	go waitlistPromoter.Run(workerCtx)
+
+	// 7.5. Фоновый планировщик обслуживания: отслеживает вход MaintenanceWindow
+	// провайдеров (в т.ч. повторяющихся по RRule) в активную фазу и отменяет
+	// затронутые слоты/бронирования через CalendarService.CancelSlotsForMaintenanceWindow
+	// (см. internal/scheduler/maintenance_scheduler.go).
+	maintenanceTriggerStateRepo := repository.NewGormMaintenanceTriggerStateRepository(gormDB)
+	maintenanceScheduler := scheduler.NewMaintenanceScheduler(gormDB, maintenanceRepo, maintenanceTriggerStateRepo, calendarSvc, scheduler.MaintenanceSchedulerConfig{})
+	go maintenanceScheduler.Run(workerCtx)
+
+	// 7.6. Фоновый диспетчер notifications_outbox: доставляет события,
+	// записанные CalendarService в той же транзакции, что отменяет/
+	// подтверждает бронирования (см. internal/scheduler/outbox_dispatcher.go),
+	// так что латентность доставки не блокирует RPC. Пока Telegram-транспорта
+	// уведомлений нет, нотификатор — notify.LogNotifier (см. internal/notify).
+	outboxDispatcher := scheduler.NewOutboxDispatcher(gormDB, notificationOutboxRepo, notify.NewLogNotifier(nil), scheduler.OutboxDispatcherConfig{})
+	go outboxDispatcher.Run(workerCtx)
+
+	// 7.7. Фоновый диспетчер events-outbox: публикует аудит-события
+	// (бронирования, блэкауты и т.п.), записанные в той же транзакции, что
+	// бизнес-изменение (см. service.writeAuditEvent), во внешнюю систему
+	// через internal/outbox.Publisher. Транспорт выбирается через
+	// EVENT_OUTBOX_TRANSPORT; по умолчанию — outbox.LogPublisher, пока
+	// подписчика нет.
+	eventOutboxCfg := config.LoadEventOutboxConfig()
+	eventPublisher := newEventPublisher(eventOutboxCfg)
+	eventOutboxDispatcher := outbox.NewDispatcher(eventOutboxRepo, eventPublisher, outbox.Config{})
+	go eventOutboxDispatcher.Run(workerCtx)
+
+	// 7.8. Фоновый сборщик мусора PIN-кодов верификации Telegram-аккаунта
+	// (см. service.VerificationService, internal/scheduler/verification_sweeper.go).
+	verificationSweeper := scheduler.NewVerificationSweeper(gormDB, verificationCodeRepo, scheduler.VerificationSweeperConfig{})
+	go verificationSweeper.Run(workerCtx)
+
+	// 7.9. Фоновый сборщик мусора журнала push-событий профиля (см.
+	// model.IdentityEvent, IdentityService.SubscribeUserEvents).
+	identityEventSweeper := scheduler.NewIdentityEventSweeper(gormDB, identityEventRepo, scheduler.IdentityEventSweeperConfig{})
+	go identityEventSweeper.Run(workerCtx)
+
 	// 8. Грейсфул-шатдаун по сигналу.
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
 	log.Println("shutting down gRPC server...")
+	cancelWorker()
 	grpcServer.GracefulStop()
+	_ = httpServer.Close()
+}
+
+// newEventPublisher выбирает реализацию outbox.Publisher по
+// cfg.Transport. Неизвестный транспорт трактуется как "log", чтобы опечатка
+// в EVENT_OUTBOX_TRANSPORT не роняла старт сервера.
+func newEventPublisher(cfg *config.EventOutboxConfig) outbox.Publisher {
+	switch cfg.Transport {
+	case "webhook":
+		return outbox.NewHTTPWebhookPublisher(cfg.WebhookURL, []byte(cfg.WebhookSecret), nil)
+	case "kafka":
+		return outbox.NewKafkaPublisher(cfg.KafkaBrokers, cfg.KafkaTopic)
+	default:
+		return &outbox.LogPublisher{Logger: log.Default()}
+	}
+}
+
+// materializationQueueAdapter подгоняет *scheduler.MaterializationQueue под
+// service.ScheduleMaterializationQueue. Нужен только здесь: service не может
+// импортировать internal/scheduler напрямую (scheduler уже зависит от
+// service), поэтому интерфейс объявлен в service, а связывает их cmd/main.go,
+// единственное место, которому позволено видеть оба пакета.
+type materializationQueueAdapter struct {
+	q *scheduler.MaterializationQueue
+}
+
+func (a materializationQueueAdapter) EnqueueSchedule(namespaceID, providerID, scheduleID uuid.UUID) {
+	a.q.EnqueueSchedule(namespaceID, providerID, scheduleID)
+}
+
+func (a materializationQueueAdapter) RebuildNow(ctx context.Context, namespaceID, providerID uuid.UUID, from, to time.Time) (int, int, int, error) {
+	summary, err := a.q.RebuildNow(ctx, namespaceID, providerID, from, to)
+	return summary.Created, summary.Updated, summary.Deleted, err
 }
@@ -0,0 +1,116 @@
+//go:build migration
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/Leganyst/appointment-platform/internal/config"
+	"github.com/Leganyst/appointment-platform/internal/db"
+	appmigrate "github.com/Leganyst/appointment-platform/internal/migrate"
+)
+
+// main — отдельный билд (`go build -tags migration`), который заменяет
+// обычный сервер (см. main.go, //go:build !migration). Единственная задача
+// этого бинаря — управлять версией схемы Postgres (см. internal/migrate),
+// поэтому он не поднимает ни gRPC, ни HTTP, ни воркер материализации.
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "up":
+		runUp()
+	case "down":
+		runDown(os.Args[2:])
+	case "status":
+		runStatus()
+	case "create":
+		runCreate(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate <up|down --force N|status|create NAME>")
+}
+
+func runUp() {
+	m := openMigrate()
+	if err := appmigrate.Up(m); err != nil {
+		log.Fatalf("migrate up: %v", err)
+	}
+	log.Println("migrate up: ok")
+}
+
+func runDown(args []string) {
+	fs := flag.NewFlagSet("down", flag.ExitOnError)
+	force := fs.Bool("force", false, "confirm a destructive down migration")
+	if err := fs.Parse(args); err != nil {
+		log.Fatalf("parse flags: %v", err)
+	}
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		usage()
+		os.Exit(2)
+	}
+	n, err := strconv.Atoi(rest[0])
+	if err != nil || n <= 0 {
+		log.Fatalf("down: N must be a positive integer, got %q", rest[0])
+	}
+	if !*force {
+		log.Fatal("down is destructive — rerun with --force to confirm")
+	}
+
+	m := openMigrate()
+	if err := appmigrate.Down(m, n); err != nil {
+		log.Fatalf("migrate down %d: %v", n, err)
+	}
+	log.Printf("migrate down %d: ok", n)
+}
+
+func runStatus() {
+	m := openMigrate()
+	version, dirty, err := appmigrate.Status(m)
+	if err != nil {
+		log.Fatalf("migrate status: %v", err)
+	}
+	fmt.Printf("version=%d dirty=%t\n", version, dirty)
+}
+
+func runCreate(args []string) {
+	if len(args) != 1 {
+		usage()
+		os.Exit(2)
+	}
+	up, down, err := appmigrate.Create("internal/migrate/migrations", args[0])
+	if err != nil {
+		log.Fatalf("migrate create: %v", err)
+	}
+	fmt.Printf("created %s\ncreated %s\n", up, down)
+}
+
+func openMigrate() *appmigrate.Migrate {
+	dbCfg, err := config.LoadDBConfig()
+	if err != nil {
+		log.Fatalf("load db config: %v", err)
+	}
+	gormDB, err := db.NewGormDB(dbCfg)
+	if err != nil {
+		log.Fatalf("init db: %v", err)
+	}
+	m, err := appmigrate.New(gormDB)
+	if err != nil {
+		log.Fatalf("init migrate: %v", err)
+	}
+	return m
+}
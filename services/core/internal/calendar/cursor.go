@@ -0,0 +1,56 @@
+package calendar
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Cursor — курсор keyset-пагинации по (starts_at, id): опорная точка, после
+// которой начинается следующая страница. В отличие от Paginate/Page (которые
+// режут уже загруженный в память срез по номеру страницы), Cursor кодирует
+// позицию в отсортированной по (starts_at, id) выборке и передаётся в
+// repository-запрос как есть — без OFFSET и без предварительной загрузки
+// всех строк.
+type Cursor struct {
+	StartsAt time.Time `json:"starts_at"`
+	ID       string    `json:"id"`
+}
+
+// Encode сериализует курсор в непрозрачный токен для передачи клиенту
+// (page_token в ListProviderSlotsRequest) — клиент не должен ни парсить, ни
+// формировать его вручную.
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor разбирает токен, полученный от клиента. Пустой token — не
+// ошибка, а признак первой страницы (возвращает nil, nil).
+func DecodeCursor(token string) (*Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor: %w", err)
+	}
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("decode cursor: %w", err)
+	}
+	return &c, nil
+}
+
+// KeysetPage — результат keyset-пагинации. NextCursor пуст, если выборка
+// вернула меньше элементов, чем запрошенный limit (страница последняя).
+// PrevCursor — курсор, с которым была запрошена текущая страница (т.е.
+// просто echo входного токена): этого достаточно, чтобы клиент мог
+// вернуться на предыдущую страницу, не реализуя отдельный обратный запрос
+// "WHERE (starts_at, id) < (?, ?) ORDER BY ... DESC".
+type KeysetPage[T any] struct {
+	Items      []T
+	NextCursor string
+	PrevCursor string
+}
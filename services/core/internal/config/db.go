@@ -6,7 +6,28 @@ import (
 	"strconv"
 )
 
+// Driver — поддерживаемые бэкенды БД (см. internal/db.NewGormDB и
+// model.Dialect). Postgres — основной бэкенд продакшена (RLS, advisory locks);
+// MySQL/SQLite годятся для тестов и небольших self-hosted развёртываний, но
+// без этих postgres-специфичных гарантий (см. model.EnableRowLevelSecurity).
+type Driver string
+
+const (
+	DriverPostgres Driver = "postgres"
+	DriverMySQL    Driver = "mysql"
+	DriverSQLite   Driver = "sqlite"
+)
+
 type DBConfig struct {
+	Driver Driver
+
+	// DSN для SQLite — это и есть единственный способ подключения (путь к
+	// файлу БД или ":memory:"). Для Postgres/MySQL он опционален: если
+	// задан, используется как есть вместо DSN, собранного из Host/Port/
+	// User/..., — удобно для интеграционных тестов и нестандартных
+	// параметров подключения.
+	DSN string
+
 	Host            string
 	Port            int
 	User            string
@@ -20,7 +41,16 @@ type DBConfig struct {
 }
 
 func LoadDBConfig() (*DBConfig, error) {
+	driver := Driver(getEnv("DB_DRIVER", string(DriverPostgres)))
+
+	dsnDefault := ""
+	if driver == DriverSQLite {
+		dsnDefault = "booking.db"
+	}
+
 	cfg := &DBConfig{
+		Driver:          driver,
+		DSN:             getEnv("DB_DSN", dsnDefault),
 		Host:            getEnv("DB_HOST", "postgres"),
 		User:            getEnv("DB_USER", "booking"),
 		Password:        getEnv("DB_PASSWORD", "booking"),
@@ -33,9 +63,18 @@ func LoadDBConfig() (*DBConfig, error) {
 		ConnMaxLifeTime: getEnvInt("DB_CONN_MAX_LIFETIME_MIN", 30),
 	}
 
-	// минимальная валидация
-	if cfg.Host == "" || cfg.User == "" || cfg.Name == "" {
-		return nil, fmt.Errorf("invalid DB config: host/user/name must not be empty")
+	switch cfg.Driver {
+	case DriverPostgres, DriverMySQL:
+		// минимальная валидация
+		if cfg.Host == "" || cfg.User == "" || cfg.Name == "" {
+			return nil, fmt.Errorf("invalid DB config: host/user/name must not be empty")
+		}
+	case DriverSQLite:
+		if cfg.DSN == "" {
+			return nil, fmt.Errorf("invalid DB config: dsn must not be empty for sqlite")
+		}
+	default:
+		return nil, fmt.Errorf("invalid DB config: unsupported driver %q", cfg.Driver)
 	}
 
 	return cfg, nil
@@ -0,0 +1,42 @@
+package config
+
+import "strings"
+
+// EventOutboxConfig — параметры internal/outbox.Dispatcher и его Publisher.
+// Publisher выбирается по Transport: "log" (по умолчанию, см.
+// outbox.LogPublisher), "webhook" (outbox.HTTPWebhookPublisher) или "kafka"
+// (outbox.KafkaPublisher) — сборка конкретной реализации остаётся за
+// cmd/main.go, конфиг только описывает, что выбрано и с какими параметрами.
+type EventOutboxConfig struct {
+	Transport     string // "log" | "webhook" | "kafka"
+	WebhookURL    string
+	WebhookSecret string
+	KafkaBrokers  []string
+	KafkaTopic    string
+}
+
+func LoadEventOutboxConfig() *EventOutboxConfig {
+	return &EventOutboxConfig{
+		Transport:     getEnv("EVENT_OUTBOX_TRANSPORT", "log"),
+		WebhookURL:    getEnv("EVENT_OUTBOX_WEBHOOK_URL", ""),
+		WebhookSecret: getEnv("EVENT_OUTBOX_WEBHOOK_SECRET", "dev-insecure-secret"),
+		KafkaBrokers:  splitEnvList(getEnv("EVENT_OUTBOX_KAFKA_BROKERS", "")),
+		KafkaTopic:    getEnv("EVENT_OUTBOX_KAFKA_TOPIC", "appointment-platform.events"),
+	}
+}
+
+// splitEnvList разбирает список брокеров вида "host1:9092,host2:9092".
+func splitEnvList(v string) []string {
+	if v == "" {
+		return nil
+	}
+	parts := strings.Split(v, ",")
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
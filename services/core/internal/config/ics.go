@@ -0,0 +1,13 @@
+package config
+
+type ICSConfig struct {
+	Addr          string // адрес HTTP-сервера для .ics фидов, например ":8081"
+	SigningSecret string // секрет для подписи токенов доступа к фиду провайдера
+}
+
+func LoadICSConfig() *ICSConfig {
+	return &ICSConfig{
+		Addr:          getEnv("ICS_HTTP_ADDR", ":8081"),
+		SigningSecret: getEnv("ICS_SIGNING_SECRET", "dev-insecure-secret"),
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+// IdentityConfig — параметры IdentityService, касающиеся проверки владения
+// Telegram-аккаунтом перед регистрацией (см. service.VerificationService,
+// cmd/identity-bot).
+type IdentityConfig struct {
+	// RequireVerification — в проде true: RegisterUser отклоняет запросы
+	// без валидного verification_token (см. verification.VerifyToken),
+	// потому что иначе фронтенд может подставить чужой telegram_id.
+	RequireVerification bool
+	// VerificationTokenSecret — тот же секрет, которым VerificationService
+	// подписывает verification_token в ConsumeVerificationCode.
+	VerificationTokenSecret string
+}
+
+func LoadIdentityConfig() *IdentityConfig {
+	return &IdentityConfig{
+		RequireVerification:     getEnv("IDENTITY_REQUIRE_VERIFICATION", "false") == "true",
+		VerificationTokenSecret: getEnv("IDENTITY_VERIFICATION_TOKEN_SECRET", "dev-insecure-secret"),
+	}
+}
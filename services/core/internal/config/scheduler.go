@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+// SchedulerConfig — параметры фонового воркера материализации слотов
+// (internal/scheduler.SlotMaterializer).
+type SchedulerConfig struct {
+	Horizon   time.Duration
+	RunEvery  time.Duration
+	BatchSize int
+	// QueueWorkers — число воркеров internal/scheduler.MaterializationQueue,
+	// разбирающих очередь перестроек по провайдерам (см. Enqueue/EnqueueSchedule).
+	QueueWorkers int
+}
+
+func LoadSchedulerConfig() *SchedulerConfig {
+	return &SchedulerConfig{
+		Horizon:      time.Duration(getEnvInt("SCHEDULER_HORIZON_HOURS", 24*30)) * time.Hour,
+		RunEvery:     time.Duration(getEnvInt("SCHEDULER_RUN_EVERY_MIN", 60)) * time.Minute,
+		BatchSize:    getEnvInt("SCHEDULER_BATCH_SIZE", 100),
+		QueueWorkers: getEnvInt("SCHEDULER_QUEUE_WORKERS", 4),
+	}
+}
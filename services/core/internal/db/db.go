@@ -4,24 +4,25 @@ import (
 	"fmt"
 	"time"
 
+	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 
 	"github.com/Leganyst/appointment-platform/internal/config"
+	"github.com/Leganyst/appointment-platform/internal/model"
 )
 
+// NewGormDB открывает соединение с БД под драйвером из cfg.Driver (Postgres —
+// основной бэкенд продакшена, MySQL/SQLite — для тестов и небольших
+// self-hosted развёртываний, см. model.Dialect). DSN собирается здесь же,
+// отдельно под каждый драйвер — у них разный формат строки подключения.
 func NewGormDB(cfg *config.DBConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
-		cfg.Host,
-		cfg.User,
-		cfg.Password,
-		cfg.Name,
-		cfg.Port,
-		cfg.SSLMode,
-		cfg.TimeZone,
-	)
+	dialector, dialect, err := openDialector(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	gormCfg := &gorm.Config{
 		Logger: gormlogger.Default.LogMode(gormlogger.Warn),
@@ -31,11 +32,24 @@ func NewGormDB(cfg *config.DBConfig) (*gorm.DB, error) {
 		},
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), gormCfg)
+	db, err := gorm.Open(dialector, gormCfg)
 	if err != nil {
 		return nil, fmt.Errorf("gorm open: %w", err)
 	}
 
+	// Диалект выставляем сразу после открытия, до любых миграций/запросов:
+	// от него зависит, включать ли Postgres RLS (model.EnableRowLevelSecurity)
+	// и нужно ли прокидывать app.namespace_id (см. tenant.go).
+	model.SetDialect(dialect)
+
+	// Многоарендность: прокидываем namespace_id из context.Context в Postgres
+	// через session-переменную app.namespace_id для RLS-политик (см.
+	// internal/model.EnableRowLevelSecurity и internal/namespace). На
+	// MySQL/SQLite плагин — no-op (см. tenant.go).
+	if err := db.Use(tenantScopePlugin{}); err != nil {
+		return nil, fmt.Errorf("register tenant scope plugin: %w", err)
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("db.DB(): %w", err)
@@ -53,3 +67,58 @@ func NewGormDB(cfg *config.DBConfig) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// openDialector собирает DSN и возвращает gorm.Dialector под выбранный в
+// конфиге драйвер, вместе с соответствующим model.Dialect.
+func openDialector(cfg *config.DBConfig) (gorm.Dialector, model.Dialect, error) {
+	switch cfg.Driver {
+	case config.DriverPostgres, "":
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = fmt.Sprintf(
+				"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+				cfg.Host,
+				cfg.User,
+				cfg.Password,
+				cfg.Name,
+				cfg.Port,
+				cfg.SSLMode,
+				cfg.TimeZone,
+			)
+		}
+		return postgres.Open(dsn), model.DialectPostgres, nil
+
+	case config.DriverMySQL:
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = fmt.Sprintf(
+				"%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+				cfg.User,
+				cfg.Password,
+				cfg.Host,
+				cfg.Port,
+				cfg.Name,
+			)
+		}
+		return mysql.Open(dsn), model.DialectMySQL, nil
+
+	case config.DriverSQLite:
+		return sqlite.Open(cfg.DSN), model.DialectSQLite, nil
+
+	default:
+		return nil, "", fmt.Errorf("unsupported db driver %q", cfg.Driver)
+	}
+}
+
+// Ping проверяет живость соединения — используется health-чеками (readiness
+// пробы в оркестраторе и т.п.).
+func Ping(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("db.DB(): %w", err)
+	}
+	if err := sqlDB.Ping(); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+	return nil
+}
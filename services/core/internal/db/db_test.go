@@ -0,0 +1,74 @@
+package db
+
+import (
+	"os"
+	"testing"
+
+	"github.com/Leganyst/appointment-platform/internal/config"
+	"github.com/Leganyst/appointment-platform/internal/model"
+)
+
+// TestNewGormDB_SQLite проверяет, что NewGormDB + model.AutoMigrate проходят
+// на SQLite (драйвер для тестов/небольших self-hosted развёртываний, см.
+// model.Dialect) без поднятого Postgres.
+func TestNewGormDB_SQLite(t *testing.T) {
+	cfg := &config.DBConfig{
+		Driver: config.DriverSQLite,
+		DSN:    ":memory:",
+	}
+
+	gdb, err := NewGormDB(cfg)
+	if err != nil {
+		t.Fatalf("NewGormDB: %v", err)
+	}
+	if model.ActiveDialect() != model.DialectSQLite {
+		t.Fatalf("expected active dialect %q, got %q", model.DialectSQLite, model.ActiveDialect())
+	}
+
+	if err := model.AutoMigrate(gdb); err != nil {
+		t.Fatalf("AutoMigrate: %v", err)
+	}
+	if err := Ping(gdb); err != nil {
+		t.Fatalf("Ping: %v", err)
+	}
+}
+
+// TestNewGormDB_Postgres — интеграционный тест против реального Postgres;
+// пропускается, если TEST_POSTGRES_DSN не выставлен (нет смысла гонять его
+// без поднятой БД в CI по умолчанию).
+func TestNewGormDB_Postgres(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set, skipping Postgres integration test")
+	}
+	testDriverAgainstRunningServer(t, config.DriverPostgres, dsn)
+}
+
+// TestNewGormDB_MySQL — интеграционный тест против реального MySQL;
+// пропускается, если TEST_MYSQL_DSN не выставлен.
+func TestNewGormDB_MySQL(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN not set, skipping MySQL integration test")
+	}
+	testDriverAgainstRunningServer(t, config.DriverMySQL, dsn)
+}
+
+// testDriverAgainstRunningServer прогоняет NewGormDB + AutoMigrate + Ping для
+// драйверов, которым нужен реальный сервер БД (DSN целиком приходит из env,
+// см. вызывающие тесты).
+func testDriverAgainstRunningServer(t *testing.T, driver config.Driver, dsn string) {
+	t.Helper()
+
+	cfg := &config.DBConfig{Driver: driver, DSN: dsn}
+	gdb, err := NewGormDB(cfg)
+	if err != nil {
+		t.Fatalf("NewGormDB(%s): %v", driver, err)
+	}
+	if err := model.AutoMigrate(gdb); err != nil {
+		t.Fatalf("AutoMigrate(%s): %v", driver, err)
+	}
+	if err := Ping(gdb); err != nil {
+		t.Fatalf("Ping(%s): %v", driver, err)
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// maxSerializableRetries — сколько раз повторить транзакцию при конфликте
+// сериализации (errs.ErrRetryable, Postgres SQLSTATE 40001), прежде чем
+// вернуть ошибку вызывающему коду. Такие конфликты ожидаемы под нагрузкой —
+// например, два одновременных запроса создают пересекающиеся слоты одного
+// провайдера — и почти всегда разрешаются повторной попыткой.
+const maxSerializableRetries = 3
+
+// WithSerializableRetry выполняет fn в транзакции с уровнем изоляции
+// SERIALIZABLE (на Postgres; на MySQL/SQLite уровень изоляции не
+// форсируется явно — конфликтов сериализации там не бывает, см.
+// model.Dialect) и при ошибке errs.ErrRetryable повторяет попытку с
+// небольшим джиттером между попытками. Используется везде, где транзакция
+// может столкнуться с конкурентным изменением того же диапазона: создание/
+// обновление слота (см. repository.GormSlotRepository) и переходы брони
+// (см. service.CalendarService.CreateBooking).
+func WithSerializableRetry(ctx context.Context, gdb *gorm.DB, fn func(tx *gorm.DB) error) error {
+	opts := &sql.TxOptions{}
+	if model.ActiveDialect() == model.DialectPostgres {
+		opts.Isolation = sql.LevelSerializable
+	}
+
+	var err error
+	for attempt := 0; attempt <= maxSerializableRetries; attempt++ {
+		err = gdb.WithContext(ctx).Transaction(fn, opts)
+		if err == nil || !errors.Is(err, errs.ErrRetryable) {
+			return err
+		}
+		time.Sleep(retryBackoff(attempt))
+	}
+	return err
+}
+
+func retryBackoff(attempt int) time.Duration {
+	base := time.Duration(5*(attempt+1)) * time.Millisecond
+	jitter := time.Duration(rand.Intn(10)) * time.Millisecond
+	return base + jitter
+}
@@ -0,0 +1,129 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+)
+
+// tenantScopePlugin — сессионный GORM-плагин, который перед каждым запросом
+// выполняет `SELECT set_config('app.namespace_id', ..., true)` (эквивалент
+// SET LOCAL, но параметризуемый), чтобы Postgres RLS-политики (см.
+// model.EnableRowLevelSecurity) видели арендатора текущего запроса.
+// Арендатор берётся из context.Context запроса через namespace.FromContext.
+//
+// SET LOCAL/set_config(..., true) имеет смысл только внутри транзакции — вне
+// неё он откатывается сразу после статемента. GORM же по умолчанию
+// оборачивает в неявную транзакцию только операции записи (Create/Update/
+// Delete); чтения (First/Find/Count/...) выполняются прямо на пуле
+// *sql.DB, и там нет гарантии, что set_config и сам запрос попадут на одно
+// и то же соединение. Поэтому для запросов вне уже открытой транзакции
+// плагин сам открывает для них короткую однострочную транзакцию (before-
+// колбэк подменяет stmt.ConnPool на неё, after-колбэк коммитит/откатывает) —
+// только ради того, чтобы set_config и сам запрос гарантированно выполнились
+// на одном соединении.
+//
+// Намеренно не регистрируется на gorm:row/gorm:raw (.Row()/.Rows()): эти
+// колбэки возвращают *sql.Row/*sql.Rows вызывающему для отложенного Scan,
+// а after-колбэк сработал бы сразу после самого вызова — закоммитив (и тем
+// самым закрыв) нашу однострочную транзакцию ещё до того, как вызывающий
+// успеет её просканировать. Сейчас в кодовой базе нет ни одного вызова
+// .Row()/.Rows() вне транзакции, так что это просто избегание ловушки для
+// будущего кода, а не исправление реального сценария.
+type tenantScopePlugin struct{}
+
+func (tenantScopePlugin) Name() string { return "namespace:set_local" }
+
+func (p tenantScopePlugin) Initialize(db *gorm.DB) error {
+	callbacks := map[string]*gorm.Callback{
+		"gorm:query":  db.Callback().Query(),
+		"gorm:create": db.Callback().Create(),
+		"gorm:update": db.Callback().Update(),
+		"gorm:delete": db.Callback().Delete(),
+	}
+	for point, cb := range callbacks {
+		if err := cb.Before(point).Register("namespace:set_local:"+point, p.setLocalNamespace); err != nil {
+			return fmt.Errorf("register namespace before-callback on %s: %w", point, err)
+		}
+		if err := cb.After(point).Register("namespace:commit_local:"+point, p.commitLocalNamespace); err != nil {
+			return fmt.Errorf("register namespace after-callback on %s: %w", point, err)
+		}
+	}
+	return nil
+}
+
+// tenantScopedTxKey — ключ для tx.InstanceSet/InstanceGet, под которым
+// setLocalNamespace прячет транзакцию, открытую им самим для одиночного
+// запроса вне внешней транзакции, чтобы commitLocalNamespace нашёл её и
+// закрыл по завершении того же запроса.
+const tenantScopedTxKey = "namespace:scoped_tx"
+
+func (tenantScopePlugin) setLocalNamespace(tx *gorm.DB) {
+	// set_config — функция Postgres; на MySQL/SQLite RLS недоступна в
+	// принципе (см. model.EnableRowLevelSecurity), поэтому плагину тут
+	// нечего делать — многоарендность там держится только на предикате
+	// namespace_id в Go-репозиториях.
+	if model.ActiveDialect() != model.DialectPostgres {
+		return
+	}
+
+	stmt := tx.Statement
+	if stmt == nil || stmt.Context == nil {
+		return
+	}
+
+	nsID, ok := namespace.FromContext(stmt.Context)
+	if !ok {
+		return
+	}
+
+	switch pool := stmt.ConnPool.(type) {
+	case *sql.Tx:
+		// Уже внутри чьей-то транзакции (db.WithSerializableRetry и т.п.) —
+		// коммитить/откатывать её не наше дело, просто выставляем GUC на её
+		// время.
+		if _, err := pool.ExecContext(stmt.Context, "SELECT set_config('app.namespace_id', $1, true)", nsID.String()); err != nil {
+			_ = tx.AddError(fmt.Errorf("set tenant namespace: %w", err))
+		}
+	case *sql.DB:
+		scopedTx, err := pool.BeginTx(stmt.Context, nil)
+		if err != nil {
+			_ = tx.AddError(fmt.Errorf("begin tenant-scoped tx: %w", err))
+			return
+		}
+		if _, err := scopedTx.ExecContext(stmt.Context, "SELECT set_config('app.namespace_id', $1, true)", nsID.String()); err != nil {
+			_ = scopedTx.Rollback()
+			_ = tx.AddError(fmt.Errorf("set tenant namespace: %w", err))
+			return
+		}
+		stmt.ConnPool = scopedTx
+		tx.InstanceSet(tenantScopedTxKey, scopedTx)
+	}
+}
+
+// commitLocalNamespace закрывает транзакцию, открытую setLocalNamespace для
+// одиночного запроса вне внешней транзакции (см. case *sql.DB выше). Если
+// setLocalNamespace не открывал такую транзакцию — запрос шёл внутри уже
+// существующей *sql.Tx или на не-Postgres диалекте — InstanceGet ничего не
+// находит, и этот колбэк не делает ничего.
+func (tenantScopePlugin) commitLocalNamespace(tx *gorm.DB) {
+	v, ok := tx.InstanceGet(tenantScopedTxKey)
+	if !ok {
+		return
+	}
+	scopedTx, ok := v.(*sql.Tx)
+	if !ok {
+		return
+	}
+	if tx.Error != nil {
+		_ = scopedTx.Rollback()
+		return
+	}
+	if err := scopedTx.Commit(); err != nil {
+		_ = tx.AddError(fmt.Errorf("commit tenant-scoped tx: %w", err))
+	}
+}
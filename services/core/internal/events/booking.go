@@ -0,0 +1,26 @@
+package events
+
+import "time"
+
+// BookingEventType — тип push-события бронирования/слота (см.
+// CalendarService.SubscribeBookingEvents).
+type BookingEventType string
+
+const (
+	BookingEventCreated   BookingEventType = "BOOKING_CREATED"
+	BookingEventCancelled BookingEventType = "BOOKING_CANCELLED"
+)
+
+// BookingEvent — полезная нагрузка Bus[string, BookingEvent] (ключ —
+// ProviderID.String()) в CalendarService.SubscribeBookingEvents. В отличие
+// от IdentityEvent, не персистится: это живой стрим для UI провайдера, а не
+// замена аудиту (см. model.Event, writeAuditEvent) — пропущенное во время
+// разрыва соединения событие подхватит обычный ListBookings при
+// переоткрытии экрана, поэтому курсора replay здесь нет.
+type BookingEvent struct {
+	Type       BookingEventType
+	ProviderID string
+	BookingID  string
+	SlotID     string
+	At         time.Time
+}
@@ -0,0 +1,95 @@
+// Package events — in-process pub/sub для server-streaming gRPC-подписок
+// (IdentityService.SubscribeUserEvents, CalendarService.SubscribeBookingEvents).
+// Сам по себе Bus ничего не сохраняет: события, пропущенные из-за
+// backpressure или разрыва стрима, должны восстанавливаться через отдельный
+// durable-журнал (см. repository.IdentityEventRepository) с cursor-based
+// replay, а не через этот пакет.
+package events
+
+import (
+	"log"
+	"sync"
+)
+
+// Config — параметры Bus.
+type Config struct {
+	// QueueDepth — размер буферизованного канала на подписчика.
+	QueueDepth int
+}
+
+func (c Config) withDefaults() Config {
+	if c.QueueDepth <= 0 {
+		c.QueueDepth = 32
+	}
+	return c
+}
+
+// Bus — fan-out событий типа V подписчикам, сгруппированным по ключу K
+// (TelegramID для IdentityEvent, ProviderID для событий календаря). Generic
+// по ключу и значению, тот же приём, что и generic tgResponse[T any] в
+// cmd/identity-bot — один Bus обслуживает оба стрима без дублирования кода.
+type Bus[K comparable, V any] struct {
+	mu          sync.RWMutex
+	subscribers map[K]map[int]chan V
+	nextSubID   int
+	cfg         Config
+	logger      *log.Logger
+}
+
+func NewBus[K comparable, V any](cfg Config) *Bus[K, V] {
+	return &Bus[K, V]{
+		subscribers: make(map[K]map[int]chan V),
+		cfg:         cfg.withDefaults(),
+		logger:      log.Default(),
+	}
+}
+
+// Subscribe регистрирует нового подписчика на события с данным ключом.
+// Вызывающий обязан вызвать возвращённую unsubscribe-функцию, когда стрим
+// завершается (обычно через defer сразу после Subscribe) — иначе подписка
+// и её канал останутся висеть до конца жизни процесса.
+func (b *Bus[K, V]) Subscribe(key K) (<-chan V, func()) {
+	ch := make(chan V, b.cfg.QueueDepth)
+
+	b.mu.Lock()
+	id := b.nextSubID
+	b.nextSubID++
+	if b.subscribers[key] == nil {
+		b.subscribers[key] = make(map[int]chan V)
+	}
+	b.subscribers[key][id] = ch
+	b.mu.Unlock()
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		delete(b.subscribers[key], id)
+		if len(b.subscribers[key]) == 0 {
+			delete(b.subscribers, key)
+		}
+		b.mu.Unlock()
+	}
+	return ch, unsubscribe
+}
+
+// Publish разносит v всем текущим подписчикам key. Backpressure: если буфер
+// подписчика полон, это событие для него отбрасывается — Publish никогда не
+// блокируется и не роняет публикацию остальным подписчикам из-за одного
+// медленного. Отброшенное событие не теряется навсегда при наличии durable
+// replay (см. package doc).
+func (b *Bus[K, V]) Publish(key K, v V) {
+	b.mu.RLock()
+	subs := b.subscribers[key]
+	chans := make([]chan V, 0, len(subs))
+	for _, ch := range subs {
+		chans = append(chans, ch)
+	}
+	b.mu.RUnlock()
+
+	for _, ch := range chans {
+		select {
+		case ch <- v:
+		default:
+			b.logger.Printf("[WARN] events.Bus: dropping event for slow subscriber")
+		}
+	}
+}
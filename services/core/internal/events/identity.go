@@ -0,0 +1,35 @@
+package events
+
+import (
+	"time"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+)
+
+// IdentityEventType зеркалит model.IdentityEventType — отдельный тип, а не
+// прямой реэкспорт, чтобы этот пакет не тянул за собой прочие зависимости
+// model (тот же приём, что и outbox.Event не переиспользует model.Event).
+type IdentityEventType string
+
+const (
+	TypeRegistered       IdentityEventType = "REGISTERED"
+	TypeContactsUpdated  IdentityEventType = "CONTACTS_UPDATED"
+	TypeRoleChanged      IdentityEventType = "ROLE_CHANGED"
+	TypeProviderAttached IdentityEventType = "PROVIDER_ATTACHED"
+	TypeReset            IdentityEventType = "RESET"
+)
+
+// IdentityEvent — полезная нагрузка Bus[int64, IdentityEvent] в
+// IdentityService.SubscribeUserEvents, на которую подписывается cmd/identity-bot
+// и веб-фронтенды вместо поллинга GetProfile/GetUserContext. ID — тот же
+// монотонный cursor, что и у одноимённой строки model.IdentityEvent (см.
+// repository.IdentityEventRepository) — по нему подписчик при
+// переподключении понимает, с какого события продолжить replay.
+type IdentityEvent struct {
+	ID         uint64
+	Type       IdentityEventType
+	TelegramID int64
+	User       *model.User
+	Provider   *model.Provider
+	At         time.Time
+}
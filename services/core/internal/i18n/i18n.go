@@ -0,0 +1,69 @@
+// Package i18n собирает локализацию текстов, которые раньше склеивались
+// вручную в Telegram-боте и HTTP-хендлерах (число + основа существительного
+// без учёта русского склонения). Plural реализует правило множественного
+// числа CLDR для русского языка, а Messages — маленький набор готовых фраз
+// поверх него, с откатом на английский через Lang.
+package i18n
+
+import "fmt"
+
+// Lang — поддерживаемый язык сообщений.
+type Lang string
+
+const (
+	LangRU Lang = "ru"
+	LangEN Lang = "en"
+)
+
+// Plural выбирает словоформу по числу n согласно правилу CLDR для русского
+// языка (http://cldr.unicode.org/index/cldr-spec/plural-rules#Russian):
+// one — "1 слот", few — "2 слота"/"3 слота"/"4 слота", many — "0 слотов",
+// "5 слотов" ... "20 слотов", "25 слотов" и т.п., other — остаток (в
+// русском для целых n практически не встречается, но категория оставлена
+// для общности и для вызова Plural с формами других языков).
+func Plural(n int, one, few, many, other string) string {
+	if n < 0 {
+		n = -n
+	}
+	mod10 := n % 10
+	mod100 := n % 100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return one
+	case mod10 >= 2 && mod10 <= 4 && !(mod100 >= 12 && mod100 <= 14):
+		return few
+	case mod10 == 0 || (mod10 >= 5 && mod10 <= 9) || (mod100 >= 11 && mod100 <= 14):
+		return many
+	default:
+		return other
+	}
+}
+
+// Messages — фразы, которые внедряются в Telegram-бота и HTTP-хендлеры
+// вместо ручной склейки чисел и основ существительных (см. CLDR-правило в
+// Plural). Нулевое значение использует LangRU.
+type Messages struct {
+	Lang Lang
+}
+
+// NewMessages возвращает Messages для языка lang; пустой Lang трактуется
+// как LangRU.
+func NewMessages(lang Lang) Messages {
+	if lang == "" {
+		lang = LangRU
+	}
+	return Messages{Lang: lang}
+}
+
+// FreeSlotsCount — "N свободный слот"/"свободных слота"/"свободных слотов"
+// или "N free slot"/"free slots" для английского.
+func (m Messages) FreeSlotsCount(n int) string {
+	if m.Lang == LangEN {
+		if n == 1 || n == -1 {
+			return fmt.Sprintf("%d free slot", n)
+		}
+		return fmt.Sprintf("%d free slots", n)
+	}
+	word := Plural(n, "свободный слот", "свободных слота", "свободных слотов", "свободных слота")
+	return fmt.Sprintf("%d %s", n, word)
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import "testing"
+
+func TestPlural_RussianGoldenTable(t *testing.T) {
+	cases := []struct {
+		n    int
+		want string
+	}{
+		{0, "many"},
+		{1, "one"},
+		{2, "few"},
+		{5, "many"},
+		{11, "many"},
+		{21, "one"},
+		{22, "few"},
+		{25, "many"},
+		{101, "one"},
+		{111, "many"},
+		{121, "one"},
+	}
+	for _, c := range cases {
+		got := Plural(c.n, "one", "few", "many", "other")
+		if got != c.want {
+			t.Errorf("Plural(%d) = %q, want %q", c.n, got, c.want)
+		}
+	}
+}
+
+func TestMessages_FreeSlotsCount_Russian(t *testing.T) {
+	m := NewMessages(LangRU)
+	cases := []struct {
+		n    int
+		want string
+	}{
+		{1, "1 свободный слот"},
+		{2, "2 свободных слота"},
+		{5, "5 свободных слотов"},
+		{0, "0 свободных слотов"},
+		{21, "21 свободный слот"},
+	}
+	for _, c := range cases {
+		if got := m.FreeSlotsCount(c.n); got != c.want {
+			t.Errorf("FreeSlotsCount(%d) = %q, want %q", c.n, got, c.want)
+		}
+	}
+}
+
+func TestMessages_FreeSlotsCount_English(t *testing.T) {
+	m := NewMessages(LangEN)
+	if got := m.FreeSlotsCount(1); got != "1 free slot" {
+		t.Errorf("FreeSlotsCount(1) = %q, want %q", got, "1 free slot")
+	}
+	if got := m.FreeSlotsCount(5); got != "5 free slots" {
+		t.Errorf("FreeSlotsCount(5) = %q, want %q", got, "5 free slots")
+	}
+}
+
+func TestNewMessages_DefaultsToRussian(t *testing.T) {
+	m := NewMessages("")
+	if m.Lang != LangRU {
+		t.Errorf("expected empty Lang to default to LangRU, got %q", m.Lang)
+	}
+}
@@ -0,0 +1,160 @@
+package icalendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	calendarutils "github.com/Leganyst/appointment-platform/internal/utils"
+)
+
+// Component — один компонент VCALENDAR (VEVENT и т.п.) в форме, уже удобной
+// для Match/Filter, а не сырая строка .ics: Range посчитан заранее —
+// напрямую из бронирования или через calendarutils.ExpandRecurringRule для
+// повторяющегося правила — так что время рекурсии не тратится на повторный
+// разбор RRULE при каждом запросе.
+type Component struct {
+	Name  string
+	Range calendarutils.TimeRange
+	Props map[string]string
+	Comps []Component
+}
+
+// PropFilter — CalDAV prop-filter (RFC 4791 §9.7.2): требует присутствия
+// свойства Name и, если TextMatch непустой, вхождения подстроки в его
+// значение без учёта регистра. IsNotDefined, наоборот, требует отсутствия
+// свойства.
+type PropFilter struct {
+	Name         string
+	TextMatch    string
+	IsNotDefined bool
+}
+
+// TimeRangeFilter — CalDAV time-range (RFC 4791 §9.9): компонент матчит,
+// если его Range пересекается с полуинтервалом [Start, End).
+type TimeRangeFilter struct {
+	Start time.Time
+	End   time.Time
+}
+
+// CompFilter — CalDAV comp-filter (RFC 4791 §9.7.1). Нулевые значения полей
+// трактуются как "матчит всё": пустой Name не ограничивает по имени
+// компонента, nil TimeRange — по времени, пустые Props/Comps не добавляют
+// условий.
+type CompFilter struct {
+	Name      string
+	TimeRange *TimeRangeFilter
+	Props     []PropFilter
+	Comps     []CompFilter
+}
+
+// Match проверяет comp на соответствие filter. Для вложенных CompFilter
+// (filter.Comps) компонент матчит, только если среди его дочерних
+// компонентов (comp.Comps) нашёлся хотя бы один, матчащий этот вложенный
+// фильтр — так CalDAV REPORT трактует comp-filter внутри comp-filter
+// (например, VCALENDAR/VEVENT с time-range именно на VEVENT).
+func Match(filter CompFilter, comp Component) bool {
+	if filter.Name != "" && !strings.EqualFold(filter.Name, comp.Name) {
+		return false
+	}
+	if filter.TimeRange != nil && !timeRangeOverlaps(*filter.TimeRange, comp.Range) {
+		return false
+	}
+	for _, pf := range filter.Props {
+		if !matchProp(pf, comp.Props) {
+			return false
+		}
+	}
+	for _, cf := range filter.Comps {
+		if !anyChildMatches(cf, comp.Comps) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter возвращает подмножество events, матчащее query — то, что
+// CalDAV-сервер вернул бы на calendar-query REPORT с этим фильтром.
+func Filter(query CompFilter, events []Component) []Component {
+	out := make([]Component, 0, len(events))
+	for _, ev := range events {
+		if Match(query, ev) {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
+
+func anyChildMatches(filter CompFilter, comps []Component) bool {
+	for _, c := range comps {
+		if Match(filter, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchProp(filter PropFilter, props map[string]string) bool {
+	val, ok := props[filter.Name]
+	if filter.IsNotDefined {
+		return !ok
+	}
+	if !ok {
+		return false
+	}
+	if filter.TextMatch == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(val), strings.ToLower(filter.TextMatch))
+}
+
+func timeRangeOverlaps(a TimeRangeFilter, b calendarutils.TimeRange) bool {
+	return a.Start.Before(b.End) && b.Start.Before(a.End)
+}
+
+// BookingComponent строит Component для одного бронирования: UID/SUMMARY/
+// STATUS как свойства VEVENT (тот же bookingICSStatus, что использует
+// writeBookingEvent), Range из Booking.Slot.
+func BookingComponent(b *model.Booking, loc *time.Location) Component {
+	if loc == nil {
+		loc = time.UTC
+	}
+	props := map[string]string{
+		"UID":    b.ID.String(),
+		"STATUS": bookingICSStatus(b.Status),
+	}
+	var rng calendarutils.TimeRange
+	if b.Slot != nil {
+		rng = calendarutils.TimeRange{Start: b.Slot.StartsAt.In(loc), End: b.Slot.EndsAt.In(loc)}
+		if b.Slot.Service != nil && b.Slot.Service.Name != "" {
+			props["SUMMARY"] = b.Slot.Service.Name
+		}
+	}
+	return Component{Name: "VEVENT", Range: rng, Props: props}
+}
+
+// RecurringRuleComponents разворачивает rule в окне window (через
+// calendarutils.ExpandRecurringRule) и строит по одному Component на
+// вхождение, с общим RRULE в Props — тот же набор вхождений, что получил
+// бы внешний CalDAV-клиент, самостоятельно разворачивающий RRULE из
+// экспортированного .ics.
+func RecurringRuleComponents(rule calendarutils.RecurringRule, window calendarutils.TimeRange, uidPrefix string) ([]Component, error) {
+	occurrences, err := calendarutils.ExpandRecurringRule(rule, window)
+	if err != nil {
+		return nil, err
+	}
+	rruleStr := calendarutils.EncodeRRULE(rule)
+	comps := make([]Component, 0, len(occurrences))
+	for i, occ := range occurrences {
+		comps = append(comps, Component{
+			Name:  "VEVENT",
+			Range: occ,
+			Props: map[string]string{
+				"UID":   fmt.Sprintf("%s-%d", uidPrefix, i),
+				"RRULE": rruleStr,
+			},
+		})
+	}
+	return comps, nil
+}
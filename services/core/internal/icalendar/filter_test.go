@@ -0,0 +1,158 @@
+package icalendar
+
+import (
+	"testing"
+	"time"
+
+	calendarutils "github.com/Leganyst/appointment-platform/internal/utils"
+)
+
+func TestMatch_NameAndTimeRange(t *testing.T) {
+	comp := Component{
+		Name:  "VEVENT",
+		Range: calendarutils.TimeRange{Start: time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC), End: time.Date(2026, 8, 1, 11, 0, 0, 0, time.UTC)},
+		Props: map[string]string{"UID": "evt-1", "SUMMARY": "Haircut"},
+	}
+
+	inRange := CompFilter{
+		Name: "VEVENT",
+		TimeRange: &TimeRangeFilter{
+			Start: time.Date(2026, 8, 1, 9, 0, 0, 0, time.UTC),
+			End:   time.Date(2026, 8, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	if !Match(inRange, comp) {
+		t.Error("expected component inside time-range filter to match")
+	}
+
+	outOfRange := CompFilter{
+		Name: "VEVENT",
+		TimeRange: &TimeRangeFilter{
+			Start: time.Date(2026, 8, 2, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(2026, 8, 3, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	if Match(outOfRange, comp) {
+		t.Error("expected component outside time-range filter not to match")
+	}
+
+	wrongName := CompFilter{Name: "VTODO"}
+	if Match(wrongName, comp) {
+		t.Error("expected component with a different name not to match")
+	}
+}
+
+func TestMatch_PropFilter(t *testing.T) {
+	comp := Component{Name: "VEVENT", Props: map[string]string{"STATUS": "CONFIRMED"}}
+
+	if !Match(CompFilter{Props: []PropFilter{{Name: "STATUS", TextMatch: "confirmed"}}}, comp) {
+		t.Error("expected case-insensitive substring match on STATUS")
+	}
+	if Match(CompFilter{Props: []PropFilter{{Name: "STATUS", TextMatch: "cancelled"}}}, comp) {
+		t.Error("expected STATUS=CONFIRMED not to match TextMatch=cancelled")
+	}
+	if Match(CompFilter{Props: []PropFilter{{Name: "RRULE"}}}, comp) {
+		t.Error("expected missing RRULE property not to match a plain presence filter")
+	}
+	if !Match(CompFilter{Props: []PropFilter{{Name: "RRULE", IsNotDefined: true}}}, comp) {
+		t.Error("expected IsNotDefined to match an absent RRULE property")
+	}
+}
+
+func TestMatch_NestedComps(t *testing.T) {
+	calendar := Component{
+		Name: "VCALENDAR",
+		Comps: []Component{
+			{Name: "VEVENT", Range: calendarutils.TimeRange{Start: time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC), End: time.Date(2026, 8, 1, 11, 0, 0, 0, time.UTC)}},
+		},
+	}
+
+	query := CompFilter{
+		Name: "VCALENDAR",
+		Comps: []CompFilter{
+			{
+				Name: "VEVENT",
+				TimeRange: &TimeRangeFilter{
+					Start: time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC),
+					End:   time.Date(2026, 8, 2, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+	if !Match(query, calendar) {
+		t.Error("expected VCALENDAR with a matching VEVENT child to match")
+	}
+
+	queryMiss := CompFilter{
+		Name: "VCALENDAR",
+		Comps: []CompFilter{
+			{
+				Name: "VEVENT",
+				TimeRange: &TimeRangeFilter{
+					Start: time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+					End:   time.Date(2026, 9, 2, 0, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+	if Match(queryMiss, calendar) {
+		t.Error("expected VCALENDAR with no matching VEVENT child not to match")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	events := []Component{
+		{Name: "VEVENT", Props: map[string]string{"STATUS": "CONFIRMED"}},
+		{Name: "VEVENT", Props: map[string]string{"STATUS": "CANCELLED"}},
+	}
+	got := Filter(CompFilter{Props: []PropFilter{{Name: "STATUS", TextMatch: "confirmed"}}}, events)
+	if len(got) != 1 || got[0].Props["STATUS"] != "CONFIRMED" {
+		t.Fatalf("expected exactly the CONFIRMED event, got %+v", got)
+	}
+}
+
+func TestRecurringRuleComponents_RoundTrip(t *testing.T) {
+	rule := calendarutils.RecurringRule{
+		Freq:      calendarutils.FreqWeekly,
+		Interval:  1,
+		Weekdays:  []time.Weekday{time.Monday, time.Wednesday},
+		StartTime: time.Date(2026, 8, 3, 9, 0, 0, 0, time.UTC),
+		Duration:  30 * time.Minute,
+		Count:     intPtr(4),
+	}
+	window := calendarutils.TimeRange{
+		Start: time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	comps, err := RecurringRuleComponents(rule, window, "sched-1")
+	if err != nil {
+		t.Fatalf("RecurringRuleComponents: %v", err)
+	}
+	if len(comps) != 4 {
+		t.Fatalf("expected 4 occurrences (COUNT=4), got %d", len(comps))
+	}
+
+	rrule := comps[0].Props["RRULE"]
+	decoded, err := calendarutils.DecodeRRULE(rrule)
+	if err != nil {
+		t.Fatalf("DecodeRRULE(%q): %v", rrule, err)
+	}
+	decoded.StartTime = rule.StartTime
+	decoded.Duration = rule.Duration
+
+	reExpanded, err := calendarutils.ExpandRecurringRule(decoded, window)
+	if err != nil {
+		t.Fatalf("ExpandRecurringRule after round-trip: %v", err)
+	}
+	if len(reExpanded) != len(comps) {
+		t.Fatalf("round-trip expansion produced %d occurrences, want %d", len(reExpanded), len(comps))
+	}
+	for i, occ := range reExpanded {
+		if !occ.Start.Equal(comps[i].Range.Start) || !occ.End.Equal(comps[i].Range.End) {
+			t.Errorf("occurrence %d = [%v, %v), want [%v, %v)", i, occ.Start, occ.End, comps[i].Range.Start, comps[i].Range.End)
+		}
+	}
+}
+
+func intPtr(v int) *int { return &v }
@@ -0,0 +1,256 @@
+package icalendar
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+	calendarutils "github.com/Leganyst/appointment-platform/internal/utils"
+)
+
+// Horizon — насколько вперёд/назад от текущего момента собирается фид.
+const (
+	feedHorizonPast   = 30 * 24 * time.Hour
+	feedHorizonFuture = 180 * 24 * time.Hour
+)
+
+// Handler обслуживает GET /providers/{id}/calendar.ics — публичный, но
+// защищённый подписанным токеном (параметр запроса token), read-only
+// iCalendar-фид слотов и бронирований провайдера.
+type Handler struct {
+	Secret          []byte
+	ProviderRepo    repository.ProviderRepository
+	SlotRepo        repository.SlotRepository
+	BookingRepo     repository.BookingRepository
+	ScheduleRepo    repository.ScheduleRepository
+	MaintenanceRepo repository.MaintenanceRepository
+	// FeedTokenRepo — хранилище выданных/отозванных токенов (см.
+	// model.CalendarFeedToken). Если задано, проверяется вместо Secret/
+	// VerifyProviderToken: токен должен существовать для этого providerID и
+	// быть ещё не отозванным, а не просто совпадать с HMAC от глобального
+	// секрета — так одну утёкшую ссылку можно отозвать точечно. nil —
+	// обратная совместимость со старыми развёртываниями без этой таблицы.
+	FeedTokenRepo repository.CalendarFeedTokenRepository
+}
+
+func NewHandler(
+	secret []byte,
+	providerRepo repository.ProviderRepository,
+	slotRepo repository.SlotRepository,
+	bookingRepo repository.BookingRepository,
+	scheduleRepo repository.ScheduleRepository,
+	maintenanceRepo repository.MaintenanceRepository,
+	feedTokenRepo repository.CalendarFeedTokenRepository,
+) *Handler {
+	return &Handler{
+		Secret:          secret,
+		ProviderRepo:    providerRepo,
+		SlotRepo:        slotRepo,
+		BookingRepo:     bookingRepo,
+		ScheduleRepo:    scheduleRepo,
+		MaintenanceRepo: maintenanceRepo,
+		FeedTokenRepo:   feedTokenRepo,
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	providerID := providerIDFromPath(r.URL.Path)
+	if providerID == "" {
+		http.Error(w, "provider id is required", http.StatusBadRequest)
+		return
+	}
+
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+		return
+	}
+
+	ctx := r.Context()
+
+	// Фид публичный и знает только provider_id из подписанного URL — сначала
+	// резолвим его арендатора (это само по себе tenant-agnostic вызов, см.
+	// ResolveNamespaceByProviderID), чтобы и проверка токена ниже (для
+	// FeedTokenRepo — namespace-скоупнутая таблица calendar_feed_tokens), и
+	// все остальные вызовы репозиториев увидели строки этого провайдера.
+	nsID, err := h.ProviderRepo.ResolveNamespaceByProviderID(ctx, providerID)
+	if err != nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	ctx = namespace.WithNamespace(ctx, nsID)
+
+	if h.FeedTokenRepo != nil {
+		providerUUID, err := uuid.Parse(providerID)
+		if err != nil {
+			http.Error(w, "provider not found", http.StatusNotFound)
+			return
+		}
+		ok, err := h.FeedTokenRepo.VerifyActive(ctx, providerUUID, token)
+		if err != nil || !ok {
+			http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+			return
+		}
+	} else if !VerifyProviderToken(h.Secret, providerID, token) {
+		http.Error(w, "invalid or missing token", http.StatusUnauthorized)
+		return
+	}
+
+	provider, err := h.ProviderRepo.GetByID(ctx, providerID)
+	if err != nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+
+	now := time.Now().UTC()
+	from := now.Add(-feedHorizonPast)
+	to := now.Add(feedHorizonFuture)
+
+	slotsModel, _, err := h.SlotRepo.ListByProviderRange(ctx, providerID, from, to, 0, 0)
+	if err != nil {
+		http.Error(w, "failed to load slots", http.StatusInternalServerError)
+		return
+	}
+
+	bookings, err := h.BookingRepo.ListByProviderAndRange(ctx, providerID, from, to)
+	if err != nil {
+		http.Error(w, "failed to load bookings", http.StatusInternalServerError)
+		return
+	}
+
+	loc := time.UTC
+
+	freeSlots := make([]calendarutils.TimeRange, 0, len(slotsModel))
+	lastModified := provider.UpdatedAt
+	for i := range slotsModel {
+		if slotsModel[i].Status == model.TimeSlotStatusPlanned {
+			freeSlots = append(freeSlots, calendarutils.TimeRange{Start: slotsModel[i].StartsAt, End: slotsModel[i].EndsAt})
+		}
+		if slotsModel[i].UpdatedAt.After(lastModified) {
+			lastModified = slotsModel[i].UpdatedAt
+		}
+	}
+	for i := range bookings {
+		if bookings[i].UpdatedAt.After(lastModified) {
+			lastModified = bookings[i].UpdatedAt
+		}
+	}
+
+	ics, err := RenderProviderICS(provider, freeSlots, bookings, loc)
+	if err != nil {
+		http.Error(w, "failed to render calendar", http.StatusInternalServerError)
+		return
+	}
+
+	if h.ScheduleRepo != nil {
+		schedules, err := h.ScheduleRepo.ListByProvider(ctx, providerID)
+		if err == nil {
+			for i := range schedules {
+				if schedules[i].UpdatedAt.After(lastModified) {
+					lastModified = schedules[i].UpdatedAt
+				}
+				rrule := extractRRULE(schedules[i].Rules)
+				if rrule == "" {
+					continue
+				}
+				ics, err = AppendScheduleEvent(ics, &schedules[i], rrule, nil, nil, loc)
+				if err != nil {
+					http.Error(w, "failed to render calendar", http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+	}
+
+	if h.MaintenanceRepo != nil {
+		windows, err := h.MaintenanceRepo.ListActiveForProvider(ctx, providerID, from, to)
+		if err == nil {
+			for i := range windows {
+				if windows[i].UpdatedAt.After(lastModified) {
+					lastModified = windows[i].UpdatedAt
+				}
+				rrule := ""
+				if windows[i].RRule != nil {
+					rrule = *windows[i].RRule
+				}
+				occurrences, err := calendarutils.ExpandRecurringWindow(windows[i].StartsAt, windows[i].EndsAt, rrule, from, to)
+				if err != nil {
+					continue
+				}
+				for _, occ := range occurrences {
+					ics, err = AppendMaintenanceEvent(ics, &windows[i], occ, loc)
+					if err != nil {
+						http.Error(w, "failed to render calendar", http.StatusInternalServerError)
+						return
+					}
+				}
+			}
+		}
+	}
+
+	etag := computeETag(ics)
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" {
+		if t, err := time.Parse(http.TimeFormat, since); err == nil && !lastModified.After(t) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(ics)
+}
+
+// providerIDFromPath извлекает {id} из пути /providers/{id}/calendar.ics.
+func providerIDFromPath(path string) string {
+	const prefix = "/providers/"
+	const suffix = "/calendar.ics"
+	if !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(path, prefix), suffix)
+}
+
+func computeETag(ics []byte) string {
+	sum := sha1.Sum(ics)
+	return fmt.Sprintf("%q", hex.EncodeToString(sum[:]))
+}
+
+// scheduleRuleRRULE — минимальный срез JSON, хранимого в model.Schedule.Rules,
+// нужный только для извлечения готовой строки RRULE, если она там есть.
+type scheduleRuleRRULE struct {
+	RRule string `json:"rrule"`
+}
+
+func extractRRULE(raw []byte) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var dto scheduleRuleRRULE
+	if err := json.Unmarshal(raw, &dto); err != nil {
+		return ""
+	}
+	return dto.RRule
+}
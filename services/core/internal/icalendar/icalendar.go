@@ -0,0 +1,258 @@
+// Package icalendar рендерит и разбирает RFC 5545 iCalendar (.ics):
+// подтверждённые/отменённые бронирования как VEVENT, опционально свободные
+// слоты (TRANSP:TRANSPARENT), мастер-события повторяющихся расписаний (через
+// AppendScheduleEvent) и окон обслуживания (через AppendMaintenanceEvent) с
+// RRULE вместо развёрнутых копий. ParseEvents (см. import.go) — обратное
+// направление: разбор внешнего .ics в события для импорта в модель.
+package icalendar
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	calendarutils "github.com/Leganyst/appointment-platform/internal/utils"
+)
+
+const icsDateTimeLayout = "20060102T150405"
+
+// RenderProviderICS собирает VCALENDAR провайдера: один VEVENT на каждое
+// бронирование из bookings (UID = ID бронирования, STATUS по BookingStatus,
+// SEQUENCE = model.Booking.Sequence) и, опционально, VEVENT на каждый
+// свободный интервал из slots, помеченный TRANSP:TRANSPARENT, чтобы не
+// занимать место в календаре подписчика.
+func RenderProviderICS(p *model.Provider, slots []calendarutils.TimeRange, bookings []model.Booking, loc *time.Location) ([]byte, error) {
+	if p == nil {
+		return nil, fmt.Errorf("icalendar: provider is required")
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var buf bytes.Buffer
+	now := time.Now().UTC()
+
+	buf.WriteString("BEGIN:VCALENDAR\r\n")
+	buf.WriteString("VERSION:2.0\r\n")
+	buf.WriteString("PRODID:-//appointment-platform//provider-calendar//RU\r\n")
+	buf.WriteString("CALSCALE:GREGORIAN\r\n")
+	buf.WriteString(foldLine(fmt.Sprintf("X-WR-CALNAME:%s", escapeText(p.DisplayName))))
+	buf.WriteString("\r\n")
+
+	for i := range bookings {
+		writeBookingEvent(&buf, &bookings[i], now, loc)
+	}
+
+	for _, slot := range slots {
+		writeFreeSlotEvent(&buf, p.ID.String(), slot, now, loc)
+	}
+
+	buf.WriteString("END:VCALENDAR\r\n")
+
+	return buf.Bytes(), nil
+}
+
+// RenderBookingEvent рендерит одно бронирование как отдельный VCALENDAR с
+// единственным VEVENT (UID = ID бронирования) — формат, который ожидают
+// CalDAV-серверы при PUT одного .ics ресурса (см. internal/integration/caldav).
+func RenderBookingEvent(b *model.Booking, loc *time.Location) ([]byte, error) {
+	if b == nil {
+		return nil, fmt.Errorf("icalendar: booking is required")
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("BEGIN:VCALENDAR\r\n")
+	buf.WriteString("VERSION:2.0\r\n")
+	buf.WriteString("PRODID:-//appointment-platform//booking-event//RU\r\n")
+	writeBookingEvent(&buf, b, time.Now().UTC(), loc)
+	buf.WriteString("END:VCALENDAR\r\n")
+	return buf.Bytes(), nil
+}
+
+func writeBookingEvent(buf *bytes.Buffer, b *model.Booking, dtstamp time.Time, loc *time.Location) {
+	summary := "Appointment"
+	var start, end time.Time
+	if b.Slot != nil {
+		start = b.Slot.StartsAt.In(loc)
+		end = b.Slot.EndsAt.In(loc)
+		if b.Slot.Service != nil && b.Slot.Service.Name != "" {
+			summary = b.Slot.Service.Name
+		}
+	}
+
+	buf.WriteString("BEGIN:VEVENT\r\n")
+	fmt.Fprintf(buf, "UID:%s\r\n", b.ID.String())
+	fmt.Fprintf(buf, "DTSTAMP:%sZ\r\n", dtstamp.Format(icsDateTimeLayout))
+	if !start.IsZero() {
+		fmt.Fprintf(buf, "DTSTART;TZID=%s:%s\r\n", loc.String(), start.Format(icsDateTimeLayout))
+		fmt.Fprintf(buf, "DTEND;TZID=%s:%s\r\n", loc.String(), end.Format(icsDateTimeLayout))
+	}
+	buf.WriteString(foldLine(fmt.Sprintf("SUMMARY:%s", escapeText(summary))))
+	buf.WriteString("\r\n")
+	fmt.Fprintf(buf, "STATUS:%s\r\n", bookingICSStatus(b.Status))
+	fmt.Fprintf(buf, "SEQUENCE:%d\r\n", b.Sequence)
+	buf.WriteString("END:VEVENT\r\n")
+}
+
+func writeFreeSlotEvent(buf *bytes.Buffer, providerID string, slot calendarutils.TimeRange, dtstamp time.Time, loc *time.Location) {
+	start := slot.Start.In(loc)
+	end := slot.End.In(loc)
+
+	buf.WriteString("BEGIN:VEVENT\r\n")
+	fmt.Fprintf(buf, "UID:free-%s-%s\r\n", providerID, start.UTC().Format(icsDateTimeLayout))
+	fmt.Fprintf(buf, "DTSTAMP:%sZ\r\n", dtstamp.Format(icsDateTimeLayout))
+	fmt.Fprintf(buf, "DTSTART;TZID=%s:%s\r\n", loc.String(), start.Format(icsDateTimeLayout))
+	fmt.Fprintf(buf, "DTEND;TZID=%s:%s\r\n", loc.String(), end.Format(icsDateTimeLayout))
+	buf.WriteString("SUMMARY:Free slot\r\n")
+	buf.WriteString("TRANSP:TRANSPARENT\r\n")
+	buf.WriteString("STATUS:CONFIRMED\r\n")
+	buf.WriteString("END:VEVENT\r\n")
+}
+
+func bookingICSStatus(s model.BookingStatus) string {
+	switch s {
+	case model.BookingStatusCancelled:
+		return "CANCELLED"
+	case model.BookingStatusPending:
+		return "TENTATIVE"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+// AppendScheduleEvent вставляет в уже отрендеренный VCALENDAR (ics) мастер-VEVENT
+// для повторяющегося расписания: один VEVENT с RRULE, EXDATE (exceptions) и
+// RDATE (rdates), если они заданы, вместо развёрнутых копий на каждое
+// вхождение. Вставка делается перед завершающим END:VCALENDAR, чтобы не
+// пересобирать весь документ.
+func AppendScheduleEvent(ics []byte, sched *model.Schedule, rrule string, exceptions, rdates []time.Time, loc *time.Location) ([]byte, error) {
+	if sched == nil {
+		return nil, fmt.Errorf("icalendar: schedule is required")
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	const marker = "END:VCALENDAR\r\n"
+	idx := bytes.LastIndex(ics, []byte(marker))
+	if idx < 0 {
+		return nil, fmt.Errorf("icalendar: malformed VCALENDAR (END:VCALENDAR not found)")
+	}
+
+	var event bytes.Buffer
+	now := time.Now().UTC()
+	start := time.Now().In(loc)
+	if sched.StartDate != nil {
+		d := time.Time(*sched.StartDate)
+		start = time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, loc)
+	}
+
+	event.WriteString("BEGIN:VEVENT\r\n")
+	fmt.Fprintf(&event, "UID:schedule-%s\r\n", sched.ID.String())
+	fmt.Fprintf(&event, "DTSTAMP:%sZ\r\n", now.Format(icsDateTimeLayout))
+	fmt.Fprintf(&event, "DTSTART;TZID=%s:%s\r\n", loc.String(), start.Format(icsDateTimeLayout))
+	if rrule != "" {
+		fmt.Fprintf(&event, "RRULE:%s\r\n", rrule)
+	}
+	if len(exceptions) > 0 {
+		dates := make([]string, 0, len(exceptions))
+		for _, ex := range exceptions {
+			dates = append(dates, ex.In(loc).Format(icsDateTimeLayout))
+		}
+		fmt.Fprintf(&event, "EXDATE;TZID=%s:%s\r\n", loc.String(), strings.Join(dates, ","))
+	}
+	if len(rdates) > 0 {
+		dates := make([]string, 0, len(rdates))
+		for _, rd := range rdates {
+			dates = append(dates, rd.In(loc).Format(icsDateTimeLayout))
+		}
+		fmt.Fprintf(&event, "RDATE;TZID=%s:%s\r\n", loc.String(), strings.Join(dates, ","))
+	}
+	event.WriteString("SUMMARY:Recurring schedule\r\n")
+	event.WriteString("STATUS:CONFIRMED\r\n")
+	event.WriteString("END:VEVENT\r\n")
+
+	result := make([]byte, 0, len(ics)+event.Len())
+	result = append(result, ics[:idx]...)
+	result = append(result, event.Bytes()...)
+	result = append(result, ics[idx:]...)
+	return result, nil
+}
+
+// AppendMaintenanceEvent вставляет в уже отрендеренный VCALENDAR (ics) VEVENT
+// для одного вхождения MaintenanceWindow: TRANSP:OPAQUE и STATUS:CONFIRMED,
+// чтобы внешний календарь-клиент показывал его как занятое время, в отличие
+// от writeFreeSlotEvent. Та же вставка перед END:VCALENDAR, что и у
+// AppendScheduleEvent.
+func AppendMaintenanceEvent(ics []byte, w *model.MaintenanceWindow, occurrence calendarutils.TimeRange, loc *time.Location) ([]byte, error) {
+	if w == nil {
+		return nil, fmt.Errorf("icalendar: maintenance window is required")
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	const marker = "END:VCALENDAR\r\n"
+	idx := bytes.LastIndex(ics, []byte(marker))
+	if idx < 0 {
+		return nil, fmt.Errorf("icalendar: malformed VCALENDAR (END:VCALENDAR not found)")
+	}
+
+	summary := w.Name
+	if summary == "" {
+		summary = "Maintenance"
+	}
+	start := occurrence.Start.In(loc)
+	end := occurrence.End.In(loc)
+
+	var event bytes.Buffer
+	event.WriteString("BEGIN:VEVENT\r\n")
+	fmt.Fprintf(&event, "UID:maintenance-%s-%s\r\n", w.ID.String(), start.UTC().Format(icsDateTimeLayout))
+	fmt.Fprintf(&event, "DTSTAMP:%sZ\r\n", time.Now().UTC().Format(icsDateTimeLayout))
+	fmt.Fprintf(&event, "DTSTART;TZID=%s:%s\r\n", loc.String(), start.Format(icsDateTimeLayout))
+	fmt.Fprintf(&event, "DTEND;TZID=%s:%s\r\n", loc.String(), end.Format(icsDateTimeLayout))
+	event.WriteString(foldLine(fmt.Sprintf("SUMMARY:%s", escapeText(summary))))
+	event.WriteString("\r\n")
+	event.WriteString("TRANSP:OPAQUE\r\n")
+	event.WriteString("STATUS:CONFIRMED\r\n")
+	event.WriteString("END:VEVENT\r\n")
+
+	result := make([]byte, 0, len(ics)+event.Len())
+	result = append(result, ics[:idx]...)
+	result = append(result, event.Bytes()...)
+	result = append(result, ics[idx:]...)
+	return result, nil
+}
+
+// escapeText экранирует спецсимволы iCalendar (RFC 5545 §3.3.11) в текстовых полях.
+func escapeText(s string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		`;`, `\;`,
+		`,`, `\,`,
+		"\n", `\n`,
+	)
+	return replacer.Replace(s)
+}
+
+// foldLine оборачивает длинные строки по 75 октетов с продолжением через
+// "\r\n " (RFC 5545 §3.1), чтобы строго соответствовать формату .ics.
+func foldLine(line string) string {
+	const maxLen = 75
+	if len(line) <= maxLen {
+		return line
+	}
+	var b strings.Builder
+	for len(line) > maxLen {
+		b.WriteString(line[:maxLen])
+		b.WriteString("\r\n ")
+		line = line[maxLen:]
+	}
+	b.WriteString(line)
+	return b.String()
+}
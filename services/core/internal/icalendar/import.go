@@ -0,0 +1,192 @@
+package icalendar
+
+import (
+	"strings"
+	"time"
+)
+
+// icsImportTimeLayouts — форматы значений DTSTART/DTEND, которые разбирает
+// ParseEvents: дата-время в UTC (...Z), дата-время без зоны (берётся в TZID
+// параметра свойства или в defaultLoc) и голая дата (весь день, 00:00 в той
+// же локации).
+var icsImportTimeLayouts = []string{
+	"20060102T150405Z",
+	"20060102T150405",
+	"20060102",
+}
+
+// ParsedEvent — один VEVENT, разобранный из импортируемого .ics, в форме,
+// достаточной для CalendarService.ImportProviderCalendar: не нужно ничего,
+// кроме UID/SUMMARY/DTSTART/DTEND/STATUS/RRULE, остальные свойства VEVENT
+// игнорируются (это тот же построчный подход, что у caldav.ParseBusyRanges,
+// но с учётом TZID и RRULE, которые реконсилятору не нужны).
+type ParsedEvent struct {
+	UID       string
+	Summary   string
+	Start     time.Time
+	End       time.Time
+	Cancelled bool
+	RRule     string
+}
+
+// ParseEvents разбирает VCALENDAR ics на список VEVENT. defaultLoc
+// используется для значений DTSTART/DTEND без TZID-параметра и без суффикса
+// "Z"; если nil, берётся time.UTC. Свойство TZID каждого VEVENT разбирается
+// независимо — .ics, экспортированный из разных клиентов, может смешивать
+// зоны в одном файле.
+func ParseEvents(ics []byte, defaultLoc *time.Location) ([]ParsedEvent, error) {
+	if defaultLoc == nil {
+		defaultLoc = time.UTC
+	}
+
+	var events []ParsedEvent
+	var (
+		inEvent      bool
+		uid, summary string
+		rrule        string
+		start, end   time.Time
+		cancelled    bool
+	)
+
+	flush := func() {
+		if uid != "" && !start.IsZero() {
+			events = append(events, ParsedEvent{
+				UID:       uid,
+				Summary:   summary,
+				Start:     start,
+				End:       end,
+				Cancelled: cancelled,
+				RRule:     rrule,
+			})
+		}
+		uid, summary, rrule = "", "", ""
+		start, end = time.Time{}, time.Time{}
+		cancelled = false
+	}
+
+	for _, line := range unfoldLines(string(ics)) {
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent = true
+			uid, summary, rrule = "", "", ""
+			start, end = time.Time{}, time.Time{}
+			cancelled = false
+		case line == "END:VEVENT":
+			if inEvent {
+				flush()
+			}
+			inEvent = false
+		case !inEvent:
+			continue
+		case hasPropertyName(line, "UID"):
+			uid = propertyValue(line)
+		case hasPropertyName(line, "SUMMARY"):
+			summary = unescapeText(propertyValue(line))
+		case hasPropertyName(line, "DTSTART"):
+			if t, ok := parseICSDateTime(line, defaultLoc); ok {
+				start = t
+			}
+		case hasPropertyName(line, "DTEND"):
+			if t, ok := parseICSDateTime(line, defaultLoc); ok {
+				end = t
+			}
+		case hasPropertyName(line, "STATUS"):
+			cancelled = strings.EqualFold(propertyValue(line), "CANCELLED")
+		case hasPropertyName(line, "RRULE"):
+			rrule = propertyValue(line)
+		}
+	}
+
+	return events, nil
+}
+
+// parseICSDateTime разбирает значение DTSTART/DTEND вместе с его TZID-
+// параметром, если он есть: "DTSTART;TZID=Europe/Moscow:20260101T090000"
+// интерпретируется в Europe/Moscow, "DTSTART:20260101T090000Z" — в UTC.
+func parseICSDateTime(line string, defaultLoc *time.Location) (time.Time, bool) {
+	loc := defaultLoc
+	if tzid := propertyParam(line, "TZID"); tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	value := propertyValue(line)
+	if strings.HasSuffix(value, "Z") {
+		loc = time.UTC
+	}
+	for _, layout := range icsImportTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// propertyParam возвращает значение параметра name из строки свойства
+// ("DTSTART;TZID=Europe/Moscow:...") или "", если параметр не задан.
+func propertyParam(line, name string) string {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		colon = len(line)
+	}
+	head := line[:colon]
+	for _, part := range strings.Split(head, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 && strings.EqualFold(kv[0], name) {
+			return kv[1]
+		}
+	}
+	return ""
+}
+
+// unescapeText обращает escapeText (RFC 5545 §3.3.11) для текстовых полей
+// импортируемых событий.
+func unescapeText(s string) string {
+	replacer := strings.NewReplacer(
+		`\n`, "\n",
+		`\,`, ",",
+		`\;`, ";",
+		`\\`, `\`,
+	)
+	return replacer.Replace(s)
+}
+
+// unfoldLines разворачивает RFC 5545 line folding (продолжение строки
+// начинается с пробела/таба) и нормализует переводы строк. Та же логика, что
+// у caldav.unfoldLines — экспортировать общий хелпер некуда без создания
+// цикла между icalendar (рендер/парсинг .ics) и integration/caldav
+// (CalDAV-клиент), так что она продублирована в обоих местах.
+func unfoldLines(doc string) []string {
+	raw := strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n")
+	var lines []string
+	for _, l := range raw {
+		if len(lines) > 0 && (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) {
+			lines[len(lines)-1] += strings.TrimPrefix(strings.TrimPrefix(l, " "), "\t")
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+// hasPropertyName проверяет, что строка свойства — это propName, с учётом
+// необязательных параметров через ";" (TZID=..., VALUE=... и т.п.).
+func hasPropertyName(line, propName string) bool {
+	idx := strings.IndexAny(line, ";:")
+	if idx < 0 {
+		return false
+	}
+	return strings.EqualFold(line[:idx], propName)
+}
+
+func propertyValue(line string) string {
+	idx := strings.Index(line, ":")
+	if idx < 0 {
+		return ""
+	}
+	return strings.TrimSpace(line[idx+1:])
+}
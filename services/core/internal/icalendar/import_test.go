@@ -0,0 +1,90 @@
+package icalendar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseEvents_Basic(t *testing.T) {
+	doc := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:evt-1\r\n" +
+		"SUMMARY:Haircut\r\n" +
+		"DTSTART:20260801T100000Z\r\n" +
+		"DTEND:20260801T110000Z\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := ParseEvents([]byte(doc), time.UTC)
+	if err != nil {
+		t.Fatalf("ParseEvents: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	ev := events[0]
+	if ev.UID != "evt-1" || ev.Summary != "Haircut" {
+		t.Errorf("UID/Summary = %q/%q, want evt-1/Haircut", ev.UID, ev.Summary)
+	}
+	wantStart := time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC)
+	wantEnd := time.Date(2026, 8, 1, 11, 0, 0, 0, time.UTC)
+	if !ev.Start.Equal(wantStart) || !ev.End.Equal(wantEnd) {
+		t.Errorf("range = [%v, %v), want [%v, %v)", ev.Start, ev.End, wantStart, wantEnd)
+	}
+	if ev.Cancelled {
+		t.Error("expected event not cancelled")
+	}
+}
+
+func TestParseEvents_TZID(t *testing.T) {
+	doc := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:evt-tz\r\n" +
+		"DTSTART;TZID=Europe/Moscow:20260801T130000\r\n" +
+		"DTEND;TZID=Europe/Moscow:20260801T140000\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := ParseEvents([]byte(doc), time.UTC)
+	if err != nil {
+		t.Fatalf("ParseEvents: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	wantStart := time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC)
+	if !events[0].Start.Equal(wantStart) {
+		t.Errorf("start = %v, want %v (13:00 Europe/Moscow == 10:00 UTC)", events[0].Start, wantStart)
+	}
+}
+
+func TestParseEvents_CancelledAndRRule(t *testing.T) {
+	doc := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:evt-cancelled\r\n" +
+		"DTSTART:20260801T100000Z\r\n" +
+		"DTEND:20260801T110000Z\r\n" +
+		"STATUS:CANCELLED\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:evt-recurring\r\n" +
+		"DTSTART:20260801T100000Z\r\n" +
+		"DTEND:20260801T110000Z\r\n" +
+		"RRULE:FREQ=WEEKLY;INTERVAL=1\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	events, err := ParseEvents([]byte(doc), time.UTC)
+	if err != nil {
+		t.Fatalf("ParseEvents: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if !events[0].Cancelled {
+		t.Error("expected first event cancelled")
+	}
+	if events[1].RRule != "FREQ=WEEKLY;INTERVAL=1" {
+		t.Errorf("RRule = %q, want FREQ=WEEKLY;INTERVAL=1", events[1].RRule)
+	}
+}
@@ -0,0 +1,23 @@
+package icalendar
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// SignProviderToken подписывает providerID секретом secret через HMAC-SHA256.
+// Токен — не Telegram-авторизация, а opaque-ключ для доступа к .ics фиду по
+// прямой ссылке (email/календарь-клиент не умеют в Telegram initData).
+func SignProviderToken(secret []byte, providerID string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(providerID))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyProviderToken проверяет токен константным по времени сравнением.
+func VerifyProviderToken(secret []byte, providerID, token string) bool {
+	expected := SignProviderToken(secret, providerID)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(token)) == 1
+}
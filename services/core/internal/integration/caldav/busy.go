@@ -0,0 +1,161 @@
+package caldav
+
+import (
+	"strings"
+	"time"
+
+	calendarutils "github.com/Leganyst/appointment-platform/internal/utils"
+)
+
+// BusyInterval — занятый интервал внешнего календаря вместе с UID исходного
+// VEVENT/VFREEBUSY, нужным реконсилятору для сопоставления строк между прогонами.
+type BusyInterval struct {
+	UID   string
+	Range calendarutils.TimeRange
+}
+
+// icsTimeLayouts — форматы значений DTSTART/DTEND/FREEBUSY, которые реально
+// встречаются у внешних CalDAV-серверов: дата-время в UTC (...Z), дата-время
+// в локальном времени без TZID-преобразования (берём как есть, в UTC) и
+// голая дата (весь день).
+var icsTimeLayouts = []string{
+	"20060102T150405Z",
+	"20060102T150405",
+	"20060102",
+}
+
+// ParseBusyRanges разбирает сырые iCalendar-документы (как их возвращает
+// Client.ListEvents) в занятые интервалы: по одному на VEVENT (если не
+// CANCELLED/TRANSPARENT) и по одному на каждый период внутри VFREEBUSY.
+// Это упрощённый построчный парсер, а не полноценная RFC 5545 грамматика —
+// ему не нужно понимать ничего, кроме UID/DTSTART/DTEND/FREEBUSY/STATUS/TRANSP,
+// остальные строки игнорируются.
+func ParseBusyRanges(docs []string) []BusyInterval {
+	var result []BusyInterval
+	for _, doc := range docs {
+		result = append(result, parseDocument(doc)...)
+	}
+	return result
+}
+
+func parseDocument(doc string) []BusyInterval {
+	var result []BusyInterval
+
+	lines := unfoldLines(doc)
+
+	var (
+		inEvent, inFreeBusy    bool
+		uid                    string
+		start, end             time.Time
+		transparent, cancelled bool
+	)
+
+	flushEvent := func() {
+		if !start.IsZero() && !end.IsZero() && !transparent && !cancelled {
+			result = append(result, BusyInterval{UID: uid, Range: calendarutils.TimeRange{Start: start, End: end}})
+		}
+		uid, start, end, transparent, cancelled = "", time.Time{}, time.Time{}, false, false
+	}
+
+	for _, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent = true
+		case line == "END:VEVENT":
+			inEvent = false
+			flushEvent()
+		case line == "BEGIN:VFREEBUSY":
+			inFreeBusy = true
+			uid = ""
+		case line == "END:VFREEBUSY":
+			inFreeBusy = false
+			uid = ""
+		case inEvent && hasPropertyName(line, "UID"):
+			uid = propertyValue(line)
+		case inEvent && hasPropertyName(line, "DTSTART"):
+			if t, ok := parseICSTime(propertyValue(line)); ok {
+				start = t
+			}
+		case inEvent && hasPropertyName(line, "DTEND"):
+			if t, ok := parseICSTime(propertyValue(line)); ok {
+				end = t
+			}
+		case inEvent && hasPropertyName(line, "STATUS"):
+			cancelled = strings.EqualFold(propertyValue(line), "CANCELLED")
+		case inEvent && hasPropertyName(line, "TRANSP"):
+			transparent = strings.EqualFold(propertyValue(line), "TRANSPARENT")
+		case inFreeBusy && hasPropertyName(line, "FREEBUSY"):
+			result = append(result, parseFreeBusyLine(line)...)
+		}
+	}
+
+	return result
+}
+
+// parseFreeBusyLine разбирает "FREEBUSY[;FBTYPE=BUSY]:start/end,start2/end2".
+func parseFreeBusyLine(line string) []BusyInterval {
+	value := propertyValue(line)
+	if strings.Contains(line, "FBTYPE=FREE") {
+		return nil
+	}
+	var out []BusyInterval
+	for _, period := range strings.Split(value, ",") {
+		parts := strings.SplitN(period, "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		start, ok1 := parseICSTime(parts[0])
+		end, ok2 := parseICSTime(parts[1])
+		if !ok1 || !ok2 {
+			continue
+		}
+		out = append(out, BusyInterval{Range: calendarutils.TimeRange{Start: start, End: end}})
+	}
+	return out
+}
+
+// hasPropertyName проверяет, что строка свойства — это propName, с учётом
+// необязательных параметров через ";" (TZID=..., FBTYPE=... и т.п.).
+func hasPropertyName(line, propName string) bool {
+	idx := strings.IndexAny(line, ";:")
+	if idx < 0 {
+		return false
+	}
+	return strings.EqualFold(line[:idx], propName)
+}
+
+func propertyValue(line string) string {
+	idx := strings.Index(line, ":")
+	if idx < 0 {
+		return ""
+	}
+	return strings.TrimSpace(line[idx+1:])
+}
+
+func parseICSTime(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	for _, layout := range icsTimeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// unfoldLines разворачивает RFC 5545 line folding (продолжение строки начинается
+// с пробела/таба) и нормализует переводы строк.
+func unfoldLines(doc string) []string {
+	raw := strings.Split(strings.ReplaceAll(doc, "\r\n", "\n"), "\n")
+	var lines []string
+	for _, l := range raw {
+		if len(lines) > 0 && (strings.HasPrefix(l, " ") || strings.HasPrefix(l, "\t")) {
+			lines[len(lines)-1] += strings.TrimPrefix(strings.TrimPrefix(l, " "), "\t")
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
@@ -0,0 +1,65 @@
+package caldav
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBusyRanges_VEVENT(t *testing.T) {
+	doc := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:abc-123\r\n" +
+		"DTSTART:20260801T100000Z\r\n" +
+		"DTEND:20260801T110000Z\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	intervals := ParseBusyRanges([]string{doc})
+	if len(intervals) != 1 {
+		t.Fatalf("expected 1 interval, got %d", len(intervals))
+	}
+	if intervals[0].UID != "abc-123" {
+		t.Errorf("UID = %q, want abc-123", intervals[0].UID)
+	}
+	wantStart := time.Date(2026, 8, 1, 10, 0, 0, 0, time.UTC)
+	wantEnd := time.Date(2026, 8, 1, 11, 0, 0, 0, time.UTC)
+	if !intervals[0].Range.Start.Equal(wantStart) || !intervals[0].Range.End.Equal(wantEnd) {
+		t.Errorf("range = [%v, %v), want [%v, %v)", intervals[0].Range.Start, intervals[0].Range.End, wantStart, wantEnd)
+	}
+}
+
+func TestParseBusyRanges_SkipsTransparentAndCancelled(t *testing.T) {
+	doc := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:transparent\r\n" +
+		"DTSTART:20260801T100000Z\r\n" +
+		"DTEND:20260801T110000Z\r\n" +
+		"TRANSP:TRANSPARENT\r\n" +
+		"END:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:cancelled\r\n" +
+		"DTSTART:20260801T120000Z\r\n" +
+		"DTEND:20260801T130000Z\r\n" +
+		"STATUS:CANCELLED\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	intervals := ParseBusyRanges([]string{doc})
+	if len(intervals) != 0 {
+		t.Fatalf("expected 0 intervals, got %d", len(intervals))
+	}
+}
+
+func TestParseBusyRanges_VFREEBUSY(t *testing.T) {
+	doc := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VFREEBUSY\r\n" +
+		"FREEBUSY;FBTYPE=BUSY:20260801T100000Z/20260801T110000Z,20260801T140000Z/20260801T150000Z\r\n" +
+		"FREEBUSY;FBTYPE=FREE:20260801T110000Z/20260801T140000Z\r\n" +
+		"END:VFREEBUSY\r\n" +
+		"END:VCALENDAR\r\n"
+
+	intervals := ParseBusyRanges([]string{doc})
+	if len(intervals) != 2 {
+		t.Fatalf("expected 2 intervals, got %d", len(intervals))
+	}
+}
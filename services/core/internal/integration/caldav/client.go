@@ -0,0 +1,216 @@
+// Package caldav — минимальный клиент и фоновый реконсилятор для
+// двусторонней синхронизации провайдерского расписания с внешним CalDAV-
+// календарём (Nextcloud/Radicale/Google Calendar): пуш подтверждённых
+// Booking как VEVENT наружу и учёт внешних VEVENT/VFREEBUSY как занятых
+// интервалов при проверке конфликтов бронирования.
+//
+// Реализован намеренно узко — ровно тот поднабор CalDAV (RFC 4791) и
+// WebDAV (RFC 4918), который нужен для PROPFIND/getctag, calendar-query
+// REPORT и PUT одного .ics ресурса. Полноценный WebDAV-клиент (ACL,
+// collection-sync, scheduling) этому сервису не нужен.
+package caldav
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Client — CalDAV-клиент одного календаря-коллекции провайдера.
+type Client struct {
+	// CalendarURL — адрес коллекции, например
+	// https://nextcloud.example.com/remote.php/dav/calendars/alice/appointments/.
+	CalendarURL string
+	Username    string
+	Password    string
+
+	HTTPClient *http.Client
+}
+
+// NewClient строит Client с http.Client по умолчанию, если HTTPClient не задан.
+func NewClient(calendarURL, username, password string) *Client {
+	return &Client{
+		CalendarURL: calendarURL,
+		Username:    username,
+		Password:    password,
+		HTTPClient:  http.DefaultClient,
+	}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) newRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	if c.Username != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	return req, nil
+}
+
+// propfindCTagBody — PROPFIND-тело для getctag (RFC 6578 / CalDAV "cs:getctag").
+const propfindCTagBody = `<?xml version="1.0" encoding="UTF-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:cs="http://calendarserver.org/ns/">
+  <d:prop>
+    <cs:getctag/>
+  </d:prop>
+</d:propfind>`
+
+type propfindResponse struct {
+	Responses []struct {
+		Propstat []struct {
+			Prop struct {
+				GetCTag string `xml:"getctag"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// GetCTag возвращает текущий ctag коллекции — меняется при любой модификации
+// внутри (добавление/изменение/удаление VEVENT), поэтому реконсилятору
+// достаточно сравнить его со значением, сохранённым при прошлой синхронизации,
+// чтобы решить, нужен ли дорогой calendar-query REPORT.
+func (c *Client) GetCTag(ctx context.Context) (string, error) {
+	req, err := c.newRequest(ctx, "PROPFIND", c.CalendarURL, []byte(propfindCTagBody))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "0")
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("caldav: PROPFIND %s: %w", c.CalendarURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("caldav: PROPFIND %s: unexpected status %d", c.CalendarURL, resp.StatusCode)
+	}
+
+	var parsed propfindResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return "", fmt.Errorf("caldav: decode PROPFIND response: %w", err)
+	}
+	for _, r := range parsed.Responses {
+		for _, ps := range r.Propstat {
+			if ps.Prop.GetCTag != "" {
+				return ps.Prop.GetCTag, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// calendarQueryBody — REPORT-тело, выбирающее все VEVENT/VFREEBUSY коллекции.
+const calendarQueryBody = `<?xml version="1.0" encoding="UTF-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop>
+    <c:calendar-data/>
+  </d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR"/>
+  </c:filter>
+</c:calendar-query>`
+
+type reportResponse struct {
+	Responses []struct {
+		Propstat []struct {
+			Prop struct {
+				CalendarData string `xml:"calendar-data"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// ListEvents выполняет calendar-query REPORT и возвращает сырые iCalendar-
+// документы каждого найденного ресурса (как правило, по одному VEVENT/VFREEBUSY
+// на ресурс — см. ParseBusyRanges для их разбора в занятые интервалы).
+func (c *Client) ListEvents(ctx context.Context) ([]string, error) {
+	req, err := c.newRequest(ctx, "REPORT", c.CalendarURL, []byte(calendarQueryBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Depth", "1")
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("caldav: REPORT %s: %w", c.CalendarURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil, fmt.Errorf("caldav: REPORT %s: unexpected status %d", c.CalendarURL, resp.StatusCode)
+	}
+
+	var parsed reportResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("caldav: decode REPORT response: %w", err)
+	}
+
+	docs := make([]string, 0, len(parsed.Responses))
+	for _, r := range parsed.Responses {
+		for _, ps := range r.Propstat {
+			if data := strings.TrimSpace(ps.Prop.CalendarData); data != "" {
+				docs = append(docs, data)
+			}
+		}
+	}
+	return docs, nil
+}
+
+// PutEvent загружает одно VEVENT-бронирование (уже отрендеренное как отдельный
+// VCALENDAR, см. icalendar.RenderBookingEvent) в коллекцию как ресурс
+// "{uid}.ics". If-None-Match не используется: overwrite безусловен, потому что
+// SEQUENCE внутри VEVENT уже несёт версию (см. model.Booking.Sequence).
+func (c *Client) PutEvent(ctx context.Context, uid string, ics []byte) error {
+	resourceURL := strings.TrimSuffix(c.CalendarURL, "/") + "/" + uid + ".ics"
+	req, err := c.newRequest(ctx, http.MethodPut, resourceURL, ics)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/calendar; charset=utf-8")
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("caldav: PUT %s: %w", resourceURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("caldav: PUT %s: unexpected status %d", resourceURL, resp.StatusCode)
+	}
+	return nil
+}
+
+// DeleteEvent удаляет ресурс бронирования, например при отмене Booking после
+// того, как оно уже было запушено наружу.
+func (c *Client) DeleteEvent(ctx context.Context, uid string) error {
+	resourceURL := strings.TrimSuffix(c.CalendarURL, "/") + "/" + uid + ".ics"
+	req, err := c.newRequest(ctx, http.MethodDelete, resourceURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("caldav: DELETE %s: %w", resourceURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("caldav: DELETE %s: unexpected status %d", resourceURL, resp.StatusCode)
+	}
+	return nil
+}
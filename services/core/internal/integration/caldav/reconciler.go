@@ -0,0 +1,179 @@
+package caldav
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/icalendar"
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// Config — параметры фонового реконсилятора CalDAV-интеграций.
+type Config struct {
+	// RunEvery — период между прогонами.
+	RunEvery time.Duration
+	// Horizon — насколько вперёд от текущего момента пушатся подтверждённые
+	// бронирования при исходящей синхронизации.
+	Horizon time.Duration
+}
+
+func (c Config) withDefaults() Config {
+	if c.RunEvery <= 0 {
+		c.RunEvery = 15 * time.Minute
+	}
+	if c.Horizon <= 0 {
+		c.Horizon = 90 * 24 * time.Hour
+	}
+	return c
+}
+
+// Reconciler периодически синхронизирует каждую подключённую CalendarIntegration
+// в обе стороны: исходящая сторона пушит подтверждённые бронирования как VEVENT,
+// входящая — сверяет ctag и, если он изменился, перекачивает VEVENT/VFREEBUSY
+// коллекции и перезаписывает CalendarBusyRange, которые затем учитываются при
+// проверке конфликтов в CreateBooking (см. CalendarService.listExternalBusyRangesTx).
+type Reconciler struct {
+	db              *gorm.DB
+	integrationRepo repository.CalendarIntegrationRepository
+	bookingRepo     repository.BookingRepository
+	cfg             Config
+	logger          *log.Logger
+}
+
+func NewReconciler(
+	db *gorm.DB,
+	integrationRepo repository.CalendarIntegrationRepository,
+	bookingRepo repository.BookingRepository,
+	cfg Config,
+) *Reconciler {
+	return &Reconciler{
+		db:              db,
+		integrationRepo: integrationRepo,
+		bookingRepo:     bookingRepo,
+		cfg:             cfg.withDefaults(),
+		logger:          log.Default(),
+	}
+}
+
+// Run блокирует вызывающего и по тикеру вызывает RunOnce, пока не отменят ctx.
+func (r *Reconciler) Run(ctx context.Context) {
+	r.runAndLog(ctx)
+
+	ticker := time.NewTicker(r.cfg.RunEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.runAndLog(ctx)
+		}
+	}
+}
+
+func (r *Reconciler) runAndLog(ctx context.Context) {
+	if err := r.RunOnce(ctx); err != nil {
+		r.logger.Printf("[ERROR] caldav.Reconciler.RunOnce: %v", err)
+	}
+}
+
+// RunOnce проходит по всем арендаторам и синхронизирует каждую CalendarIntegration
+// найденную в них — см. internal/scheduler.SlotMaterializer.RunOnce для того же
+// паттерна обхода арендаторов фоновым процессом без входящего запроса.
+func (r *Reconciler) RunOnce(ctx context.Context) error {
+	var nsIDs []uuid.UUID
+	if err := r.db.WithContext(ctx).Model(&model.Namespace{}).Pluck("id", &nsIDs).Error; err != nil {
+		return fmt.Errorf("list namespaces: %w", err)
+	}
+
+	for _, nsID := range nsIDs {
+		nsCtx := namespace.WithNamespace(ctx, nsID)
+		integrations, err := r.integrationRepo.List(nsCtx)
+		if err != nil {
+			return fmt.Errorf("list integrations for namespace %s: %w", nsID, err)
+		}
+		for i := range integrations {
+			if err := r.syncOne(nsCtx, &integrations[i]); err != nil {
+				r.logger.Printf("[ERROR] caldav.Reconciler: sync integration=%s provider=%s: %v",
+					integrations[i].ID, integrations[i].ProviderID, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) syncOne(ctx context.Context, ci *model.CalendarIntegration) error {
+	client := NewClient(ci.CalendarURL, ci.Username, ci.Password)
+	now := time.Now().UTC()
+
+	if err := r.pushConfirmedBookings(ctx, client, ci, now); err != nil {
+		_ = r.integrationRepo.UpdateSyncState(ctx, ci.ID, ci.CTag, now, err.Error())
+		return fmt.Errorf("push bookings: %w", err)
+	}
+
+	ctag, err := client.GetCTag(ctx)
+	if err != nil {
+		_ = r.integrationRepo.UpdateSyncState(ctx, ci.ID, ci.CTag, now, err.Error())
+		return fmt.Errorf("get ctag: %w", err)
+	}
+	if ctag != "" && ctag == ci.CTag {
+		// Коллекция не менялась с прошлого прогона — дорогой REPORT не нужен.
+		return r.integrationRepo.UpdateSyncState(ctx, ci.ID, ctag, now, "")
+	}
+
+	docs, err := client.ListEvents(ctx)
+	if err != nil {
+		_ = r.integrationRepo.UpdateSyncState(ctx, ci.ID, ci.CTag, now, err.Error())
+		return fmt.Errorf("list events: %w", err)
+	}
+
+	busy := ParseBusyRanges(docs)
+	ranges := make([]model.CalendarBusyRange, 0, len(busy))
+	for _, b := range busy {
+		ranges = append(ranges, model.CalendarBusyRange{
+			NamespaceID:   ci.NamespaceID,
+			IntegrationID: ci.ID,
+			ProviderID:    ci.ProviderID,
+			UID:           b.UID,
+			StartsAt:      b.Range.Start,
+			EndsAt:        b.Range.End,
+		})
+	}
+	if err := r.integrationRepo.ReplaceBusyRanges(ctx, ci.ID, ranges); err != nil {
+		return fmt.Errorf("replace busy ranges: %w", err)
+	}
+
+	return r.integrationRepo.UpdateSyncState(ctx, ci.ID, ctag, now, "")
+}
+
+// pushConfirmedBookings пушит как VEVENT все подтверждённые бронирования
+// провайдера в горизонте [now, now+Horizon). PUT идемпотентен (ресурс
+// "{booking_id}.ics" перезаписывается целиком), поэтому можно просто
+// повторять эту операцию на каждом прогоне, не отслеживая, что уже было отправлено.
+func (r *Reconciler) pushConfirmedBookings(ctx context.Context, client *Client, ci *model.CalendarIntegration, now time.Time) error {
+	bookings, err := r.bookingRepo.ListByProviderAndRange(ctx, ci.ProviderID.String(), now, now.Add(r.cfg.Horizon))
+	if err != nil {
+		return fmt.Errorf("list bookings: %w", err)
+	}
+	for i := range bookings {
+		if bookings[i].Status != model.BookingStatusConfirmed {
+			continue
+		}
+		ics, err := icalendar.RenderBookingEvent(&bookings[i], time.UTC)
+		if err != nil {
+			return fmt.Errorf("render booking %s: %w", bookings[i].ID, err)
+		}
+		if err := client.PutEvent(ctx, bookings[i].ID.String(), ics); err != nil {
+			return fmt.Errorf("put booking %s: %w", bookings[i].ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// RequirePermission — декоратор для точек входа (gRPC-хендлеры сервиса,
+// HTTP-хендлеры вроде internal/icalendar), которым нужно проверить наличие
+// конкретного права у уже известного пользователя, прежде чем выполнять
+// действие. В отличие от TenantUnaryInterceptor, здесь нет единого
+// unary-интерцептора: ни в одном методе этого сервиса "текущий пользователь"
+// не резолвится из метаданных — он приходит явным параметром (TelegramID,
+// UserID), поэтому и проверку права естественно делать там же, явным вызовом,
+// а не implicit-ным интерцептором над всеми RPC.
+//
+// Возвращает nil, если у userID есть право code; иначе — grpc-ошибку
+// codes.PermissionDenied, которую транспорт вернёт как есть (HTTP-хендлеры
+// могут мапить status.Code(err) == codes.PermissionDenied на 403). scope —
+// опциональный ProviderID, см. repository.PermissionRepository.HasPermission.
+func RequirePermission(ctx context.Context, permRepo repository.PermissionRepository, userID uuid.UUID, code string, scope *uuid.UUID) error {
+	if permRepo == nil {
+		return status.Error(codes.Internal, "permission repository is not configured")
+	}
+	ok, err := permRepo.HasPermission(ctx, userID, code, scope)
+	if err != nil {
+		return status.Errorf(codes.Internal, "check permission %q: %v", code, err)
+	}
+	if !ok {
+		return status.Errorf(codes.PermissionDenied, "missing permission %q", code)
+	}
+	return nil
+}
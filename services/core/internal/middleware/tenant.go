@@ -0,0 +1,56 @@
+// Package middleware содержит gRPC-интерцепторы, общие для всех сервисов
+// core. Сейчас в нём один интерцептор — резолв арендатора (см.
+// internal/namespace) из метаданных входящего запроса.
+package middleware
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// namespaceMetadataKey — имя заголовка gRPC-метаданных, в котором клиент
+// (бот/веб-клиент) передаёт ID своего арендатора.
+const namespaceMetadataKey = "x-namespace-id"
+
+// TenantUnaryInterceptor резолвит арендатора для каждого unary-вызова:
+// читает x-namespace-id из метаданных, проверяет, что такой арендатор
+// существует, и кладёт его в context.Context через namespace.WithNamespace —
+// дальше его подхватывают все namespace-скоупнутые репозитории этого чанка.
+//
+// Резолв арендатора по Telegram-чату/группе здесь намеренно не реализован:
+// даже у cmd/identity-bot нет способа определить арендатора из чата —
+// у него один фиксированный IDENTITY_BOT_NAMESPACE_ID на процесс, который он
+// и проставляет в этот заголовок на каждый вызов.
+func TenantUnaryInterceptor(nsRepo repository.NamespaceRepository) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing namespace metadata")
+		}
+
+		values := md.Get(namespaceMetadataKey)
+		if len(values) == 0 || values[0] == "" {
+			return nil, status.Errorf(codes.Unauthenticated, "missing %s metadata", namespaceMetadataKey)
+		}
+
+		ns, err := nsRepo.GetByID(ctx, values[0])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "unknown namespace")
+		}
+
+		ctx = namespace.WithNamespace(ctx, ns.ID)
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Create создаёт пустую пару файлов <следующий номер>_<name>.up.sql /
+// .down.sql в dir (обычно "internal/migrate/migrations" относительно корня
+// модуля). Это dev-time операция — запускается из исходников, а не из
+// собранного бинаря: миграции встраиваются через go:embed, поэтому новый
+// файл всё равно нужно закоммитить и пересобрать migrate-бинарь, прежде чем
+// он попадёт в Up/Status.
+func Create(dir, name string) (upPath, downPath string, err error) {
+	seq, err := nextSeq(dir)
+	if err != nil {
+		return "", "", err
+	}
+
+	upPath = filepath.Join(dir, fmt.Sprintf("%06d_%s.up.sql", seq, name))
+	downPath = filepath.Join(dir, fmt.Sprintf("%06d_%s.down.sql", seq, name))
+
+	for _, p := range []string{upPath, downPath} {
+		if err := os.WriteFile(p, []byte("-- TODO\n"), 0o644); err != nil {
+			return "", "", fmt.Errorf("write %s: %w", p, err)
+		}
+	}
+	return upPath, downPath, nil
+}
+
+func nextSeq(dir string) (uint, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, fmt.Errorf("read %s: %w", dir, err)
+	}
+
+	var max uint
+	for _, e := range entries {
+		var seq uint
+		if _, err := fmt.Sscanf(e.Name(), "%06d_", &seq); err == nil && seq > max {
+			max = seq
+		}
+	}
+	return max + 1, nil
+}
@@ -0,0 +1,133 @@
+// Package migrate управляет версией схемы Postgres через пронумерованные
+// SQL-файлы (см. migrations/) поверх github.com/golang-migrate/migrate.
+//
+// Раньше версию схемы держал model.AutoMigrate, молча докатывая структуру
+// таблиц на каждом старте сервера. Это нормально, пока в БД нет реальных
+// данных арендаторов, но становится опасно сразу после первого прод-релиза:
+// AutoMigrate не умеет ни DROP COLUMN, ни переименований, ни явного плана
+// отката, а неожиданный ALTER TABLE на проде — это инцидент. Теперь схему
+// накатывает отдельный бинарь (cmd, собранный с тегом `migration`, см.
+// main_migrate.go), а обычный сервер при старте только проверяет, что
+// версия в БД совпадает с тем, что знает его собственный бинарь (см.
+// VerifyVersion), и отказывается стартовать при расхождении.
+//
+// MySQL/SQLite (тесты, небольшие self-hosted развёртывания, см.
+// model.Dialect) этим инструментом не управляются — там по-прежнему
+// достаточно model.AutoMigrate, отдельные SQL-миграции для них не нужны.
+package migrate
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+
+	migratelib "github.com/golang-migrate/migrate/v4"
+	pgmigrate "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrate — псевдоним, чтобы вызывающему коду (cmd/main.go, main_migrate.go)
+// не нужно было импортировать golang-migrate напрямую.
+type Migrate = migratelib.Migrate
+
+// New собирает *Migrate поверх встроенных SQL-миграций и уже открытого
+// Postgres-соединения gdb (см. db.NewGormDB) — переиспользуем его вместо
+// того, чтобы заново собирать DSN под формат, который ждёт golang-migrate.
+func New(gdb *gorm.DB) (*Migrate, error) {
+	sqlDB, err := gdb.DB()
+	if err != nil {
+		return nil, fmt.Errorf("sql DB: %w", err)
+	}
+
+	driver, err := pgmigrate.WithInstance(sqlDB, &pgmigrate.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("init postgres migrate driver: %w", err)
+	}
+
+	src, err := iofs.New(migrationFiles, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("load embedded migrations: %w", err)
+	}
+
+	m, err := migratelib.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		return nil, fmt.Errorf("init migrate: %w", err)
+	}
+	return m, nil
+}
+
+// Up накатывает все ещё не применённые миграции.
+func Up(m *Migrate) error {
+	if err := m.Up(); err != nil && !errors.Is(err, migratelib.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down откатывает n последних применённых миграций. Вызывающий код
+// (main_migrate.go) обязан потребовать явное подтверждение разрушительной
+// операции до вызова Down — сам пакет это не решает.
+func Down(m *Migrate, n int) error {
+	if err := m.Steps(-n); err != nil && !errors.Is(err, migratelib.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Status возвращает текущую версию схемы в БД и флаг "грязного" состояния
+// (миграция оборвалась посередине). version == 0 означает, что миграции ещё
+// ни разу не накатывались.
+func Status(m *Migrate) (version uint, dirty bool, err error) {
+	version, dirty, err = m.Version()
+	if errors.Is(err, migratelib.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// VerifyVersion проверяет, что схема в БД не в "грязном" состоянии и её
+// версия совпадает с последней миграцией, встроенной в текущий бинарь.
+// Вызывается при старте обычного серверного бинаря вместо AutoMigrate —
+// расхождение версии означает, что кто-то забыл прогнать `migrate up`
+// перед раскаткой новой версии сервиса, и лучше отказаться стартовать, чем
+// работать против схемы, которую сервис не ожидает.
+func VerifyVersion(m *Migrate) error {
+	version, dirty, err := Status(m)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("schema is in a dirty state at version %d: fix manually, then rerun migrate", version)
+	}
+
+	want, err := latestEmbeddedVersion()
+	if err != nil {
+		return err
+	}
+	if version != want {
+		return fmt.Errorf("schema version drift: db is at %d, binary expects %d — run the migrate tool before starting the server", version, want)
+	}
+	return nil
+}
+
+// latestEmbeddedVersion возвращает номер старшей встроенной миграции — это
+// и есть версия схемы, которую должен видеть у себя сервер при старте.
+func latestEmbeddedVersion() (uint, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return 0, fmt.Errorf("read embedded migrations: %w", err)
+	}
+
+	var max uint
+	for _, e := range entries {
+		var seq uint
+		if _, err := fmt.Sscanf(e.Name(), "%06d_", &seq); err == nil && seq > max {
+			max = seq
+		}
+	}
+	return max, nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// blackouts — период, в который услуга (или, если ServiceID не задан, все
+// услуги провайдера) недоступна полностью: отпуск на уровне услуги,
+// праздничные дни и т.п. В отличие от UnavailabilityPeriod (заводится
+// провайдером на себя целиком) Blackout привязан к NamespaceID/ServiceID;
+// scheduler.SlotMaterializer ставит пересекающимся с ним слотам статус
+// unavailable (HasOverlap), не вычитая интервал. calendarutils.BlackoutSet
+// (ApplyBlackouts, ExpandRecurringRuleWithBlackouts) умеет такое вычитание,
+// но материализатор его не вызывает.
+type Blackout struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// NamespaceID — арендатор, которому принадлежит запись (см. model.Namespace).
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	// ServiceID — опциональное ограничение одной услугой; nil означает, что
+	// blackout распространяется на все услуги провайдера (см.
+	// MaintenanceWindow.ServiceID, UnavailabilityPeriod.ServiceID).
+	ServiceID *uuid.UUID `gorm:"type:uuid;index"`
+
+	StartsAt time.Time `gorm:"not null;index"`
+	EndsAt   time.Time `gorm:"not null;index"`
+
+	Reason string `gorm:"type:text"`
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+
+	Service *Service `gorm:"foreignKey:ServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (b *Blackout) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type BookingStatus string
@@ -16,15 +17,29 @@ const (
 
 // bookings
 type Booking struct {
-	ID          uuid.UUID     `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+	// NamespaceID — арендатор, которому принадлежит бронирование (см. model.Namespace).
+	NamespaceID uuid.UUID     `gorm:"type:uuid;not null;index"`
 	ClientID    uuid.UUID     `gorm:"type:uuid;not null;index"`
 	SlotID      uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex"`
-	CreatedAt   time.Time     `gorm:"not null;default:now()"`
-	UpdatedAt   time.Time     `gorm:"not null;default:now()"`
+	CreatedAt   time.Time     `gorm:"not null"`
+	UpdatedAt   time.Time     `gorm:"not null"`
 	Status      BookingStatus `gorm:"type:varchar(32);not null;index"`
-	CancelledAt *time.Time    `gorm:"type:timestamp with time zone"`
-	Comment     string        `gorm:"type:text"`
+	CancelledAt *time.Time
+	Comment     string `gorm:"type:text"`
+	// Sequence — счётчик версии бронирования для iCalendar SEQUENCE:
+	// увеличивается при каждом изменении статуса, чтобы подписчики фида
+	// понимали, что VEVENT нужно обновить.
+	Sequence int `gorm:"not null;default:0"`
 
 	Client *Client   `gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 	Slot   *TimeSlot `gorm:"foreignKey:SlotID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
 }
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (b *Booking) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// booking_idempotencies — результат CreateBooking, запомненный по ключу
+// идемпотентности клиента (см. CalendarService.CreateBooking). Повторный
+// вызов с тем же (ClientID, IdempotencyKey) и тем же PayloadHash в пределах
+// TTL возвращает BookingID без повторной блокировки слота; с другим
+// PayloadHash — считается конфликтом (ключ переиспользован для другого
+// запроса).
+type BookingIdempotency struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	NamespaceID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	ClientID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_booking_idempotency_key"`
+	IdempotencyKey string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_booking_idempotency_key"`
+
+	// PayloadHash — SHA-256 канонического представления запроса
+	// (client_id, slot_id, comment), чтобы отличить легитимный ретрай от
+	// переиспользования ключа для другого бронирования.
+	PayloadHash string    `gorm:"type:varchar(64);not null"`
+	BookingID   uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt   time.Time `gorm:"not null;index"`
+
+	Client  *Client  `gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Booking *Booking `gorm:"foreignKey:BookingID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (b *BookingIdempotency) BeforeCreate(tx *gorm.DB) error {
+	if b.ID == uuid.Nil {
+		b.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// calendar_feed_tokens — выданные провайдерам токены доступа к публичному
+// iCalendar-фиду (GET /providers/{id}/calendar.ics, см. icalendar.Handler).
+// Раньше токен был детерминированным HMAC-SHA256 от одного глобального
+// секрета (см. icalendar.SignProviderToken) — неподделываемым, но
+// неотзываемым: чтобы инвалидировать одну утёкшую ссылку, пришлось бы менять
+// секрет для всех провайдеров разом. Эта таблица хранит случайный токен на
+// провайдера отдельно, так что RevokeAll гасит ссылки только одного
+// провайдера.
+type CalendarFeedToken struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ProviderID  uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	// TokenHash — SHA-256 (hex) от самого токена. Сырое значение возвращается
+	// вызывающему ровно один раз, при выдаче (см.
+	// CalendarFeedTokenRepository.Issue), и нигде больше не хранится.
+	TokenHash string `gorm:"type:varchar(64);not null;uniqueIndex"`
+
+	CreatedAt time.Time  `gorm:"not null"`
+	RevokedAt *time.Time `gorm:"index"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (t *CalendarFeedToken) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// calendar_integrations — внешний CalDAV-календарь (Nextcloud/Radicale/Google
+// Calendar и т.п.), привязанный к провайдеру: подтверждённые бронирования
+// пушатся туда как VEVENT, а события из него учитываются как занятые
+// интервалы при проверке конфликтов (см. CalendarBusyRange).
+type CalendarIntegration struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// NamespaceID — арендатор, которому принадлежит провайдер (см. model.Namespace).
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	ProviderID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex"`
+
+	// CalendarURL — адрес календаря-коллекции CalDAV (например,
+	// https://nextcloud.example.com/remote.php/dav/calendars/alice/appointments/).
+	CalendarURL string `gorm:"type:text;not null"`
+	Username    string `gorm:"type:varchar(255)"`
+	// Password — пароль/app-токен приложения CalDAV. Хранится как есть, как
+	// и прочие секреты интеграций этого сервиса; ротация — ответственность
+	// провайдера при компрометации.
+	Password string `gorm:"type:text"`
+
+	// CTag — последний увиденный ctag коллекции (RFC 6578-подобный признак
+	// изменений): реконсилятор пропускает REPORT, если ctag не изменился.
+	CTag string `gorm:"type:text"`
+	// LastSyncedAt — время последней успешной синхронизации (в любую сторону).
+	LastSyncedAt *time.Time
+	// LastError — текст последней ошибки синхронизации, для диагностики в UI.
+	LastError string `gorm:"type:text"`
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+
+	Provider *Provider `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (c *CalendarIntegration) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// calendar_busy_ranges — занятые интервалы, полученные инбаунд-синхронизацией
+// из внешнего CalDAV-календаря (VEVENT/VFREEBUSY), которые не являются
+// Booking этого сервиса, но должны блокировать создание новых бронирований.
+// Реконсилятор полностью перезаписывает набор строк интеграции на каждый
+// прогон (см. caldav.Reconciler), поэтому здесь нет отдельного Status.
+type CalendarBusyRange struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	NamespaceID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	IntegrationID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ProviderID    uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	// UID — UID внешнего VEVENT/VFREEBUSY, чтобы реконсилятор мог понять,
+	// что строка соответствует тому же внешнему событию при повторном прогоне.
+	UID      string    `gorm:"type:text;not null"`
+	StartsAt time.Time `gorm:"not null;index"`
+	EndsAt   time.Time `gorm:"not null;index"`
+
+	CreatedAt time.Time `gorm:"not null"`
+
+	Integration *CalendarIntegration `gorm:"foreignKey:IntegrationID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (c *CalendarBusyRange) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
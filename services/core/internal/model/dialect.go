@@ -0,0 +1,30 @@
+package model
+
+// Dialect — драйвер БД, под который подстраиваются DDL-детали моделей и
+// миграций (см. internal/db.NewGormDB, которая выставляет его через
+// SetDialect сразу после gorm.Open, и EnableRowLevelSecurity, которая
+// пропускает себя на не-Postgres диалектах).
+type Dialect string
+
+const (
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+	DialectSQLite   Dialect = "sqlite"
+)
+
+// activeDialect — диалект текущего соединения. Пакетный уровень, а не поле
+// на *gorm.DB, потому что часть логики (generate-UUID-в-BeforeCreate не
+// нужен, а вот EnableRowLevelSecurity нужен) завязана на диалект, но не имеет
+// доступа к конфигу БД напрямую.
+var activeDialect Dialect = DialectPostgres
+
+// SetDialect выставляет активный диалект. Вызывается один раз при старте
+// приложения, сразу после gorm.Open, до AutoMigrate.
+func SetDialect(d Dialect) {
+	activeDialect = d
+}
+
+// ActiveDialect возвращает диалект, выставленный последним вызовом SetDialect.
+func ActiveDialect() Dialect {
+	return activeDialect
+}
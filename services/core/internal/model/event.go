@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // Тип события аудита.
@@ -14,22 +16,67 @@ const (
 	EventTypeBookingCancelled EventType = "booking_cancelled"
 	EventTypeBookingUpdated   EventType = "booking_updated"
 	EventTypeUserValidated    EventType = "user_validated"
+	EventTypeBlackoutAdded    EventType = "blackout_added"
+	EventTypeBlackoutRemoved  EventType = "blackout_removed"
 )
 
-// events — события аудита
+// events — события аудита и одновременно транзакционный outbox для их
+// публикации во внешние системы (см. internal/outbox.Dispatcher): строка
+// пишется в events в той же GORM-транзакции, что и бизнес-изменение (см.
+// writeAuditEvent), а доставка — отдельный, заведомо более медленный и
+// ненадёжный шаг, который не должен блокировать или проваливать эту
+// транзакцию.
 type Event struct {
-	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
 
 	EventType EventType `gorm:"type:varchar(64);not null;index"`
 
-	CreatedAt time.Time `gorm:"not null;default:now();index"`
+	CreatedAt time.Time `gorm:"not null;index"`
 
 	UserID    *uuid.UUID `gorm:"type:uuid;index"`
 	BookingID *uuid.UUID `gorm:"type:uuid;index"`
 
 	Details string `gorm:"type:text"`
 
+	// Payload — каноническое JSON-представление события для внешних
+	// потребителей (см. internal/outbox.Publisher), в отличие от Details,
+	// который остаётся человекочитаемой записью для ручного разбора
+	// аудита и может не быть валидным JSON для более старых строк. Без
+	// явного gorm-тега типа: datatypes.JSON сама подбирает колонку под
+	// текущий диалект (см. NotificationOutbox.PayloadJSON).
+	Payload datatypes.JSON
+
+	// PublishedAt — момент успешной публикации через internal/outbox.Dispatcher;
+	// nil — ещё не опубликовано (или публикация не настроена).
+	PublishedAt *time.Time `gorm:"index"`
+	// Attempts — число уже сделанных попыток публикации.
+	Attempts int `gorm:"not null;default:0"`
+	// LastError — текст последней ошибки Publisher.Publish, для
+	// GET /admin/outbox?status=failed; пустая строка, если попыток ещё не
+	// было или последняя была успешной.
+	LastError string `gorm:"type:text"`
+	// NextAttemptAt — не раньше этого момента Dispatcher возьмёт строку в
+	// очередную попытку; сдвигается экспоненциально (с джиттером) при
+	// каждой неудаче, как NextAttemptAt у NotificationOutbox.
+	NextAttemptAt time.Time `gorm:"not null;index"`
+
 	// Навигационные поля
 	User    *User    `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 	Booking *Booking `gorm:"foreignKey:BookingID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 }
+
+// BeforeCreate — см. Namespace.BeforeCreate; также проставляет CreatedAt/
+// NextAttemptAt по умолчанию, если вызывающий их не задал явно (см.
+// NotificationOutbox.BeforeCreate — тот же приём).
+func (e *Event) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now().UTC()
+	}
+	if e.NextAttemptAt.IsZero() {
+		e.NextAttemptAt = e.CreatedAt
+	}
+	return nil
+}
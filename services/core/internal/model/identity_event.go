@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdentityEventType — тип push-события профиля (см. internal/events.Bus,
+// IdentityService.SubscribeUserEvents).
+type IdentityEventType string
+
+const (
+	IdentityEventRegistered       IdentityEventType = "REGISTERED"
+	IdentityEventContactsUpdated  IdentityEventType = "CONTACTS_UPDATED"
+	IdentityEventRoleChanged      IdentityEventType = "ROLE_CHANGED"
+	IdentityEventProviderAttached IdentityEventType = "PROVIDER_ATTACHED"
+	IdentityEventReset            IdentityEventType = "RESET"
+)
+
+// identity_events — постоянный, монотонно пронумерованный журнал событий
+// профиля пользователя. internal/events.Bus разносит события подписчикам
+// стрима в процессе (низкая задержка, но теряется при разрыве соединения);
+// эта таблица — единственный источник правды для replay по cursor "since"
+// при переподключении (см. repository.IdentityEventRepository.ListSince),
+// с ретеншном через scheduler.IdentityEventSweeper. В отличие от events
+// (model.Event), который устроен как outbox для внешних систем, ID здесь —
+// auto-increment, а не uuid, потому что именно он и есть cursor: строки
+// должны читаться строго в порядке появления на сервере, а не в порядке
+// CreatedAt (возможны совпадающие по времени события).
+type IdentityEvent struct {
+	ID uint64 `gorm:"primaryKey;autoIncrement"`
+
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	TelegramID int64     `gorm:"not null;index"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	EventType IdentityEventType `gorm:"type:varchar(32);not null"`
+
+	// ProviderID — заполнено только для IdentityEventProviderAttached.
+	ProviderID *uuid.UUID `gorm:"type:uuid"`
+
+	CreatedAt time.Time `gorm:"not null;index"`
+}
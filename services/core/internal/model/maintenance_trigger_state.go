@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maintenance_trigger_states — прогресс фонового scheduler.MaintenanceScheduler
+// по каждому MaintenanceWindow: какое вхождение (разовое или очередное по
+// RRULE) уже привело к срабатыванию CancelSlotsForMaintenanceWindow. Отдельная
+// таблица, а не поля на MaintenanceWindow, по тем же причинам, что и
+// ScheduleMaterializationState — прогресс обновляется вне транзакции
+// CreateMaintenanceWindow/UpdateMaintenanceWindow и должен пережить рестарт
+// процесса без повторной отмены уже обработанного вхождения.
+type MaintenanceTriggerState struct {
+	WindowID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// TriggeredOccurrenceStart — StartsAt вхождения окна, на вход в которое уже
+	// сработала отмена. Пока now находится внутри этого же вхождения, повторный
+	// тик scheduler.MaintenanceScheduler его пропускает; как только now выходит
+	// за пределы вхождения, запись считается устаревшей и окно "перевзводится"
+	// для следующего вхождения (актуально для окон с RRule).
+	TriggeredOccurrenceStart time.Time
+
+	UpdatedAt time.Time `gorm:"not null"`
+}
@@ -0,0 +1,85 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type MaintenanceWindowKind string
+
+const (
+	// MaintenanceWindowKindHard — окно, при сохранении которого затронутые
+	// подтверждённые бронирования отменяются немедленно.
+	MaintenanceWindowKindHard MaintenanceWindowKind = "hard"
+	// MaintenanceWindowKindSoft — окно только скрывает будущие слоты, уже
+	// существующие бронирования не трогает.
+	MaintenanceWindowKindSoft MaintenanceWindowKind = "soft"
+)
+
+// MaintenanceWindowStatus управляет тем, учитывает ли окно фоновый
+// scheduler.MaintenanceScheduler и activeMaintenanceRanges вообще, независимо
+// от StartsAt/EndsAt/RRule.
+type MaintenanceWindowStatus string
+
+const (
+	// MaintenanceWindowStatusActive — окно действует по своему расписанию.
+	MaintenanceWindowStatusActive MaintenanceWindowStatus = "active"
+	// MaintenanceWindowStatusPaused — окно временно отключено провайдером:
+	// слоты не блокируются и бронирования не отменяются, пока не вернут active.
+	MaintenanceWindowStatusPaused MaintenanceWindowStatus = "paused"
+	// MaintenanceWindowStatusExpired — разовое окно, последнее вхождение
+	// которого уже прошло; проставляется scheduler.MaintenanceScheduler, чтобы
+	// не пересчитывать его развёртку на каждом тике.
+	MaintenanceWindowStatusExpired MaintenanceWindowStatus = "expired"
+)
+
+// maintenance_windows — периоды недоступности провайдера (разовые или по RRULE),
+// во время которых не должны материализовываться бронируемые слоты.
+type MaintenanceWindow struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	ProviderID uuid.UUID `gorm:"type:uuid;not null;index"`
+	// ServiceID — опциональное ограничение окна одной услугой провайдера;
+	// nil означает, что окно перекрывает все услуги провайдера целиком.
+	ServiceID *uuid.UUID `gorm:"type:uuid;index"`
+
+	StartsAt time.Time `gorm:"not null;index"`
+	EndsAt   time.Time `gorm:"not null;index"`
+
+	// RRule — опциональное правило повторения (RFC 5545), если окно не разовое.
+	RRule *string `gorm:"column:r_rule;type:text"`
+
+	// Name/Description — человекочитаемое название окна (например, "Отпуск",
+	// "Техобслуживание оборудования") для списков в UI; Reason остаётся
+	// текстом, который видят клиенты при отмене бронирования.
+	Name        string                `gorm:"type:varchar(255)"`
+	Description string                `gorm:"type:text"`
+	Reason      string                `gorm:"type:text"`
+	Kind        MaintenanceWindowKind `gorm:"type:varchar(16);not null;default:'hard';index"`
+
+	// Status — см. MaintenanceWindowStatus. Paused/expired окна исключаются
+	// из activeMaintenanceRanges и из обхода scheduler.MaintenanceScheduler.
+	Status MaintenanceWindowStatus `gorm:"type:varchar(16);not null;default:'active';index"`
+
+	// CreatedBy — пользователь (провайдер/админ), создавший окно, для аудита.
+	CreatedBy *uuid.UUID `gorm:"type:uuid"`
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+
+	Provider *Provider `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Service  *Service  `gorm:"foreignKey:ServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (m *MaintenanceWindow) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	if m.Status == "" {
+		m.Status = MaintenanceWindowStatusActive
+	}
+	return nil
+}
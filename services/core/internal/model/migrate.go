@@ -4,17 +4,47 @@ import "gorm.io/gorm"
 
 // AutoMigrate выполняет миграцию всех сущностей календарного ядра.
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
+		&Namespace{},
 		&User{},
 		&Role{},
 		&UserRole{},
+		&Permission{},
+		&PermissionGroup{},
+		&PermissionGroupPermission{},
+		&RolePermissionGroup{},
 		&Client{},
 		&Provider{},
 		&Service{},
 		&ProviderService{},
 		&Schedule{},
+		&ScheduleTemplate{},
 		&TimeSlot{},
 		&Booking{},
 		&Event{},
-	)
+		&MaintenanceWindow{},
+		&UnavailabilityPeriod{},
+		&Blackout{},
+		&CalendarIntegration{},
+		&CalendarBusyRange{},
+		&Waitlist{},
+		&Resource{},
+		&ServiceResourceRequirement{},
+		&TimeSlotResource{},
+		&BookingIdempotency{},
+		&ScheduleMaterializationState{},
+		&MaintenanceTriggerState{},
+		&NotificationOutbox{},
+		&VerificationCode{},
+		&IdentityEvent{},
+		&CalendarFeedToken{},
+	); err != nil {
+		return err
+	}
+
+	if err := EnableRowLevelSecurity(db); err != nil {
+		return err
+	}
+
+	return EnsureSlotOverlapConstraint(db)
 }
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// namespaces — арендатор (организация), в рамках которого изолированы все
+// данные календарного ядра. Одно развёртывание сервиса может обслуживать
+// много независимых бизнесов: строки User/Provider/Service/Schedule/
+// TimeSlot/Booking/UserRole помечены NamespaceID и защищены Postgres RLS
+// (см. model.EnableRowLevelSecurity) плюс предикатом на уровне Go-репозиториев.
+type Namespace struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	Name string `gorm:"type:varchar(255);not null"`
+
+	// TelegramChatID — опциональная привязка к группе/каналу Telegram, через
+	// которую бот резолвит арендатора (см. internal/namespace).
+	TelegramChatID *int64 `gorm:"uniqueIndex"`
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+// BeforeCreate генерирует ID в Go: на Postgres раньше это делал
+// gen_random_uuid() по умолчанию колонки, но MySQL/SQLite (см. model.Dialect)
+// такого не умеют, поэтому UUID везде проставляется здесь одинаково.
+func (n *Namespace) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// NotificationEventType — тип события в notifications_outbox.
+type NotificationEventType string
+
+const (
+	NotificationEventBookingCancelled  NotificationEventType = "BookingCancelled"
+	NotificationEventBookingConfirmed  NotificationEventType = "BookingConfirmed"
+	NotificationEventSlotCancelledBulk NotificationEventType = "SlotCancelledBulk"
+	NotificationEventWaitlistOffer     NotificationEventType = "WaitlistOffer"
+)
+
+// notifications_outbox — транзакционный outbox для уведомлений клиентов
+// (Telegram/etc., см. internal/notify): запись пишется в той же
+// gorm.Transaction, что и бизнес-изменение (отмена брони, массовая отмена
+// слотов, предложение из листа ожидания), поэтому появление события не
+// зависит от того, доставлено ли оно — доставка отделена и идёт через
+// фоновый scheduler.OutboxDispatcher, который выбирает неопубликованные
+// строки и делает at-least-once попытки с экспоненциальным backoff
+// (NextAttemptAt, Attempts).
+type NotificationOutbox struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	// AggregateID — сущность, к которой относится событие (бронирование,
+	// запись листа ожидания и т.п.), для группировки/дедупликации на стороне
+	// потребителя.
+	AggregateID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	EventType NotificationEventType `gorm:"type:varchar(32);not null;index"`
+
+	// PayloadJSON — см. model.Schedule.Rules: без явного gorm-тега типа,
+	// datatypes.JSON сама подбирает колонку под текущий диалект.
+	PayloadJSON datatypes.JSON
+
+	CreatedAt time.Time `gorm:"not null;index"`
+	// PublishedAt — момент успешной доставки; nil — ещё не доставлено.
+	PublishedAt *time.Time `gorm:"index"`
+	// Attempts — число уже сделанных попыток доставки.
+	Attempts int `gorm:"not null;default:0"`
+	// NextAttemptAt — не раньше этого момента дispatcher возьмёт строку в
+	// очередную попытку; сдвигается экспоненциально (с джиттером) при
+	// каждой неудаче (см. scheduler.outboxBackoff).
+	NextAttemptAt time.Time `gorm:"not null;index"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate; также проставляет CreatedAt/
+// NextAttemptAt по умолчанию, если вызывающий их не задал явно.
+func (n *NotificationOutbox) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now().UTC()
+	}
+	if n.NextAttemptAt.IsZero() {
+		n.NextAttemptAt = n.CreatedAt
+	}
+	return nil
+}
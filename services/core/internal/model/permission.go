@@ -0,0 +1,39 @@
+package model
+
+// permissions — атомарные права в формате "domain.action" (slot.read,
+// booking.cancel, ...). Права сгруппированы в PermissionGroup, а группы
+// назначаются ролям через RolePermissionGroup — так один Role может
+// объединять несколько групп прав, а одна группа — переиспользоваться
+// несколькими ролями.
+type Permission struct {
+	ID   int64  `gorm:"primaryKey;autoIncrement"`
+	Code string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	// Description — что именно разрешает право, для админки/seed-данных.
+	Description string `gorm:"type:text"`
+}
+
+// permission_groups — именованный набор прав (например, "provider-admin").
+type PermissionGroup struct {
+	ID   int64  `gorm:"primaryKey;autoIncrement"`
+	Code string `gorm:"type:varchar(64);not null;uniqueIndex"`
+	Name string `gorm:"type:varchar(255)"`
+}
+
+// permission_group_permissions — связывает группу прав с правами (многие-ко-многим).
+type PermissionGroupPermission struct {
+	PermissionGroupID int64 `gorm:"primaryKey"`
+	PermissionID      int64 `gorm:"primaryKey"`
+
+	PermissionGroup *PermissionGroup `gorm:"foreignKey:PermissionGroupID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Permission      *Permission      `gorm:"foreignKey:PermissionID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// role_permission_groups — связывает роль (Role) с группами прав, которые она
+// в себя включает (многие-ко-многим).
+type RolePermissionGroup struct {
+	RoleID            int64 `gorm:"primaryKey"`
+	PermissionGroupID int64 `gorm:"primaryKey"`
+
+	Role            *Role            `gorm:"foreignKey:RoleID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	PermissionGroup *PermissionGroup `gorm:"foreignKey:PermissionGroupID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
@@ -4,12 +4,17 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // Provider — представитель услуг (консультант, мастер и т.п.).
 // Привязан к базе пользователей через UserID.
 type Provider struct {
-	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// NamespaceID — арендатор, которому принадлежит провайдер (см. model.Namespace).
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
 
 	// Внешний ключ на таблицу пользователей.
 	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
@@ -20,8 +25,16 @@ type Provider struct {
 	// Краткое описание, специализация и т.п.
 	Description string `gorm:"type:text"`
 
-	CreatedAt time.Time `gorm:"not null;default:now()"`
-	UpdatedAt time.Time `gorm:"not null;default:now()"`
+	// BusinessHours — недельный шаблон доступности (см.
+	// calendarutils.WeeklyTemplate, JSON-сериализация через
+	// WeeklyTemplate.MarshalJSON/UnmarshalJSON), задаваемый один раз вместо
+	// повторения рабочих часов в каждом Schedule — "работаем Пн-Пт
+	// 10:00-19:00". Опционально: nil/пусто означает, что доступность
+	// целиком определяется правилами Schedule.Rules.
+	BusinessHours datatypes.JSON
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
 
 	// Навигационные поля для GORM (опционально, но удобно для Preload).
 	User *User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
@@ -31,3 +44,11 @@ type Provider struct {
 	Schedules []Schedule `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Slots     []TimeSlot `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (p *Provider) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
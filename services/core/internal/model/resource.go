@@ -0,0 +1,74 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ResourceKind — тип ресурса, требуемого для оказания услуги. Набор
+// значений открытый (Kind — просто varchar), эти три — самые частые и
+// соответствуют полям SlotKey в Google Maps Booking (MerchantID уже есть в
+// лице Provider, здесь — StaffID/RoomID/EquipmentID).
+type ResourceKind string
+
+const (
+	ResourceKindStaff     ResourceKind = "staff"
+	ResourceKindRoom      ResourceKind = "room"
+	ResourceKindEquipment ResourceKind = "equipment"
+)
+
+// resources — конкретный сотрудник/кабинет/оборудование провайдера. Ресурс
+// закрепляется за слотом через TimeSlotResource и должен быть свободен на
+// время бронирования наравне с самим слотом (см.
+// CalendarService.lockAndCheckSlotResourcesTx).
+type Resource struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// NamespaceID — арендатор, которому принадлежит ресурс (см. model.Namespace).
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ProviderID  uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	Kind ResourceKind `gorm:"type:varchar(32);not null;index"`
+	Name string       `gorm:"type:varchar(255);not null"`
+
+	IsActive bool `gorm:"not null;default:true;index"`
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+
+	Provider *Provider `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (r *Resource) BeforeCreate(tx *gorm.DB) error {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return nil
+}
+
+// service_resource_requirements — сколько ресурсов каждого типа требуется
+// услуге одновременно (например, 1 staff + 1 room для стрижки в кресле).
+// Джойн-таблица без NamespaceID, как и ProviderService.
+type ServiceResourceRequirement struct {
+	ServiceID uuid.UUID    `gorm:"type:uuid;primaryKey"`
+	Kind      ResourceKind `gorm:"type:varchar(32);primaryKey"`
+
+	Count int `gorm:"not null;default:1"`
+
+	Service *Service `gorm:"foreignKey:ServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// time_slot_resources — какие конкретные ресурсы закреплены за слотом.
+// CreateBooking блокирует и проверяет занятость каждого из них не менее
+// строго, чем сам слот (см. CalendarService.lockAndCheckSlotResourcesTx), а
+// ListFreeSlots фильтрует по этому набору через resource_ids запроса.
+type TimeSlotResource struct {
+	SlotID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	ResourceID uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+
+	Slot     *TimeSlot `gorm:"foreignKey:SlotID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Resource *Resource `gorm:"foreignKey:ResourceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// tenantScopedTables — таблицы, хранящие NamespaceID и поэтому защищаемые
+// row-level security. Порядок не важен: каждая инструкция идемпотентна.
+var tenantScopedTables = []string{
+	"users",
+	"providers",
+	"services",
+	"schedules",
+	"schedule_templates",
+	"time_slots",
+	"bookings",
+	"user_roles",
+	"calendar_integrations",
+	"calendar_busy_ranges",
+	"waitlists",
+	"resources",
+	"booking_idempotencies",
+	"schedule_materialization_states",
+	"notification_outboxes",
+	"blackouts",
+	"verification_codes",
+	"identity_events",
+	"calendar_feed_tokens",
+}
+
+// EnableRowLevelSecurity включает Postgres RLS на всех таблицах, относящихся
+// к арендатору (namespace_id), и создаёт политику, допускающую строку только
+// если namespace_id совпадает с текущей сессионной переменной
+// app.namespace_id. Эту переменную на время транзакции устанавливает
+// db.NewGormDB (см. internal/db/tenant.go) из namespace.FromContext(ctx).
+//
+// ALTER TABLE ... ENABLE ROW LEVEL SECURITY — синтаксис, специфичный для
+// Postgres (см. model.Dialect). На MySQL/SQLite (тесты, небольшие
+// self-hosted развёртывания) эта функция — no-op: многоарендность там
+// держится только на предикате namespace_id в Go-репозиториях.
+//
+// FORCE ROW LEVEL SECURITY обязателен следом за ENABLE: без него Postgres
+// освобождает от политик владельца таблицы, а значит и любую роль, от имени
+// которой эти таблицы создавались, — для такой роли ENABLE ROW LEVEL SECURITY
+// без FORCE ничего не даёт. Продакшен-развёртывание должно запускать
+// миграции (эту функцию) под ролью — владельцем схемы, а обслуживать
+// DB_USER/DB_PASSWORD приложения отдельной, не-владеющей ролью, иначе
+// политика tenant_isolation не применяется к собственным запросам сервиса.
+//
+// Вызывается из AutoMigrate, поэтому должна быть идемпотентной — на повторный
+// запуск DROP POLICY IF EXISTS + CREATE POLICY не падает.
+func EnableRowLevelSecurity(db *gorm.DB) error {
+	if ActiveDialect() != DialectPostgres {
+		return nil
+	}
+	for _, table := range tenantScopedTables {
+		if err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ENABLE ROW LEVEL SECURITY`, table)).Error; err != nil {
+			return fmt.Errorf("enable RLS on %s: %w", table, err)
+		}
+		if err := db.Exec(fmt.Sprintf(`ALTER TABLE %s FORCE ROW LEVEL SECURITY`, table)).Error; err != nil {
+			return fmt.Errorf("force RLS on %s: %w", table, err)
+		}
+		if err := db.Exec(fmt.Sprintf(`DROP POLICY IF EXISTS tenant_isolation ON %s`, table)).Error; err != nil {
+			return fmt.Errorf("drop tenant_isolation policy on %s: %w", table, err)
+		}
+		// current_setting(..., true) — с missing_ok=true, иначе на сессии, где
+		// app.namespace_id ещё не выставлен (GUC не объявлен в postgresql.conf
+		// как custom_variable_classes, только set_config'ится рантаймом),
+		// current_setting бросает "unrecognized configuration parameter" вместо
+		// того, чтобы просто не пропустить ни одной строки. missing_ok=true
+		// возвращает NULL в этом случае, а namespace_id = NULL::uuid — это
+		// NULL/unknown, а не true, так что политика по-прежнему fail-closed.
+		policy := fmt.Sprintf(
+			`CREATE POLICY tenant_isolation ON %s USING (namespace_id = current_setting('app.namespace_id', true)::uuid)`,
+			table,
+		)
+		if err := db.Exec(policy).Error; err != nil {
+			return fmt.Errorf("create tenant_isolation policy on %s: %w", table, err)
+		}
+	}
+	return nil
+}
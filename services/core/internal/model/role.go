@@ -1,6 +1,9 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 // roles
 type Role struct {
@@ -11,12 +14,38 @@ type Role struct {
 	// Users []User `gorm:"many2many:user_roles"` — можно добавить, но не обязательно
 }
 
-// user_roles — связывает пользователей и роли (комбинированный PK)
+// user_roles — связывает пользователей и роли. Суррогатный ID (а не
+// комбинированный PK из RoleID+UserID, как раньше) нужен с тех пор, как роль
+// можно назначить со scope: один и тот же пользователь может одновременно
+// иметь роль "provider" глобально и ту же роль "provider", ограниченную
+// конкретным ProviderID (например, сотрудник, admin-ящий чужую запись),
+// и это два разных user_roles, а не одна строка.
 type UserRole struct {
-	RoleID int64     `gorm:"primaryKey;index"`
-	UserID uuid.UUID `gorm:"type:uuid;primaryKey;index"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// NamespaceID — арендатор, в рамках которого пользователю назначена роль
+	// (см. model.Namespace). Одна и та же учётка может иметь разные роли в
+	// разных организациях.
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+	RoleID      int64     `gorm:"not null;index"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	// ProviderID — опциональный scope роли: если задан, роль действует
+	// только в рамках этого провайдера, иначе (nil) — глобально для
+	// арендатора, как было до scoped-ролей (см.
+	// repository.RoleRepository.AssignRole, PermissionRepository.HasPermission).
+	ProviderID *uuid.UUID `gorm:"type:uuid;index"`
 
 	// Навигационные поля (по желанию)
-	Role *Role `gorm:"foreignKey:RoleID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
-	User *User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Role     *Role     `gorm:"foreignKey:RoleID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT"`
+	User     *User     `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Provider *Provider `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (ur *UserRole) BeforeCreate(tx *gorm.DB) error {
+	if ur.ID == uuid.Nil {
+		ur.ID = uuid.New()
+	}
+	return nil
 }
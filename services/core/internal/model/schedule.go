@@ -5,25 +5,68 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 // schedules
 type Schedule struct {
-	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// NamespaceID — арендатор, которому принадлежит расписание (см. model.Namespace).
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
 
 	ProviderID uuid.UUID `gorm:"type:uuid;not null;index"`
 
+	// ServiceID/StaffID/ResourceID — опциональное закрепление расписания за
+	// конкретной услугой/сотрудником/помещением провайдера; материализованные
+	// из него слоты (см. scheduler.SlotMaterializer) наследуют все три поля,
+	// образуя вместе с ProviderID тот же составной ключ слота, что и у
+	// TimeSlot (см. model.TimeSlot.StaffID).
+	ServiceID  *uuid.UUID `gorm:"type:uuid;index"`
+	StaffID    *uuid.UUID `gorm:"type:uuid;index"`
+	ResourceID *uuid.UUID `gorm:"type:uuid;index"`
+
+	// Capacity — наследуется материализованными слотами как TimeSlot.Capacity.
+	Capacity int `gorm:"not null;default:1"`
+
+	// TemplateID — шаблон (см. model.ScheduleTemplate), из применения
+	// которого возникло это расписание; nil для расписаний, заведённых
+	// напрямую через CreateProviderSchedule. Вместе с ProviderID и StartDate
+	// образует ключ идемпотентности ApplyScheduleTemplate — повторное
+	// применение того же шаблона с тем же start_date не плодит дубликат.
+	TemplateID *uuid.UUID `gorm:"type:uuid;index"`
+
 	// Чистые даты без времени — datatypes.Date
 	StartDate *datatypes.Date `gorm:"type:date"`
 	EndDate   *datatypes.Date `gorm:"type:date"`
 
 	TimeZone string `gorm:"type:varchar(64);not null;default:'UTC'"`
 
-	// Правило повторения в виде JSON (можно хранить как JSONB в Postgres).
-	Rules datatypes.JSON `gorm:"type:jsonb"`
+	// Правило повторения в виде JSON. Без явного gorm-тега типа: datatypes.JSON
+	// сама подбирает подходящую колонку (jsonb/json/text) под текущий диалект
+	// (см. model.Dialect).
+	Rules datatypes.JSON
 
-	CreatedAt time.Time `gorm:"not null;default:now()"`
-	UpdatedAt time.Time `gorm:"not null;default:now()"`
+	// ScheduleVersion увеличивается на единицу при каждом изменении правила
+	// или дат расписания (см. repository.ScheduleRepository.Update). Фоновый
+	// материализатор (internal/scheduler.SlotMaterializer) сравнивает его с
+	// версией, на которую слоты материализованы в последний раз, чтобы
+	// пересобирать только затронутые расписания, а не весь горизонт провайдера.
+	ScheduleVersion int `gorm:"not null;default:1"`
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
 
 	Provider *Provider `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	if s.ScheduleVersion == 0 {
+		s.ScheduleVersion = 1
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// schedule_materialization_states — какую версию расписания (см.
+// Schedule.ScheduleVersion) фоновый материализатор (internal/scheduler.
+// SlotMaterializer) уже разложил в TimeSlot, и до какого момента. Отдельная
+// таблица, а не поля на Schedule, чтобы запись обновлялась вне транзакции
+// CreateProviderSchedule/UpdateProviderSchedule — материализация всегда
+// асинхронна по отношению к изменению правила расписания.
+type ScheduleMaterializationState struct {
+	ScheduleID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// NamespaceID — арендатор расписания, продублирован сюда, чтобы не
+	// джойнить schedules при каждой проверке свежести в SlotMaterializer.
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ProviderID  uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	// MaterializedVersion — Schedule.ScheduleVersion на момент последней
+	// успешной перестройки. Расхождение с текущей версией расписания
+	// означает, что правило поменялось и его нужно перематериализовать.
+	MaterializedVersion int `gorm:"not null;default:0"`
+	// MaterializedUntil — правая граница окна, до которого уже
+	// материализованы слоты этого расписания (скользящий горизонт).
+	MaterializedUntil time.Time
+
+	UpdatedAt time.Time `gorm:"not null"`
+}
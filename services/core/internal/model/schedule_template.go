@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// schedule_templates — именованный набор дефолтов (правило повторения,
+// услуги, длительность), который провайдер заводит один раз и затем
+// применяет к произвольным диапазонам дат (см.
+// CalendarService.ApplyScheduleTemplate), вместо того чтобы каждый раз
+// заново собирать ScheduleRule на клиенте под одну и ту же недельную сетку.
+type ScheduleTemplate struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// NamespaceID — арендатор, которому принадлежит шаблон (см. model.Namespace).
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	// OwnerID — провайдер, создавший шаблон; единственный, кому разрешено его
+	// применять (см. CalendarService.ensureProviderRole).
+	OwnerID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	Name string `gorm:"type:varchar(255);not null"`
+
+	// DefaultRule — правило повторения в том же JSON-формате, что
+	// Schedule.Rules (см. encodeScheduleRule/decodeScheduleRule) — именно его
+	// ApplyScheduleTemplate копирует в каждое создаваемое расписание.
+	DefaultRule datatypes.JSON
+
+	// DefaultServiceIDs — услуги, для которых применение шаблона заводит
+	// отдельное Schedule (по одной на услугу, см. model.Schedule.ServiceID).
+	// Хранится JSON-массивом строк UUID, а не отдельной M:N таблицей: список
+	// нужен только как дефолт на момент применения и не требует собственных
+	// выборок/внешних ключей. Пустой список — расписание без привязки к
+	// конкретной услуге.
+	DefaultServiceIDs datatypes.JSON
+
+	// DefaultDurationMin — длительность слота по умолчанию, когда DefaultRule
+	// её не переопределяет.
+	DefaultDurationMin int32 `gorm:"not null;default:30"`
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (t *ScheduleTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // Статус слота расписания.
@@ -13,26 +14,70 @@ const (
 	TimeSlotStatusPlanned   TimeSlotStatus = "planned"
 	TimeSlotStatusBooked    TimeSlotStatus = "booked"
 	TimeSlotStatusCancelled TimeSlotStatus = "cancelled"
+	// TimeSlotStatusBlocked — слот материализован по расписанию, но попадает в
+	// активное MaintenanceWindow провайдера: не показывается как свободный и
+	// не может быть забронирован, пока окно действует (см.
+	// scheduler.SlotMaterializer.Rebuild).
+	TimeSlotStatusBlocked TimeSlotStatus = "blocked"
+	// TimeSlotStatusUnavailable — слот попадает в UnavailabilityPeriod
+	// провайдера (отпуск/больничный): как и blocked, не показывается
+	// свободным и не может быть забронирован, но в отличие от
+	// MaintenanceWindow период одноразовый и не отменяет уже существующие
+	// бронирования (см. CalendarService.activeUnavailabilityRanges).
+	TimeSlotStatusUnavailable TimeSlotStatus = "unavailable"
 )
 
 // time_slots
 type TimeSlot struct {
-	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	// NamespaceID — арендатор, которому принадлежит слот (см. model.Namespace).
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
 
 	ScheduleID *uuid.UUID `gorm:"type:uuid;index"`
 	ProviderID uuid.UUID  `gorm:"type:uuid;not null;index"`
 	ServiceID  *uuid.UUID `gorm:"type:uuid;index"`
 
-	StartsAt time.Time `gorm:"type:timestamp with time zone;not null;index"`
-	EndsAt   time.Time `gorm:"type:timestamp with time zone;not null"`
+	// StaffID/ResourceID — опциональное закрепление слота за конкретным
+	// сотрудником и помещением/оборудованием провайдера (см. model.Resource).
+	// Вместе с ProviderID/ServiceID образуют тот же составной ключ слота, что
+	// SlotKey в Google Maps Booking — материализация и бронирование проверяют
+	// конфликты по этому кортежу, а не только по времени (см.
+	// CalendarService.BulkCreateSlots, CalendarService.countTupleConfirmedBookingsTx).
+	StaffID    *uuid.UUID `gorm:"type:uuid;index"`
+	ResourceID *uuid.UUID `gorm:"type:uuid;index"`
+
+	// Capacity — сколько одновременных бронирований допускает этот кортеж
+	// (provider, service, staff, resource) на время слота. По умолчанию 1
+	// (сотрудник/помещение заняты целиком одним клиентом); больше единицы —
+	// для ресурсов, обслуживающих нескольких клиентов параллельно.
+	Capacity int `gorm:"not null;default:1"`
+
+	StartsAt time.Time `gorm:"not null;index"`
+	EndsAt   time.Time `gorm:"not null"`
 
 	Status TimeSlotStatus `gorm:"type:varchar(32);not null;default:'planned';index"`
 
-	CreatedAt time.Time `gorm:"not null;default:now()"`
-	UpdatedAt time.Time `gorm:"not null;default:now()"`
+	// ExternalID — UID внешнего VEVENT, из которого материализован слот при
+	// CalendarService.ImportProviderCalendar. Пустая строка для слотов,
+	// созданных на платформе. Уникален в пределах провайдера, что позволяет
+	// повторный импорт того же .ics находить и обновлять уже созданную
+	// строку вместо дублирования (см. repository.SlotRepository.GetByExternalID).
+	ExternalID string `gorm:"type:varchar(255);index"`
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
 
 	// Навигационные поля (опционально, но удобно для Preload).
 	Schedule *Schedule `gorm:"foreignKey:ScheduleID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 	Provider *Provider `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 	Service  *Service  `gorm:"foreignKey:ServiceID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
 }
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (t *TimeSlot) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
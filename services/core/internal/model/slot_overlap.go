@@ -0,0 +1,38 @@
+package model
+
+import "gorm.io/gorm"
+
+// EnsureSlotOverlapConstraint ставит Postgres exclusion-ограничение,
+// запрещающее одному провайдеру иметь два активных (не cancelled) слота с
+// пересекающимися интервалами [starts_at, ends_at) — фундаментальный
+// инвариант календаря, которым обычный AutoMigrate не занимается. Требует
+// расширение btree_gist: без него GiST не умеет индексировать обычное
+// равенство (provider_id) рядом с range-пересечением.
+//
+// На MySQL/SQLite exclusion-ограничений нет в принципе — инвариант там
+// держит только фолбэк SELECT ... FOR UPDATE в GormSlotRepository.Create/
+// Update (см. internal/repository/slot_repository.go), поэтому здесь no-op.
+//
+// Вызывается из AutoMigrate следом за EnableRowLevelSecurity, идемпотентна.
+func EnsureSlotOverlapConstraint(db *gorm.DB) error {
+	if ActiveDialect() != DialectPostgres {
+		return nil
+	}
+
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS btree_gist`).Error; err != nil {
+		return err
+	}
+
+	if err := db.Exec(`ALTER TABLE time_slots DROP CONSTRAINT IF EXISTS time_slots_no_overlap`).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(`
+		ALTER TABLE time_slots
+		ADD CONSTRAINT time_slots_no_overlap
+		EXCLUDE USING GIST (
+			provider_id WITH =,
+			tstzrange(starts_at, ends_at, '[)') WITH &&
+		) WHERE (status <> 'cancelled')
+	`).Error
+}
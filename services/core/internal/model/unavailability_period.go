@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// unavailability_periods — разовые периоды недоступности провайдера
+// ("отпуск", "больничный"), которые провайдер заводит сам, в отличие от
+// MaintenanceWindow, создаваемого администратором и поддерживающего RRULE.
+// Периоды не повторяются и не отменяют уже подтверждённые бронирования — они
+// только не дают материализовать/забронировать новые слоты (см.
+// CalendarService.activeUnavailabilityRanges, scheduler.SlotMaterializer).
+type UnavailabilityPeriod struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	ProviderID uuid.UUID `gorm:"type:uuid;not null;index"`
+	// ServiceID — опциональное ограничение периода одной услугой провайдера;
+	// nil означает, что период перекрывает все услуги провайдера целиком
+	// (см. MaintenanceWindow.ServiceID).
+	ServiceID *uuid.UUID `gorm:"type:uuid;index"`
+
+	StartsAt time.Time `gorm:"not null;index"`
+	EndsAt   time.Time `gorm:"not null;index"`
+
+	Reason string `gorm:"type:text"`
+
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+
+	Provider *Provider `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Service  *Service  `gorm:"foreignKey:ServiceID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (p *UnavailabilityPeriod) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	return nil
+}
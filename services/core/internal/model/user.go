@@ -4,20 +4,24 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // users
 type User struct {
-	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
 
-	TelegramID   int64  `gorm:"not null;uniqueIndex"`
+	// NamespaceID — арендатор, которому принадлежит пользователь (см. model.Namespace).
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index;uniqueIndex:idx_users_namespace_telegram"`
+
+	TelegramID   int64  `gorm:"not null;uniqueIndex:idx_users_namespace_telegram"`
 	DisplayName  string `gorm:"type:varchar(255)"`
 	ContactPhone string `gorm:"type:varchar(32)"`
 
 	Note string `gorm:"type:text"`
 
-	CreatedAt time.Time `gorm:"not null;default:now()"`
-	UpdatedAt time.Time `gorm:"not null;default:now()"`
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
 
 	// Навигационные поля (опционально)
 	Client   *Client   `gorm:"foreignKey:UserID"`
@@ -25,16 +29,32 @@ type User struct {
 	// Roles []Role `gorm:"many2many:user_roles"` — если захотим
 }
 
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	return nil
+}
+
 // clients
 type Client struct {
-	ID uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
 
 	UserID uuid.UUID `gorm:"type:uuid;not null;index"`
 
 	Comment string `gorm:"type:text"`
 
-	CreatedAt time.Time `gorm:"not null;default:now()"`
-	UpdatedAt time.Time `gorm:"not null;default:now()"`
+	CreatedAt time.Time `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
 
 	User *User `gorm:"foreignKey:UserID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
 }
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (c *Client) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
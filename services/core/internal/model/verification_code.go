@@ -0,0 +1,56 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// verification_codes — одноразовые PIN-коды для привязки Telegram-аккаунта к
+// регистрации через отдельного Telegram-бота (см. cmd/identity-bot,
+// service.VerificationService): фронтенд/бот запрашивают код через
+// IssueVerificationCode, пользователь присылает его настоящему боту через
+// "/start <code>", и только после того как бот подтвердит владение
+// аккаунтом через ConsumeVerificationCode, RegisterUser в режиме
+// config.IdentityConfig.RequireVerification соглашается привязать
+// telegram_id (см. IdentityService.RegisterUser).
+type VerificationCode struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+
+	// Code — 6-символьный Base32-PIN в верхнем регистре (см.
+	// verification.GenerateCode). Уникален среди ещё не истёкших кодов;
+	// сравнение в ConsumeVerificationCode — без учёта регистра.
+	Code string `gorm:"type:varchar(16);not null;uniqueIndex:idx_verification_codes_code"`
+
+	// ClaimPayload — то, что передал вызывающий в IssueVerificationCode
+	// (telegram_id_hint, display_name), канонический JSON. Без явного
+	// gorm-тега типа: datatypes.JSON сама подбирает колонку под текущий
+	// диалект (см. NotificationOutbox.PayloadJSON).
+	ClaimPayload datatypes.JSON
+
+	// RequesterIP — IP вызывающего IssueVerificationCode, для рейт-лимита
+	// (см. repository.VerificationCodeRepository.CountRecentByIP).
+	RequesterIP string `gorm:"type:varchar(64);index"`
+	// TelegramIDHint — если вызывающий уже знает свой telegram_id (например,
+	// повторная привязка после сброса бота), используется и для
+	// рейт-лимита, и чтобы держать не больше одного активного кода на
+	// этот hint (см. InvalidateActiveForTelegramHint).
+	TelegramIDHint *int64 `gorm:"index"`
+
+	ExpiresAt  time.Time  `gorm:"not null;index"`
+	ConsumedAt *time.Time `gorm:"index"`
+
+	CreatedAt time.Time `gorm:"not null;index"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (v *VerificationCode) BeforeCreate(tx *gorm.DB) error {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return nil
+}
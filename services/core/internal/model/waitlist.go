@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type WaitlistStatus string
+
+const (
+	WaitlistStatusActive WaitlistStatus = "active"
+	// WaitlistStatusOffered — записи предложен конкретный освободившийся слот
+	// (HeldSlotID) с удержанием до OfferExpiresAt; слот на это время переведён
+	// в TimeSlotStatusBooked, чтобы его не перехватил обычный CreateBooking.
+	// См. CalendarService.tryPromoteWaitlistEntry/ConfirmWaitlistOffer.
+	WaitlistStatusOffered   WaitlistStatus = "offered"
+	WaitlistStatusPromoted  WaitlistStatus = "promoted"
+	WaitlistStatusCancelled WaitlistStatus = "cancelled"
+	WaitlistStatusExpired   WaitlistStatus = "expired"
+)
+
+// waitlists — лист ожидания: клиент просит забронировать его, как
+// только в желаемом окне [DesiredStart, DesiredEnd) освободится подходящий
+// слот провайдера. Продвигается фоновым промоутером (см.
+// scheduler.WaitlistPromoter и CalendarService.PromoteWaitlist) в порядке
+// priority DESC, затем FIFO по CreatedAt. Продвижение теперь двухшаговое:
+// сперва запись переходит в WaitlistStatusOffered с удержанием слота
+// (HeldSlotID, OfferExpiresAt), и только подтверждение клиентом
+// (ConfirmWaitlistOffer) создаёт бронирование; непринятое до истечения TTL
+// предложение освобождает слот следующей записи в очереди.
+type Waitlist struct {
+	ID uuid.UUID `gorm:"type:uuid;primaryKey"`
+
+	NamespaceID uuid.UUID `gorm:"type:uuid;not null;index"`
+	ClientID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	ProviderID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	// ServiceID — опциональное ограничение желаемой услугой; nil — подходит
+	// любая услуга провайдера.
+	ServiceID *uuid.UUID `gorm:"type:uuid;index"`
+
+	DesiredStart time.Time `gorm:"not null;index"`
+	DesiredEnd   time.Time `gorm:"not null;index"`
+
+	// Priority — чем выше, тем раньше запись рассматривается промоутером
+	// относительно других активных записей того же провайдера; при равном
+	// приоритете порядок — FIFO по CreatedAt.
+	Priority int `gorm:"not null;default:0"`
+
+	Status WaitlistStatus `gorm:"type:varchar(16);not null;default:'active';index"`
+
+	CreatedAt time.Time  `gorm:"not null"`
+	ExpiresAt *time.Time `gorm:"index"`
+
+	// HeldSlotID/OfferExpiresAt — слот, удерживаемый для записи в статусе
+	// WaitlistStatusOffered, и крайний срок, до которого клиент должен его
+	// подтвердить (см. CalendarService.ConfirmWaitlistOffer).
+	HeldSlotID     *uuid.UUID `gorm:"type:uuid"`
+	OfferExpiresAt *time.Time `gorm:"index"`
+
+	// PromotedBookingID — бронирование, созданное промоутером при успешном
+	// продвижении записи (см. CalendarService.PromoteWaitlist).
+	PromotedBookingID *uuid.UUID `gorm:"type:uuid"`
+
+	Client   *Client   `gorm:"foreignKey:ClientID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Provider *Provider `gorm:"foreignKey:ProviderID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Service  *Service  `gorm:"foreignKey:ServiceID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+}
+
+// BeforeCreate — см. Namespace.BeforeCreate.
+func (w *Waitlist) BeforeCreate(tx *gorm.DB) error {
+	if w.ID == uuid.Nil {
+		w.ID = uuid.New()
+	}
+	return nil
+}
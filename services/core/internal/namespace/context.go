@@ -0,0 +1,36 @@
+// Package namespace передаёт tenant (арендатора) через context.Context,
+// чтобы репозитории и db.NewGormDB могли скопировать его в Postgres-сессию
+// для row-level security (см. model.EnableRowLevelSecurity).
+package namespace
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+type ctxKey struct{}
+
+// WithNamespace возвращает контекст с привязанным к нему арендатором.
+func WithNamespace(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext достаёт арендатора, привязанного через WithNamespace.
+func FromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(ctxKey{}).(uuid.UUID)
+	return id, ok
+}
+
+// Require — как FromContext, но возвращает ошибку, если арендатор не
+// резолвлен (middleware не отработал или вызов идёт в обход него). Репозитории
+// этого чанка используют Require, чтобы namespace_id никогда не уходил в
+// запрос как NULL/нулевой UUID.
+func Require(ctx context.Context) (uuid.UUID, error) {
+	id, ok := FromContext(ctx)
+	if !ok || id == uuid.Nil {
+		return uuid.Nil, fmt.Errorf("namespace: no tenant in context")
+	}
+	return id, nil
+}
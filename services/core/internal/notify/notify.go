@@ -0,0 +1,68 @@
+// Package notify определяет транспорт доставки клиентских уведомлений
+// (Telegram/etc.) для scheduler.OutboxDispatcher. В этом снапшоте
+// Telegram-транспорта уведомлений нет (cmd/identity-bot только подтверждает
+// коды регистрации, model.User.TelegramID только хранится), поэтому
+// единственная реализация — LogNotifier: она пишет событие в лог и всегда
+// успешна, чтобы очередь notifications_outbox не копилась до появления
+// настоящего транспорта. Подключение реального бота — заменить Notifier в
+// cmd/main.go, ничего в dispatcher/outbox не меняя.
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// OutboxPayload — форма payload_json, который пишет CalendarService в
+// notifications_outbox и который читает scheduler.OutboxDispatcher перед
+// вызовом Notifier.Send. Несёт всё нужное получателю, чтобы dispatcher не
+// обращался к БД повторно за данными, которые уже были под рукой в момент
+// записи события.
+type OutboxPayload struct {
+	ClientUserID     string    `json:"client_user_id"`
+	ClientTelegramID int64     `json:"client_telegram_id"`
+	ProviderID       string    `json:"provider_id,omitempty"`
+	SlotID           string    `json:"slot_id,omitempty"`
+	BookingID        string    `json:"booking_id,omitempty"`
+	StartsAt         time.Time `json:"starts_at,omitempty"`
+	EndsAt           time.Time `json:"ends_at,omitempty"`
+	Reason           string    `json:"reason,omitempty"`
+}
+
+// Event — то, что Notifier должен доставить получателю. ClientTelegramID ==
+// 0 означает, что у клиента нет привязанного Telegram-аккаунта — конкретный
+// Notifier решает, как это трактовать (пропустить, использовать другой канал).
+type Event struct {
+	EventType        string
+	AggregateID      string
+	ClientUserID     string
+	ClientTelegramID int64
+	Payload          []byte
+}
+
+// Notifier доставляет одно событие во внешний канал. Ошибка — сигнал
+// scheduler.OutboxDispatcher повторить с backoff; успех — событие
+// помечается published_at.
+type Notifier interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// LogNotifier — временная реализация Notifier по умолчанию.
+type LogNotifier struct {
+	Logger *log.Logger
+}
+
+// NewLogNotifier строит LogNotifier с log.Default(), если logger не задан.
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{Logger: logger}
+}
+
+func (n *LogNotifier) Send(ctx context.Context, event Event) error {
+	n.Logger.Printf("[notify] %s aggregate_id=%s client_user_id=%s client_telegram_id=%d payload=%s",
+		event.EventType, event.AggregateID, event.ClientUserID, event.ClientTelegramID, event.Payload)
+	return nil
+}
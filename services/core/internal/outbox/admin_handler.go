@@ -0,0 +1,69 @@
+package outbox
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// AdminHandler обслуживает GET /admin/outbox?status=failed|pending|published
+// — список строк events в соответствующем состоянии, для ручного
+// расследования застрявшей доставки (см. repository.EventOutboxRepository.
+// ListByStatus). status по умолчанию — "pending".
+type AdminHandler struct {
+	Repo repository.EventOutboxRepository
+}
+
+func NewAdminHandler(repo repository.EventOutboxRepository) *AdminHandler {
+	return &AdminHandler{Repo: repo}
+}
+
+type adminOutboxRow struct {
+	ID            string `json:"id"`
+	EventType     string `json:"event_type"`
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error,omitempty"`
+	CreatedAt     string `json:"created_at"`
+	NextAttemptAt string `json:"next_attempt_at"`
+	PublishedAt   string `json:"published_at,omitempty"`
+}
+
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = "pending"
+	}
+
+	rows, err := h.Repo.ListByStatus(r.Context(), status, 200)
+	if err != nil {
+		http.Error(w, "list outbox: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out := make([]adminOutboxRow, 0, len(rows))
+	for _, row := range rows {
+		item := adminOutboxRow{
+			ID:            row.ID.String(),
+			EventType:     string(row.EventType),
+			Attempts:      row.Attempts,
+			LastError:     row.LastError,
+			CreatedAt:     row.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			NextAttemptAt: row.NextAttemptAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+		if row.PublishedAt != nil {
+			item.PublishedAt = row.PublishedAt.Format("2006-01-02T15:04:05Z07:00")
+		}
+		out = append(out, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(out); err != nil {
+		http.Error(w, "encode response: "+err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,155 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// Config — параметры Dispatcher.
+type Config struct {
+	// RunEvery — период между прогонами.
+	RunEvery time.Duration
+	// BatchSize — сколько неопубликованных строк забирать за один прогон.
+	BatchSize int
+	// MaxAttempts — после скольких неудачных попыток строка перестаёт
+	// переобрабатываться автоматически (остаётся неопубликованной и видна
+	// через GET /admin/outbox?status=failed).
+	MaxAttempts int
+}
+
+func (c Config) withDefaults() Config {
+	if c.RunEvery <= 0 {
+		c.RunEvery = 10 * time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 10
+	}
+	return c
+}
+
+// Dispatcher читает неопубликованные строки model.Event (см.
+// service.writeAuditEvent — запись идёт в той же транзакции, что и бизнес-
+// изменение) и публикует их через Publisher в порядке CreatedAt ASC, то
+// есть в порядке коммита — а значит и монотонно per BookingID, раз все
+// события одного бронирования пишутся последовательными транзакциями.
+// Ошибка публикации не теряет событие: Attempts растёт, NextAttemptAt
+// отодвигается с экспоненциальным backoff и джиттером (см.
+// scheduler.OutboxDispatcher — тот же приём для notifications_outbox).
+type Dispatcher struct {
+	repo      repository.EventOutboxRepository
+	publisher Publisher
+	cfg       Config
+	logger    *log.Logger
+}
+
+func NewDispatcher(repo repository.EventOutboxRepository, publisher Publisher, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		repo:      repo,
+		publisher: publisher,
+		cfg:       cfg.withDefaults(),
+		logger:    log.Default(),
+	}
+}
+
+// Run блокирует вызывающего и по тикеру вызывает RunOnce, пока не отменят ctx.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.runAndLog(ctx)
+
+	ticker := time.NewTicker(d.cfg.RunEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.runAndLog(ctx)
+		}
+	}
+}
+
+func (d *Dispatcher) runAndLog(ctx context.Context) {
+	if err := d.RunOnce(ctx); err != nil {
+		d.logger.Printf("[ERROR] outbox.Dispatcher.RunOnce: %v", err)
+	}
+}
+
+// RunOnce забирает до BatchSize просроченных-к-попытке строк и доставляет
+// их по очереди, затем обновляет outbox_pending_total.
+func (d *Dispatcher) RunOnce(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	rows, err := d.repo.ListDue(ctx, now, d.cfg.BatchSize)
+	if err != nil {
+		return fmt.Errorf("list due outbox events: %w", err)
+	}
+	for i := range rows {
+		d.deliver(ctx, &rows[i], now)
+	}
+
+	pending, err := d.repo.CountPending(ctx)
+	if err != nil {
+		return fmt.Errorf("count pending outbox events: %w", err)
+	}
+	pendingGauge.Set(float64(pending))
+	return nil
+}
+
+// deliver доставляет одно событие и фиксирует результат. Строки,
+// исчерпавшие MaxAttempts, логируются и оставляются как есть — требуется
+// ручное вмешательство (прямой UPDATE next_attempt_at/attempts или правка
+// получателя), отдельной replay-ручки для events пока нет.
+func (d *Dispatcher) deliver(ctx context.Context, row *model.Event, now time.Time) {
+	id := row.ID.String()
+	if row.Attempts >= d.cfg.MaxAttempts {
+		d.logger.Printf("[ERROR] outbox.Dispatcher: id=%s exhausted max attempts=%d, awaiting manual intervention", id, d.cfg.MaxAttempts)
+		return
+	}
+
+	event := Event{
+		ID:        id,
+		EventType: string(row.EventType),
+		CreatedAt: row.CreatedAt,
+		Payload:   []byte(row.Payload),
+	}
+	if row.BookingID != nil {
+		event.BookingID = row.BookingID.String()
+	}
+	if row.UserID != nil {
+		event.UserID = row.UserID.String()
+	}
+
+	if err := d.publisher.Publish(ctx, event); err != nil {
+		nextAttempt := now.Add(backoff(row.Attempts))
+		if markErr := d.repo.MarkAttemptFailed(ctx, id, err.Error(), nextAttempt); markErr != nil {
+			d.logger.Printf("[ERROR] outbox.Dispatcher: mark attempt failed id=%s: %v", id, markErr)
+		}
+		d.logger.Printf("[ERROR] outbox.Dispatcher: publish id=%s attempt=%d: %v", id, row.Attempts+1, err)
+		return
+	}
+
+	if err := d.repo.MarkPublished(ctx, id, now); err != nil {
+		d.logger.Printf("[ERROR] outbox.Dispatcher: mark published id=%s: %v", id, err)
+	}
+}
+
+// backoff — экспоненциальная задержка со джиттером перед следующей попыткой
+// публикации (см. scheduler.outboxBackoff — та же формула).
+func backoff(attempts int) time.Duration {
+	const maxBackoff = 10 * time.Minute
+	base := time.Duration(1<<uint(attempts)) * time.Second
+	if base > maxBackoff {
+		base = maxBackoff
+	}
+	jitter := time.Duration(rand.Intn(1000)) * time.Millisecond
+	return base + jitter
+}
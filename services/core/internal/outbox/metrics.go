@@ -0,0 +1,15 @@
+package outbox
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// pendingGauge — outbox_pending_total: число ещё не опубликованных строк
+// events на момент последнего RunOnce, для алертинга на растущий лаг
+// доставки (см. Dispatcher.RunOnce).
+var pendingGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "outbox_pending_total",
+	Help: "Number of unpublished rows in the events outbox.",
+})
+
+func init() {
+	prometheus.MustRegister(pendingGauge)
+}
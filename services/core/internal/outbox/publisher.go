@@ -0,0 +1,134 @@
+// Package outbox публикует строки model.Event во внешние системы
+// (вебхук/Kafka/лог) по транзакционно-консистентному outbox-паттерну — тому
+// же, что internal/notify использует для клиентских уведомлений, только
+// здесь получатель не клиент, а внешняя интеграция (аналитика, SIEM, другой
+// сервис), которой важен сам факт и порядок бизнес-событий, а не их
+// человекочитаемая доставка. См. Dispatcher.
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Event — то, что Publisher должен доставить во внешнюю систему. ID —
+// первичный ключ строки model.Event, используется как ключ идемпотентности
+// на стороне потребителя.
+type Event struct {
+	ID        string
+	EventType string
+	BookingID string
+	UserID    string
+	CreatedAt time.Time
+	Payload   []byte
+}
+
+// Publisher доставляет одно событие. Ошибка — сигнал Dispatcher повторить с
+// backoff; успех — строка помечается PublishedAt.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// LogPublisher — публикация по умолчанию, пока нет настоящего получателя:
+// пишет событие в лог и всегда успешна, чтобы очередь events не копилась
+// (см. notify.LogNotifier — тот же приём для notifications_outbox).
+type LogPublisher struct {
+	Logger interface {
+		Printf(format string, v ...any)
+	}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event Event) error {
+	p.Logger.Printf("[outbox] %s id=%s booking_id=%s payload=%s", event.EventType, event.ID, event.BookingID, event.Payload)
+	return nil
+}
+
+// HTTPWebhookPublisher доставляет событие POST-запросом с JSON-телом,
+// подписанным HMAC-SHA256 над сырым телом (заголовок X-Outbox-Signature:
+// "sha256=<hex>"), чтобы получатель мог проверить, что запрос пришёл от нас
+// и тело не подменено по пути. 2xx — успех, всё остальное — ошибка для
+// Dispatcher.
+type HTTPWebhookPublisher struct {
+	URL    string
+	Secret []byte
+	Client *http.Client
+}
+
+// NewHTTPWebhookPublisher строит HTTPWebhookPublisher с http.DefaultClient,
+// если client не задан.
+func NewHTTPWebhookPublisher(url string, secret []byte, client *http.Client) *HTTPWebhookPublisher {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPWebhookPublisher{URL: url, Secret: secret, Client: client}
+}
+
+func (p *HTTPWebhookPublisher) Publish(ctx context.Context, event Event) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(event.Payload))
+	if err != nil {
+		return fmt.Errorf("build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Outbox-Event-Type", event.EventType)
+	req.Header.Set("X-Outbox-Signature", "sha256="+signHMAC(p.Secret, event.Payload))
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("webhook responded %d: %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+func signHMAC(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// KafkaPublisher публикует событие в Kafka-топик, с BookingID в качестве
+// ключа сообщения — это сохраняет порядок записей одного бронирования
+// внутри партиции, даже если Dispatcher сам по себе однопоточный (см.
+// Dispatcher — порядок и так монотонный, партиционирование по ключу просто
+// не ломает его у потребителей, читающих несколько партиций параллельно).
+type KafkaPublisher struct {
+	Writer *kafka.Writer
+}
+
+// NewKafkaPublisher строит KafkaPublisher, пишущий в topic через brokers.
+func NewKafkaPublisher(brokers []string, topic string) *KafkaPublisher {
+	return &KafkaPublisher{
+		Writer: &kafka.Writer{
+			Addr:     kafka.TCP(brokers...),
+			Topic:    topic,
+			Balancer: &kafka.Hash{},
+		},
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	key := event.BookingID
+	if key == "" {
+		key = event.ID
+	}
+	return p.Writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(key),
+		Value: event.Payload,
+		Headers: []kafka.Header{
+			{Key: "event-type", Value: []byte(event.EventType)},
+		},
+	})
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// BlackoutRepository — периоды полной недоступности услуги (см.
+// model.Blackout): в отличие от UnavailabilityRepository (провайдер целиком,
+// без слияния по арендатору) здесь запись привязана к NamespaceID и
+// опционально к одной услуге через ServiceID.
+type BlackoutRepository interface {
+	Create(ctx context.Context, b *model.Blackout) (*model.Blackout, error)
+	GetByID(ctx context.Context, id string) (*model.Blackout, error)
+	// ListForService возвращает blackout-ы арендатора, пересекающиеся с [from,
+	// to); serviceID пустой строкой означает "только общие для всех услуг"
+	// (ServiceID IS NULL).
+	ListForService(ctx context.Context, serviceID string, from, to time.Time) ([]model.Blackout, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type GormBlackoutRepository struct {
+	db *gorm.DB
+}
+
+func NewGormBlackoutRepository(db *gorm.DB) *GormBlackoutRepository {
+	return &GormBlackoutRepository{db: db}
+}
+
+func (r *GormBlackoutRepository) Create(ctx context.Context, b *model.Blackout) (*model.Blackout, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.NamespaceID = nsID
+	if err := r.db.WithContext(ctx).Create(b).Error; err != nil {
+		return nil, errs.Wrap(err, "blackout")
+	}
+	return b, nil
+}
+
+func (r *GormBlackoutRepository) GetByID(ctx context.Context, id string) (*model.Blackout, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var b model.Blackout
+	if err := r.db.WithContext(ctx).
+		Where("namespace_id = ?", nsID).
+		First(&b, "id = ?", id).Error; err != nil {
+		return nil, errs.Wrap(err, "blackout")
+	}
+	return &b, nil
+}
+
+func (r *GormBlackoutRepository) ListForService(
+	ctx context.Context,
+	serviceID string,
+	from, to time.Time,
+) ([]model.Blackout, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := r.db.WithContext(ctx).
+		Where("namespace_id = ?", nsID).
+		Where("starts_at < ? AND ends_at > ?", to, from)
+	if serviceID != "" {
+		q = q.Where("service_id = ? OR service_id IS NULL", serviceID)
+	} else {
+		q = q.Where("service_id IS NULL")
+	}
+	var rows []model.Blackout
+	if err := q.Order("starts_at ASC").Find(&rows).Error; err != nil {
+		return nil, errs.Wrap(err, "blackout")
+	}
+	return rows, nil
+}
+
+func (r *GormBlackoutRepository) Delete(ctx context.Context, id string) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	res := r.db.WithContext(ctx).
+		Where("namespace_id = ?", nsID).
+		Delete(&model.Blackout{}, "id = ?", id)
+	if res.Error != nil {
+		return errs.Wrap(res.Error, "blackout")
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrNotFound("blackout")
+	}
+	return nil
+}
@@ -2,16 +2,35 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/Leganyst/appointment-platform/internal/db"
 	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
 )
 
 type BookingRepository interface {
 	// Создать новое бронирование.
 	Create(ctx context.Context, booking *model.Booking) error
+	// CreateWithSlotLock берёт эксклюзивный замок на slotID на время
+	// транзакции (на Postgres — pg_advisory_xact_lock по хэшу "slot:"+slotID,
+	// поверх которого конкурирующие запросы сериализуются безотносительно
+	// видимости строки в их снапшоте; на MySQL/SQLite, где advisory-локов
+	// нет, — SELECT ... FOR UPDATE на саму строку time_slots, см.
+	// GormSlotRepository.Create для того же деления), затем заново
+	// перечитывает слот и проверяет, что он всё ещё TimeSlotStatusPlanned —
+	// без этой проверки две горутины, ждавшие один и тот же замок, обе бы
+	// сочли устаревший статус, загруженный до захвата замка, валидным.
+	// Бизнес-запись (создание Booking, перевод слота в TimeSlotStatusBooked
+	// и всё, что должно попасть в ту же транзакцию) делает вызывающий внутри
+	// fn; первая неудача/ошибка из fn откатывает всю транзакцию, так что
+	// слот останется planned, если fn не смог завершиться.
+	CreateWithSlotLock(ctx context.Context, slotID string, fn func(tx *gorm.DB, slot *model.TimeSlot) error) error
 	// Получить бронирование по ID.
 	GetByID(ctx context.Context, id string) (*model.Booking, error)
 	// Обновить статус бронирования (например, при отмене).
@@ -23,6 +42,9 @@ type BookingRepository interface {
 		from, to time.Time,
 		limit, offset int,
 	) ([]model.Booking, int64, error)
+	// Список бронирований провайдера за период (через связанные слоты), с
+	// подгрузкой слота и услуги — используется, например, для iCalendar-фида.
+	ListByProviderAndRange(ctx context.Context, providerID string, from, to time.Time) ([]model.Booking, error)
 }
 
 // Реализация на GORM.
@@ -35,14 +57,50 @@ func NewGormBookingRepository(db *gorm.DB) *GormBookingRepository {
 }
 
 func (r *GormBookingRepository) Create(ctx context.Context, booking *model.Booking) error {
-	return r.db.WithContext(ctx).Create(booking).Error
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	booking.NamespaceID = nsID
+	return errs.Wrap(r.db.WithContext(ctx).Create(booking).Error, "booking")
+}
+
+func (r *GormBookingRepository) CreateWithSlotLock(
+	ctx context.Context,
+	slotID string,
+	fn func(tx *gorm.DB, slot *model.TimeSlot) error,
+) error {
+	return db.WithSerializableRetry(ctx, r.db, func(tx *gorm.DB) error {
+		q := tx
+		if model.ActiveDialect() == model.DialectPostgres {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtextextended(?, 0))", "slot:"+slotID).Error; err != nil {
+				return fmt.Errorf("booking: acquire slot lock: %w", err)
+			}
+		} else {
+			q = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+
+		var slot model.TimeSlot
+		if err := q.First(&slot, "id = ?", slotID).Error; err != nil {
+			return errs.Wrap(err, "slot")
+		}
+		if slot.Status != model.TimeSlotStatusPlanned {
+			return fmt.Errorf("slot: %w", errs.ErrSlotNotAvailable)
+		}
+
+		return fn(tx, &slot)
+	})
 }
 
 func (r *GormBookingRepository) GetByID(ctx context.Context, id string) (*model.Booking, error) {
-	var b model.Booking
-	if err := r.db.WithContext(ctx).First(&b, "id = ?", id).Error; err != nil {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
 		return nil, err
 	}
+	var b model.Booking
+	if err := r.db.WithContext(ctx).First(&b, "id = ? AND namespace_id = ?", id, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "booking")
+	}
 	return &b, nil
 }
 
@@ -52,17 +110,47 @@ func (r *GormBookingRepository) UpdateStatus(
 	status model.BookingStatus,
 	cancelledAt *time.Time,
 ) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
 	update := map[string]any{
-		"status": status,
+		"status":   status,
+		"sequence": gorm.Expr("sequence + 1"),
 	}
 	if cancelledAt != nil {
 		update["cancelled_at"] = *cancelledAt
 	}
-	return r.db.WithContext(ctx).
+	return errs.Wrap(r.db.WithContext(ctx).
 		Model(&model.Booking{}).
-		Where("id = ?", id).
+		Where("id = ? AND namespace_id = ?", id, nsID).
 		Updates(update).
-		Error
+		Error, "booking")
+}
+
+func (r *GormBookingRepository) ListByProviderAndRange(
+	ctx context.Context,
+	providerID string,
+	from, to time.Time,
+) ([]model.Booking, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var bookings []model.Booking
+	err = r.db.WithContext(ctx).
+		Joins("JOIN time_slots ON time_slots.id = bookings.slot_id").
+		Preload("Slot").
+		Preload("Slot.Service").
+		Where("bookings.namespace_id = ?", nsID).
+		Where("time_slots.provider_id = ?", providerID).
+		Where("time_slots.starts_at >= ? AND time_slots.ends_at <= ?", from, to).
+		Order("time_slots.starts_at ASC").
+		Find(&bookings).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "booking")
+	}
+	return bookings, nil
 }
 
 func (r *GormBookingRepository) ListByClientAndRange(
@@ -71,6 +159,10 @@ func (r *GormBookingRepository) ListByClientAndRange(
 	from, to time.Time,
 	limit, offset int,
 ) ([]model.Booking, int64, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
 	var (
 		bookings []model.Booking
 		total    int64
@@ -78,11 +170,12 @@ func (r *GormBookingRepository) ListByClientAndRange(
 
 	q := r.db.WithContext(ctx).
 		Model(&model.Booking{}).
+		Where("namespace_id = ?", nsID).
 		Where("client_id = ?", clientID).
 		Where("created_at >= ? AND created_at <= ?", from, to)
 
 	if err := q.Count(&total).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "booking")
 	}
 
 	if limit > 0 {
@@ -90,7 +183,7 @@ func (r *GormBookingRepository) ListByClientAndRange(
 	}
 
 	if err := q.Order("created_at DESC").Find(&bookings).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "booking")
 	}
 
 	return bookings, total, nil
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// CalendarFeedTokenRepository выдаёт, проверяет и отзывает токены доступа к
+// публичному iCalendar-фиду провайдера (см. model.CalendarFeedToken,
+// icalendar.Handler).
+type CalendarFeedTokenRepository interface {
+	// Issue генерирует новый случайный токен, сохраняет его хэш и возвращает
+	// сырое значение — единственный раз, когда оно видно вызывающему.
+	Issue(ctx context.Context, providerID uuid.UUID) (string, *model.CalendarFeedToken, error)
+	// VerifyActive не фильтрует по namespace_id и не вызывает
+	// namespace.Require: токен однозначно задан providerID и хэшем.
+	// icalendar.Handler вызывает его уже после того, как резолвил арендатора
+	// через ProviderRepository.ResolveNamespaceByProviderID и положил его в
+	// контекст. Возвращает true, если такой токен существует, относится к
+	// providerID и ещё не отозван.
+	VerifyActive(ctx context.Context, providerID uuid.UUID, rawToken string) (bool, error)
+	// RevokeAll отзывает все ещё активные токены провайдера — используется
+	// перед выдачей новой ссылки, чтобы разом инвалидировать старые.
+	RevokeAll(ctx context.Context, providerID uuid.UUID, at time.Time) error
+}
+
+// GormCalendarFeedTokenRepository — реализация на GORM.
+type GormCalendarFeedTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCalendarFeedTokenRepository(db *gorm.DB) *GormCalendarFeedTokenRepository {
+	return &GormCalendarFeedTokenRepository{db: db}
+}
+
+func (r *GormCalendarFeedTokenRepository) Issue(ctx context.Context, providerID uuid.UUID) (string, *model.CalendarFeedToken, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, fmt.Errorf("calendar_feed_token: generate token: %w", err)
+	}
+	rawToken := hex.EncodeToString(raw)
+
+	token := &model.CalendarFeedToken{
+		NamespaceID: nsID,
+		ProviderID:  providerID,
+		TokenHash:   hashFeedToken(rawToken),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return "", nil, errs.Wrap(err, "calendar_feed_token")
+	}
+	return rawToken, token, nil
+}
+
+func (r *GormCalendarFeedTokenRepository) VerifyActive(ctx context.Context, providerID uuid.UUID, rawToken string) (bool, error) {
+	if rawToken == "" {
+		return false, nil
+	}
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&model.CalendarFeedToken{}).
+		Where("provider_id = ? AND token_hash = ? AND revoked_at IS NULL", providerID, hashFeedToken(rawToken)).
+		Count(&count).Error
+	if err != nil {
+		return false, errs.Wrap(err, "calendar_feed_token")
+	}
+	return count > 0, nil
+}
+
+func (r *GormCalendarFeedTokenRepository) RevokeAll(ctx context.Context, providerID uuid.UUID, at time.Time) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).
+		Model(&model.CalendarFeedToken{}).
+		Where("namespace_id = ? AND provider_id = ? AND revoked_at IS NULL", nsID, providerID).
+		Update("revoked_at", at).Error, "calendar_feed_token")
+}
+
+func hashFeedToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+type CalendarIntegrationRepository interface {
+	Create(ctx context.Context, ci *model.CalendarIntegration) error
+	GetByProviderID(ctx context.Context, providerID string) (*model.CalendarIntegration, error)
+	// UpdateSyncState обновляет ctag/время последней синхронизации/ошибку после прогона реконсилятора.
+	UpdateSyncState(ctx context.Context, id uuid.UUID, ctag string, syncedAt time.Time, lastErr string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	// List возвращает все интеграции текущего арендатора — реконсилятор
+	// (см. internal/integration/caldav.Reconciler) сам проходит по
+	// арендаторам и кладёт каждый namespace_id в контекст перед вызовом,
+	// так же как internal/scheduler.SlotMaterializer.
+	List(ctx context.Context) ([]model.CalendarIntegration, error)
+
+	ReplaceBusyRanges(ctx context.Context, integrationID uuid.UUID, ranges []model.CalendarBusyRange) error
+	ListBusyRangesForProvider(ctx context.Context, providerID uuid.UUID, from, to time.Time) ([]model.CalendarBusyRange, error)
+}
+
+type GormCalendarIntegrationRepository struct {
+	db *gorm.DB
+}
+
+func NewGormCalendarIntegrationRepository(db *gorm.DB) *GormCalendarIntegrationRepository {
+	return &GormCalendarIntegrationRepository{db: db}
+}
+
+func (r *GormCalendarIntegrationRepository) Create(ctx context.Context, ci *model.CalendarIntegration) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	ci.NamespaceID = nsID
+	return errs.Wrap(r.db.WithContext(ctx).Create(ci).Error, "calendar_integration")
+}
+
+func (r *GormCalendarIntegrationRepository) GetByProviderID(ctx context.Context, providerID string) (*model.CalendarIntegration, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var ci model.CalendarIntegration
+	if err := r.db.WithContext(ctx).First(&ci, "provider_id = ? AND namespace_id = ?", providerID, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "calendar_integration")
+	}
+	return &ci, nil
+}
+
+func (r *GormCalendarIntegrationRepository) UpdateSyncState(
+	ctx context.Context,
+	id uuid.UUID,
+	ctag string,
+	syncedAt time.Time,
+	lastErr string,
+) error {
+	res := r.db.WithContext(ctx).Model(&model.CalendarIntegration{}).Where("id = ?", id).Updates(map[string]any{
+		"c_tag":          ctag,
+		"last_synced_at": syncedAt,
+		"last_error":     lastErr,
+	})
+	if res.Error != nil {
+		return errs.Wrap(res.Error, "calendar_integration")
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrNotFound("calendar_integration")
+	}
+	return nil
+}
+
+func (r *GormCalendarIntegrationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(
+		r.db.WithContext(ctx).Where("namespace_id = ?", nsID).Delete(&model.CalendarIntegration{}, "id = ?", id).Error,
+		"calendar_integration",
+	)
+}
+
+func (r *GormCalendarIntegrationRepository) List(ctx context.Context) ([]model.CalendarIntegration, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rows []model.CalendarIntegration
+	if err := r.db.WithContext(ctx).Where("namespace_id = ?", nsID).Find(&rows).Error; err != nil {
+		return nil, errs.Wrap(err, "calendar_integration")
+	}
+	return rows, nil
+}
+
+// ReplaceBusyRanges полностью заменяет набор занятых интервалов интеграции
+// внутри одной транзакции — инбаунд-синхронизация CalDAV не умеет в
+// инкрементальные диффы на уровне отдельных VEVENT (серверы CalDAV не все
+// поддерживают sync-collection), поэтому каждый прогон реконсилятора — это
+// полный REPORT и полная перезапись.
+func (r *GormCalendarIntegrationRepository) ReplaceBusyRanges(
+	ctx context.Context,
+	integrationID uuid.UUID,
+	ranges []model.CalendarBusyRange,
+) error {
+	return errs.Wrap(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("integration_id = ?", integrationID).Delete(&model.CalendarBusyRange{}).Error; err != nil {
+			return err
+		}
+		if len(ranges) == 0 {
+			return nil
+		}
+		return tx.Create(&ranges).Error
+	}), "calendar_busy_range")
+}
+
+func (r *GormCalendarIntegrationRepository) ListBusyRangesForProvider(
+	ctx context.Context,
+	providerID uuid.UUID,
+	from, to time.Time,
+) ([]model.CalendarBusyRange, error) {
+	var rows []model.CalendarBusyRange
+	err := r.db.WithContext(ctx).
+		Where("provider_id = ?", providerID).
+		Where("starts_at < ? AND ends_at > ?", to, from).
+		Order("starts_at ASC").
+		Find(&rows).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "calendar_busy_range")
+	}
+	return rows, nil
+}
@@ -7,6 +7,7 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
 )
 
 type ClientRepository interface {
@@ -26,7 +27,7 @@ func NewGormClientRepository(db *gorm.DB) *GormClientRepository {
 func (r *GormClientRepository) GetByID(ctx context.Context, id string) (*model.Client, error) {
 	var c model.Client
 	if err := r.db.WithContext(ctx).First(&c, "id = ?", id).Error; err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "client")
 	}
 	return &c, nil
 }
@@ -34,14 +35,14 @@ func (r *GormClientRepository) GetByID(ctx context.Context, id string) (*model.C
 func (r *GormClientRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.Client, error) {
 	var c model.Client
 	if err := r.db.WithContext(ctx).First(&c, "user_id = ?", userID).Error; err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "client")
 	}
 	return &c, nil
 }
 
 func (r *GormClientRepository) EnsureByUserID(ctx context.Context, userID uuid.UUID) (*model.Client, error) {
 	if userID == uuid.Nil {
-		return nil, gorm.ErrRecordNotFound
+		return nil, errs.ErrNotFound("client")
 	}
 	var c model.Client
 	tx := r.db.WithContext(ctx).First(&c, "user_id = ?", userID)
@@ -49,12 +50,12 @@ func (r *GormClientRepository) EnsureByUserID(ctx context.Context, userID uuid.U
 		return &c, nil
 	}
 	if tx.Error != gorm.ErrRecordNotFound {
-		return nil, tx.Error
+		return nil, errs.Wrap(tx.Error, "client")
 	}
 
 	c = model.Client{UserID: userID}
 	if err := r.db.WithContext(ctx).Create(&c).Error; err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "client")
 	}
 	return &c, nil
 }
@@ -0,0 +1,103 @@
+// Package errs содержит типизированные ошибки репозиториев этого сервиса.
+// Сервисный и бот-слой не должны импортировать gorm.io/gorm или
+// github.com/jackc/pgconn только ради errors.Is на конкретный код ошибки —
+// Wrap переводит raw-ошибку GORM/Postgres в один из сентинелов отсюда, и
+// дальше достаточно errors.Is(err, errs.ErrConflict) и т.п.
+package errs
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgconn"
+	"gorm.io/gorm"
+)
+
+// NotFoundError — "сущность не найдена", с именем сущности для сообщений
+// (provider, slot, booking...). Is реализован так, что errors.Is(err,
+// ErrNotFound("")) true для NotFoundError с любым Entity — удобно, когда
+// вызывающему не важно, какая именно сущность не найдена, только факт.
+type NotFoundError struct {
+	Entity string
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Entity == "" {
+		return "not found"
+	}
+	return fmt.Sprintf("%s: not found", e.Entity)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	_, ok := target.(*NotFoundError)
+	return ok
+}
+
+// ErrNotFound конструирует NotFoundError для entity (например, "provider").
+func ErrNotFound(entity string) error {
+	return &NotFoundError{Entity: entity}
+}
+
+var (
+	// ErrConflict — нарушение уникального ограничения (Postgres 23505).
+	ErrConflict = errors.New("conflict: unique constraint violated")
+	// ErrInvalidArgument — ошибка валидации на уровне БД (check-констрейнт и т.п.).
+	ErrInvalidArgument = errors.New("invalid argument")
+	// ErrForeignKey — нарушение внешнего ключа (Postgres 23503): ссылка на
+	// несуществующую/удалённую запись.
+	ErrForeignKey = errors.New("foreign key violation")
+	// ErrSlotOverlap — нарушение exclusion-констрейнта слотов (Postgres
+	// 23P01): новый слот пересекается с уже существующим.
+	ErrSlotOverlap = errors.New("slot overlaps with an existing slot")
+	// ErrSlotNotAvailable — слот под замком (см.
+	// BookingRepository.CreateWithSlotLock) оказался не в статусе planned:
+	// его успел забрать конкурентный запрос, пока текущий ждал блокировку.
+	ErrSlotNotAvailable = errors.New("slot is not available")
+	// ErrRetryable — транзакция упала из-за сериализации (Postgres 40001:
+	// serialization_failure) и имеет смысл в повторе как есть.
+	ErrRetryable = errors.New("retryable: serialization failure")
+)
+
+// Postgres SQLSTATE-коды, которые Wrap умеет распознавать. См.
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation      = "23505"
+	pgForeignKeyViolation  = "23503"
+	pgExclusionViolation   = "23P01"
+	pgSerializationFailure = "40001"
+)
+
+// Wrap переводит ошибку GORM/Postgres-драйвера в один из сентинелов пакета,
+// сохраняя исходную ошибку через %w (errors.Is/errors.As продолжают видеть
+// и сентинел, и *pgconn.PgError/gorm.ErrRecordNotFound). entity — имя
+// сущности репозитория ("provider", "slot", ...), используется только для
+// ErrNotFound и текста сообщения.
+//
+// err == nil возвращает nil, так что репозитории могут оборачивать любой
+// `.Error` без дополнительной проверки:
+//
+//	return errs.Wrap(r.db.WithContext(ctx).Create(slot).Error, "slot")
+func Wrap(err error, entity string) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound(entity)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case pgUniqueViolation:
+			return fmt.Errorf("%s: %w: %s", entity, ErrConflict, pgErr.ConstraintName)
+		case pgForeignKeyViolation:
+			return fmt.Errorf("%s: %w: %s", entity, ErrForeignKey, pgErr.ConstraintName)
+		case pgExclusionViolation:
+			return fmt.Errorf("%s: %w", entity, ErrSlotOverlap)
+		case pgSerializationFailure:
+			return fmt.Errorf("%s: %w", entity, ErrRetryable)
+		}
+	}
+
+	return err
+}
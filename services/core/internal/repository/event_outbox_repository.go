@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// EventOutboxRepository читает/продвигает очередь публикации events (см.
+// model.Event) во внешние системы — тот же приём, что и
+// NotificationOutboxRepository для notifications_outbox, но без
+// namespace-скоупинга: events не хранит NamespaceID (таблица не в
+// tenantScopedTables, см. internal/model/rls.go), поэтому Dispatcher читает
+// её глобально, в едином commit-порядке по CreatedAt. Запись строк —
+// по-прежнему забота вызывающей бизнес-транзакции напрямую через tx.Create
+// (см. service.writeAuditEvent), а не этого репозитория.
+type EventOutboxRepository interface {
+	// ListDue — неопубликованные строки с NextAttemptAt <= now, в порядке
+	// CreatedAt ASC (порядок коммита), не более limit штук.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]model.Event, error)
+	// ListByStatus — для GET /admin/outbox: "pending" (PublishedAt IS NULL),
+	// "failed" (PublishedAt IS NULL и Attempts > 0) или "published"
+	// (PublishedAt IS NOT NULL), в порядке CreatedAt DESC (последние сверху).
+	ListByStatus(ctx context.Context, status string, limit int) ([]model.Event, error)
+	// CountPending — общее число неопубликованных строк, вне зависимости от
+	// limit, для outbox_pending_total.
+	CountPending(ctx context.Context) (int64, error)
+	// MarkPublished фиксирует успешную доставку.
+	MarkPublished(ctx context.Context, id string, at time.Time) error
+	// MarkAttemptFailed увеличивает Attempts, записывает lastErr и переносит
+	// NextAttemptAt.
+	MarkAttemptFailed(ctx context.Context, id string, lastErr string, nextAttemptAt time.Time) error
+}
+
+// GormEventOutboxRepository — реализация на GORM.
+type GormEventOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewGormEventOutboxRepository(db *gorm.DB) *GormEventOutboxRepository {
+	return &GormEventOutboxRepository{db: db}
+}
+
+func (r *GormEventOutboxRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]model.Event, error) {
+	var rows []model.Event
+	q := r.db.WithContext(ctx).
+		Where("published_at IS NULL").
+		Where("next_attempt_at <= ?", now).
+		Order("created_at ASC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, errs.Wrap(err, "event_outbox")
+	}
+	return rows, nil
+}
+
+func (r *GormEventOutboxRepository) ListByStatus(ctx context.Context, status string, limit int) ([]model.Event, error) {
+	q := r.db.WithContext(ctx).Order("created_at DESC")
+	switch status {
+	case "failed":
+		q = q.Where("published_at IS NULL AND attempts > 0")
+	case "published":
+		q = q.Where("published_at IS NOT NULL")
+	default:
+		q = q.Where("published_at IS NULL")
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var rows []model.Event
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, errs.Wrap(err, "event_outbox")
+	}
+	return rows, nil
+}
+
+func (r *GormEventOutboxRepository) CountPending(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.Event{}).
+		Where("published_at IS NULL").
+		Count(&count).Error; err != nil {
+		return 0, errs.Wrap(err, "event_outbox")
+	}
+	return count, nil
+}
+
+func (r *GormEventOutboxRepository) MarkPublished(ctx context.Context, id string, at time.Time) error {
+	return errs.Wrap(r.db.WithContext(ctx).
+		Model(&model.Event{}).
+		Where("id = ?", id).
+		Update("published_at", at).Error, "event_outbox")
+}
+
+func (r *GormEventOutboxRepository) MarkAttemptFailed(ctx context.Context, id string, lastErr string, nextAttemptAt time.Time) error {
+	return errs.Wrap(r.db.WithContext(ctx).
+		Model(&model.Event{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"last_error":      lastErr,
+			"next_attempt_at": nextAttemptAt,
+		}).Error, "event_outbox")
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// IdentityEventRepository хранит журнал model.IdentityEvent — durable-часть
+// push-уведомлений профиля (см. internal/events.Bus,
+// IdentityService.SubscribeUserEvents). Bus сам по себе теряет события при
+// перезапуске процесса или разрыве стрима; ListSince позволяет
+// переподключившемуся подписчику забрать всё, что он пропустил.
+type IdentityEventRepository interface {
+	// Append пишет новое событие и проставляет его ID (см.
+	// model.IdentityEvent — auto-increment, это и есть cursor).
+	Append(ctx context.Context, ev *model.IdentityEvent) (*model.IdentityEvent, error)
+	// ListSince — события этого telegram_id с ID > sinceID, в порядке ID
+	// ASC, не более limit штук. sinceID == 0 — с самого начала (в пределах
+	// retention, см. scheduler.IdentityEventSweeper).
+	ListSince(ctx context.Context, telegramID int64, sinceID uint64, limit int) ([]model.IdentityEvent, error)
+	// DeleteOlderThan удаляет строки старше before — фоновый sweeper, а не
+	// ответственность Append/ListSince.
+	DeleteOlderThan(ctx context.Context, before time.Time) (int64, error)
+}
+
+// GormIdentityEventRepository — реализация на GORM.
+type GormIdentityEventRepository struct {
+	db *gorm.DB
+}
+
+func NewGormIdentityEventRepository(db *gorm.DB) *GormIdentityEventRepository {
+	return &GormIdentityEventRepository{db: db}
+}
+
+func (r *GormIdentityEventRepository) Append(ctx context.Context, ev *model.IdentityEvent) (*model.IdentityEvent, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ev.NamespaceID = nsID
+	if err := r.db.WithContext(ctx).Create(ev).Error; err != nil {
+		return nil, errs.Wrap(err, "identity_event")
+	}
+	return ev, nil
+}
+
+func (r *GormIdentityEventRepository) ListSince(ctx context.Context, telegramID int64, sinceID uint64, limit int) ([]model.IdentityEvent, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := r.db.WithContext(ctx).
+		Where("namespace_id = ? AND telegram_id = ? AND id > ?", nsID, telegramID, sinceID).
+		Order("id ASC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var rows []model.IdentityEvent
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, errs.Wrap(err, "identity_event")
+	}
+	return rows, nil
+}
+
+func (r *GormIdentityEventRepository) DeleteOlderThan(ctx context.Context, before time.Time) (int64, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return 0, err
+	}
+	res := r.db.WithContext(ctx).
+		Where("namespace_id = ? AND created_at < ?", nsID, before).
+		Delete(&model.IdentityEvent{})
+	if res.Error != nil {
+		return 0, errs.Wrap(res.Error, "identity_event")
+	}
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+type MaintenanceRepository interface {
+	Create(ctx context.Context, w *model.MaintenanceWindow) error
+	GetByID(ctx context.Context, id string) (*model.MaintenanceWindow, error)
+	// ListActiveForProvider возвращает окна провайдера, пересекающиеся с [from, to),
+	// включая окна с RRULE (их развёртка по периоду — забота вызывающего кода).
+	ListActiveForProvider(ctx context.Context, providerID string, from, to time.Time) ([]model.MaintenanceWindow, error)
+	// Update сохраняет изменяемые поля окна (имя/описание/расписание/kind/status).
+	Update(ctx context.Context, w *model.MaintenanceWindow) error
+	Delete(ctx context.Context, id string) error
+	// ListAllActiveForNamespace возвращает все окна со Status=active, чьи
+	// провайдеры принадлежат арендатору текущего ctx (см. namespace.WithNamespace).
+	// MaintenanceWindow сам по себе не namespace-scoped (см. model.rls.go), поэтому
+	// область видимости задаётся явным join на providers.namespace_id — используется
+	// только scheduler.MaintenanceScheduler, который, как и SlotMaterializer.RunOnce/
+	// WaitlistPromoter.RunOnce, обходит арендаторов по очереди, проставляя namespace
+	// в ctx перед каждым вызовом.
+	ListAllActiveForNamespace(ctx context.Context) ([]model.MaintenanceWindow, error)
+}
+
+type GormMaintenanceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormMaintenanceRepository(db *gorm.DB) *GormMaintenanceRepository {
+	return &GormMaintenanceRepository{db: db}
+}
+
+func (r *GormMaintenanceRepository) Create(ctx context.Context, w *model.MaintenanceWindow) error {
+	return errs.Wrap(r.db.WithContext(ctx).Create(w).Error, "maintenance_window")
+}
+
+func (r *GormMaintenanceRepository) GetByID(ctx context.Context, id string) (*model.MaintenanceWindow, error) {
+	var w model.MaintenanceWindow
+	if err := r.db.WithContext(ctx).First(&w, "id = ?", id).Error; err != nil {
+		return nil, errs.Wrap(err, "maintenance_window")
+	}
+	return &w, nil
+}
+
+func (r *GormMaintenanceRepository) ListActiveForProvider(
+	ctx context.Context,
+	providerID string,
+	from, to time.Time,
+) ([]model.MaintenanceWindow, error) {
+	var windows []model.MaintenanceWindow
+	err := r.db.WithContext(ctx).
+		Where("provider_id = ?", providerID).
+		Where("status = ?", model.MaintenanceWindowStatusActive).
+		Where("r_rule IS NOT NULL OR (starts_at < ? AND ends_at > ?)", to, from).
+		Order("starts_at ASC").
+		Find(&windows).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "maintenance_window")
+	}
+	return windows, nil
+}
+
+func (r *GormMaintenanceRepository) Update(ctx context.Context, w *model.MaintenanceWindow) error {
+	return errs.Wrap(r.db.WithContext(ctx).Save(w).Error, "maintenance_window")
+}
+
+func (r *GormMaintenanceRepository) Delete(ctx context.Context, id string) error {
+	return errs.Wrap(r.db.WithContext(ctx).Delete(&model.MaintenanceWindow{}, "id = ?", id).Error, "maintenance_window")
+}
+
+func (r *GormMaintenanceRepository) ListAllActiveForNamespace(ctx context.Context) ([]model.MaintenanceWindow, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var windows []model.MaintenanceWindow
+	err = r.db.WithContext(ctx).
+		Select("maintenance_windows.*").
+		Joins("JOIN providers ON providers.id = maintenance_windows.provider_id").
+		Where("providers.namespace_id = ?", nsID).
+		Where("maintenance_windows.status = ?", model.MaintenanceWindowStatusActive).
+		Find(&windows).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "maintenance_window")
+	}
+	return windows, nil
+}
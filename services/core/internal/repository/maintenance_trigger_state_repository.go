@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// MaintenanceTriggerStateRepository хранит прогресс scheduler.MaintenanceScheduler
+// по каждому MaintenanceWindow (см. model.MaintenanceTriggerState). Используется
+// только этим воркером, поэтому, как и ScheduleMaterializationStateRepository,
+// намеренно не проходит через namespace.Require — арендатор окну не нужен,
+// состояние ключуется только WindowID.
+type MaintenanceTriggerStateRepository interface {
+	Get(ctx context.Context, windowID uuid.UUID) (*model.MaintenanceTriggerState, error)
+	Upsert(ctx context.Context, state *model.MaintenanceTriggerState) error
+	Delete(ctx context.Context, windowID uuid.UUID) error
+}
+
+type GormMaintenanceTriggerStateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormMaintenanceTriggerStateRepository(db *gorm.DB) *GormMaintenanceTriggerStateRepository {
+	return &GormMaintenanceTriggerStateRepository{db: db}
+}
+
+func (r *GormMaintenanceTriggerStateRepository) Get(ctx context.Context, windowID uuid.UUID) (*model.MaintenanceTriggerState, error) {
+	var state model.MaintenanceTriggerState
+	err := r.db.WithContext(ctx).First(&state, "window_id = ?", windowID).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "maintenance_trigger_state")
+	}
+	return &state, nil
+}
+
+// Upsert записывает вхождение окна, на вход в которое уже отработала
+// CalendarService.CancelSlotsForMaintenanceWindow.
+func (r *GormMaintenanceTriggerStateRepository) Upsert(ctx context.Context, state *model.MaintenanceTriggerState) error {
+	state.UpdatedAt = time.Now().UTC()
+	return errs.Wrap(r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "window_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"triggered_occurrence_start", "updated_at"}),
+		}).
+		Create(state).Error, "maintenance_trigger_state")
+}
+
+// Delete убирает запись после того, как окно удалено (DeleteMaintenanceWindow),
+// чтобы не накапливать сироты в таблице прогресса.
+func (r *GormMaintenanceTriggerStateRepository) Delete(ctx context.Context, windowID uuid.UUID) error {
+	return errs.Wrap(r.db.WithContext(ctx).Delete(&model.MaintenanceTriggerState{}, "window_id = ?", windowID).Error, "maintenance_trigger_state")
+}
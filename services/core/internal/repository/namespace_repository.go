@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// NamespaceRepository — CRUD над арендаторами (model.Namespace). В отличие от
+// остальных репозиториев этого чанка, сам не требует namespace_id в
+// контексте: именно он используется middleware/ботом, чтобы resolve'ить
+// арендатора ДО того, как он появится в контексте остальных запросов.
+type NamespaceRepository interface {
+	Create(ctx context.Context, ns *model.Namespace) error
+	GetByID(ctx context.Context, id string) (*model.Namespace, error)
+	GetByTelegramChatID(ctx context.Context, chatID int64) (*model.Namespace, error)
+	Update(ctx context.Context, ns *model.Namespace) error
+	Delete(ctx context.Context, id string) error
+}
+
+type GormNamespaceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormNamespaceRepository(db *gorm.DB) *GormNamespaceRepository {
+	return &GormNamespaceRepository{db: db}
+}
+
+func (r *GormNamespaceRepository) Create(ctx context.Context, ns *model.Namespace) error {
+	return errs.Wrap(r.db.WithContext(ctx).Create(ns).Error, "namespace")
+}
+
+func (r *GormNamespaceRepository) GetByID(ctx context.Context, id string) (*model.Namespace, error) {
+	var ns model.Namespace
+	if err := r.db.WithContext(ctx).First(&ns, "id = ?", id).Error; err != nil {
+		return nil, errs.Wrap(err, "namespace")
+	}
+	return &ns, nil
+}
+
+func (r *GormNamespaceRepository) GetByTelegramChatID(ctx context.Context, chatID int64) (*model.Namespace, error) {
+	var ns model.Namespace
+	if err := r.db.WithContext(ctx).First(&ns, "telegram_chat_id = ?", chatID).Error; err != nil {
+		return nil, errs.Wrap(err, "namespace")
+	}
+	return &ns, nil
+}
+
+func (r *GormNamespaceRepository) Update(ctx context.Context, ns *model.Namespace) error {
+	return errs.Wrap(r.db.WithContext(ctx).Model(&model.Namespace{}).Where("id = ?", ns.ID).Updates(map[string]any{
+		"name":             ns.Name,
+		"telegram_chat_id": ns.TelegramChatID,
+	}).Error, "namespace")
+}
+
+func (r *GormNamespaceRepository) Delete(ctx context.Context, id string) error {
+	return errs.Wrap(r.db.WithContext(ctx).Delete(&model.Namespace{}, "id = ?", id).Error, "namespace")
+}
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// NotificationOutboxRepository читает/продвигает очередь
+// notifications_outbox (см. model.NotificationOutbox). Запись строк —
+// забота вызывающей бизнес-транзакции напрямую через tx.Create (см.
+// CalendarService.writeOutboxEvent), а не этого репозитория: outbox-событие
+// обязано коммититься в одной транзакции с бизнес-изменением, а остальные
+// репозитории этого гарантировать не умеют.
+type NotificationOutboxRepository interface {
+	// ListDue — неопубликованные записи арендатора с NextAttemptAt <= now, в
+	// порядке CreatedAt ASC, не более limit штук — именно в этом порядке их
+	// забирает scheduler.OutboxDispatcher.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]model.NotificationOutbox, error)
+	// ClaimDue — то же самое, что ListDue, но безопасно для нескольких
+	// одновременно работающих scheduler.OutboxDispatcher (например, при
+	// нескольких репликах сервиса): на Postgres строки выбираются через
+	// SELECT ... FOR UPDATE SKIP LOCKED в одной транзакции с немедленным
+	// переносом NextAttemptAt на leaseFor вперёд, так что вторая реплика,
+	// опоздавшая с тем же прогоном, просто не увидит уже занятые строки.
+	// На диалектах без поддержки SKIP LOCKED (MySQL/SQLite — см.
+	// model.EnableRowLevelSecurity для того же деления) ведёт себя как
+	// обычный ListDue: там фонового воркера, как и на RLS, предполагается
+	// один процесс.
+	ClaimDue(ctx context.Context, now time.Time, limit int, leaseFor time.Duration) ([]model.NotificationOutbox, error)
+	// MarkPublished фиксирует успешную доставку.
+	MarkPublished(ctx context.Context, id string, at time.Time) error
+	// MarkAttemptFailed увеличивает Attempts и переносит NextAttemptAt.
+	MarkAttemptFailed(ctx context.Context, id string, nextAttemptAt time.Time) error
+	// Replay сбрасывает published_at и переставляет NextAttemptAt на now,
+	// независимо от текущего состояния строки — используется ops-ручкой,
+	// когда событие нужно доставить повторно (внешний потребитель потерял
+	// его, несмотря на published_at, или запрашивает принудительный повтор).
+	Replay(ctx context.Context, id string, now time.Time) error
+}
+
+// Реализация на GORM.
+type GormNotificationOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewGormNotificationOutboxRepository(db *gorm.DB) *GormNotificationOutboxRepository {
+	return &GormNotificationOutboxRepository{db: db}
+}
+
+func (r *GormNotificationOutboxRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]model.NotificationOutbox, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var rows []model.NotificationOutbox
+	q := r.db.WithContext(ctx).
+		Where("namespace_id = ?", nsID).
+		Where("published_at IS NULL").
+		Where("next_attempt_at <= ?", now).
+		Order("created_at ASC")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	if err := q.Find(&rows).Error; err != nil {
+		return nil, errs.Wrap(err, "notification_outbox")
+	}
+	return rows, nil
+}
+
+func (r *GormNotificationOutboxRepository) ClaimDue(ctx context.Context, now time.Time, limit int, leaseFor time.Duration) ([]model.NotificationOutbox, error) {
+	if model.ActiveDialect() != model.DialectPostgres {
+		return r.ListDue(ctx, now, limit)
+	}
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if leaseFor <= 0 {
+		leaseFor = time.Minute
+	}
+
+	var rows []model.NotificationOutbox
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("namespace_id = ?", nsID).
+			Where("published_at IS NULL").
+			Where("next_attempt_at <= ?", now).
+			Order("created_at ASC")
+		if limit > 0 {
+			q = q.Limit(limit)
+		}
+		if err := q.Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(rows))
+		for i := range rows {
+			ids[i] = rows[i].ID
+		}
+		return tx.Model(&model.NotificationOutbox{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(leaseFor)).Error
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "notification_outbox")
+	}
+	return rows, nil
+}
+
+func (r *GormNotificationOutboxRepository) MarkPublished(ctx context.Context, id string, at time.Time) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).
+		Model(&model.NotificationOutbox{}).
+		Where("id = ? AND namespace_id = ?", id, nsID).
+		Update("published_at", at).Error, "notification_outbox")
+}
+
+func (r *GormNotificationOutboxRepository) MarkAttemptFailed(ctx context.Context, id string, nextAttemptAt time.Time) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).
+		Model(&model.NotificationOutbox{}).
+		Where("id = ? AND namespace_id = ?", id, nsID).
+		Updates(map[string]any{
+			"attempts":        gorm.Expr("attempts + 1"),
+			"next_attempt_at": nextAttemptAt,
+		}).Error, "notification_outbox")
+}
+
+func (r *GormNotificationOutboxRepository) Replay(ctx context.Context, id string, now time.Time) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	res := r.db.WithContext(ctx).
+		Model(&model.NotificationOutbox{}).
+		Where("id = ? AND namespace_id = ?", id, nsID).
+		Updates(map[string]any{
+			"published_at":    nil,
+			"next_attempt_at": now,
+		})
+	if res.Error != nil {
+		return errs.Wrap(res.Error, "notification_outbox")
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrNotFound("notification_outbox")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// PermissionRepository управляет справочником прав/групп прав (model.Permission,
+// model.PermissionGroup) и их привязкой к ролям (model.RolePermissionGroup).
+// Роли и права общие для всех арендаторов (см. model.Role), поэтому
+// Ensure*/Attach*/Detach* ничего не знают о namespace — в отличие от
+// HasPermission/ListPermissions, которые идут через user_roles и обязаны
+// учитывать арендатора пользователя, как и остальные репозитории этого чанка.
+type PermissionRepository interface {
+	// HasPermission проверяет, даёт ли хотя бы одна из ролей пользователя (в
+	// текущем арендаторе) право code через любую привязанную к роли группу.
+	// scope — опциональный ProviderID: если передан, учитываются и глобальные
+	// роли (user_roles.provider_id IS NULL), и роли, заскоупленные именно на
+	// этого провайдера; если nil, учитываются только глобальные роли (см.
+	// model.UserRole.ProviderID, RoleRepository.AssignRole).
+	HasPermission(ctx context.Context, userID uuid.UUID, code string, scope *uuid.UUID) (bool, error)
+	// ListPermissions возвращает коды всех прав, доступных пользователю через
+	// его роли в текущем арендаторе, без дублей.
+	ListPermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
+	// EnsurePermission гарантирует наличие права code в справочнике (используется сидером).
+	EnsurePermission(ctx context.Context, code, description string) (*model.Permission, error)
+	// EnsureGroup гарантирует наличие группы прав code в справочнике (используется сидером).
+	EnsureGroup(ctx context.Context, code, name string) (*model.PermissionGroup, error)
+	// AttachPermissionToGroup добавляет право в группу; повторный вызов — no-op.
+	AttachPermissionToGroup(ctx context.Context, groupID, permissionID int64) error
+	// AttachPermissionGroup привязывает группу прав к роли; повторный вызов — no-op.
+	AttachPermissionGroup(ctx context.Context, roleID, groupID int64) error
+	// DetachPermissionGroup отвязывает группу прав от роли.
+	DetachPermissionGroup(ctx context.Context, roleID, groupID int64) error
+	// GrantPermissionToRole — удобный ярлык для AuthorizationService.
+	// GrantPermissionToRole: прав напрямую между ролью и правом не хранится
+	// (см. RolePermissionGroup), поэтому метод заводит (или переиспользует)
+	// выделенную "прямую" группу для этой роли и привязывает право через неё.
+	// permissionCode должен уже существовать в справочнике (см.
+	// EnsurePermission) — иначе errs.ErrNotFound("permission").
+	GrantPermissionToRole(ctx context.Context, roleID int64, permissionCode string) error
+}
+
+type GormPermissionRepository struct {
+	db *gorm.DB
+}
+
+func NewGormPermissionRepository(db *gorm.DB) *GormPermissionRepository {
+	return &GormPermissionRepository{db: db}
+}
+
+func (r *GormPermissionRepository) HasPermission(ctx context.Context, userID uuid.UUID, code string, scope *uuid.UUID) (bool, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return false, err
+	}
+	query := r.db.WithContext(ctx).
+		Table("user_roles").
+		Joins("JOIN role_permission_groups ON role_permission_groups.role_id = user_roles.role_id").
+		Joins("JOIN permission_group_permissions ON permission_group_permissions.permission_group_id = role_permission_groups.permission_group_id").
+		Joins("JOIN permissions ON permissions.id = permission_group_permissions.permission_id").
+		Where("user_roles.namespace_id = ? AND user_roles.user_id = ? AND permissions.code = ?", nsID, userID, code)
+	if scope != nil {
+		query = query.Where("user_roles.provider_id IS NULL OR user_roles.provider_id = ?", *scope)
+	} else {
+		query = query.Where("user_roles.provider_id IS NULL")
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, errs.Wrap(err, "permission")
+	}
+	return count > 0, nil
+}
+
+func (r *GormPermissionRepository) ListPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var codes []string
+	err = r.db.WithContext(ctx).
+		Table("user_roles").
+		Joins("JOIN role_permission_groups ON role_permission_groups.role_id = user_roles.role_id").
+		Joins("JOIN permission_group_permissions ON permission_group_permissions.permission_group_id = role_permission_groups.permission_group_id").
+		Joins("JOIN permissions ON permissions.id = permission_group_permissions.permission_id").
+		Where("user_roles.namespace_id = ? AND user_roles.user_id = ?", nsID, userID).
+		Distinct().
+		Order("permissions.code ASC").
+		Pluck("permissions.code", &codes).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "permission")
+	}
+	return codes, nil
+}
+
+func (r *GormPermissionRepository) EnsurePermission(ctx context.Context, code, description string) (*model.Permission, error) {
+	var p model.Permission
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&p).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, errs.Wrap(err, "permission")
+		}
+		p = model.Permission{Code: code, Description: description}
+		if err := r.db.WithContext(ctx).Create(&p).Error; err != nil {
+			return nil, errs.Wrap(err, "permission")
+		}
+	}
+	return &p, nil
+}
+
+func (r *GormPermissionRepository) EnsureGroup(ctx context.Context, code, name string) (*model.PermissionGroup, error) {
+	var g model.PermissionGroup
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&g).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, errs.Wrap(err, "permission_group")
+		}
+		g = model.PermissionGroup{Code: code, Name: name}
+		if err := r.db.WithContext(ctx).Create(&g).Error; err != nil {
+			return nil, errs.Wrap(err, "permission_group")
+		}
+	}
+	return &g, nil
+}
+
+func (r *GormPermissionRepository) AttachPermissionToGroup(ctx context.Context, groupID, permissionID int64) error {
+	link := model.PermissionGroupPermission{PermissionGroupID: groupID, PermissionID: permissionID}
+	return errs.Wrap(r.db.WithContext(ctx).
+		Where("permission_group_id = ? AND permission_id = ?", groupID, permissionID).
+		FirstOrCreate(&link).Error, "permission_group_permission")
+}
+
+func (r *GormPermissionRepository) AttachPermissionGroup(ctx context.Context, roleID, groupID int64) error {
+	link := model.RolePermissionGroup{RoleID: roleID, PermissionGroupID: groupID}
+	return errs.Wrap(r.db.WithContext(ctx).
+		Where("role_id = ? AND permission_group_id = ?", roleID, groupID).
+		FirstOrCreate(&link).Error, "role_permission_group")
+}
+
+func (r *GormPermissionRepository) DetachPermissionGroup(ctx context.Context, roleID, groupID int64) error {
+	return errs.Wrap(r.db.WithContext(ctx).
+		Where("role_id = ? AND permission_group_id = ?", roleID, groupID).
+		Delete(&model.RolePermissionGroup{}).Error, "role_permission_group")
+}
+
+// directGroupCode — код "прямой" группы прав, которую GrantPermissionToRole
+// заводит под конкретную роль, чтобы не заставлять вызывающего придумывать
+// группу самостоятельно ради одного права.
+func directGroupCode(roleID int64) string {
+	return fmt.Sprintf("role-direct-%d", roleID)
+}
+
+func (r *GormPermissionRepository) GrantPermissionToRole(ctx context.Context, roleID int64, permissionCode string) error {
+	var p model.Permission
+	if err := r.db.WithContext(ctx).Where("code = ?", permissionCode).First(&p).Error; err != nil {
+		return errs.Wrap(err, "permission")
+	}
+
+	g, err := r.EnsureGroup(ctx, directGroupCode(roleID), fmt.Sprintf("Прямые права роли #%d", roleID))
+	if err != nil {
+		return err
+	}
+	if err := r.AttachPermissionToGroup(ctx, g.ID, p.ID); err != nil {
+		return err
+	}
+	return r.AttachPermissionGroup(ctx, roleID, g.ID)
+}
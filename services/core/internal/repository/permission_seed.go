@@ -0,0 +1,85 @@
+package repository
+
+import "context"
+
+// baselinePermissionGroups описывает стартовый набор прав для доменной модели
+// записи на приём: группы прав, которые SeedBaselinePermissions устанавливает
+// при первом запуске, и роли (model.Role.Code), к которым они сразу
+// привязываются. Роли общие для всех арендаторов, поэтому сидер идемпотентен
+// и безопасен на каждом старте сервиса (см. EnsurePermission/EnsureGroup).
+var baselinePermissionGroups = []struct {
+	code        string
+	name        string
+	permissions []string
+	roles       []string
+}{
+	{
+		code:        "client-base",
+		name:        "Базовые права клиента",
+		permissions: []string{"slot.read"},
+		roles:       []string{"client"},
+	},
+	{
+		code:        "provider-admin",
+		name:        "Администрирование провайдера",
+		permissions: []string{"slot.read", "slot.create", "booking.cancel", "provider.manage", "service.manage"},
+		roles:       []string{"provider"},
+	},
+	{
+		code:        "namespace-admin",
+		name:        "Администрирование арендатора",
+		permissions: []string{"admin.namespace"},
+		roles:       []string{"admin"},
+	},
+}
+
+// basePermissionDescriptions — описания атомарных прав домена для справочника
+// (админка/seed-данные), независимо от того, в какие группы они входят.
+var basePermissionDescriptions = map[string]string{
+	"slot.read":       "Просмотр слотов расписания",
+	"slot.create":     "Создание слотов расписания",
+	"booking.cancel":  "Отмена записи клиента",
+	"provider.manage": "Управление профилем и расписанием провайдера",
+	"service.manage":  "Управление каталогом услуг",
+	"admin.namespace": "Администрирование арендатора (namespace) целиком",
+}
+
+// SeedBaselinePermissions устанавливает права appointment-домена, группирует
+// их в baselinePermissionGroups и привязывает эти группы к соответствующим
+// ролям. Роли, упомянутые в baselinePermissionGroups, создаются при
+// отсутствии — как и при обычном SetRole. Вызывается один раз при старте
+// сервиса, после model.AutoMigrate; повторные вызовы безопасны (каждый шаг —
+// EnsureX/FirstOrCreate).
+func SeedBaselinePermissions(ctx context.Context, permRepo PermissionRepository, userRepo UserRepository) error {
+	for code, description := range basePermissionDescriptions {
+		if _, err := permRepo.EnsurePermission(ctx, code, description); err != nil {
+			return err
+		}
+	}
+
+	for _, group := range baselinePermissionGroups {
+		g, err := permRepo.EnsureGroup(ctx, group.code, group.name)
+		if err != nil {
+			return err
+		}
+		for _, permCode := range group.permissions {
+			p, err := permRepo.EnsurePermission(ctx, permCode, basePermissionDescriptions[permCode])
+			if err != nil {
+				return err
+			}
+			if err := permRepo.AttachPermissionToGroup(ctx, g.ID, p.ID); err != nil {
+				return err
+			}
+		}
+		for _, roleCode := range group.roles {
+			role, err := userRepo.EnsureRole(ctx, roleCode)
+			if err != nil {
+				return err
+			}
+			if err := permRepo.AttachPermissionGroup(ctx, role.ID, g.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
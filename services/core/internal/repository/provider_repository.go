@@ -7,9 +7,17 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
 )
 
 type ProviderRepository interface {
+	// ResolveNamespaceByProviderID — единственный намеренно tenant-agnostic
+	// метод этого репозитория: публичный iCalendar-фид (internal/icalendar)
+	// знает только provider_id из подписанного URL и должен сначала
+	// выяснить его арендатора, прежде чем положить его в контекст и сделать
+	// остальные (уже namespace-скоупнутые) вызовы.
+	ResolveNamespaceByProviderID(ctx context.Context, id string) (uuid.UUID, error)
 	GetByID(ctx context.Context, id string) (*model.Provider, error)
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*model.Provider, error)
 	EnsureByUserID(ctx context.Context, userID uuid.UUID, displayName string) (*model.Provider, error)
@@ -26,58 +34,86 @@ func NewGormProviderRepository(db *gorm.DB) *GormProviderRepository {
 	return &GormProviderRepository{db: db}
 }
 
-func (r *GormProviderRepository) GetByID(ctx context.Context, id string) (*model.Provider, error) {
+func (r *GormProviderRepository) ResolveNamespaceByProviderID(ctx context.Context, id string) (uuid.UUID, error) {
 	var p model.Provider
-	if err := r.db.WithContext(ctx).First(&p, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Select("namespace_id").First(&p, "id = ?", id).Error; err != nil {
+		return uuid.Nil, errs.Wrap(err, "provider")
+	}
+	return p.NamespaceID, nil
+}
+
+func (r *GormProviderRepository) GetByID(ctx context.Context, id string) (*model.Provider, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
 		return nil, err
 	}
+	var p model.Provider
+	if err := r.db.WithContext(ctx).First(&p, "id = ? AND namespace_id = ?", id, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "provider")
+	}
 	return &p, nil
 }
 
 func (r *GormProviderRepository) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.Provider, error) {
-	var p model.Provider
-	if err := r.db.WithContext(ctx).First(&p, "user_id = ?", userID).Error; err != nil {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
 		return nil, err
 	}
+	var p model.Provider
+	if err := r.db.WithContext(ctx).First(&p, "user_id = ? AND namespace_id = ?", userID, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "provider")
+	}
 	return &p, nil
 }
 
 func (r *GormProviderRepository) EnsureByUserID(ctx context.Context, userID uuid.UUID, displayName string) (*model.Provider, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if userID == uuid.Nil {
-		return nil, gorm.ErrRecordNotFound
+		return nil, errs.ErrNotFound("provider")
 	}
 	var p model.Provider
-	tx := r.db.WithContext(ctx).First(&p, "user_id = ?", userID)
+	tx := r.db.WithContext(ctx).First(&p, "user_id = ? AND namespace_id = ?", userID, nsID)
 	if tx.Error == nil {
 		return &p, nil
 	}
 	if tx.Error != gorm.ErrRecordNotFound {
-		return nil, tx.Error
+		return nil, errs.Wrap(tx.Error, "provider")
 	}
 
 	if displayName == "" {
 		displayName = "Provider"
 	}
 
-	p = model.Provider{UserID: userID, DisplayName: displayName}
+	p = model.Provider{NamespaceID: nsID, UserID: userID, DisplayName: displayName}
 	if err := r.db.WithContext(ctx).Create(&p).Error; err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "provider")
 	}
 	return &p, nil
 }
 
 func (r *GormProviderRepository) Update(ctx context.Context, provider *model.Provider) error {
-	return r.db.WithContext(ctx).
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).
 		Model(&model.Provider{}).
-		Where("id = ?", provider.ID).
+		Where("id = ? AND namespace_id = ?", provider.ID, nsID).
 		Updates(map[string]any{
 			"display_name": provider.DisplayName,
 			"description":  provider.Description,
-		}).Error
+		}).Error, "provider")
 }
 
 func (r *GormProviderRepository) List(ctx context.Context, serviceID *uuid.UUID, limit, offset int) ([]model.Provider, int64, error) {
-	q := r.db.WithContext(ctx).Model(&model.Provider{})
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	q := r.db.WithContext(ctx).Model(&model.Provider{}).Where("providers.namespace_id = ?", nsID)
 	if serviceID != nil {
 		q = q.Joins("JOIN provider_services ON provider_services.provider_id = providers.id").
 			Where("provider_services.service_id = ?", *serviceID)
@@ -85,7 +121,7 @@ func (r *GormProviderRepository) List(ctx context.Context, serviceID *uuid.UUID,
 
 	var total int64
 	if err := q.Count(&total).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "provider")
 	}
 
 	if limit <= 0 {
@@ -97,15 +133,19 @@ func (r *GormProviderRepository) List(ctx context.Context, serviceID *uuid.UUID,
 
 	var providers []model.Provider
 	if err := q.Order("display_name ASC").Limit(limit).Offset(offset).Find(&providers).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "provider")
 	}
 
 	return providers, total, nil
 }
 
 func (r *GormProviderRepository) SetServices(ctx context.Context, providerID uuid.UUID, serviceIDs []uuid.UUID) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("provider_id = ?", providerID).Delete(&model.ProviderService{}).Error; err != nil {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("provider_id = ? AND namespace_id = ?", providerID, nsID).Delete(&model.ProviderService{}).Error; err != nil {
 			return err
 		}
 		if len(serviceIDs) == 0 {
@@ -116,5 +156,5 @@ func (r *GormProviderRepository) SetServices(ctx context.Context, providerID uui
 			rows = append(rows, model.ProviderService{ProviderID: providerID, ServiceID: sid})
 		}
 		return tx.Create(&rows).Error
-	})
+	}), "provider_service")
 }
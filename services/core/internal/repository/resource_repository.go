@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// ResourceRepository хранит ресурсы провайдера (staff/room/equipment, см.
+// model.Resource) и их закрепление за слотами (model.TimeSlotResource).
+type ResourceRepository interface {
+	Create(ctx context.Context, r *model.Resource) error
+	GetByID(ctx context.Context, id string) (*model.Resource, error)
+	ListByProvider(ctx context.Context, providerID string) ([]model.Resource, error)
+	// AssignToSlot заменяет набор ресурсов, закреплённых за slotID, на
+	// resourceIDs — используется при ручном создании/обновлении слота (см.
+	// CalendarService.CreateSlot).
+	AssignToSlot(ctx context.Context, slotID uuid.UUID, resourceIDs []uuid.UUID) error
+	// ListAssignedToSlots возвращает resource_id, закреплённые за каждым из
+	// slotIDs — используется ListFreeSlots для ответа клиенту и
+	// CreateBooking для определения, что именно нужно резервировать вместе
+	// со слотом.
+	ListAssignedToSlots(ctx context.Context, slotIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error)
+}
+
+// Реализация на GORM.
+type GormResourceRepository struct {
+	db *gorm.DB
+}
+
+func NewGormResourceRepository(db *gorm.DB) *GormResourceRepository {
+	return &GormResourceRepository{db: db}
+}
+
+func (r *GormResourceRepository) Create(ctx context.Context, res *model.Resource) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	res.NamespaceID = nsID
+	return errs.Wrap(r.db.WithContext(ctx).Create(res).Error, "resource")
+}
+
+func (r *GormResourceRepository) GetByID(ctx context.Context, id string) (*model.Resource, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var res model.Resource
+	if err := r.db.WithContext(ctx).First(&res, "id = ? AND namespace_id = ?", id, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "resource")
+	}
+	return &res, nil
+}
+
+func (r *GormResourceRepository) ListByProvider(ctx context.Context, providerID string) ([]model.Resource, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var resources []model.Resource
+	err = r.db.WithContext(ctx).
+		Where("namespace_id = ? AND provider_id = ?", nsID, providerID).
+		Order("kind ASC, name ASC").
+		Find(&resources).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "resource")
+	}
+	return resources, nil
+}
+
+func (r *GormResourceRepository) AssignToSlot(ctx context.Context, slotID uuid.UUID, resourceIDs []uuid.UUID) error {
+	return errs.Wrap(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("slot_id = ?", slotID).Delete(&model.TimeSlotResource{}).Error; err != nil {
+			return err
+		}
+		if len(resourceIDs) == 0 {
+			return nil
+		}
+		links := make([]model.TimeSlotResource, 0, len(resourceIDs))
+		for _, rid := range resourceIDs {
+			links = append(links, model.TimeSlotResource{SlotID: slotID, ResourceID: rid})
+		}
+		return tx.Create(&links).Error
+	}), "resource")
+}
+
+func (r *GormResourceRepository) ListAssignedToSlots(ctx context.Context, slotIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	out := make(map[uuid.UUID][]uuid.UUID, len(slotIDs))
+	if len(slotIDs) == 0 {
+		return out, nil
+	}
+	var links []model.TimeSlotResource
+	if err := r.db.WithContext(ctx).Where("slot_id IN ?", slotIDs).Find(&links).Error; err != nil {
+		return nil, errs.Wrap(err, "resource")
+	}
+	for _, l := range links {
+		out[l.SlotID] = append(out[l.SlotID], l.ResourceID)
+	}
+	return out, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// RoleRepository управляет назначением ролей пользователям, в том числе со
+// scope на конкретного провайдера (см. model.UserRole.ProviderID). В отличие
+// от UserRepository.SetRole/GetRole/ListRoles (старый, нескоупнутый путь,
+// оставлен как есть ради обратной совместимости с IdentityService.SetRole),
+// этот репозиторий — основа новой AuthorizationService: RBAC с несколькими
+// ролями на пользователя и опциональным scope на роль.
+type RoleRepository interface {
+	// EnsureRole возвращает роль с кодом roleCode, создавая её в общем (без
+	// namespace) справочнике ролей при первом использовании.
+	EnsureRole(ctx context.Context, roleCode string) (*model.Role, error)
+	// AssignRole назначает пользователю роль roleCode, опционально
+	// ограниченную providerID. Идемпотентно: повторное назначение той же
+	// пары (roleCode, providerID) — no-op.
+	AssignRole(ctx context.Context, userID uuid.UUID, roleCode string, providerID *uuid.UUID) (*model.UserRole, error)
+	// RevokeRole снимает с пользователя роль roleCode с тем же scope
+	// (providerID должен совпасть, включая случай nil == глобальная роль).
+	RevokeRole(ctx context.Context, userID uuid.UUID, roleCode string, providerID *uuid.UUID) error
+	// ListUserRoles возвращает все назначения роли пользователю в текущем
+	// арендаторе (с предзагруженным Role), включая scope.
+	ListUserRoles(ctx context.Context, userID uuid.UUID) ([]model.UserRole, error)
+}
+
+type GormRoleRepository struct {
+	db *gorm.DB
+}
+
+func NewGormRoleRepository(db *gorm.DB) *GormRoleRepository {
+	return &GormRoleRepository{db: db}
+}
+
+func (r *GormRoleRepository) EnsureRole(ctx context.Context, roleCode string) (*model.Role, error) {
+	var role model.Role
+	if err := r.db.WithContext(ctx).Where("code = ?", roleCode).First(&role).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			return nil, errs.Wrap(err, "role")
+		}
+		role = model.Role{Code: roleCode, Name: roleCode}
+		if err := r.db.WithContext(ctx).Create(&role).Error; err != nil {
+			return nil, errs.Wrap(err, "role")
+		}
+	}
+	return &role, nil
+}
+
+func (r *GormRoleRepository) AssignRole(ctx context.Context, userID uuid.UUID, roleCode string, providerID *uuid.UUID) (*model.UserRole, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := r.EnsureRole(ctx, roleCode)
+	if err != nil {
+		return nil, err
+	}
+
+	ur := model.UserRole{NamespaceID: nsID, RoleID: role.ID, UserID: userID, ProviderID: providerID}
+	query := r.db.WithContext(ctx).Where("namespace_id = ? AND user_id = ? AND role_id = ?", nsID, userID, role.ID)
+	if providerID != nil {
+		query = query.Where("provider_id = ?", *providerID)
+	} else {
+		query = query.Where("provider_id IS NULL")
+	}
+	if err := query.FirstOrCreate(&ur).Error; err != nil {
+		return nil, errs.Wrap(err, "user_role")
+	}
+	return &ur, nil
+}
+
+func (r *GormRoleRepository) RevokeRole(ctx context.Context, userID uuid.UUID, roleCode string, providerID *uuid.UUID) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	var role model.Role
+	if err := r.db.WithContext(ctx).Where("code = ?", roleCode).First(&role).Error; err != nil {
+		return errs.Wrap(err, "role")
+	}
+
+	query := r.db.WithContext(ctx).
+		Where("namespace_id = ? AND user_id = ? AND role_id = ?", nsID, userID, role.ID)
+	if providerID != nil {
+		query = query.Where("provider_id = ?", *providerID)
+	} else {
+		query = query.Where("provider_id IS NULL")
+	}
+	return errs.Wrap(query.Delete(&model.UserRole{}).Error, "user_role")
+}
+
+func (r *GormRoleRepository) ListUserRoles(ctx context.Context, userID uuid.UUID) ([]model.UserRole, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var roles []model.UserRole
+	err = r.db.WithContext(ctx).
+		Preload("Role").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.namespace_id = ? AND user_roles.user_id = ?", nsID, userID).
+		Order("roles.id ASC").
+		Find(&roles).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "user_role")
+	}
+	return roles, nil
+}
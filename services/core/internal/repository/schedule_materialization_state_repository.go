@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// ScheduleMaterializationStateRepository хранит прогресс фонового
+// материализатора по каждому расписанию (см. model.ScheduleMaterializationState)
+// — какая версия правила уже разложена в TimeSlot и до какого момента.
+// Используется только internal/scheduler.SlotMaterializer, поэтому намеренно
+// не проходит через namespace.Require — арендатор в каждой записи уже
+// известен и передаётся явно вызывающим кодом фонового воркера.
+type ScheduleMaterializationStateRepository interface {
+	Get(ctx context.Context, scheduleID uuid.UUID) (*model.ScheduleMaterializationState, error)
+	Upsert(ctx context.Context, state *model.ScheduleMaterializationState) error
+}
+
+type GormScheduleMaterializationStateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormScheduleMaterializationStateRepository(db *gorm.DB) *GormScheduleMaterializationStateRepository {
+	return &GormScheduleMaterializationStateRepository{db: db}
+}
+
+func (r *GormScheduleMaterializationStateRepository) Get(ctx context.Context, scheduleID uuid.UUID) (*model.ScheduleMaterializationState, error) {
+	var state model.ScheduleMaterializationState
+	err := r.db.WithContext(ctx).First(&state, "schedule_id = ?", scheduleID).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "schedule_materialization_state")
+	}
+	return &state, nil
+}
+
+// Upsert записывает прогресс материализации расписания после успешного
+// прогона SlotMaterializer.Rebuild/RebuildSchedule.
+func (r *GormScheduleMaterializationStateRepository) Upsert(ctx context.Context, state *model.ScheduleMaterializationState) error {
+	state.UpdatedAt = time.Now().UTC()
+	return errs.Wrap(r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "schedule_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"namespace_id", "provider_id", "materialized_version", "materialized_until", "updated_at"}),
+		}).
+		Create(state).Error, "schedule_materialization_state")
+}
@@ -6,6 +6,8 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
 )
 
 type ScheduleRepository interface {
@@ -26,33 +28,69 @@ func NewGormScheduleRepository(db *gorm.DB) *GormScheduleRepository {
 }
 
 func (r *GormScheduleRepository) ListByProvider(ctx context.Context, providerID string) ([]model.Schedule, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var schedules []model.Schedule
-	err := r.db.WithContext(ctx).
-		Where("provider_id = ?", providerID).
+	err = r.db.WithContext(ctx).
+		Where("provider_id = ? AND namespace_id = ?", providerID, nsID).
 		Order("created_at DESC").
 		Find(&schedules).Error
 	if err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "schedule")
 	}
 	return schedules, nil
 }
 
 func (r *GormScheduleRepository) GetByID(ctx context.Context, id string) (*model.Schedule, error) {
-	var s model.Schedule
-	if err := r.db.WithContext(ctx).First(&s, "id = ?", id).Error; err != nil {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
 		return nil, err
 	}
+	var s model.Schedule
+	if err := r.db.WithContext(ctx).First(&s, "id = ? AND namespace_id = ?", id, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "schedule")
+	}
 	return &s, nil
 }
 
 func (r *GormScheduleRepository) Create(ctx context.Context, s *model.Schedule) error {
-	return r.db.WithContext(ctx).Create(s).Error
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	s.NamespaceID = nsID
+	return errs.Wrap(r.db.WithContext(ctx).Create(s).Error, "schedule")
 }
 
+// Update обновляет правило/границы расписания и увеличивает ScheduleVersion
+// на единицу — так фоновый материализатор (internal/scheduler.
+// SlotMaterializer) узнаёт, что ранее материализованные слоты этого
+// расписания устарели и его нужно перематериализовать, не трогая остальные
+// расписания провайдера. Zero-value поля s, как и раньше (см. GORM
+// Updates(struct)), не затирают сохранённые значения.
 func (r *GormScheduleRepository) Update(ctx context.Context, s *model.Schedule) error {
-	return r.db.WithContext(ctx).Model(&model.Schedule{}).Where("id = ?", s.ID).Updates(s).Error
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Schedule{}).
+			Where("id = ? AND namespace_id = ?", s.ID, nsID).
+			Updates(s).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.Schedule{}).
+			Where("id = ? AND namespace_id = ?", s.ID, nsID).
+			Update("schedule_version", gorm.Expr("schedule_version + 1")).Error
+	}), "schedule")
 }
 
 func (r *GormScheduleRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.Schedule{}, "id = ?", id).Error
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).Delete(&model.Schedule{}, "id = ? AND namespace_id = ?", id, nsID).Error, "schedule")
 }
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+type ScheduleTemplateRepository interface {
+	Create(ctx context.Context, t *model.ScheduleTemplate) error
+	GetByID(ctx context.Context, id string) (*model.ScheduleTemplate, error)
+	ListByOwner(ctx context.Context, ownerID string) ([]model.ScheduleTemplate, error)
+	// FindApplication возвращает расписание, уже заведённое предыдущим
+	// применением шаблона templateID к providerID с тем же serviceID и
+	// startDate, если такое есть — см. CalendarService.ApplyScheduleTemplate.
+	FindApplication(
+		ctx context.Context,
+		providerID, templateID string,
+		serviceID *string,
+		startDate datatypes.Date,
+	) (*model.Schedule, error)
+}
+
+type GormScheduleTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewGormScheduleTemplateRepository(db *gorm.DB) *GormScheduleTemplateRepository {
+	return &GormScheduleTemplateRepository{db: db}
+}
+
+func (r *GormScheduleTemplateRepository) Create(ctx context.Context, t *model.ScheduleTemplate) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	t.NamespaceID = nsID
+	return errs.Wrap(r.db.WithContext(ctx).Create(t).Error, "schedule_template")
+}
+
+func (r *GormScheduleTemplateRepository) GetByID(ctx context.Context, id string) (*model.ScheduleTemplate, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var t model.ScheduleTemplate
+	if err := r.db.WithContext(ctx).First(&t, "id = ? AND namespace_id = ?", id, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "schedule_template")
+	}
+	return &t, nil
+}
+
+func (r *GormScheduleTemplateRepository) ListByOwner(ctx context.Context, ownerID string) ([]model.ScheduleTemplate, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var templates []model.ScheduleTemplate
+	err = r.db.WithContext(ctx).
+		Where("owner_id = ? AND namespace_id = ?", ownerID, nsID).
+		Order("created_at DESC").
+		Find(&templates).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "schedule_template")
+	}
+	return templates, nil
+}
+
+func (r *GormScheduleTemplateRepository) FindApplication(
+	ctx context.Context,
+	providerID, templateID string,
+	serviceID *string,
+	startDate datatypes.Date,
+) (*model.Schedule, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := r.db.WithContext(ctx).
+		Where("namespace_id = ? AND provider_id = ? AND template_id = ?", nsID, providerID, templateID).
+		Where("start_date = ?", startDate)
+	if serviceID != nil {
+		q = q.Where("service_id = ?", *serviceID)
+	} else {
+		q = q.Where("service_id IS NULL")
+	}
+	var sched model.Schedule
+	if err := q.First(&sched).Error; err != nil {
+		return nil, errs.Wrap(err, "schedule")
+	}
+	return &sched, nil
+}
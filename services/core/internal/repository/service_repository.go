@@ -7,6 +7,8 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
 )
 
 type ServiceRepository interface {
@@ -26,26 +28,39 @@ func NewGormServiceRepository(db *gorm.DB) *GormServiceRepository {
 }
 
 func (r *GormServiceRepository) GetByID(ctx context.Context, id string) (*model.Service, error) {
-	var s model.Service
-	if err := r.db.WithContext(ctx).First(&s, "id = ?", id).Error; err != nil {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
 		return nil, err
 	}
+	var s model.Service
+	if err := r.db.WithContext(ctx).First(&s, "id = ? AND namespace_id = ?", id, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "service")
+	}
 	return &s, nil
 }
 
 func (r *GormServiceRepository) Create(ctx context.Context, service *model.Service) error {
-	return r.db.WithContext(ctx).Create(service).Error
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	service.NamespaceID = nsID
+	return errs.Wrap(r.db.WithContext(ctx).Create(service).Error, "service")
 }
 
 func (r *GormServiceRepository) List(ctx context.Context, onlyActive bool, limit, offset int) ([]model.Service, int64, error) {
-	q := r.db.WithContext(ctx).Model(&model.Service{})
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	q := r.db.WithContext(ctx).Model(&model.Service{}).Where("namespace_id = ?", nsID)
 	if onlyActive {
 		q = q.Where("is_active = ?", true)
 	}
 
 	var total int64
 	if err := q.Count(&total).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "service")
 	}
 
 	if limit <= 0 {
@@ -57,22 +72,26 @@ func (r *GormServiceRepository) List(ctx context.Context, onlyActive bool, limit
 
 	var services []model.Service
 	if err := q.Order("name ASC").Limit(limit).Offset(offset).Find(&services).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "service")
 	}
 	return services, total, nil
 }
 
 func (r *GormServiceRepository) ListByProvider(ctx context.Context, providerID uuid.UUID) ([]model.Service, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var services []model.Service
-	err := r.db.WithContext(ctx).
+	err = r.db.WithContext(ctx).
 		Table("services").
 		Select("services.*").
 		Joins("JOIN provider_services ON provider_services.service_id = services.id").
-		Where("provider_services.provider_id = ?", providerID).
+		Where("provider_services.provider_id = ? AND services.namespace_id = ?", providerID, nsID).
 		Order("services.name ASC").
 		Scan(&services).Error
 	if err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "service")
 	}
 	return services, nil
 }
@@ -81,12 +100,16 @@ func (r *GormServiceRepository) ListByIDs(ctx context.Context, ids []uuid.UUID)
 	if len(ids) == 0 {
 		return []model.Service{}, nil
 	}
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var services []model.Service
-	err := r.db.WithContext(ctx).
-		Where("id IN ?", ids).
+	err = r.db.WithContext(ctx).
+		Where("id IN ? AND namespace_id = ?", ids, nsID).
 		Find(&services).Error
 	if err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "service")
 	}
 	return services, nil
 }
@@ -2,25 +2,58 @@ package repository
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	calpage "github.com/Leganyst/appointment-platform/internal/calendar"
+	"github.com/Leganyst/appointment-platform/internal/db"
 	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
 )
 
 type SlotRepository interface {
-	// Свободные слоты провайдера по интервалу и услуге.
-	ListFreeSlots(ctx context.Context, providerID, serviceID string, from, to time.Time, limit, offset int) ([]model.TimeSlot, int64, error)
+	// Свободные слоты провайдера по интервалу и услуге. resourceIDs,
+	// если непусто, оставляет только слоты, за которыми закреплены ВСЕ
+	// перечисленные ресурсы (см. model.TimeSlotResource) — так
+	// ListFreeSlots пересекает доступность сразу по всем требуемым типам
+	// ресурсов (staff/room/equipment), а не только по провайдеру/услуге.
+	ListFreeSlots(ctx context.Context, providerID, serviceID string, resourceIDs []string, from, to time.Time, limit, offset int) ([]model.TimeSlot, int64, error)
 	// Все слоты провайдера по интервалу (любые статусы).
 	ListByProviderRange(ctx context.Context, providerID string, from, to time.Time, limit, offset int) ([]model.TimeSlot, int64, error)
+	// ListSlotsAfter — keyset-пагинация по (starts_at, id): возвращает до
+	// limit слотов провайдера строго после cursor (nil — с самого начала),
+	// отсортированных по (starts_at, id) ASC. ListProviderSlots просит на один
+	// больше размера страницы и лишний элемент обрезает сам — так по длине
+	// результата видно, есть ли следующая страница, без отдельного COUNT(*) (в отличие от
+	// ListFreeSlots/ListByProviderRange, которым total нужен для
+	// offset-пагинации).
+	ListSlotsAfter(ctx context.Context, providerID string, cursor *calpage.Cursor, limit int) ([]model.TimeSlot, error)
+	// ListOverlapping возвращает активные (не cancelled) слоты провайдера,
+	// пересекающиеся с [from, to). excludeID, если не пустой, исключает
+	// слот с этим ID из результата — нужно при Update, чтобы слот не
+	// считался пересекающимся сам с собой. Используется как pre-flight
+	// проверка перед Create/Update и как фолбэк overlap-проверки на
+	// диалектах без EXCLUDE-ограничения (см. model.EnsureSlotOverlapConstraint).
+	ListOverlapping(ctx context.Context, providerID string, from, to time.Time, excludeID string) ([]model.TimeSlot, error)
 	// Найти слот по ID.
 	GetByID(ctx context.Context, id string) (*model.TimeSlot, error)
+	// Найти слот провайдера по ExternalID (UID исходного VEVENT) — нужно
+	// ImportProviderCalendar, чтобы повторный импорт того же .ics обновлял
+	// уже созданную строку, а не плодил дубликаты. Возвращает errs.ErrNotFound,
+	// если такого слота ещё нет.
+	GetByExternalID(ctx context.Context, providerID, externalID string) (*model.TimeSlot, error)
 	// Обновить статус слота.
 	UpdateStatus(ctx context.Context, id string, status model.TimeSlotStatus) error
-	// Создать слот.
+	// Создать слот. На Postgres пересечение интервалов провайдера ловит
+	// EXCLUDE-ограничение (см. model.EnsureSlotOverlapConstraint); на
+	// MySQL/SQLite, где его нет, перед вставкой под блокировкой строк
+	// проверяется ListOverlapping в той же SERIALIZABLE-транзакции.
 	Create(ctx context.Context, slot *model.TimeSlot) error
-	// Обновить слот.
+	// Обновить слот. Те же гарантии отсутствия пересечений, что и у Create.
 	Update(ctx context.Context, slot *model.TimeSlot) error
 	// Удалить слот.
 	Delete(ctx context.Context, id string) error
@@ -30,19 +63,25 @@ type GormSlotRepository struct {
 	db *gorm.DB
 }
 
-func NewGormSlotRepository(db *gorm.DB) *GormSlotRepository {
-	return &GormSlotRepository{db: db}
+func NewGormSlotRepository(gdb *gorm.DB) *GormSlotRepository {
+	return &GormSlotRepository{db: gdb}
 }
 
 func (r *GormSlotRepository) ListFreeSlots(
 	ctx context.Context,
 	providerID, serviceID string,
+	resourceIDs []string,
 	from, to time.Time,
 	limit, offset int,
 ) ([]model.TimeSlot, int64, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
 	var slots []model.TimeSlot
 	q := r.db.WithContext(ctx).
 		Model(&model.TimeSlot{}).
+		Where("namespace_id = ?", nsID).
 		Where("provider_id = ?", providerID).
 		Where("starts_at >= ? AND ends_at <= ?", from, to).
 		Where("status = ?", model.TimeSlotStatusPlanned)
@@ -51,9 +90,17 @@ func (r *GormSlotRepository) ListFreeSlots(
 		q = q.Where("service_id = ?", serviceID)
 	}
 
+	if len(resourceIDs) > 0 {
+		q = q.Where(
+			"(SELECT COUNT(DISTINCT resource_id) FROM time_slot_resources "+
+				"WHERE time_slot_resources.slot_id = time_slots.id AND resource_id IN ?) = ?",
+			resourceIDs, len(resourceIDs),
+		)
+	}
+
 	var total int64
 	if err := q.Count(&total).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "slot")
 	}
 
 	if limit > 0 {
@@ -61,7 +108,7 @@ func (r *GormSlotRepository) ListFreeSlots(
 	}
 
 	if err := q.Order("starts_at ASC").Find(&slots).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "slot")
 	}
 
 	return slots, total, nil
@@ -73,15 +120,20 @@ func (r *GormSlotRepository) ListByProviderRange(
 	from, to time.Time,
 	limit, offset int,
 ) ([]model.TimeSlot, int64, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
 	var slots []model.TimeSlot
 	q := r.db.WithContext(ctx).
 		Model(&model.TimeSlot{}).
+		Where("namespace_id = ?", nsID).
 		Where("provider_id = ?", providerID).
 		Where("starts_at >= ? AND ends_at <= ?", from, to)
 
 	var total int64
 	if err := q.Count(&total).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "slot")
 	}
 
 	if limit > 0 {
@@ -89,36 +141,174 @@ func (r *GormSlotRepository) ListByProviderRange(
 	}
 
 	if err := q.Order("starts_at ASC").Find(&slots).Error; err != nil {
-		return nil, 0, err
+		return nil, 0, errs.Wrap(err, "slot")
 	}
 
 	return slots, total, nil
 }
 
+func (r *GormSlotRepository) ListSlotsAfter(
+	ctx context.Context,
+	providerID string,
+	cursor *calpage.Cursor,
+	limit int,
+) ([]model.TimeSlot, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	q := r.db.WithContext(ctx).
+		Model(&model.TimeSlot{}).
+		Where("namespace_id = ?", nsID).
+		Where("provider_id = ?", providerID)
+	if cursor != nil {
+		q = q.Where("(starts_at, id) > (?, ?)", cursor.StartsAt, cursor.ID)
+	}
+
+	var slots []model.TimeSlot
+	if err := q.Order("starts_at ASC, id ASC").Limit(limit).Find(&slots).Error; err != nil {
+		return nil, errs.Wrap(err, "slot")
+	}
+	return slots, nil
+}
+
+func (r *GormSlotRepository) ListOverlapping(
+	ctx context.Context,
+	providerID string,
+	from, to time.Time,
+	excludeID string,
+) ([]model.TimeSlot, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := listOverlapping(r.db.WithContext(ctx), nsID.String(), providerID, from, to, excludeID, false)
+	if err != nil {
+		return nil, errs.Wrap(err, "slot")
+	}
+	return slots, nil
+}
+
+// listOverlapping — общий запрос для ListOverlapping и фолбэк-проверки
+// внутри Create/Update. locked включает SELECT ... FOR UPDATE, чтобы
+// пересекающиеся строки были заблокированы до конца транзакции вставки/
+// обновления (нужно только там, где нет EXCLUDE-ограничения, см.
+// model.EnsureSlotOverlapConstraint).
+func listOverlapping(
+	tx *gorm.DB,
+	nsID, providerID string,
+	from, to time.Time,
+	excludeID string,
+	locked bool,
+) ([]model.TimeSlot, error) {
+	q := tx.Model(&model.TimeSlot{}).
+		Where("namespace_id = ?", nsID).
+		Where("provider_id = ?", providerID).
+		Where("status <> ?", model.TimeSlotStatusCancelled).
+		Where("starts_at < ? AND ends_at > ?", to, from)
+	if excludeID != "" {
+		q = q.Where("id <> ?", excludeID)
+	}
+	if locked {
+		q = q.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var slots []model.TimeSlot
+	if err := q.Find(&slots).Error; err != nil {
+		return nil, err
+	}
+	return slots, nil
+}
+
 func (r *GormSlotRepository) GetByID(ctx context.Context, id string) (*model.TimeSlot, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	var slot model.TimeSlot
-	if err := r.db.WithContext(ctx).First(&slot, "id = ?", id).Error; err != nil {
+	if err := r.db.WithContext(ctx).First(&slot, "id = ? AND namespace_id = ?", id, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "slot")
+	}
+	return &slot, nil
+}
+
+func (r *GormSlotRepository) GetByExternalID(ctx context.Context, providerID, externalID string) (*model.TimeSlot, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
 		return nil, err
 	}
+	var slot model.TimeSlot
+	if err := r.db.WithContext(ctx).First(
+		&slot,
+		"provider_id = ? AND external_id = ? AND namespace_id = ?", providerID, externalID, nsID,
+	).Error; err != nil {
+		return nil, errs.Wrap(err, "slot")
+	}
 	return &slot, nil
 }
 
 func (r *GormSlotRepository) UpdateStatus(ctx context.Context, id string, status model.TimeSlotStatus) error {
-	return r.db.WithContext(ctx).
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).
 		Model(&model.TimeSlot{}).
-		Where("id = ?", id).
+		Where("id = ? AND namespace_id = ?", id, nsID).
 		Update("status", status).
-		Error
+		Error, "slot")
 }
 
 func (r *GormSlotRepository) Create(ctx context.Context, slot *model.TimeSlot) error {
-	return r.db.WithContext(ctx).Create(slot).Error
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	slot.NamespaceID = nsID
+
+	return db.WithSerializableRetry(ctx, r.db, func(tx *gorm.DB) error {
+		if model.ActiveDialect() != model.DialectPostgres {
+			overlapping, err := listOverlapping(tx, nsID.String(), slot.ProviderID.String(), slot.StartsAt, slot.EndsAt, "", true)
+			if err != nil {
+				return errs.Wrap(err, "slot")
+			}
+			if len(overlapping) > 0 {
+				return fmt.Errorf("slot: %w", errs.ErrSlotOverlap)
+			}
+		}
+		return errs.Wrap(tx.Create(slot).Error, "slot")
+	})
 }
 
 func (r *GormSlotRepository) Update(ctx context.Context, slot *model.TimeSlot) error {
-	return r.db.WithContext(ctx).Model(&model.TimeSlot{}).Where("id = ?", slot.ID).Updates(slot).Error
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+
+	return db.WithSerializableRetry(ctx, r.db, func(tx *gorm.DB) error {
+		if model.ActiveDialect() != model.DialectPostgres {
+			overlapping, err := listOverlapping(tx, nsID.String(), slot.ProviderID.String(), slot.StartsAt, slot.EndsAt, slot.ID.String(), true)
+			if err != nil {
+				return errs.Wrap(err, "slot")
+			}
+			if len(overlapping) > 0 {
+				return fmt.Errorf("slot: %w", errs.ErrSlotOverlap)
+			}
+		}
+		return errs.Wrap(tx.Model(&model.TimeSlot{}).
+			Where("id = ? AND namespace_id = ?", slot.ID, nsID).
+			Updates(slot).Error, "slot")
+	})
 }
 
 func (r *GormSlotRepository) Delete(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.TimeSlot{}, "id = ?", id).Error
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).Delete(&model.TimeSlot{}, "id = ? AND namespace_id = ?", id, nsID).Error, "slot")
 }
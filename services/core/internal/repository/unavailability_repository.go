@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+type UnavailabilityRepository interface {
+	// Create сохраняет период, предварительно слив его с пересекающимися или
+	// вплотную примыкающими периодами того же провайдера и услуги (см.
+	// mergeOverlappingPeriods) — возвращает итоговый (возможно расширенный)
+	// период, который действительно лёг в таблицу.
+	Create(ctx context.Context, p *model.UnavailabilityPeriod) (*model.UnavailabilityPeriod, error)
+	GetByID(ctx context.Context, id string) (*model.UnavailabilityPeriod, error)
+	// ListForProvider возвращает периоды провайдера, пересекающиеся с [from, to).
+	ListForProvider(ctx context.Context, providerID string, from, to time.Time) ([]model.UnavailabilityPeriod, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type GormUnavailabilityRepository struct {
+	db *gorm.DB
+}
+
+func NewGormUnavailabilityRepository(db *gorm.DB) *GormUnavailabilityRepository {
+	return &GormUnavailabilityRepository{db: db}
+}
+
+// mergeOverlappingPeriods сортирует периоды по StartsAt и сворачивает
+// соприкасающиеся/пересекающиеся окна (next.StartsAt <= cur.EndsAt) в одно —
+// тот же алгоритм, что у mergeOverlappingPeriods внешнего booking-service.
+func mergeOverlappingPeriods(periods []model.UnavailabilityPeriod) []model.UnavailabilityPeriod {
+	if len(periods) == 0 {
+		return periods
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].StartsAt.Before(periods[j].StartsAt) })
+
+	merged := []model.UnavailabilityPeriod{periods[0]}
+	for _, next := range periods[1:] {
+		cur := &merged[len(merged)-1]
+		if !next.StartsAt.After(cur.EndsAt) {
+			if next.EndsAt.After(cur.EndsAt) {
+				cur.EndsAt = next.EndsAt
+			}
+			continue
+		}
+		merged = append(merged, next)
+	}
+	return merged
+}
+
+// Create сохраняет новый период недоступности, предварительно слив его со
+// всеми пересекающимися или вплотную примыкающими периодами того же
+// провайдера и услуги: старые строки удаляются, а объединённое окно
+// вставляется заново под своим собственным ID. Выполняется в транзакции,
+// чтобы конкурентный материализатор не увидел промежуточное состояние
+// (удалённые старые периоды, но ещё не вставленный объединённый).
+func (r *GormUnavailabilityRepository) Create(ctx context.Context, p *model.UnavailabilityPeriod) (*model.UnavailabilityPeriod, error) {
+	var result model.UnavailabilityPeriod
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var overlapping []model.UnavailabilityPeriod
+		q := tx.
+			Where("provider_id = ?", p.ProviderID).
+			Where("starts_at <= ? AND ends_at >= ?", p.EndsAt, p.StartsAt)
+		if p.ServiceID != nil {
+			q = q.Where("service_id = ?", *p.ServiceID)
+		} else {
+			q = q.Where("service_id IS NULL")
+		}
+		if err := q.Find(&overlapping).Error; err != nil {
+			return err
+		}
+
+		merged := mergeOverlappingPeriods(append(overlapping, *p))[0]
+		merged.ProviderID = p.ProviderID
+		merged.ServiceID = p.ServiceID
+		merged.Reason = p.Reason
+		merged.ID = uuid.Nil
+
+		if len(overlapping) > 0 {
+			ids := make([]uuid.UUID, len(overlapping))
+			for i, o := range overlapping {
+				ids[i] = o.ID
+			}
+			if err := tx.Delete(&model.UnavailabilityPeriod{}, "id IN ?", ids).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&merged).Error; err != nil {
+			return err
+		}
+		result = merged
+		return nil
+	})
+	if err != nil {
+		return nil, errs.Wrap(err, "unavailability_period")
+	}
+	return &result, nil
+}
+
+func (r *GormUnavailabilityRepository) GetByID(ctx context.Context, id string) (*model.UnavailabilityPeriod, error) {
+	var p model.UnavailabilityPeriod
+	if err := r.db.WithContext(ctx).First(&p, "id = ?", id).Error; err != nil {
+		return nil, errs.Wrap(err, "unavailability_period")
+	}
+	return &p, nil
+}
+
+func (r *GormUnavailabilityRepository) ListForProvider(
+	ctx context.Context,
+	providerID string,
+	from, to time.Time,
+) ([]model.UnavailabilityPeriod, error) {
+	var periods []model.UnavailabilityPeriod
+	err := r.db.WithContext(ctx).
+		Where("provider_id = ?", providerID).
+		Where("starts_at < ? AND ends_at > ?", to, from).
+		Order("starts_at ASC").
+		Find(&periods).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "unavailability_period")
+	}
+	return periods, nil
+}
+
+func (r *GormUnavailabilityRepository) Delete(ctx context.Context, id string) error {
+	return errs.Wrap(r.db.WithContext(ctx).Delete(&model.UnavailabilityPeriod{}, "id = ?", id).Error, "unavailability_period")
+}
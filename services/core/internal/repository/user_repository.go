@@ -7,6 +7,8 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
 	"github.com/google/uuid"
 )
 
@@ -18,8 +20,20 @@ type UserRepository interface {
 	ResetAccount(ctx context.Context, telegramID int64) (*model.User, error)
 	UpsertUser(ctx context.Context, telegramID int64, displayName, username, contactPhone string) (*model.User, error)
 	UpdateContacts(ctx context.Context, telegramID int64, displayName, username, contactPhone string) (*model.User, error)
+	// SetRole добавляет пользователю роль roleCode, не трогая уже назначенные
+	// (RBAC этого чанка допускает несколько ролей одновременно). Чтобы
+	// заменить набор ролей целиком, вызовите ClearRoles перед SetRole.
 	SetRole(ctx context.Context, userID uuid.UUID, roleCode string) error
+	// GetRole возвращает код "основной" (первой назначенной) роли — для
+	// обратной совместимости с местами, которые ожидают одну роль на
+	// пользователя (см. identitypb.User.RoleCode). Полный список — ListRoles.
 	GetRole(ctx context.Context, userID uuid.UUID) (string, error)
+	// ListRoles возвращает коды всех ролей, назначенных пользователю в
+	// текущем арендаторе, в порядке назначения.
+	ListRoles(ctx context.Context, userID uuid.UUID) ([]string, error)
+	// EnsureRole возвращает роль с кодом roleCode, создавая её в общем (без
+	// namespace) справочнике ролей при первом использовании.
+	EnsureRole(ctx context.Context, roleCode string) (*model.Role, error)
 }
 
 type GormUserRepository struct {
@@ -31,10 +45,16 @@ func NewGormUserRepository(db *gorm.DB) *GormUserRepository {
 }
 
 func (r *GormUserRepository) FindByTelegramID(ctx context.Context, telegramID int64) (*model.User, error) {
-	var u model.User
-	if err := r.db.WithContext(ctx).Where("telegram_id = ?", telegramID).First(&u).Error; err != nil {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
 		return nil, err
 	}
+	var u model.User
+	if err := r.db.WithContext(ctx).
+		Where("namespace_id = ? AND telegram_id = ?", nsID, telegramID).
+		First(&u).Error; err != nil {
+		return nil, errs.Wrap(err, "user")
+	}
 	return &u, nil
 }
 
@@ -62,20 +82,25 @@ func normalizePhone(phone string) string {
 }
 
 func (r *GormUserRepository) FindByPhone(ctx context.Context, phone string) (*model.User, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	n := normalizePhone(phone)
 	if n == "" {
-		return nil, gorm.ErrRecordNotFound
+		return nil, errs.ErrNotFound("user")
 	}
 
 	var u model.User
 	// Try normalized first, then raw (in case old data is not normalized).
 	q := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("namespace_id = ?", nsID).
 		Where("contact_phone = ?", n)
 	if strings.TrimSpace(phone) != n {
-		q = q.Or("contact_phone = ?", strings.TrimSpace(phone))
+		q = q.Or("namespace_id = ? AND contact_phone = ?", nsID, strings.TrimSpace(phone))
 	}
 	if err := q.First(&u).Error; err != nil {
-		return nil, err
+		return nil, errs.Wrap(err, "user")
 	}
 	return &u, nil
 }
@@ -88,58 +113,81 @@ func normalizeUsername(username string) string {
 }
 
 func (r *GormUserRepository) FindByUsername(ctx context.Context, username string) (*model.User, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	uq := normalizeUsername(username)
 	if uq == "" {
-		return nil, gorm.ErrRecordNotFound
+		return nil, errs.ErrNotFound("user")
 	}
 	var u model.User
 	// Username stored in Note (see mapUser). Make lookup case-insensitive.
-	if err := r.db.WithContext(ctx).Model(&model.User{}).Where("LOWER(note) = ?", uq).First(&u).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("namespace_id = ? AND LOWER(note) = ?", nsID, uq).
+		First(&u).Error; err != nil {
+		return nil, errs.Wrap(err, "user")
 	}
 	return &u, nil
 }
 
 func (r *GormUserRepository) ClearRoles(ctx context.Context, userID uuid.UUID) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
 	if userID == uuid.Nil {
-		return gorm.ErrRecordNotFound
+		return errs.ErrNotFound("user")
 	}
-	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.UserRole{}).Error
+	return errs.Wrap(r.db.WithContext(ctx).
+		Where("namespace_id = ? AND user_id = ?", nsID, userID).
+		Delete(&model.UserRole{}).Error, "user_role")
 }
 
 func (r *GormUserRepository) ResetAccount(ctx context.Context, telegramID int64) (*model.User, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	if telegramID <= 0 {
-		return nil, gorm.ErrRecordNotFound
+		return nil, errs.ErrNotFound("user")
 	}
 	updates := map[string]any{
 		"display_name":  "",
 		"contact_phone": "",
 		"note":          "",
 	}
-	if err := r.db.WithContext(ctx).Model(&model.User{}).Where("telegram_id = ?", telegramID).Updates(updates).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("namespace_id = ? AND telegram_id = ?", nsID, telegramID).
+		Updates(updates).Error; err != nil {
+		return nil, errs.Wrap(err, "user")
 	}
 	return r.FindByTelegramID(ctx, telegramID)
 }
 
 func (r *GormUserRepository) UpsertUser(ctx context.Context, telegramID int64, displayName, username, contactPhone string) (*model.User, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	contactPhone = normalizePhone(contactPhone)
 	username = normalizeUsername(username)
 	var u model.User
-	tx := r.db.WithContext(ctx).Where("telegram_id = ?", telegramID).First(&u)
+	tx := r.db.WithContext(ctx).Where("namespace_id = ? AND telegram_id = ?", nsID, telegramID).First(&u)
 	if tx.Error != nil {
 		if tx.Error == gorm.ErrRecordNotFound {
+			u.NamespaceID = nsID
 			u.TelegramID = telegramID
 			u.DisplayName = displayName
 			u.ContactPhone = contactPhone
 			// username не хранится отдельно в модели — можем сохранить в Note или расширить модель
 			u.Note = username
 			if err := r.db.WithContext(ctx).Create(&u).Error; err != nil {
-				return nil, err
+				return nil, errs.Wrap(err, "user")
 			}
 			return &u, nil
 		}
-		return nil, tx.Error
+		return nil, errs.Wrap(tx.Error, "user")
 	}
 	// update existing
 	updates := map[string]any{
@@ -147,8 +195,10 @@ func (r *GormUserRepository) UpsertUser(ctx context.Context, telegramID int64, d
 		"contact_phone": contactPhone,
 		"note":          username,
 	}
-	if err := r.db.WithContext(ctx).Model(&model.User{}).Where("telegram_id = ?", telegramID).Updates(updates).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("namespace_id = ? AND telegram_id = ?", nsID, telegramID).
+		Updates(updates).Error; err != nil {
+		return nil, errs.Wrap(err, "user")
 	}
 	u.DisplayName = displayName
 	u.ContactPhone = contactPhone
@@ -157,6 +207,10 @@ func (r *GormUserRepository) UpsertUser(ctx context.Context, telegramID int64, d
 }
 
 func (r *GormUserRepository) UpdateContacts(ctx context.Context, telegramID int64, displayName, username, contactPhone string) (*model.User, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
 	updates := map[string]any{}
 	if displayName != "" {
 		updates["display_name"] = displayName
@@ -171,44 +225,74 @@ func (r *GormUserRepository) UpdateContacts(ctx context.Context, telegramID int6
 		// nothing to update; just return current user
 		return r.FindByTelegramID(ctx, telegramID)
 	}
-	if err := r.db.WithContext(ctx).Model(&model.User{}).Where("telegram_id = ?", telegramID).Updates(updates).Error; err != nil {
-		return nil, err
+	if err := r.db.WithContext(ctx).Model(&model.User{}).
+		Where("namespace_id = ? AND telegram_id = ?", nsID, telegramID).
+		Updates(updates).Error; err != nil {
+		return nil, errs.Wrap(err, "user")
 	}
 	return r.FindByTelegramID(ctx, telegramID)
 }
 
-func (r *GormUserRepository) SetRole(ctx context.Context, userID uuid.UUID, roleCode string) error {
-	// ensure role exists
+func (r *GormUserRepository) EnsureRole(ctx context.Context, roleCode string) (*model.Role, error) {
 	var role model.Role
 	if err := r.db.WithContext(ctx).Where("code = ?", roleCode).First(&role).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			role.Code = roleCode
-			role.Name = roleCode
-			if err := r.db.WithContext(ctx).Create(&role).Error; err != nil {
-				return err
-			}
-		} else {
-			return err
+		if err != gorm.ErrRecordNotFound {
+			return nil, errs.Wrap(err, "role")
+		}
+		role.Code = roleCode
+		role.Name = roleCode
+		if err := r.db.WithContext(ctx).Create(&role).Error; err != nil {
+			return nil, errs.Wrap(err, "role")
 		}
 	}
+	return &role, nil
+}
 
-	// remove previous roles and set new one (single role policy)
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&model.UserRole{}).Error; err != nil {
+func (r *GormUserRepository) SetRole(ctx context.Context, userID uuid.UUID, roleCode string) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
 		return err
 	}
 
-	ur := model.UserRole{RoleID: role.ID, UserID: userID}
-	return r.db.WithContext(ctx).Create(&ur).Error
+	role, err := r.EnsureRole(ctx, roleCode)
+	if err != nil {
+		return err
+	}
+
+	// Идемпотентно добавляем роль, не трогая остальные — начиная с этого
+	// чанка пользователь может иметь несколько ролей одновременно. Полная
+	// замена набора ролей — через явный ClearRoles перед SetRole.
+	ur := model.UserRole{NamespaceID: nsID, RoleID: role.ID, UserID: userID}
+	return errs.Wrap(r.db.WithContext(ctx).
+		Where("namespace_id = ? AND user_id = ? AND role_id = ?", nsID, userID, role.ID).
+		FirstOrCreate(&ur).Error, "user_role")
 }
 
 func (r *GormUserRepository) GetRole(ctx context.Context, userID uuid.UUID) (string, error) {
-	var ur model.UserRole
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&ur).Error; err != nil {
+	codes, err := r.ListRoles(ctx, userID)
+	if err != nil {
 		return "", err
 	}
-	var role model.Role
-	if err := r.db.WithContext(ctx).First(&role, "id = ?", ur.RoleID).Error; err != nil {
-		return "", err
+	if len(codes) == 0 {
+		return "", errs.ErrNotFound("role")
+	}
+	return codes[0], nil
+}
+
+func (r *GormUserRepository) ListRoles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var codes []string
+	err = r.db.WithContext(ctx).
+		Table("user_roles").
+		Joins("JOIN roles ON roles.id = user_roles.role_id").
+		Where("user_roles.namespace_id = ? AND user_roles.user_id = ?", nsID, userID).
+		Order("roles.id ASC").
+		Pluck("roles.code", &codes).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "role")
 	}
-	return role.Code, nil
+	return codes, nil
 }
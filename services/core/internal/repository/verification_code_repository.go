@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// VerificationCodeRepository хранит PIN-коды привязки Telegram-аккаунта (см.
+// model.VerificationCode, service.VerificationService).
+type VerificationCodeRepository interface {
+	Create(ctx context.Context, vc *model.VerificationCode) (*model.VerificationCode, error)
+	// FindActiveByCode ищет ещё не потреблённый и не истёкший код
+	// (сравнение без учёта регистра — code должен быть уже приведён к
+	// верхнему регистру вызывающим, см. verification.NormalizeCode).
+	FindActiveByCode(ctx context.Context, code string, now time.Time) (*model.VerificationCode, error)
+	// MarkConsumed помечает код потреблённым; RowsAffected == 0 (уже
+	// потреблён конкурентным вызовом) — errs.ErrConflict.
+	MarkConsumed(ctx context.Context, id string, at time.Time) error
+	// InvalidateActiveForTelegramHint истекает все ещё активные коды с тем
+	// же TelegramIDHint — держим не больше одного активного кода на hint.
+	InvalidateActiveForTelegramHint(ctx context.Context, telegramIDHint int64, now time.Time) error
+	// CountRecentByIP — сколько кодов выдано с этого IP с since, для
+	// рейт-лимита IssueVerificationCode.
+	CountRecentByIP(ctx context.Context, ip string, since time.Time) (int64, error)
+	// CountRecentByTelegramHint — аналог CountRecentByIP, но по
+	// TelegramIDHint.
+	CountRecentByTelegramHint(ctx context.Context, telegramIDHint int64, since time.Time) (int64, error)
+	// DeleteExpiredBefore удаляет строки с ExpiresAt < before — фоновый
+	// sweeper (см. scheduler.VerificationSweeper), а не ответственность
+	// IssueVerificationCode/ConsumeVerificationCode.
+	DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// GormVerificationCodeRepository — реализация на GORM.
+type GormVerificationCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewGormVerificationCodeRepository(db *gorm.DB) *GormVerificationCodeRepository {
+	return &GormVerificationCodeRepository{db: db}
+}
+
+func (r *GormVerificationCodeRepository) Create(ctx context.Context, vc *model.VerificationCode) (*model.VerificationCode, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vc.NamespaceID = nsID
+	if err := r.db.WithContext(ctx).Create(vc).Error; err != nil {
+		return nil, errs.Wrap(err, "verification_code")
+	}
+	return vc, nil
+}
+
+func (r *GormVerificationCodeRepository) FindActiveByCode(ctx context.Context, code string, now time.Time) (*model.VerificationCode, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var vc model.VerificationCode
+	err = r.db.WithContext(ctx).
+		Where("namespace_id = ?", nsID).
+		Where("code = ?", code).
+		Where("consumed_at IS NULL").
+		Where("expires_at > ?", now).
+		First(&vc).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "verification_code")
+	}
+	return &vc, nil
+}
+
+func (r *GormVerificationCodeRepository) MarkConsumed(ctx context.Context, id string, at time.Time) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	res := r.db.WithContext(ctx).
+		Model(&model.VerificationCode{}).
+		Where("id = ? AND namespace_id = ? AND consumed_at IS NULL", id, nsID).
+		Update("consumed_at", at)
+	if res.Error != nil {
+		return errs.Wrap(res.Error, "verification_code")
+	}
+	if res.RowsAffected == 0 {
+		return errs.ErrConflict
+	}
+	return nil
+}
+
+func (r *GormVerificationCodeRepository) InvalidateActiveForTelegramHint(ctx context.Context, telegramIDHint int64, now time.Time) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).
+		Model(&model.VerificationCode{}).
+		Where("namespace_id = ? AND telegram_id_hint = ? AND consumed_at IS NULL AND expires_at > ?", nsID, telegramIDHint, now).
+		Update("expires_at", now).Error, "verification_code")
+}
+
+func (r *GormVerificationCodeRepository) CountRecentByIP(ctx context.Context, ip string, since time.Time) (int64, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.VerificationCode{}).
+		Where("namespace_id = ? AND requester_ip = ? AND created_at >= ?", nsID, ip, since).
+		Count(&count).Error; err != nil {
+		return 0, errs.Wrap(err, "verification_code")
+	}
+	return count, nil
+}
+
+func (r *GormVerificationCodeRepository) CountRecentByTelegramHint(ctx context.Context, telegramIDHint int64, since time.Time) (int64, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.VerificationCode{}).
+		Where("namespace_id = ? AND telegram_id_hint = ? AND created_at >= ?", nsID, telegramIDHint, since).
+		Count(&count).Error; err != nil {
+		return 0, errs.Wrap(err, "verification_code")
+	}
+	return count, nil
+}
+
+func (r *GormVerificationCodeRepository) DeleteExpiredBefore(ctx context.Context, before time.Time) (int64, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return 0, err
+	}
+	res := r.db.WithContext(ctx).
+		Where("namespace_id = ? AND expires_at < ?", nsID, before).
+		Delete(&model.VerificationCode{})
+	if res.Error != nil {
+		return 0, errs.Wrap(res.Error, "verification_code")
+	}
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+)
+
+// WaitlistRepository хранит записи листа ожидания (см. model.Waitlist).
+type WaitlistRepository interface {
+	Create(ctx context.Context, w *model.Waitlist) error
+	GetByID(ctx context.Context, id string) (*model.Waitlist, error)
+	// Cancel — мягкая отмена записи (LeaveWaitlist): переводит в WaitlistStatusCancelled.
+	Cancel(ctx context.Context, id string) error
+	// MarkPromoted фиксирует успешное продвижение записи в бронирование bookingID.
+	MarkPromoted(ctx context.Context, id string, bookingID string) error
+	// ListByClient — записи клиента, новые сверху.
+	ListByClient(ctx context.Context, clientID string) ([]model.Waitlist, error)
+	// ListActiveForProvider — активные записи провайдера в порядке priority
+	// DESC, затем FIFO по created_at — именно в этом порядке их рассматривает
+	// промоутер (см. scheduler.WaitlistPromoter) и в этом порядке считается
+	// PositionInQueue.
+	ListActiveForProvider(ctx context.Context, providerID string) ([]model.Waitlist, error)
+	// PositionInQueue — 1-based место записи id среди активных записей того
+	// же провайдера, которые рассматриваются раньше неё.
+	PositionInQueue(ctx context.Context, id string) (int, error)
+	// ExpireStale переводит в WaitlistStatusExpired все активные записи с
+	// ExpiresAt <= now и возвращает их количество.
+	ExpireStale(ctx context.Context, now time.Time) (int64, error)
+	// ListExpiredOffers — записи в WaitlistStatusOffered с OfferExpiresAt <=
+	// now; освобождение удерживаемого слота и перевод записи в
+	// WaitlistStatusExpired выполняется вызывающей стороной в той же
+	// транзакции, что и обновление TimeSlot (см. CalendarService.reclaimExpiredOffers).
+	ListExpiredOffers(ctx context.Context, now time.Time) ([]model.Waitlist, error)
+}
+
+// Реализация на GORM.
+type GormWaitlistRepository struct {
+	db *gorm.DB
+}
+
+func NewGormWaitlistRepository(db *gorm.DB) *GormWaitlistRepository {
+	return &GormWaitlistRepository{db: db}
+}
+
+func (r *GormWaitlistRepository) Create(ctx context.Context, w *model.Waitlist) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	w.NamespaceID = nsID
+	return errs.Wrap(r.db.WithContext(ctx).Create(w).Error, "waitlist")
+}
+
+func (r *GormWaitlistRepository) GetByID(ctx context.Context, id string) (*model.Waitlist, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var w model.Waitlist
+	if err := r.db.WithContext(ctx).First(&w, "id = ? AND namespace_id = ?", id, nsID).Error; err != nil {
+		return nil, errs.Wrap(err, "waitlist")
+	}
+	return &w, nil
+}
+
+func (r *GormWaitlistRepository) Cancel(ctx context.Context, id string) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).
+		Model(&model.Waitlist{}).
+		Where("id = ? AND namespace_id = ?", id, nsID).
+		Update("status", model.WaitlistStatusCancelled).Error, "waitlist")
+}
+
+func (r *GormWaitlistRepository) MarkPromoted(ctx context.Context, id string, bookingID string) error {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return err
+	}
+	return errs.Wrap(r.db.WithContext(ctx).
+		Model(&model.Waitlist{}).
+		Where("id = ? AND namespace_id = ?", id, nsID).
+		Updates(map[string]any{
+			"status":              model.WaitlistStatusPromoted,
+			"promoted_booking_id": bookingID,
+		}).Error, "waitlist")
+}
+
+func (r *GormWaitlistRepository) ListByClient(ctx context.Context, clientID string) ([]model.Waitlist, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var entries []model.Waitlist
+	err = r.db.WithContext(ctx).
+		Where("namespace_id = ? AND client_id = ?", nsID, clientID).
+		Order("created_at DESC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "waitlist")
+	}
+	return entries, nil
+}
+
+func (r *GormWaitlistRepository) ListActiveForProvider(ctx context.Context, providerID string) ([]model.Waitlist, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var entries []model.Waitlist
+	err = r.db.WithContext(ctx).
+		Where("namespace_id = ? AND provider_id = ?", nsID, providerID).
+		Where("status = ?", model.WaitlistStatusActive).
+		Order("priority DESC, created_at ASC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "waitlist")
+	}
+	return entries, nil
+}
+
+func (r *GormWaitlistRepository) PositionInQueue(ctx context.Context, id string) (int, error) {
+	entry, err := r.GetByID(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var ahead int64
+	err = r.db.WithContext(ctx).
+		Model(&model.Waitlist{}).
+		Where("namespace_id = ? AND provider_id = ?", nsID, entry.ProviderID).
+		Where("status = ?", model.WaitlistStatusActive).
+		Where("priority > ? OR (priority = ? AND created_at < ?)", entry.Priority, entry.Priority, entry.CreatedAt).
+		Count(&ahead).Error
+	if err != nil {
+		return 0, errs.Wrap(err, "waitlist")
+	}
+	return int(ahead) + 1, nil
+}
+
+func (r *GormWaitlistRepository) ExpireStale(ctx context.Context, now time.Time) (int64, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return 0, err
+	}
+	res := r.db.WithContext(ctx).
+		Model(&model.Waitlist{}).
+		Where("namespace_id = ?", nsID).
+		Where("status = ?", model.WaitlistStatusActive).
+		Where("expires_at IS NOT NULL AND expires_at <= ?", now).
+		Update("status", model.WaitlistStatusExpired)
+	if res.Error != nil {
+		return 0, errs.Wrap(res.Error, "waitlist")
+	}
+	return res.RowsAffected, nil
+}
+
+func (r *GormWaitlistRepository) ListExpiredOffers(ctx context.Context, now time.Time) ([]model.Waitlist, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var entries []model.Waitlist
+	err = r.db.WithContext(ctx).
+		Where("namespace_id = ?", nsID).
+		Where("status = ?", model.WaitlistStatusOffered).
+		Where("offer_expires_at IS NOT NULL AND offer_expires_at <= ?", now).
+		Find(&entries).Error
+	if err != nil {
+		return nil, errs.Wrap(err, "waitlist")
+	}
+	return entries, nil
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// IdentityEventSweeperConfig — параметры фонового сборщика мусора
+// identity_events.
+type IdentityEventSweeperConfig struct {
+	// RunEvery — период между прогонами.
+	RunEvery time.Duration
+	// Retention — как долго хранить событие после его создания, прежде чем
+	// удалить (ограничивает окно replay по cursor "since" в
+	// IdentityService.SubscribeUserEvents).
+	Retention time.Duration
+}
+
+func (c IdentityEventSweeperConfig) withDefaults() IdentityEventSweeperConfig {
+	if c.RunEvery <= 0 {
+		c.RunEvery = 15 * time.Minute
+	}
+	if c.Retention <= 0 {
+		c.Retention = 7 * 24 * time.Hour
+	}
+	return c
+}
+
+// IdentityEventSweeper удаляет строки model.IdentityEvent старше Retention —
+// та же схема обхода арендаторов, что у VerificationSweeper/WaitlistPromoter.
+type IdentityEventSweeper struct {
+	db                *gorm.DB
+	identityEventRepo repository.IdentityEventRepository
+	cfg               IdentityEventSweeperConfig
+	logger            *log.Logger
+}
+
+func NewIdentityEventSweeper(db *gorm.DB, identityEventRepo repository.IdentityEventRepository, cfg IdentityEventSweeperConfig) *IdentityEventSweeper {
+	return &IdentityEventSweeper{
+		db:                db,
+		identityEventRepo: identityEventRepo,
+		cfg:               cfg.withDefaults(),
+		logger:            log.Default(),
+	}
+}
+
+// Run блокирует вызывающего и по тикеру вызывает RunOnce, пока не отменят ctx.
+func (sw *IdentityEventSweeper) Run(ctx context.Context) {
+	sw.runAndLog(ctx)
+
+	ticker := time.NewTicker(sw.cfg.RunEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sw.runAndLog(ctx)
+		}
+	}
+}
+
+func (sw *IdentityEventSweeper) runAndLog(ctx context.Context) {
+	if err := sw.RunOnce(ctx); err != nil {
+		sw.logger.Printf("[ERROR] scheduler.IdentityEventSweeper.RunOnce: %v", err)
+	}
+}
+
+// RunOnce проходит по всем арендаторам и удаляет строки старше Retention.
+func (sw *IdentityEventSweeper) RunOnce(ctx context.Context) error {
+	var nsIDs []uuid.UUID
+	if err := sw.db.WithContext(ctx).Model(&model.Namespace{}).Pluck("id", &nsIDs).Error; err != nil {
+		return fmt.Errorf("list namespaces: %w", err)
+	}
+
+	before := time.Now().UTC().Add(-sw.cfg.Retention)
+	for _, nsID := range nsIDs {
+		nsCtx := namespace.WithNamespace(ctx, nsID)
+		deleted, err := sw.identityEventRepo.DeleteOlderThan(nsCtx, before)
+		if err != nil {
+			return fmt.Errorf("sweep identity events for namespace %s: %w", nsID, err)
+		}
+		if deleted > 0 {
+			sw.logger.Printf("[INFO] scheduler.IdentityEventSweeper: namespace=%s deleted=%d", nsID, deleted)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+	"github.com/Leganyst/appointment-platform/internal/service"
+	calendarutils "github.com/Leganyst/appointment-platform/internal/utils"
+)
+
+// MaintenanceSchedulerConfig — параметры фонового воркера планового
+// обслуживания.
+type MaintenanceSchedulerConfig struct {
+	// RunEvery — период между прогонами.
+	RunEvery time.Duration
+}
+
+func (c MaintenanceSchedulerConfig) withDefaults() MaintenanceSchedulerConfig {
+	if c.RunEvery <= 0 {
+		c.RunEvery = time.Minute
+	}
+	return c
+}
+
+// MaintenanceScheduler по тикеру смотрит, какие MaintenanceWindow (в т.ч.
+// повторяющиеся по RRule) провайдеров сейчас вошли в активную фазу, и на
+// вход вызывает CalendarService.CancelSlotsForMaintenanceWindow — тот же
+// разделяемый помощник, который использует админский RPC
+// CalendarService.BulkCancelProviderSlots. Определения окон перечитываются из
+// БД на каждом прогоне, поэтому CRUD над MaintenanceWindow (см.
+// CalendarService.CreateMaintenanceWindow/UpdateMaintenanceWindow/
+// DeleteMaintenanceWindow) подхватывается без отдельного сигнала. Прогресс
+// срабатываний хранится в model.MaintenanceTriggerState, ключуется по
+// фактическому началу вхождения окна: повторный прогон с тем же вхождением —
+// no-op, а следующее вхождение (другой Start) естественным образом
+// "перевзводит" срабатывание без явной логики выхода из окна.
+type MaintenanceScheduler struct {
+	db               *gorm.DB
+	maintenanceRepo  repository.MaintenanceRepository
+	triggerStateRepo repository.MaintenanceTriggerStateRepository
+	calendarSvc      *service.CalendarService
+	cfg              MaintenanceSchedulerConfig
+	logger           *log.Logger
+}
+
+func NewMaintenanceScheduler(
+	db *gorm.DB,
+	maintenanceRepo repository.MaintenanceRepository,
+	triggerStateRepo repository.MaintenanceTriggerStateRepository,
+	calendarSvc *service.CalendarService,
+	cfg MaintenanceSchedulerConfig,
+) *MaintenanceScheduler {
+	return &MaintenanceScheduler{
+		db:               db,
+		maintenanceRepo:  maintenanceRepo,
+		triggerStateRepo: triggerStateRepo,
+		calendarSvc:      calendarSvc,
+		cfg:              cfg.withDefaults(),
+		logger:           log.Default(),
+	}
+}
+
+// Run блокирует вызывающего и по тикеру вызывает RunOnce, пока не отменят ctx.
+// Первый прогон — сразу при старте, до первого тика: это и есть "replay"
+// активных окон из БД после рестарта процесса.
+func (m *MaintenanceScheduler) Run(ctx context.Context) {
+	m.runAndLog(ctx)
+
+	ticker := time.NewTicker(m.cfg.RunEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.runAndLog(ctx)
+		}
+	}
+}
+
+func (m *MaintenanceScheduler) runAndLog(ctx context.Context) {
+	if err := m.RunOnce(ctx); err != nil {
+		m.logger.Printf("[ERROR] scheduler.MaintenanceScheduler.RunOnce: %v", err)
+	}
+}
+
+// RunOnce проходит по всем арендаторам (тот же обход, что у
+// SlotMaterializer.RunOnce/WaitlistPromoter.RunOnce) и для каждого активного
+// окна обслуживания проверяет, не вошло ли оно только что в очередное
+// вхождение.
+func (m *MaintenanceScheduler) RunOnce(ctx context.Context) error {
+	var nsIDs []uuid.UUID
+	if err := m.db.WithContext(ctx).Model(&model.Namespace{}).Pluck("id", &nsIDs).Error; err != nil {
+		return fmt.Errorf("list namespaces: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, nsID := range nsIDs {
+		nsCtx := namespace.WithNamespace(ctx, nsID)
+
+		windows, err := m.maintenanceRepo.ListAllActiveForNamespace(nsCtx)
+		if err != nil {
+			return fmt.Errorf("list active maintenance windows for namespace %s: %w", nsID, err)
+		}
+
+		for i := range windows {
+			if err := m.evaluateWindow(nsCtx, &windows[i], now); err != nil {
+				m.logger.Printf("[ERROR] scheduler.MaintenanceScheduler: evaluate window=%s: %v", windows[i].ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// evaluateWindow — единичная проверка одного окна на момент now.
+func (m *MaintenanceScheduler) evaluateWindow(ctx context.Context, w *model.MaintenanceWindow, now time.Time) error {
+	rrule := ""
+	if w.RRule != nil {
+		rrule = *w.RRule
+	}
+
+	occurrences, err := calendarutils.ExpandRecurringWindow(w.StartsAt, w.EndsAt, rrule, now, now.Add(time.Nanosecond))
+	if err != nil {
+		return fmt.Errorf("expand window: %w", err)
+	}
+	if len(occurrences) == 0 {
+		if rrule == "" && now.After(w.EndsAt) {
+			w.Status = model.MaintenanceWindowStatusExpired
+			if err := m.maintenanceRepo.Update(ctx, w); err != nil {
+				return fmt.Errorf("expire one-shot window: %w", err)
+			}
+		}
+		return nil
+	}
+
+	// Разовое и RRULE-окно раскрываются в одно вхождение на запрос длиной в
+	// наносекунду — достаточно первого.
+	occ := occurrences[0]
+
+	state, err := m.triggerStateRepo.Get(ctx, w.ID)
+	if err != nil && !errors.Is(err, errs.ErrNotFound("")) {
+		return fmt.Errorf("get trigger state: %w", err)
+	}
+	if state != nil && state.TriggeredOccurrenceStart.Equal(occ.Start) {
+		return nil
+	}
+
+	affected, cancelledSlots, cancelledBookings, err := m.calendarSvc.CancelSlotsForMaintenanceWindow(ctx, w.ProviderID, occ.Start, occ.End, w.Reason)
+	if err != nil {
+		return fmt.Errorf("cancel slots for maintenance window: %w", err)
+	}
+
+	if err := m.triggerStateRepo.Upsert(ctx, &model.MaintenanceTriggerState{
+		WindowID:                 w.ID,
+		TriggeredOccurrenceStart: occ.Start,
+	}); err != nil {
+		return fmt.Errorf("save trigger state: %w", err)
+	}
+
+	m.logger.Printf(
+		"[INFO] scheduler.MaintenanceScheduler: window=%s provider=%s entered occurrence start=%s cancelled_slots=%d cancelled_bookings=%d affected_bookings=%d",
+		w.ID, w.ProviderID, occ.Start, cancelledSlots, cancelledBookings, len(affected),
+	)
+	return nil
+}
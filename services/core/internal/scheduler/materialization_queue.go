@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+)
+
+// rebuildJob — одна отложенная перестройка в очереди MaterializationQueue.
+// ScheduleID, если не nil, сужает перестройку до одного расписания (см.
+// SlotMaterializer.RebuildSchedule); иначе обрабатывается весь провайдер
+// (SlotMaterializer.Rebuild).
+type rebuildJob struct {
+	NamespaceID uuid.UUID
+	ProviderID  uuid.UUID
+	ScheduleID  *uuid.UUID
+}
+
+// MaterializationQueue — внутрипроцессная очередь перестроек календаря с
+// сериализацией по провайдеру: на провайдера в очереди лежит не больше
+// одного отложенного задания, так что параллельные ListFreeSlots (см.
+// service.CalendarService.ListFreeSlots) не устраивают stampede одновременных
+// Rebuild одного и того же провайдера. Задания разбирают воркеры Start(ctx);
+// сам Rebuild для конкретного провайдера остаётся сериализован ещё и
+// advisory-блокировкой (см. advisoryLockSupported) на случай нескольких
+// инстансов сервиса.
+type MaterializationQueue struct {
+	materializer *SlotMaterializer
+	workers      int
+	logger       *log.Logger
+
+	jobs chan rebuildJob
+	// pending отслеживает провайдеров с уже поставленным, но ещё не
+	// обработанным заданием — повторный Enqueue для того же провайдера,
+	// пока предыдущее задание не разобрано, схлопывается в него.
+	pending chan map[uuid.UUID]struct{}
+}
+
+func NewMaterializationQueue(materializer *SlotMaterializer, workers int) *MaterializationQueue {
+	if workers <= 0 {
+		workers = 4
+	}
+	q := &MaterializationQueue{
+		materializer: materializer,
+		workers:      workers,
+		logger:       log.Default(),
+		jobs:         make(chan rebuildJob, 1024),
+		pending:      make(chan map[uuid.UUID]struct{}, 1),
+	}
+	q.pending <- make(map[uuid.UUID]struct{})
+	return q
+}
+
+// Start запускает воркеров, разбирающих очередь, пока не отменят ctx.
+func (q *MaterializationQueue) Start(ctx context.Context) {
+	for i := 0; i < q.workers; i++ {
+		go q.worker(ctx)
+	}
+}
+
+func (q *MaterializationQueue) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.jobs:
+			q.process(ctx, job)
+		}
+	}
+}
+
+func (q *MaterializationQueue) process(ctx context.Context, job rebuildJob) {
+	q.clearPending(job.ProviderID)
+
+	nsCtx := namespace.WithNamespace(ctx, job.NamespaceID)
+	var err error
+	if job.ScheduleID != nil {
+		_, err = q.materializer.RebuildSchedule(nsCtx, job.ProviderID, *job.ScheduleID)
+	} else {
+		_, err = q.materializer.Rebuild(nsCtx, job.ProviderID)
+	}
+	if err != nil {
+		q.logger.Printf("[ERROR] scheduler.MaterializationQueue: rebuild provider=%s: %v", job.ProviderID, err)
+	}
+}
+
+// Enqueue — неблокирующая постановка полной перестройки провайдера в очередь.
+// Best-effort: если в очереди уже есть необработанное задание для этого
+// провайдера, повторный вызов — no-op; если очередь переполнена, запрос
+// просто теряется — ближайший периодический SlotMaterializer.RunOnce всё
+// равно досчитает горизонт.
+func (q *MaterializationQueue) Enqueue(namespaceID, providerID uuid.UUID) {
+	q.enqueue(rebuildJob{NamespaceID: namespaceID, ProviderID: providerID})
+}
+
+// EnqueueSchedule — то же самое, что Enqueue, но для точечной перестройки
+// одного расписания (см. SlotMaterializer.RebuildSchedule) — вызывается
+// сразу после правки правила расписания.
+func (q *MaterializationQueue) EnqueueSchedule(namespaceID, providerID, scheduleID uuid.UUID) {
+	q.enqueue(rebuildJob{NamespaceID: namespaceID, ProviderID: providerID, ScheduleID: &scheduleID})
+}
+
+func (q *MaterializationQueue) enqueue(job rebuildJob) {
+	pending := <-q.pending
+	if _, ok := pending[job.ProviderID]; ok {
+		q.pending <- pending
+		return
+	}
+	pending[job.ProviderID] = struct{}{}
+	q.pending <- pending
+
+	select {
+	case q.jobs <- job:
+	default:
+		q.clearPending(job.ProviderID)
+	}
+}
+
+func (q *MaterializationQueue) clearPending(providerID uuid.UUID) {
+	pending := <-q.pending
+	delete(pending, providerID)
+	q.pending <- pending
+}
+
+// RebuildNow форсирует синхронную перестройку провайдера на произвольном
+// окне в обход очереди — используется админским RPC
+// CalendarService.RebuildProviderCalendar, которому нужен результат сразу, а
+// не best-effort постановка в очередь.
+func (q *MaterializationQueue) RebuildNow(ctx context.Context, namespaceID, providerID uuid.UUID, from, to time.Time) (RunSummary, error) {
+	nsCtx := namespace.WithNamespace(ctx, namespaceID)
+	return q.materializer.RebuildRange(nsCtx, providerID, from, to)
+}
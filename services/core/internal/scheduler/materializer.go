@@ -0,0 +1,655 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+	"github.com/Leganyst/appointment-platform/internal/service"
+	calendarutils "github.com/Leganyst/appointment-platform/internal/utils"
+)
+
+// advisoryLockSupported сообщает, умеет ли текущий диалект (см. model.Dialect)
+// pg_advisory_xact_lock. На MySQL/SQLite блокировки между параллельными
+// воркерами не будет — материализация там рассчитана на единственный
+// инстанс воркера (небольшие self-hosted развёртывания).
+func advisoryLockSupported() bool {
+	return model.ActiveDialect() == model.DialectPostgres
+}
+
+// Config — параметры фонового воркера материализации слотов.
+type Config struct {
+	// Horizon — насколько далеко в будущее от текущего момента материализуются слоты.
+	Horizon time.Duration
+	// RunEvery — период между прогонами воркера.
+	RunEvery time.Duration
+	// BatchSize — размер страницы при постраничной выборке расписаний.
+	BatchSize int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Horizon <= 0 {
+		c.Horizon = 30 * 24 * time.Hour
+	}
+	if c.RunEvery <= 0 {
+		c.RunEvery = time.Hour
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	return c
+}
+
+// RunSummary — сводка одного прогона материализации по провайдеру.
+type RunSummary struct {
+	ProviderID uuid.UUID
+	Created    int
+	Updated    int
+	Deleted    int
+}
+
+// SlotMaterializer периодически разворачивает активные расписания провайдеров
+// в конкретные TimeSlot на скользящем горизонте [now, now+Horizon), чтобы путь
+// бронирования читал уже материализованные строки вместо пересчёта правил на
+// каждый запрос. Расписания, чьё правило не менялось с прошлого прогона (см.
+// Schedule.ScheduleVersion и model.ScheduleMaterializationState), повторно не
+// разворачиваются — это и есть "targeted re-materialization" из ТЗ.
+type SlotMaterializer struct {
+	db                 *gorm.DB
+	scheduleRepo       repository.ScheduleRepository
+	maintenanceRepo    repository.MaintenanceRepository
+	unavailabilityRepo repository.UnavailabilityRepository
+	blackoutRepo       repository.BlackoutRepository
+	stateRepo          repository.ScheduleMaterializationStateRepository
+	cfg                Config
+
+	logger *log.Logger
+}
+
+func NewSlotMaterializer(
+	db *gorm.DB,
+	scheduleRepo repository.ScheduleRepository,
+	maintenanceRepo repository.MaintenanceRepository,
+	unavailabilityRepo repository.UnavailabilityRepository,
+	blackoutRepo repository.BlackoutRepository,
+	stateRepo repository.ScheduleMaterializationStateRepository,
+	cfg Config,
+) *SlotMaterializer {
+	return &SlotMaterializer{
+		db:                 db,
+		scheduleRepo:       scheduleRepo,
+		maintenanceRepo:    maintenanceRepo,
+		unavailabilityRepo: unavailabilityRepo,
+		blackoutRepo:       blackoutRepo,
+		stateRepo:          stateRepo,
+		cfg:                cfg.withDefaults(),
+		logger:             log.Default(),
+	}
+}
+
+// maintenanceRangesFor возвращает развёрнутые интервалы активных окон
+// обслуживания провайдера в [from, to) — см.
+// service.CalendarService.activeMaintenanceRanges для того же расчёта на
+// стороне запроса (ListFreeSlots/CreateBooking).
+func (m *SlotMaterializer) maintenanceRangesFor(
+	ctx context.Context,
+	providerID uuid.UUID,
+	from, to time.Time,
+) ([]calendarutils.TimeRange, error) {
+	if m.maintenanceRepo == nil {
+		return nil, nil
+	}
+	windows, err := m.maintenanceRepo.ListActiveForProvider(ctx, providerID.String(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	var ranges []calendarutils.TimeRange
+	for _, w := range windows {
+		rrule := ""
+		if w.RRule != nil {
+			rrule = *w.RRule
+		}
+		occ, err := calendarutils.ExpandRecurringWindow(w.StartsAt, w.EndsAt, rrule, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("expand maintenance window %s: %w", w.ID, err)
+		}
+		ranges = append(ranges, occ...)
+	}
+	return ranges, nil
+}
+
+// unavailabilityRangesFor возвращает периоды недоступности провайдера в
+// [from, to) — см. service.CalendarService.activeUnavailabilityRanges для
+// того же расчёта на стороне запроса (CreateBooking/CheckAvailability).
+// Периоды, в отличие от окон обслуживания, разовые (без RRULE), поэтому
+// развёртка не требуется.
+func (m *SlotMaterializer) unavailabilityRangesFor(
+	ctx context.Context,
+	providerID uuid.UUID,
+	from, to time.Time,
+) ([]calendarutils.TimeRange, error) {
+	if m.unavailabilityRepo == nil {
+		return nil, nil
+	}
+	periods, err := m.unavailabilityRepo.ListForProvider(ctx, providerID.String(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]calendarutils.TimeRange, 0, len(periods))
+	for _, p := range periods {
+		ranges = append(ranges, calendarutils.TimeRange{Start: p.StartsAt.UTC(), End: p.EndsAt.UTC()})
+	}
+	return ranges, nil
+}
+
+// blackoutRangesFor возвращает интервалы blackout-ов, покрывающих конкретную
+// услугу расписания (и общие для всех услуг, serviceID == nil), в [from,
+// to) — в отличие от maintenance/unavailability периодов, привязанных к
+// провайдеру целиком, blackout масштабируется на услугу (см.
+// model.Blackout, repository.BlackoutRepository.ListForService).
+func (m *SlotMaterializer) blackoutRangesFor(
+	ctx context.Context,
+	serviceID *uuid.UUID,
+	from, to time.Time,
+) ([]calendarutils.TimeRange, error) {
+	if m.blackoutRepo == nil {
+		return nil, nil
+	}
+	sid := ""
+	if serviceID != nil {
+		sid = serviceID.String()
+	}
+	rows, err := m.blackoutRepo.ListForService(ctx, sid, from, to)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]calendarutils.TimeRange, 0, len(rows))
+	for _, b := range rows {
+		ranges = append(ranges, calendarutils.TimeRange{Start: b.StartsAt.UTC(), End: b.EndsAt.UTC()})
+	}
+	return ranges, nil
+}
+
+// Run блокирует вызывающего и по тикеру вызывает RunOnce, пока не отменят ctx.
+// Первый прогон выполняется сразу, не дожидаясь первого тика.
+func (m *SlotMaterializer) Run(ctx context.Context) {
+	m.runAndLog(ctx)
+
+	ticker := time.NewTicker(m.cfg.RunEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.runAndLog(ctx)
+		}
+	}
+}
+
+func (m *SlotMaterializer) runAndLog(ctx context.Context) {
+	summaries, err := m.RunOnce(ctx)
+	if err != nil {
+		m.logger.Printf("[ERROR] SlotMaterializer.RunOnce: %v", err)
+		return
+	}
+	for _, s := range summaries {
+		m.logger.Printf(
+			"[INFO] SlotMaterializer: provider=%s created=%d updated=%d deleted=%d",
+			s.ProviderID, s.Created, s.Updated, s.Deleted,
+		)
+	}
+}
+
+// RunOnce проходит по всем арендаторам (namespaces) и в рамках каждого
+// постранично собирает провайдеров с хотя бы одним расписанием, перестраивая
+// их слоты на горизонте воркера. Материализация — фоновый процесс без
+// входящего запроса, поэтому арендатор для каждого провайдера резолвится
+// здесь же, а не передаётся вызывающим кодом (см. internal/namespace).
+func (m *SlotMaterializer) RunOnce(ctx context.Context) ([]RunSummary, error) {
+	nsIDs, err := m.listNamespaceIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list namespaces: %w", err)
+	}
+
+	var summaries []RunSummary
+	for _, nsID := range nsIDs {
+		nsCtx := namespace.WithNamespace(ctx, nsID)
+
+		providerIDs, err := m.listActiveProviderIDs(nsCtx, nsID)
+		if err != nil {
+			return summaries, fmt.Errorf("list active providers for namespace %s: %w", nsID, err)
+		}
+
+		for _, providerID := range providerIDs {
+			summary, err := m.Rebuild(nsCtx, providerID)
+			if err != nil {
+				return summaries, fmt.Errorf("rebuild provider %s: %w", providerID, err)
+			}
+			summaries = append(summaries, summary)
+		}
+	}
+	return summaries, nil
+}
+
+// listNamespaceIDs вычитывает все арендаторы. Таблица namespaces не участвует
+// в row-level security (это и есть сам тенант), поэтому запрос не требует
+// арендатора в контексте.
+func (m *SlotMaterializer) listNamespaceIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := m.db.WithContext(ctx).Model(&model.Namespace{}).Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// listActiveProviderIDs постранично вычитывает schedules арендатора nsID и
+// возвращает уникальные provider_id (страница размером BatchSize).
+func (m *SlotMaterializer) listActiveProviderIDs(ctx context.Context, nsID uuid.UUID) ([]uuid.UUID, error) {
+	seen := make(map[uuid.UUID]struct{})
+	var ids []uuid.UUID
+
+	offset := 0
+	for {
+		var page []model.Schedule
+		err := m.db.WithContext(ctx).
+			Model(&model.Schedule{}).
+			Select("id", "provider_id").
+			Where("namespace_id = ?", nsID).
+			Order("provider_id ASC").
+			Limit(m.cfg.BatchSize).
+			Offset(offset).
+			Find(&page).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, sched := range page {
+			if _, ok := seen[sched.ProviderID]; !ok {
+				seen[sched.ProviderID] = struct{}{}
+				ids = append(ids, sched.ProviderID)
+			}
+		}
+
+		if len(page) < m.cfg.BatchSize {
+			break
+		}
+		offset += m.cfg.BatchSize
+	}
+
+	return ids, nil
+}
+
+// wantedSlot — слот, который должен существовать согласно текущим правилам
+// провайдера, с указанием породившего его расписания.
+type wantedSlot struct {
+	Start, End time.Time
+	ScheduleID uuid.UUID
+}
+
+// Rebuild пересобирает слоты одного провайдера на горизонте
+// [now, now+Horizon). Эквивалентно RebuildRange(ctx, providerID, now, now+Horizon).
+func (m *SlotMaterializer) Rebuild(ctx context.Context, providerID uuid.UUID) (RunSummary, error) {
+	now := time.Now().UTC()
+	return m.RebuildRange(ctx, providerID, now, now.Add(m.cfg.Horizon))
+}
+
+// RebuildRange — то же самое, что Rebuild, но с произвольным окном, а не
+// скользящим горизонтом воркера. Используется админским RPC
+// CalendarService.RebuildProviderCalendar, которому нужна принудительная
+// перестройка конкретного диапазона.
+//
+// Расписание пересобирается, только если его ScheduleVersion разошлась с
+// запомненной в model.ScheduleMaterializationState, или если горизонт ушёл
+// дальше MaterializedUntil — иначе существующие слоты считаются актуальными
+// и повторно не разворачиваются (targeted re-materialization из ТЗ). Окна
+// обслуживания при этом пересчитываются всегда — это отдельная от правила
+// расписания причина смены статуса planned/blocked.
+func (m *SlotMaterializer) RebuildRange(ctx context.Context, providerID uuid.UUID, from, to time.Time) (RunSummary, error) {
+	summary := RunSummary{ProviderID: providerID}
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return summary, err
+	}
+	if !to.After(from) {
+		return summary, nil
+	}
+
+	schedules, err := m.scheduleRepo.ListByProvider(ctx, providerID.String())
+	if err != nil {
+		return summary, fmt.Errorf("list schedules: %w", err)
+	}
+
+	maintenanceRanges, err := m.maintenanceRangesFor(ctx, providerID, from, to)
+	if err != nil {
+		return summary, fmt.Errorf("list maintenance windows: %w", err)
+	}
+	unavailabilityRanges, err := m.unavailabilityRangesFor(ctx, providerID, from, to)
+	if err != nil {
+		return summary, fmt.Errorf("list unavailability periods: %w", err)
+	}
+	blackoutRangesByService := make(map[uuid.UUID][]calendarutils.TimeRange)
+	statusFor := func(start, end time.Time, serviceID *uuid.UUID) (model.TimeSlotStatus, error) {
+		r := calendarutils.TimeRange{Start: start, End: end}
+		if has, _ := calendarutils.HasOverlap(r, maintenanceRanges, false); has {
+			return model.TimeSlotStatusBlocked, nil
+		}
+		if has, _ := calendarutils.HasOverlap(r, unavailabilityRanges, false); has {
+			return model.TimeSlotStatusUnavailable, nil
+		}
+		key := uuid.Nil
+		if serviceID != nil {
+			key = *serviceID
+		}
+		blackoutRanges, ok := blackoutRangesByService[key]
+		if !ok {
+			var err error
+			blackoutRanges, err = m.blackoutRangesFor(ctx, serviceID, from, to)
+			if err != nil {
+				return "", fmt.Errorf("list blackouts: %w", err)
+			}
+			blackoutRangesByService[key] = blackoutRanges
+		}
+		if has, _ := calendarutils.HasOverlap(r, blackoutRanges, false); has {
+			return model.TimeSlotStatusUnavailable, nil
+		}
+		return model.TimeSlotStatusPlanned, nil
+	}
+
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Провайдерская advisory-блокировка на время транзакции: два воркера
+		// не могут материализовать одного и того же провайдера параллельно.
+		// Доступно только на Postgres (см. advisoryLockSupported).
+		if advisoryLockSupported() {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", providerID.String()).Error; err != nil {
+				return fmt.Errorf("acquire advisory lock: %w", err)
+			}
+		}
+
+		for i := range schedules {
+			sched := schedules[i]
+			stale, err := m.isStale(ctx, &sched, to)
+			if err != nil {
+				return fmt.Errorf("check materialization state %s: %w", sched.ID, err)
+			}
+			if !stale {
+				continue
+			}
+			if err := m.rebuildScheduleTx(ctx, tx, nsID, providerID, &sched, from, to, statusFor, &summary); err != nil {
+				return fmt.Errorf("rebuild schedule %s: %w", sched.ID, err)
+			}
+			if err := m.stateRepo.Upsert(ctx, &model.ScheduleMaterializationState{
+				ScheduleID:          sched.ID,
+				NamespaceID:         nsID,
+				ProviderID:          providerID,
+				MaterializedVersion: sched.ScheduleVersion,
+				MaterializedUntil:   to,
+			}); err != nil {
+				return fmt.Errorf("store materialization state %s: %w", sched.ID, err)
+			}
+		}
+
+		return m.resyncSlotStatusTx(tx, nsID, providerID, from, to, statusFor)
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	return summary, nil
+}
+
+// RebuildSchedule принудительно пересобирает ровно одно расписание на
+// скользящем горизонте воркера, не дожидаясь очередного прогона RunOnce —
+// вызывается MaterializationQueue сразу после правки правила расписания
+// (см. repository.ScheduleRepository.Update, бампающий ScheduleVersion).
+func (m *SlotMaterializer) RebuildSchedule(ctx context.Context, providerID, scheduleID uuid.UUID) (RunSummary, error) {
+	summary := RunSummary{ProviderID: providerID}
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return summary, err
+	}
+	sched, err := m.scheduleRepo.GetByID(ctx, scheduleID.String())
+	if err != nil {
+		return summary, fmt.Errorf("get schedule %s: %w", scheduleID, err)
+	}
+
+	now := time.Now().UTC()
+	windowEnd := now.Add(m.cfg.Horizon)
+	if !windowEnd.After(now) {
+		return summary, nil
+	}
+
+	maintenanceRanges, err := m.maintenanceRangesFor(ctx, providerID, now, windowEnd)
+	if err != nil {
+		return summary, fmt.Errorf("list maintenance windows: %w", err)
+	}
+	unavailabilityRanges, err := m.unavailabilityRangesFor(ctx, providerID, now, windowEnd)
+	if err != nil {
+		return summary, fmt.Errorf("list unavailability periods: %w", err)
+	}
+	blackoutRanges, err := m.blackoutRangesFor(ctx, sched.ServiceID, now, windowEnd)
+	if err != nil {
+		return summary, fmt.Errorf("list blackouts: %w", err)
+	}
+	statusFor := func(start, end time.Time, serviceID *uuid.UUID) (model.TimeSlotStatus, error) {
+		r := calendarutils.TimeRange{Start: start, End: end}
+		if has, _ := calendarutils.HasOverlap(r, maintenanceRanges, false); has {
+			return model.TimeSlotStatusBlocked, nil
+		}
+		if has, _ := calendarutils.HasOverlap(r, unavailabilityRanges, false); has {
+			return model.TimeSlotStatusUnavailable, nil
+		}
+		if has, _ := calendarutils.HasOverlap(r, blackoutRanges, false); has {
+			return model.TimeSlotStatusUnavailable, nil
+		}
+		return model.TimeSlotStatusPlanned, nil
+	}
+
+	err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if advisoryLockSupported() {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", providerID.String()).Error; err != nil {
+				return fmt.Errorf("acquire advisory lock: %w", err)
+			}
+		}
+		if err := m.rebuildScheduleTx(ctx, tx, nsID, providerID, sched, now, windowEnd, statusFor, &summary); err != nil {
+			return err
+		}
+		return m.stateRepo.Upsert(ctx, &model.ScheduleMaterializationState{
+			ScheduleID:          sched.ID,
+			NamespaceID:         nsID,
+			ProviderID:          providerID,
+			MaterializedVersion: sched.ScheduleVersion,
+			MaterializedUntil:   windowEnd,
+		})
+	})
+	if err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+// isStale решает, нужно ли разворачивать расписание sched заново: либо его
+// ScheduleVersion ещё ни разу не материализовывался, либо она разошлась с
+// сохранённой, либо горизонт ушёл дальше ранее материализованного момента.
+func (m *SlotMaterializer) isStale(ctx context.Context, sched *model.Schedule, windowEnd time.Time) (bool, error) {
+	state, err := m.stateRepo.Get(ctx, sched.ID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound("")) {
+			return true, nil
+		}
+		return false, err
+	}
+	if state.MaterializedVersion != sched.ScheduleVersion {
+		return true, nil
+	}
+	if windowEnd.After(state.MaterializedUntil) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// rebuildScheduleTx разворачивает одно расписание в [from, to) и сверяет
+// результат ТОЛЬКО с существующими слотами этого же расписания (schedule_id),
+// создавая недостающие и мягко отменяя (Cancelled) осиротевшие
+// незабронированные. Слоты других расписаний провайдера не затрагиваются —
+// в этом и состоит целевая (targeted) перестройка в отличие от полного
+// прохода по всему провайдеру.
+func (m *SlotMaterializer) rebuildScheduleTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	nsID, providerID uuid.UUID,
+	sched *model.Schedule,
+	from, to time.Time,
+	statusFor func(start, end time.Time, serviceID *uuid.UUID) (model.TimeSlotStatus, error),
+	summary *RunSummary,
+) error {
+	occurrences, err := service.ExpandScheduleModelInWindowUTC(sched, from, to)
+	if err != nil {
+		return fmt.Errorf("expand schedule: %w", err)
+	}
+
+	wanted := make(map[int64]wantedSlot, len(occurrences))
+	for _, occ := range occurrences {
+		start := occ.Start.UTC()
+		wanted[start.UnixNano()] = wantedSlot{Start: start, End: occ.End.UTC(), ScheduleID: sched.ID}
+	}
+
+	var existing []model.TimeSlot
+	if err := tx.
+		Where("namespace_id = ? AND provider_id = ? AND schedule_id = ?", nsID, providerID, sched.ID).
+		Where("starts_at >= ? AND starts_at < ?", from, to).
+		Find(&existing).Error; err != nil {
+		return fmt.Errorf("list existing slots: %w", err)
+	}
+
+	existingByStart := make(map[int64]model.TimeSlot, len(existing))
+	for _, sl := range existing {
+		existingByStart[sl.StartsAt.UTC().UnixNano()] = sl
+	}
+
+	var toCreate []model.TimeSlot
+	var touchIDs []uuid.UUID
+	for key, w := range wanted {
+		if sl, ok := existingByStart[key]; ok {
+			touchIDs = append(touchIDs, sl.ID)
+			continue
+		}
+		status, err := statusFor(w.Start, w.End, sched.ServiceID)
+		if err != nil {
+			return err
+		}
+		schedID := w.ScheduleID
+		toCreate = append(toCreate, model.TimeSlot{
+			NamespaceID: nsID,
+			ScheduleID:  &schedID,
+			ProviderID:  providerID,
+			ServiceID:   sched.ServiceID,
+			StaffID:     sched.StaffID,
+			ResourceID:  sched.ResourceID,
+			Capacity:    sched.Capacity,
+			StartsAt:    w.Start,
+			EndsAt:      w.End,
+			Status:      status,
+		})
+	}
+
+	if len(toCreate) > 0 {
+		sort.Slice(toCreate, func(i, j int) bool { return toCreate[i].StartsAt.Before(toCreate[j].StartsAt) })
+		if err := tx.Create(&toCreate).Error; err != nil {
+			return fmt.Errorf("create slots: %w", err)
+		}
+		summary.Created += len(toCreate)
+	}
+
+	if len(touchIDs) > 0 {
+		res := tx.Model(&model.TimeSlot{}).
+			Where("id IN ?", touchIDs).
+			Update("updated_at", time.Now().UTC())
+		if res.Error != nil {
+			return fmt.Errorf("touch slots: %w", res.Error)
+		}
+		summary.Updated += int(res.RowsAffected)
+	}
+
+	var orphanIDs []uuid.UUID
+	for key, sl := range existingByStart {
+		if _, ok := wanted[key]; ok {
+			continue
+		}
+		if sl.Status != model.TimeSlotStatusPlanned && sl.Status != model.TimeSlotStatusBlocked && sl.Status != model.TimeSlotStatusUnavailable {
+			continue // забронированные и уже отменённые слоты не трогаем
+		}
+		orphanIDs = append(orphanIDs, sl.ID)
+	}
+	if len(orphanIDs) > 0 {
+		res := tx.Model(&model.TimeSlot{}).
+			Where("id IN ?", orphanIDs).
+			Update("status", model.TimeSlotStatusCancelled)
+		if res.Error != nil {
+			return fmt.Errorf("cancel orphaned slots: %w", res.Error)
+		}
+		summary.Deleted += int(res.RowsAffected)
+	}
+
+	return nil
+}
+
+// resyncSlotStatusTx переключает статус planned/blocked/unavailable уже
+// существующих (в т.ч. "свежих" по ScheduleVersion) слотов провайдера в
+// [from, to) в соответствии с окнами обслуживания и периодами недоступности —
+// эта причина смены статуса не связана с версией расписания и поэтому
+// проверяется при каждом прогоне независимо от isStale.
+func (m *SlotMaterializer) resyncSlotStatusTx(
+	tx *gorm.DB,
+	nsID, providerID uuid.UUID,
+	from, to time.Time,
+	statusFor func(start, end time.Time, serviceID *uuid.UUID) (model.TimeSlotStatus, error),
+) error {
+	var existing []model.TimeSlot
+	if err := tx.
+		Where("namespace_id = ? AND provider_id = ?", nsID, providerID).
+		Where("starts_at >= ? AND starts_at < ?", from, to).
+		Where("status IN ?", []model.TimeSlotStatus{
+			model.TimeSlotStatusPlanned,
+			model.TimeSlotStatusBlocked,
+			model.TimeSlotStatusUnavailable,
+		}).
+		Find(&existing).Error; err != nil {
+		return fmt.Errorf("list existing slots: %w", err)
+	}
+
+	byDesired := map[model.TimeSlotStatus][]uuid.UUID{}
+	for _, sl := range existing {
+		desired, err := statusFor(sl.StartsAt.UTC(), sl.EndsAt.UTC(), sl.ServiceID)
+		if err != nil {
+			return err
+		}
+		if desired == sl.Status {
+			continue
+		}
+		byDesired[desired] = append(byDesired[desired], sl.ID)
+	}
+
+	for desired, ids := range byDesired {
+		if len(ids) == 0 {
+			continue
+		}
+		if err := tx.Model(&model.TimeSlot{}).Where("id IN ?", ids).
+			Update("status", desired).Error; err != nil {
+			return fmt.Errorf("resync slots to status %s: %w", desired, err)
+		}
+	}
+	return nil
+}
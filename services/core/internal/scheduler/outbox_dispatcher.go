@@ -0,0 +1,180 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/notify"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// OutboxDispatcherConfig — параметры фонового диспетчера notifications_outbox.
+type OutboxDispatcherConfig struct {
+	// RunEvery — период между прогонами.
+	RunEvery time.Duration
+	// BatchSize — сколько неопубликованных строк забирать за один прогон на
+	// арендатора.
+	BatchSize int
+	// MaxAttempts — после скольких неудачных попыток доставки строка
+	// перестаёт переобрабатываться автоматически (остаётся неопубликованной
+	// и ждёт ops-ручку OutboxReplayHandler).
+	MaxAttempts int
+	// ClaimLease — на сколько NotificationOutboxRepository.ClaimDue отодвигает
+	// NextAttemptAt занятых строк вперёд, пока этот прогон их доставляет.
+	// Должен быть заметно больше времени, которое обычно занимает доставка
+	// BatchSize строк — иначе другая реплика может перехватить ту же строку
+	// раньше, чем текущая успеет её опубликовать.
+	ClaimLease time.Duration
+}
+
+func (c OutboxDispatcherConfig) withDefaults() OutboxDispatcherConfig {
+	if c.RunEvery <= 0 {
+		c.RunEvery = 10 * time.Second
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 10
+	}
+	if c.ClaimLease <= 0 {
+		c.ClaimLease = time.Minute
+	}
+	return c
+}
+
+// OutboxDispatcher читает неопубликованные строки model.NotificationOutbox
+// (см. CalendarService.writeOutboxEvent — запись идёт в той же транзакции,
+// что и бизнес-изменение) и доставляет их через notify.Notifier. Ошибка
+// доставки не теряет событие: Attempts растёт, NextAttemptAt отодвигается с
+// экспоненциальным backoff и джиттером, а строка остаётся неопубликованной
+// до следующего прогона или ручного OutboxReplayHandler — вместо связывания
+// задержки RPC (CancelBooking и т.п.) с латентностью внешнего канала.
+type OutboxDispatcher struct {
+	db         *gorm.DB
+	outboxRepo repository.NotificationOutboxRepository
+	notifier   notify.Notifier
+	cfg        OutboxDispatcherConfig
+	logger     *log.Logger
+}
+
+func NewOutboxDispatcher(
+	db *gorm.DB,
+	outboxRepo repository.NotificationOutboxRepository,
+	notifier notify.Notifier,
+	cfg OutboxDispatcherConfig,
+) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		db:         db,
+		outboxRepo: outboxRepo,
+		notifier:   notifier,
+		cfg:        cfg.withDefaults(),
+		logger:     log.Default(),
+	}
+}
+
+// Run блокирует вызывающего и по тикеру вызывает RunOnce, пока не отменят ctx.
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	d.runAndLog(ctx)
+
+	ticker := time.NewTicker(d.cfg.RunEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.runAndLog(ctx)
+		}
+	}
+}
+
+func (d *OutboxDispatcher) runAndLog(ctx context.Context) {
+	if err := d.RunOnce(ctx); err != nil {
+		d.logger.Printf("[ERROR] scheduler.OutboxDispatcher.RunOnce: %v", err)
+	}
+}
+
+// RunOnce проходит по всем арендаторам (тот же обход, что у
+// SlotMaterializer.RunOnce/WaitlistPromoter.RunOnce) и доставляет до
+// BatchSize просроченных-к-попытке строк каждого.
+func (d *OutboxDispatcher) RunOnce(ctx context.Context) error {
+	var nsIDs []uuid.UUID
+	if err := d.db.WithContext(ctx).Model(&model.Namespace{}).Pluck("id", &nsIDs).Error; err != nil {
+		return fmt.Errorf("list namespaces: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, nsID := range nsIDs {
+		nsCtx := namespace.WithNamespace(ctx, nsID)
+
+		rows, err := d.outboxRepo.ClaimDue(nsCtx, now, d.cfg.BatchSize, d.cfg.ClaimLease)
+		if err != nil {
+			return fmt.Errorf("list due outbox rows for namespace %s: %w", nsID, err)
+		}
+
+		for i := range rows {
+			d.deliver(nsCtx, &rows[i], now)
+		}
+	}
+	return nil
+}
+
+// deliver доставляет одну строку и фиксирует результат. Строки, исчерпавшие
+// MaxAttempts, логируются и оставляются как есть — их подхватит только
+// OutboxReplayHandler.
+func (d *OutboxDispatcher) deliver(ctx context.Context, row *model.NotificationOutbox, now time.Time) {
+	if row.Attempts >= d.cfg.MaxAttempts {
+		d.logger.Printf("[ERROR] scheduler.OutboxDispatcher: id=%s event_type=%s exhausted max attempts=%d, awaiting replay", row.ID, row.EventType, d.cfg.MaxAttempts)
+		return
+	}
+
+	event := notify.Event{
+		EventType:   string(row.EventType),
+		AggregateID: row.AggregateID.String(),
+		Payload:     row.PayloadJSON,
+	}
+	var payload notify.OutboxPayload
+	if err := json.Unmarshal(row.PayloadJSON, &payload); err == nil {
+		event.ClientUserID = payload.ClientUserID
+		event.ClientTelegramID = payload.ClientTelegramID
+	}
+
+	if err := d.notifier.Send(ctx, event); err != nil {
+		nextAttempt := now.Add(outboxBackoff(row.Attempts))
+		if markErr := d.outboxRepo.MarkAttemptFailed(ctx, row.ID.String(), nextAttempt); markErr != nil {
+			d.logger.Printf("[ERROR] scheduler.OutboxDispatcher: mark attempt failed id=%s: %v", row.ID, markErr)
+		}
+		d.logger.Printf("[ERROR] scheduler.OutboxDispatcher: deliver id=%s event_type=%s attempt=%d: %v", row.ID, row.EventType, row.Attempts+1, err)
+		return
+	}
+
+	if err := d.outboxRepo.MarkPublished(ctx, row.ID.String(), now); err != nil {
+		d.logger.Printf("[ERROR] scheduler.OutboxDispatcher: mark published id=%s: %v", row.ID, err)
+	}
+}
+
+// outboxBackoff — экспоненциальная задержка со джиттером перед следующей
+// попыткой доставки: база удваивается с каждой неудачей (капается на 10
+// минут, чтобы не растягивать доставку на часы при долгом сбое нотификатора),
+// джиттер сглаживает одновременные ретраи пачки строк, упавших вместе (см.
+// db.retryBackoff для того же приёма на ретраях транзакций).
+func outboxBackoff(attempts int) time.Duration {
+	const maxBackoff = 10 * time.Minute
+	base := time.Duration(1<<uint(attempts)) * time.Second
+	if base > maxBackoff {
+		base = maxBackoff
+	}
+	jitter := time.Duration(rand.Intn(1000)) * time.Millisecond
+	return base + jitter
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// OutboxReplayHandler обслуживает POST /ops/outbox/replay?id=...&provider=...
+// — ручной повтор одной строки notifications_outbox, когда OutboxDispatcher
+// исчерпал MaxAttempts или внешний потребитель потерял уже доставленное
+// событие. Привязки к конкретному арендатору в запросе нет — как и
+// icalendar.Handler, обработчик резолвит арендатора по provider через
+// ProviderRepository.ResolveNamespaceByProviderID; provider — resolve-ключ,
+// которым ops обычно и оперируют.
+type OutboxReplayHandler struct {
+	ProviderRepo repository.ProviderRepository
+	OutboxRepo   repository.NotificationOutboxRepository
+}
+
+func NewOutboxReplayHandler(providerRepo repository.ProviderRepository, outboxRepo repository.NotificationOutboxRepository) *OutboxReplayHandler {
+	return &OutboxReplayHandler{ProviderRepo: providerRepo, OutboxRepo: outboxRepo}
+}
+
+func (h *OutboxReplayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	providerID := r.URL.Query().Get("provider")
+	if id == "" || providerID == "" {
+		http.Error(w, "id and provider are required", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	nsID, err := h.ProviderRepo.ResolveNamespaceByProviderID(ctx, providerID)
+	if err != nil {
+		http.Error(w, "provider not found", http.StatusNotFound)
+		return
+	}
+	ctx = namespace.WithNamespace(ctx, nsID)
+
+	if err := h.OutboxRepo.Replay(ctx, id, time.Now().UTC()); err != nil {
+		http.Error(w, "replay failed: "+err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
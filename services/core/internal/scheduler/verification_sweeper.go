@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// VerificationSweeperConfig — параметры фонового сборщика мусора
+// verification_codes.
+type VerificationSweeperConfig struct {
+	// RunEvery — период между прогонами.
+	RunEvery time.Duration
+	// Retention — как долго хранить уже истёкший код после ExpiresAt,
+	// прежде чем его удалить (полезно при расследовании жалоб "код не
+	// сработал" сразу после истечения).
+	Retention time.Duration
+}
+
+func (c VerificationSweeperConfig) withDefaults() VerificationSweeperConfig {
+	if c.RunEvery <= 0 {
+		c.RunEvery = 15 * time.Minute
+	}
+	if c.Retention <= 0 {
+		c.Retention = 24 * time.Hour
+	}
+	return c
+}
+
+// VerificationSweeper удаляет старые строки model.VerificationCode — те,
+// у кого ExpiresAt раньше now-Retention, независимо от того, были они
+// потреблены или просто истекли (см. service.VerificationService,
+// cmd/identity-bot). Та же схема обхода арендаторов, что у
+// WaitlistPromoter/SlotMaterializer.
+type VerificationSweeper struct {
+	db               *gorm.DB
+	verificationRepo repository.VerificationCodeRepository
+	cfg              VerificationSweeperConfig
+	logger           *log.Logger
+}
+
+func NewVerificationSweeper(db *gorm.DB, verificationRepo repository.VerificationCodeRepository, cfg VerificationSweeperConfig) *VerificationSweeper {
+	return &VerificationSweeper{
+		db:               db,
+		verificationRepo: verificationRepo,
+		cfg:              cfg.withDefaults(),
+		logger:           log.Default(),
+	}
+}
+
+// Run блокирует вызывающего и по тикеру вызывает RunOnce, пока не отменят ctx.
+func (sw *VerificationSweeper) Run(ctx context.Context) {
+	sw.runAndLog(ctx)
+
+	ticker := time.NewTicker(sw.cfg.RunEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sw.runAndLog(ctx)
+		}
+	}
+}
+
+func (sw *VerificationSweeper) runAndLog(ctx context.Context) {
+	if err := sw.RunOnce(ctx); err != nil {
+		sw.logger.Printf("[ERROR] scheduler.VerificationSweeper.RunOnce: %v", err)
+	}
+}
+
+// RunOnce проходит по всем арендаторам и удаляет строки, старше Retention
+// после истечения.
+func (sw *VerificationSweeper) RunOnce(ctx context.Context) error {
+	var nsIDs []uuid.UUID
+	if err := sw.db.WithContext(ctx).Model(&model.Namespace{}).Pluck("id", &nsIDs).Error; err != nil {
+		return fmt.Errorf("list namespaces: %w", err)
+	}
+
+	before := time.Now().UTC().Add(-sw.cfg.Retention)
+	for _, nsID := range nsIDs {
+		nsCtx := namespace.WithNamespace(ctx, nsID)
+		deleted, err := sw.verificationRepo.DeleteExpiredBefore(nsCtx, before)
+		if err != nil {
+			return fmt.Errorf("sweep expired verification codes for namespace %s: %w", nsID, err)
+		}
+		if deleted > 0 {
+			sw.logger.Printf("[INFO] scheduler.VerificationSweeper: namespace=%s deleted=%d", nsID, deleted)
+		}
+	}
+	return nil
+}
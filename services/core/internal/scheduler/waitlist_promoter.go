@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+	"github.com/Leganyst/appointment-platform/internal/service"
+)
+
+// WaitlistPromoterConfig — параметры фонового воркера продвижения листа
+// ожидания.
+type WaitlistPromoterConfig struct {
+	// RunEvery — период между прогонами.
+	RunEvery time.Duration
+}
+
+func (c WaitlistPromoterConfig) withDefaults() WaitlistPromoterConfig {
+	if c.RunEvery <= 0 {
+		c.RunEvery = 5 * time.Minute
+	}
+	return c
+}
+
+// WaitlistPromoter — страховочный backstop поверх вызовов
+// CalendarService.PromoteWaitlistForProvider, сделанных best-effort сразу
+// после CancelBooking/материализации новых слотов (см. calendar_service.go).
+// Он нужен для случаев, которые те вызовы не покрывают: ExpiresAt записи
+// листа ожидания вышел, а также любые гонки/сбои, из-за которых встроенная
+// попытка продвижения не сработала. Периодически просматривает все
+// арендаторы и для каждого провайдера с хотя бы одной активной записью
+// вызывает PromoteWaitlistForProvider, предварительно устаревшие по
+// ExpiresAt записи переводя в WaitlistStatusExpired.
+type WaitlistPromoter struct {
+	db           *gorm.DB
+	waitlistRepo repository.WaitlistRepository
+	calendarSvc  *service.CalendarService
+	cfg          WaitlistPromoterConfig
+	logger       *log.Logger
+}
+
+func NewWaitlistPromoter(
+	db *gorm.DB,
+	waitlistRepo repository.WaitlistRepository,
+	calendarSvc *service.CalendarService,
+	cfg WaitlistPromoterConfig,
+) *WaitlistPromoter {
+	return &WaitlistPromoter{
+		db:           db,
+		waitlistRepo: waitlistRepo,
+		calendarSvc:  calendarSvc,
+		cfg:          cfg.withDefaults(),
+		logger:       log.Default(),
+	}
+}
+
+// Run блокирует вызывающего и по тикеру вызывает RunOnce, пока не отменят ctx.
+func (p *WaitlistPromoter) Run(ctx context.Context) {
+	p.runAndLog(ctx)
+
+	ticker := time.NewTicker(p.cfg.RunEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.runAndLog(ctx)
+		}
+	}
+}
+
+func (p *WaitlistPromoter) runAndLog(ctx context.Context) {
+	if err := p.RunOnce(ctx); err != nil {
+		p.logger.Printf("[ERROR] scheduler.WaitlistPromoter.RunOnce: %v", err)
+	}
+}
+
+// RunOnce проходит по всем арендаторам — см. SlotMaterializer.RunOnce для
+// того же паттерна обхода арендаторов фоновым процессом без входящего
+// запроса — истекает просроченные записи и пытается продвинуть активные.
+func (p *WaitlistPromoter) RunOnce(ctx context.Context) error {
+	var nsIDs []uuid.UUID
+	if err := p.db.WithContext(ctx).Model(&model.Namespace{}).Pluck("id", &nsIDs).Error; err != nil {
+		return fmt.Errorf("list namespaces: %w", err)
+	}
+
+	now := time.Now().UTC()
+	for _, nsID := range nsIDs {
+		nsCtx := namespace.WithNamespace(ctx, nsID)
+
+		if _, err := p.waitlistRepo.ExpireStale(nsCtx, now); err != nil {
+			return fmt.Errorf("expire stale waitlist entries for namespace %s: %w", nsID, err)
+		}
+
+		var providerIDs []uuid.UUID
+		if err := p.db.WithContext(nsCtx).Model(&model.Waitlist{}).
+			Where("namespace_id = ? AND status = ?", nsID, model.WaitlistStatusActive).
+			Distinct().
+			Pluck("provider_id", &providerIDs).Error; err != nil {
+			return fmt.Errorf("list providers with active waitlist for namespace %s: %w", nsID, err)
+		}
+
+		for _, providerID := range providerIDs {
+			if _, err := p.calendarSvc.PromoteWaitlistForProvider(nsCtx, providerID); err != nil {
+				p.logger.Printf("[ERROR] scheduler.WaitlistPromoter: promote provider=%s: %v", providerID, err)
+			}
+		}
+	}
+	return nil
+}
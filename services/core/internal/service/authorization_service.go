@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	authorizationpb "github.com/Leganyst/appointment-platform/internal/api/authorization/v1"
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+)
+
+// mapUserRole переводит model.UserRole (+код роли, т.к. Role может быть не
+// предзагружен вызывающим) в authorizationpb.Role.
+func mapUserRole(ur model.UserRole, code string) *authorizationpb.Role {
+	providerID := ""
+	if ur.ProviderID != nil {
+		providerID = ur.ProviderID.String()
+	}
+	return &authorizationpb.Role{
+		Code:       code,
+		ProviderId: providerID,
+	}
+}
+
+// AuthorizationService — полноценный RBAC поверх repository.RoleRepository /
+// repository.PermissionRepository (см. model.Role, model.Permission,
+// model.PermissionGroup, model.UserRole.ProviderID). В отличие от
+// IdentityService.SetRole (сохранён как устаревший удобный путь на одну
+// глобальную роль), этот сервис — основной вход для назначения нескольких
+// ролей, в том числе заскоупленных на провайдера, и для проверки прав
+// (см. CalendarService.ensureProviderRole, middleware.RequirePermission).
+type AuthorizationService struct {
+	authorizationpb.UnimplementedAuthorizationServiceServer
+
+	roleRepo repository.RoleRepository
+	permRepo repository.PermissionRepository
+	logger   *log.Logger
+}
+
+func NewAuthorizationService(roleRepo repository.RoleRepository, permRepo repository.PermissionRepository) *AuthorizationService {
+	return &AuthorizationService{roleRepo: roleRepo, permRepo: permRepo, logger: log.Default()}
+}
+
+func parseUserID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "user_id must be a valid uuid")
+	}
+	return id, nil
+}
+
+// parseScope — provider_id в запросах этого сервиса опционален (пустая
+// строка значит "глобальный scope"), в отличие от user_id, который обязателен.
+func parseScope(raw string) (*uuid.UUID, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "provider_id must be a valid uuid")
+	}
+	return &id, nil
+}
+
+func (s *AuthorizationService) AssignRole(ctx context.Context, req *authorizationpb.AssignRoleRequest) (*authorizationpb.AssignRoleResponse, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetRoleCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "role_code is required")
+	}
+	scope, err := parseScope(req.GetProviderId())
+	if err != nil {
+		return nil, err
+	}
+
+	ur, err := s.roleRepo.AssignRole(ctx, userID, req.GetRoleCode(), scope)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "assign role: %v", err)
+	}
+
+	s.logger.Printf("[AUTHZ][INFO] AssignRole | user_id=%s role=%s provider_id=%v", userID, req.GetRoleCode(), scope)
+	return &authorizationpb.AssignRoleResponse{Role: mapUserRole(*ur, req.GetRoleCode())}, nil
+}
+
+func (s *AuthorizationService) RevokeRole(ctx context.Context, req *authorizationpb.RevokeRoleRequest) (*authorizationpb.RevokeRoleResponse, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetRoleCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "role_code is required")
+	}
+	scope, err := parseScope(req.GetProviderId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.RevokeRole(ctx, userID, req.GetRoleCode(), scope); err != nil {
+		return nil, status.Errorf(codes.Internal, "revoke role: %v", err)
+	}
+
+	s.logger.Printf("[AUTHZ][INFO] RevokeRole | user_id=%s role=%s provider_id=%v", userID, req.GetRoleCode(), scope)
+	return &authorizationpb.RevokeRoleResponse{}, nil
+}
+
+func (s *AuthorizationService) ListRoles(ctx context.Context, req *authorizationpb.ListRolesRequest) (*authorizationpb.ListRolesResponse, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := s.roleRepo.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "list roles: %v", err)
+	}
+
+	resp := &authorizationpb.ListRolesResponse{Roles: make([]*authorizationpb.Role, 0, len(roles))}
+	for _, ur := range roles {
+		code := ""
+		if ur.Role != nil {
+			code = ur.Role.Code
+		}
+		resp.Roles = append(resp.Roles, mapUserRole(ur, code))
+	}
+	return resp, nil
+}
+
+func (s *AuthorizationService) CreateRoleGroup(ctx context.Context, req *authorizationpb.CreateRoleGroupRequest) (*authorizationpb.CreateRoleGroupResponse, error) {
+	if req.GetCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	group, err := s.permRepo.EnsureGroup(ctx, req.GetCode(), req.GetName())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "create role group: %v", err)
+	}
+
+	s.logger.Printf("[AUTHZ][INFO] CreateRoleGroup | code=%s id=%d", group.Code, group.ID)
+	return &authorizationpb.CreateRoleGroupResponse{GroupId: group.ID, Code: group.Code, Name: group.Name}, nil
+}
+
+func (s *AuthorizationService) GrantPermissionToRole(ctx context.Context, req *authorizationpb.GrantPermissionToRoleRequest) (*authorizationpb.GrantPermissionToRoleResponse, error) {
+	if req.GetRoleCode() == "" || req.GetPermissionCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "role_code and permission_code are required")
+	}
+
+	role, err := s.roleRepo.EnsureRole(ctx, req.GetRoleCode())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "resolve role: %v", err)
+	}
+	if err := s.permRepo.GrantPermissionToRole(ctx, role.ID, req.GetPermissionCode()); err != nil {
+		return nil, status.Errorf(codes.Internal, "grant permission: %v", err)
+	}
+
+	s.logger.Printf("[AUTHZ][INFO] GrantPermissionToRole | role=%s permission=%s", req.GetRoleCode(), req.GetPermissionCode())
+	return &authorizationpb.GrantPermissionToRoleResponse{}, nil
+}
+
+func (s *AuthorizationService) CheckPermission(ctx context.Context, req *authorizationpb.CheckPermissionRequest) (*authorizationpb.CheckPermissionResponse, error) {
+	userID, err := parseUserID(req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	if req.GetPermissionCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "permission_code is required")
+	}
+	scope, err := parseScope(req.GetProviderId())
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := s.permRepo.HasPermission(ctx, userID, req.GetPermissionCode(), scope)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "check permission: %v", err)
+	}
+	return &authorizationpb.CheckPermissionResponse{Allowed: allowed}, nil
+}
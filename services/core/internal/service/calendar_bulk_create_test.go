@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	calendarpb "github.com/Leganyst/appointment-platform/internal/api/calendar/v1"
+	commonpb "github.com/Leganyst/appointment-platform/internal/api/common/v1"
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+)
+
+func newBulkCreateTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	schema := []string{
+		`CREATE TABLE time_slots (
+			id TEXT PRIMARY KEY,
+			namespace_id TEXT NOT NULL,
+			schedule_id TEXT,
+			provider_id TEXT NOT NULL,
+			service_id TEXT,
+			staff_id TEXT,
+			resource_id TEXT,
+			capacity INTEGER NOT NULL DEFAULT 1,
+			starts_at DATETIME NOT NULL,
+			ends_at DATETIME NOT NULL,
+			status TEXT NOT NULL,
+			external_id TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		);`,
+	}
+	for _, stmt := range schema {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("create schema: %v", err)
+		}
+	}
+	return db
+}
+
+func timeRange(start, end time.Time) *commonpb.TimeRange {
+	return &commonpb.TimeRange{Start: timestamppb.New(start), End: timestamppb.New(end)}
+}
+
+func TestCalendarService_BulkCreateSlots_SkipsConflicts(t *testing.T) {
+	db := newBulkCreateTestDB(t)
+	nsID := uuid.New()
+	providerID := uuid.New()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	existing := model.TimeSlot{
+		NamespaceID: nsID,
+		ProviderID:  providerID,
+		StartsAt:    now,
+		EndsAt:      now.Add(30 * time.Minute),
+		Status:      model.TimeSlotStatusPlanned,
+	}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("seed existing slot: %v", err)
+	}
+
+	svc := &CalendarService{db: db}
+	ctx := namespace.WithNamespace(context.Background(), nsID)
+
+	resp, err := svc.BulkCreateSlots(ctx, &calendarpb.BulkCreateSlotsRequest{
+		ProviderId: providerID.String(),
+		Ranges: []*commonpb.TimeRange{
+			timeRange(now, now.Add(30*time.Minute)),             // overlaps existing
+			timeRange(now.Add(time.Hour), now.Add(90*time.Minute)), // clear
+		},
+		OnConflict: calendarpb.ConflictMode_CONFLICT_MODE_SKIP,
+	})
+	if err != nil {
+		t.Fatalf("BulkCreateSlots: %v", err)
+	}
+	if resp.GetCreatedCount() != 1 || resp.GetSkippedCount() != 1 {
+		t.Fatalf("created=%d skipped=%d, want 1/1", resp.GetCreatedCount(), resp.GetSkippedCount())
+	}
+	if len(resp.GetResults()) != 2 {
+		t.Fatalf("results len = %d, want 2", len(resp.GetResults()))
+	}
+	if resp.GetResults()[0].GetSlotId() != "" {
+		t.Errorf("expected conflicting range to have no slot_id")
+	}
+	if len(resp.GetResults()[0].GetConflictWithSlotIds()) != 1 {
+		t.Errorf("expected 1 conflicting slot id, got %d", len(resp.GetResults()[0].GetConflictWithSlotIds()))
+	}
+	if resp.GetResults()[1].GetSlotId() == "" {
+		t.Errorf("expected clear range to create a slot")
+	}
+}
+
+func TestCalendarService_BulkCreateSlots_FailAbortsWholeBatch(t *testing.T) {
+	db := newBulkCreateTestDB(t)
+	nsID := uuid.New()
+	providerID := uuid.New()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := db.Create(&model.TimeSlot{
+		NamespaceID: nsID,
+		ProviderID:  providerID,
+		StartsAt:    now,
+		EndsAt:      now.Add(30 * time.Minute),
+		Status:      model.TimeSlotStatusPlanned,
+	}).Error; err != nil {
+		t.Fatalf("seed existing slot: %v", err)
+	}
+
+	svc := &CalendarService{db: db}
+	ctx := namespace.WithNamespace(context.Background(), nsID)
+
+	_, err := svc.BulkCreateSlots(ctx, &calendarpb.BulkCreateSlotsRequest{
+		ProviderId: providerID.String(),
+		Ranges: []*commonpb.TimeRange{
+			timeRange(now.Add(2*time.Hour), now.Add(150*time.Minute)), // clear
+			timeRange(now, now.Add(30*time.Minute)),                   // conflicts
+		},
+		OnConflict: calendarpb.ConflictMode_CONFLICT_MODE_FAIL,
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	var count int64
+	if err := db.Model(&model.TimeSlot{}).Count(&count).Error; err != nil {
+		t.Fatalf("count slots: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("slot count = %d, want 1 (nothing new committed)", count)
+	}
+}
+
+func TestCalendarService_BulkCreateSlots_MergeUnionsOverlappingRanges(t *testing.T) {
+	db := newBulkCreateTestDB(t)
+	nsID := uuid.New()
+	providerID := uuid.New()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	svc := &CalendarService{db: db}
+	ctx := namespace.WithNamespace(context.Background(), nsID)
+
+	resp, err := svc.BulkCreateSlots(ctx, &calendarpb.BulkCreateSlotsRequest{
+		ProviderId: providerID.String(),
+		Ranges: []*commonpb.TimeRange{
+			timeRange(now, now.Add(30*time.Minute)),
+			timeRange(now.Add(20*time.Minute), now.Add(50*time.Minute)), // overlaps previous
+		},
+		OnConflict: calendarpb.ConflictMode_CONFLICT_MODE_MERGE,
+	})
+	if err != nil {
+		t.Fatalf("BulkCreateSlots: %v", err)
+	}
+	if resp.GetCreatedCount() != 1 {
+		t.Fatalf("created = %d, want 1 (merged)", resp.GetCreatedCount())
+	}
+	if resp.GetResults()[0].GetSlotId() == "" || resp.GetResults()[0].GetSlotId() != resp.GetResults()[1].GetSlotId() {
+		t.Fatalf("expected both indices to map to the same merged slot")
+	}
+}
@@ -2,10 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,21 +22,65 @@ import (
 
 	calendarpb "github.com/Leganyst/appointment-platform/internal/api/calendar/v1"
 	commonpb "github.com/Leganyst/appointment-platform/internal/api/common/v1"
+	calpage "github.com/Leganyst/appointment-platform/internal/calendar"
+	appdb "github.com/Leganyst/appointment-platform/internal/db"
+	"github.com/Leganyst/appointment-platform/internal/events"
+	"github.com/Leganyst/appointment-platform/internal/icalendar"
+	"github.com/Leganyst/appointment-platform/internal/integration/caldav"
 	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/namespace"
+	"github.com/Leganyst/appointment-platform/internal/notify"
 	"github.com/Leganyst/appointment-platform/internal/repository"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
 	calendarutils "github.com/Leganyst/appointment-platform/internal/utils"
 )
 
+// bookingIdempotencyTTL — срок жизни записи об идемпотентном запросе (см.
+// model.BookingIdempotency). Повтор с тем же ключом позже этого срока уже
+// не дедуплицируется и обрабатывается как новый запрос.
+const bookingIdempotencyTTL = 24 * time.Hour
+
+// ScheduleMaterializationQueue — два крючка в фоновый материализатор слотов
+// (внутрипроцессная очередь internal/scheduler.MaterializationQueue), нужных
+// CalendarService: точечная перестройка одного расписания после правки его
+// правила и синхронная принудительная перестройка произвольного окна для
+// RebuildProviderCalendar. Интерфейс объявлен здесь, а не в internal/scheduler,
+// потому что scheduler уже зависит от service (см.
+// service.ExpandScheduleModelInWindowUTC) — обратный импорт дал бы цикл.
+// Единственная реализация подключается в cmd/main.go.
+type ScheduleMaterializationQueue interface {
+	// EnqueueSchedule — неблокирующая постановка точечной перестройки
+	// одного расписания (см. UpdateProviderSchedule).
+	EnqueueSchedule(namespaceID, providerID, scheduleID uuid.UUID)
+	// RebuildNow — синхронная перестройка провайдера на [from, to), в обход
+	// очереди. Возвращает число созданных/тронутых/отменённых слотов.
+	RebuildNow(ctx context.Context, namespaceID, providerID uuid.UUID, from, to time.Time) (created, updated, cancelled int, err error)
+}
+
 type CalendarService struct {
 	calendarpb.UnimplementedCalendarServiceServer
 
-	db           *gorm.DB
-	slotRepo     repository.SlotRepository
-	bookingRepo  repository.BookingRepository
-	scheduleRepo repository.ScheduleRepository
-	providerRepo repository.ProviderRepository
-	serviceRepo  repository.ServiceRepository
-	userRepo     repository.UserRepository
+	db                      *gorm.DB
+	slotRepo                repository.SlotRepository
+	bookingRepo             repository.BookingRepository
+	scheduleRepo            repository.ScheduleRepository
+	providerRepo            repository.ProviderRepository
+	serviceRepo             repository.ServiceRepository
+	userRepo                repository.UserRepository
+	maintenanceRepo         repository.MaintenanceRepository
+	calendarIntegrationRepo repository.CalendarIntegrationRepository
+	waitlistRepo            repository.WaitlistRepository
+	resourceRepo            repository.ResourceRepository
+	unavailabilityRepo      repository.UnavailabilityRepository
+	scheduleTemplateRepo    repository.ScheduleTemplateRepository
+	blackoutRepo            repository.BlackoutRepository
+	materializationQueue    ScheduleMaterializationQueue
+	permRepo                repository.PermissionRepository
+	feedTokenRepo           repository.CalendarFeedTokenRepository
+	// eventBus — живой push-стрим бронирований/слотов по ключу ProviderID
+	// (см. SubscribeBookingEvents, internal/events.Bus); nil в сборках, где
+	// push-уведомления не настроены (тогда publishBookingEvent — no-op).
+	eventBus *events.Bus[string, events.BookingEvent]
 
 	logger *log.Logger
 }
@@ -45,17 +93,56 @@ func NewCalendarService(
 	providerRepo repository.ProviderRepository,
 	serviceRepo repository.ServiceRepository,
 	userRepo repository.UserRepository,
+	maintenanceRepo repository.MaintenanceRepository,
+	calendarIntegrationRepo repository.CalendarIntegrationRepository,
+	waitlistRepo repository.WaitlistRepository,
+	resourceRepo repository.ResourceRepository,
+	unavailabilityRepo repository.UnavailabilityRepository,
+	scheduleTemplateRepo repository.ScheduleTemplateRepository,
+	blackoutRepo repository.BlackoutRepository,
+	materializationQueue ScheduleMaterializationQueue,
+	permRepo repository.PermissionRepository,
+	feedTokenRepo repository.CalendarFeedTokenRepository,
+	eventBus *events.Bus[string, events.BookingEvent],
 ) *CalendarService {
 	return &CalendarService{
-		db:           db,
-		slotRepo:     slotRepo,
-		bookingRepo:  bookingRepo,
-		scheduleRepo: scheduleRepo,
-		providerRepo: providerRepo,
-		serviceRepo:  serviceRepo,
-		userRepo:     userRepo,
-		logger:       log.Default(),
+		db:                      db,
+		slotRepo:                slotRepo,
+		bookingRepo:             bookingRepo,
+		scheduleRepo:            scheduleRepo,
+		providerRepo:            providerRepo,
+		serviceRepo:             serviceRepo,
+		userRepo:                userRepo,
+		maintenanceRepo:         maintenanceRepo,
+		calendarIntegrationRepo: calendarIntegrationRepo,
+		waitlistRepo:            waitlistRepo,
+		resourceRepo:            resourceRepo,
+		unavailabilityRepo:      unavailabilityRepo,
+		scheduleTemplateRepo:    scheduleTemplateRepo,
+		blackoutRepo:            blackoutRepo,
+		materializationQueue:    materializationQueue,
+		permRepo:                permRepo,
+		feedTokenRepo:           feedTokenRepo,
+		eventBus:                eventBus,
+		logger:                  log.Default(),
+	}
+}
+
+// publishBookingEvent публикует событие бронирования/слота активным
+// подписчикам SubscribeBookingEvents этого провайдера (см. eventBus).
+// Вызывается уже после успешного коммита транзакции, поэтому не может
+// провалить саму мутацию — push-уведомление вторично по отношению к ней.
+func (s *CalendarService) publishBookingEvent(evType events.BookingEventType, providerID, bookingID, slotID string) {
+	if s.eventBus == nil || providerID == "" {
+		return
 	}
+	s.eventBus.Publish(providerID, events.BookingEvent{
+		Type:       evType,
+		ProviderID: providerID,
+		BookingID:  bookingID,
+		SlotID:     slotID,
+		At:         time.Now().UTC(),
+	})
 }
 
 func (s *CalendarService) logErr(method string, err error, fields ...any) {
@@ -80,6 +167,84 @@ func (s *CalendarService) logInfo(method string, fields ...any) {
 	s.logger.Printf("[INFO] %s", method)
 }
 
+// writeOutboxEvent пишет строку в notifications_outbox внутри уже открытой
+// бизнес-транзакции tx (см. model.NotificationOutbox) — вызывается из тех же
+// транзакций, что отменяют/подтверждают бронирования, а не через репозиторий,
+// чтобы коммит события и коммит бизнес-изменения были одной транзакцией.
+// Доставку делает отдельно scheduler.OutboxDispatcher, читая через
+// repository.NotificationOutboxRepository.
+func writeOutboxEvent(tx *gorm.DB, nsID, aggregateID uuid.UUID, eventType model.NotificationEventType, payload notify.OutboxPayload) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal outbox payload: %w", err)
+	}
+	return tx.Create(&model.NotificationOutbox{
+		NamespaceID: nsID,
+		AggregateID: aggregateID,
+		EventType:   eventType,
+		PayloadJSON: datatypes.JSON(raw),
+	}).Error
+}
+
+// writeAuditEvent пишет строку в events (см. model.Event) внутри уже открытой
+// бизнес-транзакции tx — тем же способом, что writeOutboxEvent пишет в
+// notifications_outbox. Details остаётся человекочитаемой записью для
+// ручного разбора аудита; Payload — каноническое JSON-представление того же
+// события, которое читает и публикует внешним системам
+// internal/outbox.Dispatcher.
+func writeAuditEvent(tx *gorm.DB, eventType model.EventType, details string) error {
+	payload, err := json.Marshal(struct {
+		EventType model.EventType `json:"event_type"`
+		Details   string          `json:"details"`
+	}{EventType: eventType, Details: details})
+	if err != nil {
+		return fmt.Errorf("marshal audit event payload: %w", err)
+	}
+	return tx.Create(&model.Event{
+		EventType: eventType,
+		Details:   details,
+		Payload:   payload,
+	}).Error
+}
+
+// SubscribeBookingEvents — server-streaming RPC: зеркало
+// IdentityService.SubscribeUserEvents для провайдерской стороны. Шлёт
+// BOOKING_CREATED/BOOKING_CANCELLED для provider_id, пока вызывающий
+// (обычно веб-фронтенд провайдера) не отменит ctx, заменяя поллинг
+// ListBookings. Без replay-курсора — см. events.BookingEvent.
+func (s *CalendarService) SubscribeBookingEvents(req *calendarpb.SubscribeBookingEventsRequest, stream calendarpb.CalendarService_SubscribeBookingEventsServer) error {
+	if req.GetProviderId() == "" {
+		return status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if s.eventBus == nil {
+		return status.Error(codes.Unavailable, "booking event stream is not configured")
+	}
+
+	ctx := stream.Context()
+	ch, unsubscribe := s.eventBus.Subscribe(req.GetProviderId())
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(&calendarpb.BookingEvent{
+				Type:       string(ev.Type),
+				ProviderId: ev.ProviderID,
+				BookingId:  ev.BookingID,
+				SlotId:     ev.SlotID,
+				At:         timestamppb.New(ev.At),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // ListFreeSlots — реализация RPC из сгенерённого интерфейса.
 func (s *CalendarService) ListFreeSlots(
 	ctx context.Context,
@@ -105,39 +270,16 @@ func (s *CalendarService) ListFreeSlots(
 	}
 	offset := (int(page) - 1) * int(size)
 
-	// Генерируем (материализуем) слоты из расписаний провайдера в окне,
-	// чтобы далее отдавать их через существующий репозиторий с пагинацией.
-	if s.db != nil && s.scheduleRepo != nil {
-		providerUUID, err := uuid.Parse(req.GetProviderId())
-		if err != nil {
-			s.logErr("ListFreeSlots", err, "provider_id", req.GetProviderId())
-			return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
-		}
-		var serviceUUID *uuid.UUID
-		if req.GetServiceId() != "" {
-			sid, err := uuid.Parse(req.GetServiceId())
-			if err != nil {
-				s.logErr("ListFreeSlots", err, "service_id", req.GetServiceId())
-				return nil, status.Error(codes.InvalidArgument, "invalid service_id")
-			}
-			serviceUUID = &sid
-		}
-
-		schedules, err := s.scheduleRepo.ListByProvider(ctx, req.GetProviderId())
-		if err != nil {
-			s.logErr("ListFreeSlots", err, "stage", "list schedules")
-			return nil, status.Errorf(codes.Internal, "list schedules: %v", err)
-		}
-		if err := s.materializeSlotsFromSchedules(ctx, providerUUID, serviceUUID, from.UTC(), to.UTC(), schedules); err != nil {
-			s.logErr("ListFreeSlots", err, "stage", "materialize")
-			return nil, status.Errorf(codes.Internal, "materialize schedule slots: %v", err)
-		}
-	}
-
+	// Слоты материализованы заранее фоновым scheduler.SlotMaterializer (на
+	// скользящем горизонте) и scheduler.MaterializationQueue (точечно, сразу
+	// после правки расписания — см. UpdateProviderSchedule), поэтому тут
+	// только чтение уже готовых TimeSlot — без разворачивания правил на
+	// каждый вызов.
 	slots, total, err := s.slotRepo.ListFreeSlots(
 		ctx,
 		req.GetProviderId(),
 		req.GetServiceId(),
+		req.GetResourceIds(),
 		from,
 		to,
 		int(size),
@@ -153,125 +295,210 @@ func (s *CalendarService) ListFreeSlots(
 		TotalCount: int32(total),
 	}
 
-	for _, slot := range slots {
-		resp.Slots = append(resp.Slots, &commonpb.Slot{
-			Id:         slot.ID.String(),
-			ProviderId: slot.ProviderID.String(),
-			ServiceId:  slot.ServiceID.String(),
-			StartsAt:   timestamppb.New(slot.StartsAt),
-			EndsAt:     timestamppb.New(slot.EndsAt),
-			Status:     mapSlotStatus(slot.Status),
-		})
+	for i := range slots {
+		resp.Slots = append(resp.Slots, mapSlot(&slots[i]))
 	}
 
 	return resp, nil
 }
 
-type slotKey struct {
-	ServiceID string
-	StartNS   int64
-	EndNS     int64
-}
+// ListProviderSlots — то же, что ListByProviderRange, но постранично через
+// keyset-курсор (см. internal/calendar.Cursor) вместо OFFSET, чтобы большой
+// календарь провайдера не приходилось грузить целиком ради одной страницы.
+// Принимает либо page_token (приоритетно), либо устаревшие page/page_size —
+// для клиентов, ещё не перешедших на курсор; если задано и то, и другое,
+// page_token побеждает.
+func (s *CalendarService) ListProviderSlots(
+	ctx context.Context,
+	req *calendarpb.ListProviderSlotsRequest,
+) (*calendarpb.ListProviderSlotsResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if s.slotRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "slot repository is not configured")
+	}
+
+	size := int(req.GetPageSize())
+	if size <= 0 {
+		size = 20
+	}
+
+	var cursor *calpage.Cursor
+	if token := req.GetPageToken(); token != "" {
+		c, err := calpage.DecodeCursor(token)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page_token: %v", err)
+		}
+		cursor = c
+	} else if page := req.GetPage(); page > 1 {
+		// Обратная совместимость: эмулируем offset-страницу page/page_size
+		// через ту же keyset-выборку, догружая и отбрасывая предыдущие
+		// страницы. Дороже настоящего OFFSET на поздних страницах, но не
+		// требует второго пути в репозитории ради клиентов, которые ещё не
+		// перешли на page_token.
+		for p := int32(1); p < page; p++ {
+			skip, err := s.slotRepo.ListSlotsAfter(ctx, req.GetProviderId(), cursor, size)
+			if err != nil {
+				s.logErr("ListProviderSlots", err, "stage", "emulate legacy page", "page", p)
+				return nil, status.Errorf(codes.Internal, "list slots: %v", err)
+			}
+			if len(skip) == 0 {
+				break
+			}
+			last := skip[len(skip)-1]
+			cursor = &calpage.Cursor{StartsAt: last.StartsAt, ID: last.ID.String()}
+		}
+	}
+
+	slots, err := s.slotRepo.ListSlotsAfter(ctx, req.GetProviderId(), cursor, size+1)
+	if err != nil {
+		s.logErr("ListProviderSlots", err, "stage", "list slots")
+		return nil, status.Errorf(codes.Internal, "list slots: %v", err)
+	}
 
-func makeSlotKey(serviceID *uuid.UUID, start, end time.Time) slotKey {
-	s := ""
-	if serviceID != nil {
-		s = serviceID.String()
+	hasNext := len(slots) > size
+	if hasNext {
+		slots = slots[:size]
 	}
-	return slotKey{ServiceID: s, StartNS: start.UnixNano(), EndNS: end.UnixNano()}
+
+	page := calpage.KeysetPage[model.TimeSlot]{Items: slots}
+	if req.GetPageToken() != "" {
+		page.PrevCursor = req.GetPageToken()
+	}
+	if hasNext && len(slots) > 0 {
+		last := slots[len(slots)-1]
+		page.NextCursor = calpage.Cursor{StartsAt: last.StartsAt, ID: last.ID.String()}.Encode()
+	}
+
+	resp := &calendarpb.ListProviderSlotsResponse{
+		Slots:         make([]*commonpb.Slot, 0, len(page.Items)),
+		NextPageToken: page.NextCursor,
+		PrevPageToken: page.PrevCursor,
+	}
+	for i := range page.Items {
+		resp.Slots = append(resp.Slots, mapSlot(&page.Items[i]))
+	}
+	return resp, nil
 }
 
-func (s *CalendarService) materializeSlotsFromSchedules(
+// activeMaintenanceRanges возвращает развёрнутые интервалы активных (в т.ч.
+// повторяющихся по RRULE) MaintenanceWindow провайдера, пересекающиеся с
+// [from, to). Используется и материализацией (чтобы помечать слоты
+// TimeSlotStatusBlocked), и проверкой конфликтов при бронировании.
+func (s *CalendarService) activeMaintenanceRanges(
 	ctx context.Context,
 	providerID uuid.UUID,
-	serviceID *uuid.UUID,
-	fromUTC, toUTC time.Time,
-	schedules []model.Schedule,
-) error {
-	if len(schedules) == 0 {
-		return nil
+	from, to time.Time,
+) ([]calendarutils.TimeRange, error) {
+	if s.maintenanceRepo == nil {
+		return nil, nil
 	}
-	if !toUTC.After(fromUTC) {
-		return nil
+	windows, err := s.maintenanceRepo.ListActiveForProvider(ctx, providerID.String(), from, to)
+	if err != nil {
+		return nil, err
 	}
-
-	// Expand all schedules to occurrences inside the window.
-	occBySchedule := make(map[uuid.UUID][]calendarutils.TimeRange, len(schedules))
-	for i := range schedules {
-		sched := schedules[i]
-		occ, err := s.expandScheduleModelInWindowUTC(&sched, fromUTC, toUTC)
-		if err != nil {
-			return fmt.Errorf("expand schedule %s: %w", sched.ID.String(), err)
+	var ranges []calendarutils.TimeRange
+	for _, w := range windows {
+		rrule := ""
+		if w.RRule != nil {
+			rrule = *w.RRule
 		}
-		if len(occ) == 0 {
-			continue
+		occ, err := calendarutils.ExpandRecurringWindow(w.StartsAt, w.EndsAt, rrule, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("expand maintenance window %s: %w", w.ID, err)
 		}
-		occBySchedule[sched.ID] = occ
+		ranges = append(ranges, occ...)
 	}
-	if len(occBySchedule) == 0 {
-		return nil
+	return ranges, nil
+}
+
+// activeUnavailabilityRanges возвращает интервалы UnavailabilityPeriod
+// провайдера, пересекающиеся с [from, to) — периоды разовые (без RRULE,
+// в отличие от MaintenanceWindow), поэтому развёртка не нужна, диапазоны
+// репозитория используются как есть.
+func (s *CalendarService) activeUnavailabilityRanges(
+	ctx context.Context,
+	providerID uuid.UUID,
+	from, to time.Time,
+) ([]calendarutils.TimeRange, error) {
+	if s.unavailabilityRepo == nil {
+		return nil, nil
+	}
+	periods, err := s.unavailabilityRepo.ListForProvider(ctx, providerID.String(), from, to)
+	if err != nil {
+		return nil, err
 	}
+	ranges := make([]calendarutils.TimeRange, 0, len(periods))
+	for _, p := range periods {
+		ranges = append(ranges, calendarutils.TimeRange{Start: p.StartsAt.UTC(), End: p.EndsAt.UTC()})
+	}
+	return ranges, nil
+}
 
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// Fetch existing slots in window once.
-		var existing []model.TimeSlot
-		q := tx.Model(&model.TimeSlot{}).
-			Where("provider_id = ?", providerID).
-			Where("starts_at >= ? AND ends_at <= ?", fromUTC, toUTC)
-		if serviceID != nil {
-			q = q.Where("service_id = ?", *serviceID)
-		} else {
-			q = q.Where("service_id IS NULL")
-		}
-		if err := q.Find(&existing).Error; err != nil {
-			return err
-		}
+// decodeByDayTokens переводит список токенов BYDAY ("MO", "-1SU", "2MO") в ByDayEntry.
+func decodeByDayTokens(tokens []string) ([]calendarutils.ByDayEntry, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	entries, err := calendarutils.ParseByDay(strings.Join(tokens, ","))
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
 
-		existingByKey := make(map[slotKey]model.TimeSlot, len(existing))
-		for _, sl := range existing {
-			sid := sl.ServiceID
-			k := makeSlotKey(sid, sl.StartsAt.UTC(), sl.EndsAt.UTC())
-			existingByKey[k] = sl
-		}
+// uuidPtrEqual сравнивает два опциональных UUID (nil считается отдельным
+// значением, не равным ни одному непустому UUID) — используется при
+// сопоставлении кортежа (provider, service, staff, resource) слота.
+func uuidPtrEqual(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
 
-		var toCreate []model.TimeSlot
-		for schedID, occurrences := range occBySchedule {
-			sid := schedID
-			for _, occ := range occurrences {
-				start := occ.Start.UTC()
-				end := occ.End.UTC()
-				k := makeSlotKey(serviceID, start, end)
-				if _, ok := existingByKey[k]; ok {
-					continue
-				}
-				toCreate = append(toCreate, model.TimeSlot{
-					ScheduleID: &sid,
-					ProviderID: providerID,
-					ServiceID:  serviceID,
-					StartsAt:   start,
-					EndsAt:     end,
-					Status:     model.TimeSlotStatusPlanned,
-				})
-			}
-		}
+// intSlice переводит []int32 из proto в []int, как ожидает calendarutils.RecurringRule.
+func intSlice(values []int32) []int {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]int, len(values))
+	for i, v := range values {
+		result[i] = int(v)
+	}
+	return result
+}
 
-		if len(toCreate) == 0 {
-			return nil
+// timestampsIn переводит список protobuf-таймстемпов в []time.Time в указанной локации.
+func timestampsIn(values []*timestamppb.Timestamp, loc *time.Location) []time.Time {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]time.Time, 0, len(values))
+	for _, ts := range values {
+		if ts == nil {
+			continue
 		}
+		result = append(result, ts.AsTime().In(loc))
+	}
+	return result
+}
 
-		// Make deterministic insert order to reduce chances of diff on retries.
-		sort.Slice(toCreate, func(i, j int) bool {
-			if toCreate[i].StartsAt.Equal(toCreate[j].StartsAt) {
-				return toCreate[i].EndsAt.Before(toCreate[j].EndsAt)
-			}
-			return toCreate[i].StartsAt.Before(toCreate[j].StartsAt)
-		})
-
-		return tx.Create(&toCreate).Error
-	})
+// rruleWeekday переводит двухбуквенный код дня недели (WKST) в time.Weekday.
+func rruleWeekday(code string) (time.Weekday, bool) {
+	if code == "" {
+		return 0, false
+	}
+	return calendarutils.WeekdayFromRRULECode(code)
 }
 
-func (s *CalendarService) expandScheduleModelInWindowUTC(sched *model.Schedule, fromUTC, toUTC time.Time) ([]calendarutils.TimeRange, error) {
+// ExpandScheduleModelInWindowUTC разворачивает правило расписания sched в
+// конкретные интервалы внутри окна [fromUTC, toUTC]. Вынесена в виде
+// свободной функции (не метод), чтобы её мог переиспользовать фоновый
+// воркер материализации (internal/scheduler) без зависимости от состояния
+// CalendarService.
+func ExpandScheduleModelInWindowUTC(sched *model.Schedule, fromUTC, toUTC time.Time) ([]calendarutils.TimeRange, error) {
 	if sched == nil {
 		return []calendarutils.TimeRange{}, nil
 	}
@@ -329,6 +556,10 @@ func (s *CalendarService) expandScheduleModelInWindowUTC(sched *model.Schedule,
 	switch rulePB.GetFrequency() {
 	case commonpb.RecurrenceFrequency_RECURRENCE_FREQUENCY_WEEKLY:
 		freq = calendarutils.FreqWeekly
+	case commonpb.RecurrenceFrequency_RECURRENCE_FREQUENCY_MONTHLY:
+		freq = calendarutils.FreqMonthly
+	case commonpb.RecurrenceFrequency_RECURRENCE_FREQUENCY_YEARLY:
+		freq = calendarutils.FreqYearly
 	case commonpb.RecurrenceFrequency_RECURRENCE_FREQUENCY_DAILY:
 		freq = calendarutils.FreqDaily
 	}
@@ -346,6 +577,16 @@ func (s *CalendarService) expandScheduleModelInWindowUTC(sched *model.Schedule,
 		}
 	}
 
+	byDay, err := decodeByDayTokens(rulePB.GetByDay())
+	if err != nil {
+		return nil, fmt.Errorf("rule.by_day: %w", err)
+	}
+
+	weekStart := time.Monday
+	if wd, ok := rruleWeekday(rulePB.GetWeekStart()); ok {
+		weekStart = wd
+	}
+
 	var until *time.Time
 	if rulePB.GetUntil() != nil {
 		u := rulePB.GetUntil().AsTime().In(loc)
@@ -383,6 +624,36 @@ func (s *CalendarService) expandScheduleModelInWindowUTC(sched *model.Schedule,
 		Until:      until,
 		Count:      count,
 		Exceptions: exceptions,
+		ByMonth:    intSlice(rulePB.GetByMonth()),
+		ByMonthDay: intSlice(rulePB.GetByMonthDay()),
+		ByYearDay:  intSlice(rulePB.GetByYearDay()),
+		ByWeekNo:   intSlice(rulePB.GetByWeekNo()),
+		ByDay:      byDay,
+		ByHour:     intSlice(rulePB.GetByHour()),
+		ByMinute:   intSlice(rulePB.GetByMinute()),
+		BySetPos:   intSlice(rulePB.GetBySetPos()),
+		WeekStart:  weekStart,
+		RDates:     timestampsIn(rulePB.GetRdates(), loc),
+		ExDates:    timestampsIn(rulePB.GetExdates(), loc),
+	}
+
+	// Расписания, импортированные из внешних календарей (Google/Apple/Outlook), могут
+	// нести полную строку RRULE вместо структурированных BYxxx-полей — она имеет
+	// приоритет над ними, т.к. несёт весь набор RFC 5545 без потерь при экспорте/импорте.
+	if raw := rulePB.GetRawRrule(); raw != "" {
+		parsed, err := calendarutils.ParseRRULE(raw)
+		if err != nil {
+			return nil, fmt.Errorf("rule.raw_rrule: %w", err)
+		}
+		parsed.StartTime = rule.StartTime
+		parsed.Duration = rule.Duration
+		parsed.Exceptions = rule.Exceptions
+		parsed.RDates = rule.RDates
+		parsed.ExDates = rule.ExDates
+		if parsed.Until == nil {
+			parsed.Until = rule.Until
+		}
+		rule = parsed
 	}
 
 	intervals, err := calendarutils.ExpandRecurringRule(rule, window)
@@ -398,6 +669,102 @@ func (s *CalendarService) expandScheduleModelInWindowUTC(sched *model.Schedule,
 	return intervals, nil
 }
 
+// scheduleMasterRRULE строит каноническую строку RRULE (RFC 5545) из
+// Schedule.Rules для master-VEVENT экспорта (см. icalendar.AppendScheduleEvent)
+// — raw_rrule, если он есть (импортирован из внешнего календаря и несёт весь
+// набор RFC 5545 без потерь), иначе собирается из структурированных
+// BYxxx-полей тем же маппингом частоты/дней, что ExpandScheduleModelInWindowUTC.
+// exdates/rdates возвращаются отдельно: в самой строке RRULE их не бывает.
+func scheduleMasterRRULE(sched *model.Schedule) (rrule string, exdates, rdates []time.Time, err error) {
+	rulePB := decodeScheduleRule(sched.Rules)
+	if rulePB == nil {
+		return "", nil, nil, nil
+	}
+
+	loc := time.UTC
+	if sched.TimeZone != "" {
+		if l, lerr := time.LoadLocation(sched.TimeZone); lerr == nil {
+			loc = l
+		}
+	}
+
+	for _, ts := range rulePB.GetExceptions() {
+		exdates = append(exdates, ts.AsTime().In(loc))
+	}
+	for _, ts := range rulePB.GetExdates() {
+		exdates = append(exdates, ts.AsTime().In(loc))
+	}
+	for _, ts := range rulePB.GetRdates() {
+		rdates = append(rdates, ts.AsTime().In(loc))
+	}
+
+	if raw := rulePB.GetRawRrule(); raw != "" {
+		return raw, exdates, rdates, nil
+	}
+
+	freq := calendarutils.FreqDaily
+	switch rulePB.GetFrequency() {
+	case commonpb.RecurrenceFrequency_RECURRENCE_FREQUENCY_WEEKLY:
+		freq = calendarutils.FreqWeekly
+	case commonpb.RecurrenceFrequency_RECURRENCE_FREQUENCY_MONTHLY:
+		freq = calendarutils.FreqMonthly
+	case commonpb.RecurrenceFrequency_RECURRENCE_FREQUENCY_YEARLY:
+		freq = calendarutils.FreqYearly
+	}
+
+	var weekdays []time.Weekday
+	for _, d := range rulePB.GetWeekdays() {
+		if d < 1 || d > 7 {
+			continue
+		}
+		if d == 7 {
+			weekdays = append(weekdays, time.Sunday)
+		} else {
+			weekdays = append(weekdays, time.Weekday(d))
+		}
+	}
+
+	byDay, err := decodeByDayTokens(rulePB.GetByDay())
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("rule.by_day: %w", err)
+	}
+
+	weekStart := time.Monday
+	if wd, ok := rruleWeekday(rulePB.GetWeekStart()); ok {
+		weekStart = wd
+	}
+
+	var until *time.Time
+	if rulePB.GetUntil() != nil {
+		u := rulePB.GetUntil().AsTime()
+		until = &u
+	}
+	var count *int
+	if rulePB.GetCount() > 0 {
+		c := int(rulePB.GetCount())
+		count = &c
+	}
+
+	rule := calendarutils.RecurringRule{
+		Freq:       freq,
+		Interval:   int(rulePB.GetInterval()),
+		Weekdays:   weekdays,
+		Until:      until,
+		Count:      count,
+		ByMonth:    intSlice(rulePB.GetByMonth()),
+		ByMonthDay: intSlice(rulePB.GetByMonthDay()),
+		ByYearDay:  intSlice(rulePB.GetByYearDay()),
+		ByWeekNo:   intSlice(rulePB.GetByWeekNo()),
+		ByDay:      byDay,
+		ByHour:     intSlice(rulePB.GetByHour()),
+		ByMinute:   intSlice(rulePB.GetByMinute()),
+		BySetPos:   intSlice(rulePB.GetBySetPos()),
+		WeekStart:  weekStart,
+	}
+
+	return calendarutils.EncodeRRULE(rule), exdates, rdates, nil
+}
+
 // GetAvailableSlots — alias метода из ТЗ.
 func (s *CalendarService) GetAvailableSlots(
 	ctx context.Context,
@@ -430,17 +797,33 @@ func (s *CalendarService) CreateBooking(
 		}
 	}
 
+	idempotencyKey := req.GetIdempotencyKey()
+	var payloadHash string
+	if idempotencyKey != "" {
+		payloadHash = hashBookingPayload(clientID.String(), req.GetSlotId(), req.GetComment())
+	}
+
 	var resp *calendarpb.CreateBookingResponse
-	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var slot model.TimeSlot
-		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, "id = ?", req.GetSlotId()).Error; err != nil {
-			s.logErr("CreateBooking", err, "stage", "find slot")
-			return status.Errorf(codes.NotFound, "slot not found: %v", err)
-		}
-		if slot.Status != model.TimeSlotStatusPlanned {
-			return status.Error(codes.FailedPrecondition, "slot is not free")
+	if idempotencyKey != "" {
+		err = appdb.WithSerializableRetry(ctx, s.db, func(tx *gorm.DB) error {
+			_, err := s.reuseIdempotentBookingTx(ctx, tx, clientID, idempotencyKey, payloadHash, &resp)
+			return err
+		})
+		if err != nil {
+			return nil, err
 		}
+	}
+	if resp != nil {
+		s.logInfo("CreateBooking", "booking_id", resp.Booking.GetId(), "slot_id", resp.Booking.GetSlotId(), "client_id", resp.Booking.GetClientId(), "idempotent_replay", true)
+		return resp, nil
+	}
 
+	// Слот захватывается через bookingRepo.CreateWithSlotLock (advisory-лок
+	// на Postgres, FOR UPDATE на MySQL/SQLite — см. её doc-comment), а не
+	// напрямую через tx.Clauses(clause.Locking{...}), чтобы конкурентные
+	// CreateBooking на один и тот же slot_id сериализовались одинаково на
+	// всех диалектах, а не только там, где это покрывает SERIALIZABLE.
+	err = s.bookingRepo.CreateWithSlotLock(ctx, req.GetSlotId(), func(tx *gorm.DB, slot *model.TimeSlot) error {
 		// Проверка конфликтов по времени (ТЗ 3.5.1–3.5.2):
 		// - у клиента не должно быть пересекающихся подтверждённых бронирований;
 		// - у провайдера не должно быть пересекающихся подтверждённых бронирований.
@@ -464,6 +847,64 @@ func (s *CalendarService) CreateBooking(
 			return status.Error(codes.FailedPrecondition, "provider has conflicting booking")
 		}
 
+		externalRanges, err := s.listExternalBusyRangesTx(ctx, tx, slot.ProviderID)
+		if err != nil {
+			s.logErr("CreateBooking", err, "stage", "list external busy ranges")
+			return status.Errorf(codes.Internal, "list external busy ranges: %v", err)
+		}
+		if has, _ := calendarutils.HasOverlap(newRange, externalRanges, false); has {
+			return status.Error(codes.FailedPrecondition, "provider has conflicting external calendar event")
+		}
+
+		maintenanceRanges, err := s.activeMaintenanceRanges(ctx, slot.ProviderID, slot.StartsAt.UTC(), slot.EndsAt.UTC())
+		if err != nil {
+			s.logErr("CreateBooking", err, "stage", "list maintenance windows")
+			return status.Errorf(codes.Internal, "list maintenance windows: %v", err)
+		}
+		if has, _ := calendarutils.HasOverlap(newRange, maintenanceRanges, false); has {
+			return status.Error(codes.FailedPrecondition, "provider is under maintenance during this slot")
+		}
+
+		unavailabilityRanges, err := s.activeUnavailabilityRanges(ctx, slot.ProviderID, slot.StartsAt.UTC(), slot.EndsAt.UTC())
+		if err != nil {
+			s.logErr("CreateBooking", err, "stage", "list unavailability periods")
+			return status.Errorf(codes.Internal, "list unavailability periods: %v", err)
+		}
+		if has, _ := calendarutils.HasOverlap(newRange, unavailabilityRanges, false); has {
+			return status.Error(codes.FailedPrecondition, "provider is unavailable during this slot")
+		}
+
+		capacity := slot.Capacity
+		if capacity <= 0 {
+			capacity = 1
+		}
+		if slot.StaffID != nil {
+			busy, err := s.countTupleConfirmedBookingsTx(ctx, tx, *slot.StaffID, true, slot.StartsAt.UTC(), slot.EndsAt.UTC(), slot.ID)
+			if err != nil {
+				s.logErr("CreateBooking", err, "stage", "count staff bookings")
+				return status.Errorf(codes.Internal, "check staff availability: %v", err)
+			}
+			if busy >= capacity {
+				return status.Error(codes.FailedPrecondition, "staff is already booked during this slot")
+			}
+		}
+		if slot.ResourceID != nil {
+			busy, err := s.countTupleConfirmedBookingsTx(ctx, tx, *slot.ResourceID, false, slot.StartsAt.UTC(), slot.EndsAt.UTC(), slot.ID)
+			if err != nil {
+				s.logErr("CreateBooking", err, "stage", "count resource bookings")
+				return status.Errorf(codes.Internal, "check resource availability: %v", err)
+			}
+			if busy >= capacity {
+				return status.Error(codes.FailedPrecondition, "resource is already booked during this slot")
+			}
+		}
+
+		if s.resourceRepo != nil {
+			if err := s.lockAndCheckSlotResourcesTx(ctx, tx, *slot, newRange); err != nil {
+				return err
+			}
+		}
+
 		booking := &model.Booking{
 			ClientID: clientID,
 			SlotID:   slot.ID,
@@ -483,27 +924,113 @@ func (s *CalendarService) CreateBooking(
 			return status.Errorf(codes.Internal, "mark slot booked: %v", err)
 		}
 
+		if idempotencyKey != "" {
+			nsID, err := namespace.Require(ctx)
+			if err != nil {
+				return err
+			}
+			record := &model.BookingIdempotency{
+				NamespaceID:    nsID,
+				ClientID:       clientID,
+				IdempotencyKey: idempotencyKey,
+				PayloadHash:    payloadHash,
+				BookingID:      booking.ID,
+				CreatedAt:      time.Now().UTC(),
+			}
+			if err := tx.Create(record).Error; err != nil {
+				s.logErr("CreateBooking", err, "stage", "store idempotency record")
+				return status.Errorf(codes.Internal, "store idempotency record: %v", err)
+			}
+		}
+
 		resp = &calendarpb.CreateBookingResponse{Booking: s.mapBooking(ctx, booking)}
 		return nil
 	})
 	if err != nil {
+		if errors.Is(err, errs.ErrNotFound("")) {
+			s.logErr("CreateBooking", err, "stage", "find slot")
+			return nil, status.Errorf(codes.NotFound, "slot not found: %v", err)
+		}
+		if errors.Is(err, errs.ErrSlotNotAvailable) {
+			// Тот же idempotency_key мог проиграть гонку за замок слота
+			// легитимному ретраю: оригинальный запрос успел забрать слот и
+			// закоммитить свою запись BookingIdempotency, пока этот ждал
+			// CreateWithSlotLock. Раз слот теперь не planned — перепроверяем
+			// таблицу идемпотентности ещё раз, прежде чем отдавать ошибку:
+			// если запись там уже есть, это не конфликт, а ретрай, и нужно
+			// вернуть тот же booking, что и обычный idempotent replay выше.
+			if idempotencyKey != "" {
+				var replay *calendarpb.CreateBookingResponse
+				replayErr := appdb.WithSerializableRetry(ctx, s.db, func(tx *gorm.DB) error {
+					_, rerr := s.reuseIdempotentBookingTx(ctx, tx, clientID, idempotencyKey, payloadHash, &replay)
+					return rerr
+				})
+				if replayErr == nil && replay != nil {
+					s.logInfo("CreateBooking", "booking_id", replay.Booking.GetId(), "slot_id", replay.Booking.GetSlotId(), "client_id", replay.Booking.GetClientId(), "idempotent_replay", true)
+					return replay, nil
+				}
+			}
+			return nil, status.Error(codes.FailedPrecondition, "slot is not free")
+		}
 		return nil, err
 	}
 
 	if resp != nil && resp.Booking != nil {
 		s.logInfo("CreateBooking", "booking_id", resp.Booking.GetId(), "slot_id", resp.Booking.GetSlotId(), "client_id", resp.Booking.GetClientId())
+		s.pushBookingToExternalCalendar(ctx, resp.Booking.GetId())
+		s.publishBookingEvent(events.BookingEventCreated, resp.Booking.GetProviderId(), resp.Booking.GetId(), resp.Booking.GetSlotId())
 	}
 
 	return resp, nil
 }
 
-// BookSlot — alias метода из ТЗ.
-func (s *CalendarService) BookSlot(
-	ctx context.Context,
-	req *calendarpb.CreateBookingRequest,
-) (*calendarpb.CreateBookingResponse, error) {
-	return s.CreateBooking(ctx, req)
-}
+// pushBookingToExternalCalendar — лучшее-усилие (best-effort) исходящий пуш
+// только что созданного бронирования во внешний CalDAV-календарь провайдера,
+// если он подключён. Ошибки только логируются: бронирование уже зафиксировано
+// в транзакции выше и не должно откатываться из-за недоступности стороннего
+// сервера — полная картина досинхронизируется следующим прогоном caldav.Reconciler.
+func (s *CalendarService) pushBookingToExternalCalendar(ctx context.Context, bookingID string) {
+	if s.calendarIntegrationRepo == nil {
+		return
+	}
+	booking, err := s.bookingRepo.GetByID(ctx, bookingID)
+	if err != nil {
+		s.logErr("CreateBooking", err, "stage", "push to external calendar: get booking", "booking_id", bookingID)
+		return
+	}
+	slot, err := s.slotRepo.GetByID(ctx, booking.SlotID.String())
+	if err != nil {
+		s.logErr("CreateBooking", err, "stage", "push to external calendar: get slot", "booking_id", bookingID)
+		return
+	}
+	booking.Slot = slot
+
+	ci, err := s.calendarIntegrationRepo.GetByProviderID(ctx, slot.ProviderID.String())
+	if err != nil {
+		if !errors.Is(err, errs.ErrNotFound("")) {
+			s.logErr("CreateBooking", err, "stage", "push to external calendar: get integration", "booking_id", bookingID)
+		}
+		return
+	}
+
+	ics, err := icalendar.RenderBookingEvent(booking, time.UTC)
+	if err != nil {
+		s.logErr("CreateBooking", err, "stage", "push to external calendar: render", "booking_id", bookingID)
+		return
+	}
+	client := caldav.NewClient(ci.CalendarURL, ci.Username, ci.Password)
+	if err := client.PutEvent(ctx, booking.ID.String(), ics); err != nil {
+		s.logErr("CreateBooking", err, "stage", "push to external calendar: put", "booking_id", bookingID, "integration_id", ci.ID.String())
+	}
+}
+
+// BookSlot — alias метода из ТЗ.
+func (s *CalendarService) BookSlot(
+	ctx context.Context,
+	req *calendarpb.CreateBookingRequest,
+) (*calendarpb.CreateBookingResponse, error) {
+	return s.CreateBooking(ctx, req)
+}
 
 func (s *CalendarService) CheckAvailability(
 	ctx context.Context,
@@ -530,6 +1057,12 @@ func (s *CalendarService) CheckAvailability(
 	if err != nil {
 		return &calendarpb.CheckAvailabilityResponse{Available: false, Reason: "slot not found"}, nil
 	}
+	if slot.Status == model.TimeSlotStatusBlocked {
+		return &calendarpb.CheckAvailabilityResponse{Available: false, Reason: "provider is under maintenance during this slot"}, nil
+	}
+	if slot.Status == model.TimeSlotStatusUnavailable {
+		return &calendarpb.CheckAvailabilityResponse{Available: false, Reason: "provider is unavailable during this slot"}, nil
+	}
 	if slot.Status != model.TimeSlotStatusPlanned {
 		return &calendarpb.CheckAvailabilityResponse{Available: false, Reason: "slot is not free"}, nil
 	}
@@ -554,11 +1087,34 @@ func (s *CalendarService) CheckAvailability(
 		if has, _ := calendarutils.HasOverlap(newRange, providerRanges, false); has {
 			return &calendarpb.CheckAvailabilityResponse{Available: false, Reason: "provider has conflicting booking"}, nil
 		}
+		// Окно обслуживания могло появиться уже после материализации слота.
+		maintenanceRanges, err := s.activeMaintenanceRanges(ctx, slot.ProviderID, slot.StartsAt.UTC(), slot.EndsAt.UTC())
+		if err != nil {
+			s.logErr("CheckAvailability", err, "stage", "maintenance windows")
+			return nil, status.Errorf(codes.Internal, "check conflicts: %v", err)
+		}
+		if has, _ := calendarutils.HasOverlap(newRange, maintenanceRanges, false); has {
+			return &calendarpb.CheckAvailabilityResponse{Available: false, Reason: "provider is under maintenance during this slot"}, nil
+		}
+		// Период недоступности тоже мог появиться уже после материализации слота.
+		unavailabilityRanges, err := s.activeUnavailabilityRanges(ctx, slot.ProviderID, slot.StartsAt.UTC(), slot.EndsAt.UTC())
+		if err != nil {
+			s.logErr("CheckAvailability", err, "stage", "unavailability periods")
+			return nil, status.Errorf(codes.Internal, "check conflicts: %v", err)
+		}
+		if has, _ := calendarutils.HasOverlap(newRange, unavailabilityRanges, false); has {
+			return &calendarpb.CheckAvailabilityResponse{Available: false, Reason: "provider is unavailable during this slot"}, nil
+		}
 	}
 
 	return &calendarpb.CheckAvailabilityResponse{Available: true}, nil
 }
 
+// ExpandSchedule — дай-ран превью правила расписания по окну без записи
+// слотов (своего рода PreviewRecurrence для model.Schedule: DTSTART/duration/
+// timezone уже хранятся в Schedule/Schedule.Rules — см.
+// encodeScheduleRule/decodeScheduleRule и RawRrule в RFC 5545 виде). Реальную
+// запись того же окна делает MaterializeProviderSchedule.
 func (s *CalendarService) ExpandSchedule(
 	ctx context.Context,
 	req *calendarpb.ExpandScheduleRequest,
@@ -588,7 +1144,7 @@ func (s *CalendarService) ExpandSchedule(
 		return nil, status.Error(codes.InvalidArgument, "window_end must be after window_start")
 	}
 
-	intervals, err := s.expandScheduleModelInWindowUTC(sched, fromUTC, toUTC)
+	intervals, err := ExpandScheduleModelInWindowUTC(sched, fromUTC, toUTC)
 	if err != nil {
 		s.logErr("ExpandSchedule", err, "stage", "expand rule")
 		return nil, status.Errorf(codes.InvalidArgument, "expand rule: %v", err)
@@ -605,6 +1161,205 @@ func (s *CalendarService) ExpandSchedule(
 	return resp, nil
 }
 
+// sliceIntoBookableSlots нарезает каждое вхождение правила расписания (из
+// ExpandScheduleModelInWindowUTC — как правило, один протяжённый рабочий
+// блок на вхождение, например "понедельник 09:00–17:00") на последовательные
+// слоты длиной slotDuration с перерывом breakDuration между ними. Последний
+// неполный остаток блока (короче slotDuration) отбрасывается.
+func sliceIntoBookableSlots(occurrences []calendarutils.TimeRange, slotDuration, breakDuration time.Duration) []calendarutils.TimeRange {
+	if slotDuration <= 0 {
+		return nil
+	}
+	var slots []calendarutils.TimeRange
+	for _, occ := range occurrences {
+		cursor := occ.Start
+		for {
+			end := cursor.Add(slotDuration)
+			if end.After(occ.End) {
+				break
+			}
+			slots = append(slots, calendarutils.TimeRange{Start: cursor, End: end})
+			cursor = end.Add(breakDuration)
+		}
+	}
+	return slots
+}
+
+// ExpandProviderSchedule превью: разбивает каждое вхождение правила
+// расписания на бронируемые слоты длиной slot_duration_min с перерывом
+// break_min, не сохраняя ничего в БД. То, что вернёт этот вызов для данного
+// range — ровно то, что создаст MaterializeProviderSchedule (минус конфликты
+// с уже существующими слотами/бронированиями, которые превью не проверяет).
+func (s *CalendarService) ExpandProviderSchedule(
+	ctx context.Context,
+	req *calendarpb.ExpandProviderScheduleRequest,
+) (*calendarpb.ExpandProviderScheduleResponse, error) {
+	if req.GetScheduleId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "schedule_id is required")
+	}
+	if req.GetRange() == nil || req.GetRange().GetStart() == nil || req.GetRange().GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "range is required")
+	}
+	if req.GetSlotDurationMin() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "slot_duration_min must be positive")
+	}
+
+	sched, err := s.scheduleRepo.GetByID(ctx, req.GetScheduleId())
+	if err != nil {
+		s.logErr("ExpandProviderSchedule", err, "stage", "get schedule", "schedule_id", req.GetScheduleId())
+		return nil, status.Errorf(codes.NotFound, "schedule not found: %v", err)
+	}
+	if err := s.ensureProviderRole(ctx, sched.ProviderID.String()); err != nil {
+		return nil, err
+	}
+
+	fromUTC := req.GetRange().GetStart().AsTime().UTC()
+	toUTC := req.GetRange().GetEnd().AsTime().UTC()
+	if !toUTC.After(fromUTC) {
+		return nil, status.Error(codes.InvalidArgument, "range end must be after start")
+	}
+
+	occurrences, err := ExpandScheduleModelInWindowUTC(sched, fromUTC, toUTC)
+	if err != nil {
+		s.logErr("ExpandProviderSchedule", err, "stage", "expand rule")
+		return nil, status.Errorf(codes.InvalidArgument, "expand rule: %v", err)
+	}
+
+	slots := sliceIntoBookableSlots(
+		occurrences,
+		time.Duration(req.GetSlotDurationMin())*time.Minute,
+		time.Duration(req.GetBreakMin())*time.Minute,
+	)
+
+	resp := &calendarpb.ExpandProviderScheduleResponse{Slots: make([]*commonpb.TimeRange, 0, len(slots))}
+	for _, sl := range slots {
+		resp.Slots = append(resp.Slots, &commonpb.TimeRange{
+			Start: timestamppb.New(sl.Start),
+			End:   timestamppb.New(sl.End),
+		})
+	}
+
+	return resp, nil
+}
+
+// MaterializeProviderSchedule — то же нарезание, что и ExpandProviderSchedule,
+// но с сохранением результата через slotRepo.Create (если не dry_run). Слоты,
+// пересекающиеся с уже существующими слотами провайдера или с подтверждёнными
+// бронированиями (см. listProviderConfirmedBookingRangesTx), пропускаются —
+// это инструмент массовой генерации слотов из правила, а не перезапись
+// расписания "с нуля" (для этого есть RebuildProviderCalendar).
+func (s *CalendarService) MaterializeProviderSchedule(
+	ctx context.Context,
+	req *calendarpb.MaterializeProviderScheduleRequest,
+) (*calendarpb.MaterializeProviderScheduleResponse, error) {
+	if req.GetScheduleId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "schedule_id is required")
+	}
+	if req.GetRange() == nil || req.GetRange().GetStart() == nil || req.GetRange().GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "range is required")
+	}
+	if req.GetSlotDurationMin() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "slot_duration_min must be positive")
+	}
+
+	sched, err := s.scheduleRepo.GetByID(ctx, req.GetScheduleId())
+	if err != nil {
+		s.logErr("MaterializeProviderSchedule", err, "stage", "get schedule", "schedule_id", req.GetScheduleId())
+		return nil, status.Errorf(codes.NotFound, "schedule not found: %v", err)
+	}
+	if err := s.ensureProviderRole(ctx, sched.ProviderID.String()); err != nil {
+		return nil, err
+	}
+
+	var serviceID *uuid.UUID
+	if req.GetServiceId() != "" {
+		id, err := uuid.Parse(req.GetServiceId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid service_id")
+		}
+		serviceID = &id
+	}
+
+	fromUTC := req.GetRange().GetStart().AsTime().UTC()
+	toUTC := req.GetRange().GetEnd().AsTime().UTC()
+	if !toUTC.After(fromUTC) {
+		return nil, status.Error(codes.InvalidArgument, "range end must be after start")
+	}
+
+	occurrences, err := ExpandScheduleModelInWindowUTC(sched, fromUTC, toUTC)
+	if err != nil {
+		s.logErr("MaterializeProviderSchedule", err, "stage", "expand rule")
+		return nil, status.Errorf(codes.InvalidArgument, "expand rule: %v", err)
+	}
+	candidates := sliceIntoBookableSlots(
+		occurrences,
+		time.Duration(req.GetSlotDurationMin())*time.Minute,
+		time.Duration(req.GetBreakMin())*time.Minute,
+	)
+
+	existing, err := s.slotRepo.ListOverlapping(ctx, sched.ProviderID.String(), fromUTC, toUTC, "")
+	if err != nil {
+		s.logErr("MaterializeProviderSchedule", err, "stage", "list existing slots")
+		return nil, status.Errorf(codes.Internal, "materialize schedule: %v", err)
+	}
+	existingRanges := make([]calendarutils.TimeRange, 0, len(existing))
+	for _, sl := range existing {
+		existingRanges = append(existingRanges, calendarutils.TimeRange{Start: sl.StartsAt, End: sl.EndsAt})
+	}
+
+	confirmedBookings, err := s.listProviderConfirmedBookingRangesTx(ctx, s.db, sched.ProviderID, uuid.Nil)
+	if err != nil {
+		s.logErr("MaterializeProviderSchedule", err, "stage", "list confirmed bookings")
+		return nil, status.Errorf(codes.Internal, "materialize schedule: %v", err)
+	}
+
+	created := make([]*commonpb.TimeRange, 0, len(candidates))
+	var skipped int32
+	for _, c := range candidates {
+		if has, _ := calendarutils.HasOverlap(c, existingRanges, false); has {
+			skipped++
+			continue
+		}
+		if has, _ := calendarutils.HasOverlap(c, confirmedBookings, false); has {
+			skipped++
+			continue
+		}
+
+		if req.GetDryRun() {
+			created = append(created, &commonpb.TimeRange{Start: timestamppb.New(c.Start), End: timestamppb.New(c.End)})
+			continue
+		}
+
+		schedID := sched.ID
+		slot := model.TimeSlot{
+			ScheduleID: &schedID,
+			ProviderID: sched.ProviderID,
+			ServiceID:  serviceID,
+			StartsAt:   c.Start,
+			EndsAt:     c.End,
+			Status:     model.TimeSlotStatusPlanned,
+		}
+		if err := s.slotRepo.Create(ctx, &slot); err != nil {
+			if errors.Is(err, errs.ErrSlotOverlap) {
+				skipped++
+				continue
+			}
+			s.logErr("MaterializeProviderSchedule", err, "stage", "create slot")
+			return nil, status.Errorf(codes.Internal, "materialize schedule: %v", err)
+		}
+		existingRanges = append(existingRanges, c)
+		created = append(created, &commonpb.TimeRange{Start: timestamppb.New(c.Start), End: timestamppb.New(c.End)})
+	}
+
+	s.logInfo("MaterializeProviderSchedule", "schedule_id", req.GetScheduleId(), "dry_run", req.GetDryRun(),
+		"created", len(created), "skipped", skipped)
+
+	return &calendarpb.MaterializeProviderScheduleResponse{
+		CreatedSlots: created,
+		SkippedCount: skipped,
+	}, nil
+}
+
 func (s *CalendarService) ValidateSlot(
 	ctx context.Context,
 	req *calendarpb.ValidateSlotRequest,
@@ -641,7 +1396,7 @@ func (s *CalendarService) GetNearestFreeSlot(
 		return nil, status.Error(codes.InvalidArgument, "until must be after from")
 	}
 
-	slots, _, err := s.slotRepo.ListFreeSlots(ctx, req.GetProviderId(), req.GetServiceId(), from, until, 1, 0)
+	slots, _, err := s.slotRepo.ListFreeSlots(ctx, req.GetProviderId(), req.GetServiceId(), nil, from, until, 1, 0)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "list slots: %v", err)
 	}
@@ -672,7 +1427,7 @@ func (s *CalendarService) GetNextProviderSlot(
 		return nil, status.Error(codes.InvalidArgument, "until must be after from")
 	}
 
-	slots, _, err := s.slotRepo.ListFreeSlots(ctx, req.GetProviderId(), req.GetServiceId(), from, until, 1, 0)
+	slots, _, err := s.slotRepo.ListFreeSlots(ctx, req.GetProviderId(), req.GetServiceId(), nil, from, until, 1, 0)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "list slots: %v", err)
 	}
@@ -701,7 +1456,7 @@ func (s *CalendarService) FindFreeSlots(
 		limit = 5
 	}
 
-	slots, _, err := s.slotRepo.ListFreeSlots(ctx, req.GetProviderId(), req.GetServiceId(), start, end, limit, 0)
+	slots, _, err := s.slotRepo.ListFreeSlots(ctx, req.GetProviderId(), req.GetServiceId(), nil, start, end, limit, 0)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "list slots: %v", err)
 	}
@@ -742,6 +1497,58 @@ type dbTimeRange struct {
 	EndsAt   time.Time `gorm:"column:ends_at"`
 }
 
+// reuseIdempotentBookingTx ищет запись об уже обработанном запросе с тем же
+// (client_id, idempotency_key) (см. model.BookingIdempotency). Блокирует
+// найденную строку FOR UPDATE, чтобы не состязаться с параллельным ретраем
+// того же ключа в соседней SERIALIZABLE-транзакции. Возвращает handled=true,
+// если вызывающему коду (CreateBooking) больше ничего делать не нужно: либо
+// *resp уже заполнен исходным бронированием, либо возвращённая ошибка —
+// AlreadyExists из-за переиспользования ключа с другим payload.
+func (s *CalendarService) reuseIdempotentBookingTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	clientID uuid.UUID,
+	idempotencyKey string,
+	payloadHash string,
+	resp **calendarpb.CreateBookingResponse,
+) (bool, error) {
+	var existing model.BookingIdempotency
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("client_id = ? AND idempotency_key = ?", clientID, idempotencyKey).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, status.Errorf(codes.Internal, "check idempotency key: %v", err)
+	}
+
+	if time.Since(existing.CreatedAt) > bookingIdempotencyTTL {
+		return false, nil
+	}
+
+	if existing.PayloadHash != payloadHash {
+		return true, status.Errorf(codes.AlreadyExists,
+			"idempotency key already used with a different request, original booking_id=%s", existing.BookingID)
+	}
+
+	var booking model.Booking
+	if err := tx.First(&booking, "id = ?", existing.BookingID).Error; err != nil {
+		return true, status.Errorf(codes.Internal, "load original booking: %v", err)
+	}
+	*resp = &calendarpb.CreateBookingResponse{Booking: s.mapBooking(ctx, &booking)}
+	return true, nil
+}
+
+// hashBookingPayload — каноническое представление полей CreateBookingRequest,
+// влияющих на результат бронирования, хэшируется, чтобы отличить легитимный
+// ретрай (тот же payload под тем же ключом) от переиспользования ключа для
+// другого запроса.
+func hashBookingPayload(clientID, slotID, comment string) string {
+	sum := sha256.Sum256([]byte(clientID + "\x00" + slotID + "\x00" + comment))
+	return hex.EncodeToString(sum[:])
+}
+
 func (s *CalendarService) listClientConfirmedBookingRangesTx(
 	ctx context.Context,
 	tx *gorm.DB,
@@ -800,6 +1607,126 @@ func (s *CalendarService) listProviderConfirmedBookingRangesTx(
 	return res, nil
 }
 
+// countTupleConfirmedBookingsTx считает подтверждённые бронирования других
+// слотов, пересекающихся по времени с [start, end) и закреплённых за тем же
+// сотрудником/ресурсом (staffOrResourceID) — т.е. занятость того же зубца
+// кортежа (provider, service, staff, resource), что и у SlotKey из Google
+// Maps Booking. Используется и при бронировании (CreateBooking), и при
+// генерации слотов (BulkCreateSlots), чтобы общий сотрудник/кабинет двух
+// разных услуг не получил больше одновременных занятых слотов, чем
+// позволяет TimeSlot.Capacity.
+func (s *CalendarService) countTupleConfirmedBookingsTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	staffOrResourceID uuid.UUID,
+	byStaff bool,
+	start, end time.Time,
+	excludeSlotID uuid.UUID,
+) (int, error) {
+	if tx == nil {
+		return 0, nil
+	}
+	column := "time_slots.resource_id"
+	if byStaff {
+		column = "time_slots.staff_id"
+	}
+	var count int64
+	err := tx.WithContext(ctx).
+		Table("bookings").
+		Joins("JOIN time_slots ON time_slots.id = bookings.slot_id").
+		Where(column+" = ?", staffOrResourceID).
+		Where("bookings.status = ?", model.BookingStatusConfirmed).
+		Where("time_slots.id <> ?", excludeSlotID).
+		Where("time_slots.starts_at < ? AND time_slots.ends_at > ?", end, start).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+	return int(count), nil
+}
+
+// lockAndCheckSlotResourcesTx блокирует (SELECT ... FOR UPDATE) ресурсы,
+// закреплённые за слотом (см. model.TimeSlotResource), и проверяет, что ни
+// один из них не занят уже подтверждённым бронированием другого слота в
+// пересекающийся интервал. Ресурсов у слота может не быть вовсе — тогда
+// шаг пропускается (обратная совместимость со слотами, созданными до
+// появления multi-resource бронирования).
+func (s *CalendarService) lockAndCheckSlotResourcesTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	slot model.TimeSlot,
+	newRange calendarutils.TimeRange,
+) error {
+	var resourceIDs []uuid.UUID
+	if err := tx.WithContext(ctx).
+		Model(&model.TimeSlotResource{}).
+		Where("slot_id = ?", slot.ID).
+		Pluck("resource_id", &resourceIDs).Error; err != nil {
+		return status.Errorf(codes.Internal, "list slot resources: %v", err)
+	}
+	if len(resourceIDs) == 0 {
+		return nil
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", resourceIDs).
+		Find(&[]model.Resource{}).Error; err != nil {
+		return status.Errorf(codes.Internal, "lock resources: %v", err)
+	}
+
+	var rows []dbTimeRange
+	err := tx.WithContext(ctx).
+		Table("bookings").
+		Select("time_slots.starts_at AS starts_at, time_slots.ends_at AS ends_at").
+		Joins("JOIN time_slots ON time_slots.id = bookings.slot_id").
+		Joins("JOIN time_slot_resources ON time_slot_resources.slot_id = time_slots.id").
+		Where("time_slot_resources.resource_id IN ?", resourceIDs).
+		Where("bookings.status = ?", model.BookingStatusConfirmed).
+		Where("time_slots.id <> ?", slot.ID).
+		Group("time_slots.id, time_slots.starts_at, time_slots.ends_at").
+		Scan(&rows).Error
+	if err != nil {
+		return status.Errorf(codes.Internal, "list resource busy ranges: %v", err)
+	}
+	busyRanges := make([]calendarutils.TimeRange, 0, len(rows))
+	for _, r := range rows {
+		busyRanges = append(busyRanges, calendarutils.TimeRange{Start: r.StartsAt.UTC(), End: r.EndsAt.UTC()})
+	}
+	if has, _ := calendarutils.HasOverlap(newRange, busyRanges, false); has {
+		return status.Error(codes.FailedPrecondition, "required resource is already booked during this slot")
+	}
+	return nil
+}
+
+// listExternalBusyRangesTx возвращает занятые интервалы провайдера, которые
+// пришли из подключённого внешнего CalDAV-календаря (см. caldav.Reconciler),
+// чтобы CreateBooking учитывал их наравне с обычными подтверждёнными
+// бронированиями при проверке пересечений.
+func (s *CalendarService) listExternalBusyRangesTx(
+	ctx context.Context,
+	tx *gorm.DB,
+	providerID uuid.UUID,
+) ([]calendarutils.TimeRange, error) {
+	if tx == nil {
+		return []calendarutils.TimeRange{}, nil
+	}
+	var rows []dbTimeRange
+	err := tx.WithContext(ctx).
+		Table("calendar_busy_ranges").
+		Select("starts_at, ends_at").
+		Where("provider_id = ?", providerID).
+		Order("starts_at ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make([]calendarutils.TimeRange, 0, len(rows))
+	for _, r := range rows {
+		res = append(res, calendarutils.TimeRange{Start: r.StartsAt.UTC(), End: r.EndsAt.UTC()})
+	}
+	return res, nil
+}
+
 // GetBooking — получить бронирование.
 func (s *CalendarService) GetBooking(
 	ctx context.Context,
@@ -835,7 +1762,13 @@ func (s *CalendarService) CancelBooking(
 		return &calendarpb.CancelBookingResponse{Booking: mapBooking(booking)}, nil
 	}
 
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var resp *calendarpb.CancelBookingResponse
+	var freedProviderID uuid.UUID
 	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		now := time.Now().UTC()
 		if err := tx.Model(&model.Booking{}).
@@ -844,11 +1777,39 @@ func (s *CalendarService) CancelBooking(
 			return status.Errorf(codes.Internal, "cancel booking: %v", err)
 		}
 
+		var slot model.TimeSlot
+		if err := tx.First(&slot, "id = ?", booking.SlotID).Error; err != nil {
+			return status.Errorf(codes.Internal, "find slot: %v", err)
+		}
 		if err := tx.Model(&model.TimeSlot{}).
 			Where("id = ?", booking.SlotID).
 			Update("status", model.TimeSlotStatusPlanned).Error; err != nil {
 			return status.Errorf(codes.Internal, "free slot: %v", err)
 		}
+		freedProviderID = slot.ProviderID
+
+		var recipient struct {
+			ClientUserID     string `gorm:"column:user_id"`
+			ClientTelegramID int64  `gorm:"column:telegram_id"`
+		}
+		if err := tx.Table("clients").
+			Select("clients.user_id AS user_id, users.telegram_id AS telegram_id").
+			Joins("JOIN users ON users.id = clients.user_id").
+			Where("clients.id = ?", booking.ClientID).
+			Scan(&recipient).Error; err != nil {
+			return status.Errorf(codes.Internal, "find client recipient: %v", err)
+		}
+		if err := writeOutboxEvent(tx, nsID, booking.ID, model.NotificationEventBookingCancelled, notify.OutboxPayload{
+			ClientUserID:     recipient.ClientUserID,
+			ClientTelegramID: recipient.ClientTelegramID,
+			ProviderID:       slot.ProviderID.String(),
+			SlotID:           slot.ID.String(),
+			BookingID:        booking.ID.String(),
+			StartsAt:         slot.StartsAt,
+			EndsAt:           slot.EndsAt,
+		}); err != nil {
+			return status.Errorf(codes.Internal, "write outbox event: %v", err)
+		}
 
 		booking.Status = model.BookingStatusCancelled
 		booking.CancelledAt = &now
@@ -859,6 +1820,18 @@ func (s *CalendarService) CancelBooking(
 		return nil, err
 	}
 
+	// Отменённое бронирование освободило слот — лист ожидания провайдера мог
+	// как раз его ждать. Лучшее усилие: ошибка здесь не откатывает отмену,
+	// очередной прогон scheduler.WaitlistPromoter всё равно его подберёт.
+	if freedProviderID != uuid.Nil {
+		if _, perr := s.PromoteWaitlistForProvider(ctx, freedProviderID); perr != nil {
+			s.logErr("CancelBooking", perr, "stage", "promote waitlist", "provider_id", freedProviderID)
+		}
+		if resp != nil && resp.Booking != nil {
+			s.publishBookingEvent(events.BookingEventCancelled, freedProviderID.String(), resp.Booking.GetId(), resp.Booking.GetSlotId())
+		}
+	}
+
 	return resp, nil
 }
 
@@ -905,33 +1878,528 @@ func (s *CalendarService) ListBookings(
 	return resp, nil
 }
 
-// ListProviderSchedules — вернуть расписания провайдера.
-func (s *CalendarService) ListProviderSchedules(
+// errWaitlistNoSlot сигнализирует tryPromoteWaitlistEntry, что для записи
+// сейчас нет подходящего свободного слота — не ошибка выполнения, а обычный
+// повод перейти к следующей записи очереди, поэтому наружу не всплывает.
+var errWaitlistNoSlot = errors.New("waitlist: no matching slot available")
+
+// JoinWaitlist ставит клиента в лист ожидания на желаемое окно провайдера
+// (опционально — конкретной услуги). Запись продвигается промоутером (см.
+// PromoteWaitlistForProvider) в порядке priority DESC, затем FIFO.
+func (s *CalendarService) JoinWaitlist(
 	ctx context.Context,
-	req *calendarpb.ListProviderSchedulesRequest,
-) (*calendarpb.ListProviderSchedulesResponse, error) {
-	if req.GetProviderId() == "" {
-		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	req *calendarpb.JoinWaitlistRequest,
+) (*calendarpb.JoinWaitlistResponse, error) {
+	if req.GetClientId() == "" || req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id and provider_id are required")
+	}
+	if s.waitlistRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "waitlist is not configured")
 	}
 
-	schedules, err := s.scheduleRepo.ListByProvider(ctx, req.GetProviderId())
+	clientID, err := uuid.Parse(req.GetClientId())
 	if err != nil {
-		s.logErr("ListProviderSchedules", err, "stage", "list schedules", "provider_id", req.GetProviderId())
-		return nil, status.Errorf(codes.Internal, "list schedules: %v", err)
+		return nil, status.Error(codes.InvalidArgument, "invalid client_id")
+	}
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+	if req.GetDesiredStart() == nil || req.GetDesiredEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "desired_start and desired_end are required")
+	}
+	start := req.GetDesiredStart().AsTime().UTC()
+	end := req.GetDesiredEnd().AsTime().UTC()
+	if !end.After(start) {
+		return nil, status.Error(codes.InvalidArgument, "desired_end must be after desired_start")
 	}
 
-	resp := &calendarpb.ListProviderSchedulesResponse{
-		Schedules: make([]*commonpb.ProviderSchedule, 0, len(schedules)),
+	entry := &model.Waitlist{
+		ClientID:     clientID,
+		ProviderID:   providerID,
+		DesiredStart: start,
+		DesiredEnd:   end,
+		Priority:     int(req.GetPriority()),
+		Status:       model.WaitlistStatusActive,
 	}
-	for i := range schedules {
-		resp.Schedules = append(resp.Schedules, mapProviderSchedule(&schedules[i]))
+	if sid := req.GetServiceId(); sid != "" {
+		id, err := uuid.Parse(sid)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid service_id")
+		}
+		entry.ServiceID = &id
+	}
+	if req.GetExpiresAt() != nil {
+		exp := req.GetExpiresAt().AsTime().UTC()
+		entry.ExpiresAt = &exp
 	}
 
-	return resp, nil
-}
+	if err := s.waitlistRepo.Create(ctx, entry); err != nil {
+		s.logErr("JoinWaitlist", err, "client_id", req.GetClientId(), "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "join waitlist: %v", err)
+	}
 
-// CreateProviderSchedule — создать расписание провайдера.
-func (s *CalendarService) CreateProviderSchedule(
+	s.logInfo("JoinWaitlist", "waitlist_id", entry.ID, "client_id", entry.ClientID, "provider_id", entry.ProviderID)
+
+	// Желаемое окно могло уже перекрывать свободный слот — пробуем сразу,
+	// не дожидаясь события бронирования/материализации.
+	if _, perr := s.PromoteWaitlistForProvider(ctx, providerID); perr != nil {
+		s.logErr("JoinWaitlist", perr, "stage", "promote waitlist", "provider_id", providerID)
+	}
+
+	return &calendarpb.JoinWaitlistResponse{Id: entry.ID.String()}, nil
+}
+
+// LeaveWaitlist отменяет запись клиента в листе ожидания. Если к моменту
+// отмены записи уже предложен слот (WaitlistStatusOffered), удержание с него
+// снимается в той же транзакции, чтобы не ждать TTL зря — см. reclaimExpiredOffers.
+func (s *CalendarService) LeaveWaitlist(
+	ctx context.Context,
+	req *calendarpb.LeaveWaitlistRequest,
+) (*calendarpb.LeaveWaitlistResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if s.waitlistRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "waitlist is not configured")
+	}
+
+	entry, err := s.waitlistRepo.GetByID(ctx, req.GetId())
+	if err != nil {
+		s.logErr("LeaveWaitlist", err, "id", req.GetId())
+		return nil, status.Errorf(codes.NotFound, "waitlist entry: %v", err)
+	}
+
+	if entry.Status == model.WaitlistStatusOffered && entry.HeldSlotID != nil {
+		err = appdb.WithSerializableRetry(ctx, s.db, func(tx *gorm.DB) error {
+			if err := tx.Model(&model.TimeSlot{}).
+				Where("id = ? AND status = ?", *entry.HeldSlotID, model.TimeSlotStatusBooked).
+				Update("status", model.TimeSlotStatusPlanned).Error; err != nil {
+				return err
+			}
+			return tx.Model(&model.Waitlist{}).
+				Where("id = ?", entry.ID).
+				Updates(map[string]any{
+					"status":           model.WaitlistStatusCancelled,
+					"held_slot_id":     nil,
+					"offer_expires_at": nil,
+				}).Error
+		})
+	} else {
+		err = s.waitlistRepo.Cancel(ctx, req.GetId())
+	}
+	if err != nil {
+		s.logErr("LeaveWaitlist", err, "id", req.GetId())
+		return nil, status.Errorf(codes.Internal, "leave waitlist: %v", err)
+	}
+
+	if entry.Status == model.WaitlistStatusOffered {
+		if _, perr := s.PromoteWaitlistForProvider(ctx, entry.ProviderID); perr != nil {
+			s.logErr("LeaveWaitlist", perr, "stage", "promote waitlist", "provider_id", entry.ProviderID)
+		}
+	}
+
+	return &calendarpb.LeaveWaitlistResponse{}, nil
+}
+
+// ListWaitlist — записи клиента в листах ожидания (история и активные).
+func (s *CalendarService) ListWaitlist(
+	ctx context.Context,
+	req *calendarpb.ListWaitlistRequest,
+) (*calendarpb.ListWaitlistResponse, error) {
+	if req.GetClientId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "client_id is required")
+	}
+	if s.waitlistRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "waitlist is not configured")
+	}
+	entries, err := s.waitlistRepo.ListByClient(ctx, req.GetClientId())
+	if err != nil {
+		s.logErr("ListWaitlist", err, "client_id", req.GetClientId())
+		return nil, status.Errorf(codes.Internal, "list waitlist: %v", err)
+	}
+	out := make([]*calendarpb.WaitlistEntry, 0, len(entries))
+	for i := range entries {
+		position := 0
+		if entries[i].Status == model.WaitlistStatusActive {
+			if pos, err := s.waitlistRepo.PositionInQueue(ctx, entries[i].ID.String()); err == nil {
+				position = pos
+			}
+		}
+		out = append(out, mapWaitlistEntry(&entries[i], position))
+	}
+	return &calendarpb.ListWaitlistResponse{Entries: out}, nil
+}
+
+// PeekWaitlistPosition возвращает 1-based место записи в очереди провайдера
+// (см. repository.WaitlistRepository.PositionInQueue), без попытки продвижения.
+func (s *CalendarService) PeekWaitlistPosition(
+	ctx context.Context,
+	req *calendarpb.PeekWaitlistPositionRequest,
+) (*calendarpb.PeekWaitlistPositionResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if s.waitlistRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "waitlist is not configured")
+	}
+	pos, err := s.waitlistRepo.PositionInQueue(ctx, req.GetId())
+	if err != nil {
+		s.logErr("PeekWaitlistPosition", err, "id", req.GetId())
+		return nil, status.Errorf(codes.NotFound, "waitlist entry not found: %v", err)
+	}
+	return &calendarpb.PeekWaitlistPositionResponse{Position: int32(pos)}, nil
+}
+
+// waitlistOfferTTL — время, в течение которого клиент должен подтвердить
+// предложенный ему слот (ConfirmWaitlistOffer), прежде чем удержание снимут
+// и предложение уйдёт следующей записи очереди.
+const waitlistOfferTTL = 15 * time.Minute
+
+// PromoteWaitlistForProvider сначала освобождает слоты по истёкшим
+// предложениям (см. reclaimExpiredOffers), затем просматривает активные
+// записи листа ожидания providerID в порядке очереди (priority DESC, затем
+// FIFO) и для каждой пытается удержать освободившийся слот внутри её
+// желаемого окна. Вызывается best-effort сразу после CancelBooking/
+// BulkCancelProviderSlots и периодически — как страховочный backstop —
+// scheduler.WaitlistPromoter. Возвращает число записей, которым в этом
+// прогоне предложен слот (WaitlistStatusOffered), не число бронирований —
+// бронирование появляется только после ConfirmWaitlistOffer.
+func (s *CalendarService) PromoteWaitlistForProvider(ctx context.Context, providerID uuid.UUID) (int, error) {
+	if s.waitlistRepo == nil {
+		return 0, nil
+	}
+
+	if _, err := s.reclaimExpiredOffers(ctx, providerID); err != nil {
+		s.logErr("PromoteWaitlist", err, "stage", "reclaim expired offers", "provider_id", providerID)
+	}
+
+	entries, err := s.waitlistRepo.ListActiveForProvider(ctx, providerID.String())
+	if err != nil {
+		return 0, fmt.Errorf("list waitlist: %w", err)
+	}
+
+	offered := 0
+	for i := range entries {
+		ok, err := s.tryPromoteWaitlistEntry(ctx, &entries[i])
+		if err != nil {
+			s.logErr("PromoteWaitlist", err, "waitlist_id", entries[i].ID)
+			continue
+		}
+		if ok {
+			offered++
+		}
+	}
+	return offered, nil
+}
+
+// reclaimExpiredOffers освобождает слоты, удержанные по предложениям, чей
+// OfferExpiresAt уже прошёл: слот возвращается в TimeSlotStatusPlanned (и тем
+// же прогоном PromoteWaitlistForProvider может быть предложен следующей
+// записи очереди), а запись переводится в WaitlistStatusExpired. Возвращает
+// число освобождённых предложений.
+func (s *CalendarService) reclaimExpiredOffers(ctx context.Context, providerID uuid.UUID) (int, error) {
+	expired, err := s.waitlistRepo.ListExpiredOffers(ctx, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("list expired offers: %w", err)
+	}
+
+	reclaimed := 0
+	for i := range expired {
+		entry := &expired[i]
+		if entry.ProviderID != providerID || entry.HeldSlotID == nil {
+			continue
+		}
+		err := appdb.WithSerializableRetry(ctx, s.db, func(tx *gorm.DB) error {
+			if err := tx.Model(&model.TimeSlot{}).
+				Where("id = ? AND status = ?", *entry.HeldSlotID, model.TimeSlotStatusBooked).
+				Update("status", model.TimeSlotStatusPlanned).Error; err != nil {
+				return err
+			}
+			return tx.Model(&model.Waitlist{}).
+				Where("id = ?", entry.ID).
+				Updates(map[string]any{
+					"status":           model.WaitlistStatusExpired,
+					"held_slot_id":     nil,
+					"offer_expires_at": nil,
+				}).Error
+		})
+		if err != nil {
+			s.logErr("PromoteWaitlist", err, "stage", "reclaim expired offer", "waitlist_id", entry.ID)
+			continue
+		}
+		s.logInfo("PromoteWaitlist", "stage", "reclaimed expired offer", "waitlist_id", entry.ID, "slot_id", entry.HeldSlotID)
+		reclaimed++
+	}
+	return reclaimed, nil
+}
+
+// tryPromoteWaitlistEntry ищет самый ранний ещё свободный слот провайдера
+// (и, если задано, услуги) внутри желаемого окна entry и, если он не
+// конфликтует с уже подтверждёнными бронированиями клиента/провайдера (те же
+// проверки, что и CreateBooking — см. listClientConfirmedBookingRangesTx,
+// listProviderConfirmedBookingRangesTx), удерживает его за записью: переводит
+// слот в TimeSlotStatusBooked (чтобы CreateBooking его больше не видел) и
+// запись — в WaitlistStatusOffered с TTL waitlistOfferTTL, не создавая
+// бронирование напрямую — это делает только ConfirmWaitlistOffer. Возвращает
+// (false, nil), если подходящего слота сейчас нет.
+func (s *CalendarService) tryPromoteWaitlistEntry(ctx context.Context, entry *model.Waitlist) (bool, error) {
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var slotID uuid.UUID
+	var offerExpiresAt time.Time
+	err = appdb.WithSerializableRetry(ctx, s.db, func(tx *gorm.DB) error {
+		var slot model.TimeSlot
+		q := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("provider_id = ?", entry.ProviderID).
+			Where("status = ?", model.TimeSlotStatusPlanned).
+			Where("starts_at >= ? AND ends_at <= ?", entry.DesiredStart, entry.DesiredEnd)
+		if entry.ServiceID != nil {
+			q = q.Where("service_id = ?", *entry.ServiceID)
+		}
+		if err := q.Order("starts_at ASC").First(&slot).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errWaitlistNoSlot
+			}
+			return err
+		}
+
+		newRange := calendarutils.TimeRange{Start: slot.StartsAt.UTC(), End: slot.EndsAt.UTC()}
+
+		clientRanges, err := s.listClientConfirmedBookingRangesTx(ctx, tx, entry.ClientID, slot.ID)
+		if err != nil {
+			return err
+		}
+		if has, _ := calendarutils.HasOverlap(newRange, clientRanges, false); has {
+			return errWaitlistNoSlot
+		}
+
+		providerRanges, err := s.listProviderConfirmedBookingRangesTx(ctx, tx, slot.ProviderID, slot.ID)
+		if err != nil {
+			return err
+		}
+		if has, _ := calendarutils.HasOverlap(newRange, providerRanges, false); has {
+			return errWaitlistNoSlot
+		}
+
+		offerExpiresAt = time.Now().UTC().Add(waitlistOfferTTL)
+		if err := tx.Model(&model.TimeSlot{}).
+			Where("id = ?", slot.ID).
+			Update("status", model.TimeSlotStatusBooked).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Waitlist{}).
+			Where("id = ?", entry.ID).
+			Updates(map[string]any{
+				"status":           model.WaitlistStatusOffered,
+				"held_slot_id":     slot.ID,
+				"offer_expires_at": offerExpiresAt,
+			}).Error; err != nil {
+			return err
+		}
+
+		var recipient struct {
+			ClientUserID     string `gorm:"column:user_id"`
+			ClientTelegramID int64  `gorm:"column:telegram_id"`
+		}
+		if err := tx.Table("clients").
+			Select("clients.user_id AS user_id, users.telegram_id AS telegram_id").
+			Joins("JOIN users ON users.id = clients.user_id").
+			Where("clients.id = ?", entry.ClientID).
+			Scan(&recipient).Error; err != nil {
+			return err
+		}
+		if err := writeOutboxEvent(tx, nsID, entry.ID, model.NotificationEventWaitlistOffer, notify.OutboxPayload{
+			ClientUserID:     recipient.ClientUserID,
+			ClientTelegramID: recipient.ClientTelegramID,
+			ProviderID:       slot.ProviderID.String(),
+			SlotID:           slot.ID.String(),
+			StartsAt:         slot.StartsAt,
+			EndsAt:           slot.EndsAt,
+		}); err != nil {
+			return err
+		}
+
+		slotID = slot.ID
+		return nil
+	})
+	if errors.Is(err, errWaitlistNoSlot) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	s.logInfo("PromoteWaitlist", "waitlist_id", entry.ID, "slot_id", slotID, "client_id", entry.ClientID, "offer_expires_at", offerExpiresAt)
+	return true, nil
+}
+
+// ConfirmWaitlistOffer подтверждает предложенный записи слот (см.
+// tryPromoteWaitlistEntry): если запись ещё в WaitlistStatusOffered и
+// OfferExpiresAt не прошёл, атомарно создаёт бронирование на HeldSlotID и
+// переводит запись в WaitlistStatusPromoted. Иначе — FailedPrecondition:
+// предложение уже снято (TTL истёк и подобран reclaimExpiredOffers) либо
+// запись не находится в состоянии предложения.
+func (s *CalendarService) ConfirmWaitlistOffer(
+	ctx context.Context,
+	req *calendarpb.ConfirmWaitlistOfferRequest,
+) (*calendarpb.ConfirmWaitlistOfferResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if s.waitlistRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "waitlist is not configured")
+	}
+
+	entry, err := s.waitlistRepo.GetByID(ctx, req.GetId())
+	if err != nil {
+		s.logErr("ConfirmWaitlistOffer", err, "id", req.GetId())
+		return nil, status.Errorf(codes.NotFound, "waitlist entry: %v", err)
+	}
+	if entry.Status != model.WaitlistStatusOffered || entry.HeldSlotID == nil {
+		return nil, status.Error(codes.FailedPrecondition, "no active offer for this waitlist entry")
+	}
+	if entry.OfferExpiresAt == nil || !entry.OfferExpiresAt.After(time.Now().UTC()) {
+		return nil, status.Error(codes.FailedPrecondition, "offer has expired")
+	}
+
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var booking model.Booking
+	err = appdb.WithSerializableRetry(ctx, s.db, func(tx *gorm.DB) error {
+		var fresh model.Waitlist
+		if err := tx.First(&fresh, "id = ?", entry.ID).Error; err != nil {
+			return err
+		}
+		if fresh.Status != model.WaitlistStatusOffered || fresh.HeldSlotID == nil {
+			return status.Error(codes.FailedPrecondition, "no active offer for this waitlist entry")
+		}
+		if fresh.OfferExpiresAt == nil || !fresh.OfferExpiresAt.After(time.Now().UTC()) {
+			return status.Error(codes.FailedPrecondition, "offer has expired")
+		}
+
+		var slot model.TimeSlot
+		if err := tx.First(&slot, "id = ?", *fresh.HeldSlotID).Error; err != nil {
+			return err
+		}
+
+		booking = model.Booking{
+			ClientID: fresh.ClientID,
+			SlotID:   *fresh.HeldSlotID,
+			Status:   model.BookingStatusConfirmed,
+			Comment:  "confirmed from waitlist offer",
+		}
+		if err := tx.Create(&booking).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Waitlist{}).
+			Where("id = ?", fresh.ID).
+			Updates(map[string]any{
+				"status":              model.WaitlistStatusPromoted,
+				"promoted_booking_id": booking.ID,
+			}).Error; err != nil {
+			return err
+		}
+
+		var recipient struct {
+			ClientUserID     string `gorm:"column:user_id"`
+			ClientTelegramID int64  `gorm:"column:telegram_id"`
+		}
+		if err := tx.Table("clients").
+			Select("clients.user_id AS user_id, users.telegram_id AS telegram_id").
+			Joins("JOIN users ON users.id = clients.user_id").
+			Where("clients.id = ?", fresh.ClientID).
+			Scan(&recipient).Error; err != nil {
+			return err
+		}
+		return writeOutboxEvent(tx, nsID, booking.ID, model.NotificationEventBookingConfirmed, notify.OutboxPayload{
+			ClientUserID:     recipient.ClientUserID,
+			ClientTelegramID: recipient.ClientTelegramID,
+			ProviderID:       slot.ProviderID.String(),
+			SlotID:           slot.ID.String(),
+			BookingID:        booking.ID.String(),
+			StartsAt:         slot.StartsAt,
+			EndsAt:           slot.EndsAt,
+		})
+	})
+	if err != nil {
+		s.logErr("ConfirmWaitlistOffer", err, "id", req.GetId())
+		if st, ok := status.FromError(err); ok {
+			return nil, st.Err()
+		}
+		return nil, status.Errorf(codes.Internal, "confirm waitlist offer: %v", err)
+	}
+
+	s.logInfo("ConfirmWaitlistOffer", "waitlist_id", entry.ID, "booking_id", booking.ID, "client_id", entry.ClientID)
+	return &calendarpb.ConfirmWaitlistOfferResponse{BookingId: booking.ID.String()}, nil
+}
+
+// mapWaitlistEntry переводит модель записи листа ожидания в protobuf-представление.
+// position — 1-based место в очереди провайдера (см.
+// repository.WaitlistRepository.PositionInQueue); 0, если запись не
+// WaitlistStatusActive и очередь для неё не имеет смысла.
+func mapWaitlistEntry(w *model.Waitlist, position int) *calendarpb.WaitlistEntry {
+	out := &calendarpb.WaitlistEntry{
+		Id:           w.ID.String(),
+		ClientId:     w.ClientID.String(),
+		ProviderId:   w.ProviderID.String(),
+		DesiredStart: timestamppb.New(w.DesiredStart),
+		DesiredEnd:   timestamppb.New(w.DesiredEnd),
+		Priority:     int32(w.Priority),
+		Status:       string(w.Status),
+		Position:     int32(position),
+	}
+	if w.ServiceID != nil {
+		out.ServiceId = w.ServiceID.String()
+	}
+	if w.ExpiresAt != nil {
+		out.ExpiresAt = timestamppb.New(*w.ExpiresAt)
+	}
+	if w.PromotedBookingID != nil {
+		out.PromotedBookingId = w.PromotedBookingID.String()
+	}
+	if w.HeldSlotID != nil {
+		out.HeldSlotId = w.HeldSlotID.String()
+	}
+	if w.OfferExpiresAt != nil {
+		out.OfferExpiresAt = timestamppb.New(*w.OfferExpiresAt)
+	}
+	return out
+}
+
+// ListProviderSchedules — вернуть расписания провайдера.
+func (s *CalendarService) ListProviderSchedules(
+	ctx context.Context,
+	req *calendarpb.ListProviderSchedulesRequest,
+) (*calendarpb.ListProviderSchedulesResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+
+	schedules, err := s.scheduleRepo.ListByProvider(ctx, req.GetProviderId())
+	if err != nil {
+		s.logErr("ListProviderSchedules", err, "stage", "list schedules", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "list schedules: %v", err)
+	}
+
+	resp := &calendarpb.ListProviderSchedulesResponse{
+		Schedules: make([]*commonpb.ProviderSchedule, 0, len(schedules)),
+	}
+	for i := range schedules {
+		resp.Schedules = append(resp.Schedules, mapProviderSchedule(&schedules[i]))
+	}
+
+	return resp, nil
+}
+
+// CreateProviderSchedule — создать расписание провайдера.
+func (s *CalendarService) CreateProviderSchedule(
 	ctx context.Context,
 	req *calendarpb.CreateProviderScheduleRequest,
 ) (*calendarpb.CreateProviderScheduleResponse, error) {
@@ -962,243 +2430,2015 @@ func (s *CalendarService) CreateProviderSchedule(
 	}
 	sched.Rules = ruleJSON
 
-	if err := s.scheduleRepo.Create(ctx, &sched); err != nil {
-		s.logErr("CreateProviderSchedule", err, "stage", "create schedule")
-		return nil, status.Errorf(codes.Internal, "create schedule: %v", err)
+	if err := s.scheduleRepo.Create(ctx, &sched); err != nil {
+		s.logErr("CreateProviderSchedule", err, "stage", "create schedule")
+		return nil, status.Errorf(codes.Internal, "create schedule: %v", err)
+	}
+
+	s.logInfo("CreateProviderSchedule", "schedule_id", sched.ID.String(), "provider_id", sched.ProviderID.String())
+
+	return &calendarpb.CreateProviderScheduleResponse{Schedule: mapProviderSchedule(&sched)}, nil
+}
+
+// UpdateProviderSchedule — обновить расписание.
+func (s *CalendarService) UpdateProviderSchedule(
+	ctx context.Context,
+	req *calendarpb.UpdateProviderScheduleRequest,
+) (*calendarpb.UpdateProviderScheduleResponse, error) {
+	if req.GetScheduleId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "schedule_id is required")
+	}
+
+	ps := req.GetSchedule()
+	if ps == nil {
+		return nil, status.Error(codes.InvalidArgument, "schedule is required")
+	}
+
+	// обновляем timezone / rule при необходимости
+	schedID, err := uuid.Parse(req.GetScheduleId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid schedule_id")
+	}
+
+	existing, err := s.scheduleRepo.GetByID(ctx, req.GetScheduleId())
+	if err != nil {
+		s.logErr("UpdateProviderSchedule", err, "stage", "get schedule", "schedule_id", req.GetScheduleId())
+		return nil, status.Errorf(codes.NotFound, "schedule not found: %v", err)
+	}
+
+	targetProviderID := existing.ProviderID.String()
+	if ps.GetProviderId() != "" {
+		targetProviderID = ps.GetProviderId()
+	}
+
+	if err := s.ensureProviderRole(ctx, targetProviderID); err != nil {
+		return nil, err
+	}
+
+	// запрещаем менять владельца расписания
+	if ps.GetProviderId() != "" && ps.GetProviderId() != existing.ProviderID.String() {
+		return nil, status.Error(codes.PermissionDenied, "schedule owner cannot be changed")
+	}
+
+	ruleJSON, err := encodeScheduleRule(ps.GetRule())
+	if err != nil {
+		s.logErr("UpdateProviderSchedule", err, "stage", "encode rule")
+		return nil, status.Errorf(codes.InvalidArgument, "invalid rule: %v", err)
+	}
+
+	sched := model.Schedule{
+		ID:        schedID,
+		TimeZone:  ps.GetTimeZone(),
+		StartDate: protoDateToDate(ps.GetStartDate()),
+		EndDate:   protoDateToDate(ps.GetEndDate()),
+		Rules:     ruleJSON,
+	}
+
+	if ps.GetProviderId() != "" {
+		if pid, err := uuid.Parse(ps.GetProviderId()); err == nil {
+			sched.ProviderID = pid
+		}
+	}
+
+	if err := s.scheduleRepo.Update(ctx, &sched); err != nil {
+		s.logErr("UpdateProviderSchedule", err, "stage", "update schedule")
+		return nil, status.Errorf(codes.Internal, "update schedule: %v", err)
+	}
+
+	// scheduleRepo.Update бампнул ScheduleVersion — ставим точечную
+	// перестройку этого расписания в очередь материализатора, не дожидаясь
+	// очередного периодического прогона (см. ScheduleMaterializationQueue).
+	// Best-effort: ошибка очереди не должна откатывать уже сохранённое
+	// правило.
+	if s.materializationQueue != nil {
+		if nsID, err := namespace.Require(ctx); err == nil {
+			s.materializationQueue.EnqueueSchedule(nsID, existing.ProviderID, sched.ID)
+		}
+	}
+
+	s.logInfo("UpdateProviderSchedule", "schedule_id", sched.ID.String(), "provider_id", sched.ProviderID.String())
+
+	return &calendarpb.UpdateProviderScheduleResponse{Schedule: mapProviderSchedule(&sched)}, nil
+}
+
+// RebuildProviderCalendar принудительно перестраивает материализованные
+// слоты провайдера на произвольном окне [start, end), в обход скользящего
+// горизонта фонового SlotMaterializer и очереди ScheduleMaterializationQueue
+// — админский RPC для ручной пересборки (например, после массовой правки
+// расписаний в обход обычного UpdateProviderSchedule).
+func (s *CalendarService) RebuildProviderCalendar(
+	ctx context.Context,
+	req *calendarpb.RebuildProviderCalendarRequest,
+) (*calendarpb.RebuildProviderCalendarResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if req.GetStart() == nil || req.GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start and end are required")
+	}
+	start := req.GetStart().AsTime()
+	end := req.GetEnd().AsTime()
+	if !end.After(start) {
+		return nil, status.Error(codes.InvalidArgument, "end must be after start")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+	if s.materializationQueue == nil {
+		return nil, status.Error(codes.FailedPrecondition, "materialization queue is not configured")
+	}
+
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	created, updated, cancelled, err := s.materializationQueue.RebuildNow(ctx, nsID, providerID, start.UTC(), end.UTC())
+	if err != nil {
+		s.logErr("RebuildProviderCalendar", err, "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "rebuild provider calendar: %v", err)
+	}
+
+	s.logInfo("RebuildProviderCalendar", "provider_id", req.GetProviderId(), "start", start, "end", end,
+		"created", created, "updated", updated, "cancelled", cancelled)
+
+	return &calendarpb.RebuildProviderCalendarResponse{
+		CreatedSlots:   int32(created),
+		UpdatedSlots:   int32(updated),
+		CancelledSlots: int32(cancelled),
+	}, nil
+}
+
+// DeleteProviderSchedule — удалить расписание.
+func (s *CalendarService) DeleteProviderSchedule(
+	ctx context.Context,
+	req *calendarpb.DeleteProviderScheduleRequest,
+) (*calendarpb.DeleteProviderScheduleResponse, error) {
+	if req.GetScheduleId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "schedule_id is required")
+	}
+	sched, err := s.scheduleRepo.GetByID(ctx, req.GetScheduleId())
+	if err != nil {
+		s.logErr("DeleteProviderSchedule", err, "stage", "get schedule", "schedule_id", req.GetScheduleId())
+		return nil, status.Errorf(codes.NotFound, "schedule not found: %v", err)
+	}
+	if err := s.ensureProviderRole(ctx, sched.ProviderID.String()); err != nil {
+		return nil, err
+	}
+	if err := s.scheduleRepo.Delete(ctx, req.GetScheduleId()); err != nil {
+		s.logErr("DeleteProviderSchedule", err, "stage", "delete schedule")
+		return nil, status.Errorf(codes.Internal, "delete schedule: %v", err)
+	}
+
+	s.logInfo("DeleteProviderSchedule", "schedule_id", req.GetScheduleId(), "provider_id", sched.ProviderID.String())
+	return &calendarpb.DeleteProviderScheduleResponse{}, nil
+}
+
+// ConnectCalendar — привязать внешний CalDAV-календарь к провайдеру. Один
+// провайдер — одна интеграция (см. уникальный индекс по provider_id в
+// model.CalendarIntegration); повторный вызов с другим URL переписывает
+// предыдущую привязку.
+func (s *CalendarService) ConnectCalendar(
+	ctx context.Context,
+	req *calendarpb.ConnectCalendarRequest,
+) (*calendarpb.ConnectCalendarResponse, error) {
+	if req.GetProviderId() == "" || req.GetCalendarUrl() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id and calendar_url are required")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+	if s.calendarIntegrationRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "calendar integrations are not configured")
+	}
+
+	ci := &model.CalendarIntegration{
+		ProviderID:  providerID,
+		CalendarURL: req.GetCalendarUrl(),
+		Username:    req.GetUsername(),
+		Password:    req.GetPassword(),
+	}
+	if err := s.calendarIntegrationRepo.Create(ctx, ci); err != nil {
+		s.logErr("ConnectCalendar", err, "stage", "create integration", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "connect calendar: %v", err)
+	}
+
+	s.logInfo("ConnectCalendar", "provider_id", req.GetProviderId(), "integration_id", ci.ID.String())
+	return &calendarpb.ConnectCalendarResponse{IntegrationId: ci.ID.String()}, nil
+}
+
+// SyncCalendar — синхронизировать интеграцию провайдера немедленно, не
+// дожидаясь следующего прогона caldav.Reconciler. Полезно сразу после
+// ConnectCalendar, чтобы UI увидел первые занятые интервалы без 15-минутной
+// задержки фонового воркера.
+func (s *CalendarService) SyncCalendar(
+	ctx context.Context,
+	req *calendarpb.SyncCalendarRequest,
+) (*calendarpb.SyncCalendarResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+	if s.calendarIntegrationRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "calendar integrations are not configured")
+	}
+
+	ci, err := s.calendarIntegrationRepo.GetByProviderID(ctx, req.GetProviderId())
+	if err != nil {
+		s.logErr("SyncCalendar", err, "stage", "get integration", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.NotFound, "calendar integration not found: %v", err)
+	}
+
+	client := caldav.NewClient(ci.CalendarURL, ci.Username, ci.Password)
+	docs, err := client.ListEvents(ctx)
+	if err != nil {
+		s.logErr("SyncCalendar", err, "stage", "list events", "integration_id", ci.ID.String())
+		_ = s.calendarIntegrationRepo.UpdateSyncState(ctx, ci.ID, ci.CTag, time.Now().UTC(), err.Error())
+		return nil, status.Errorf(codes.Unavailable, "sync calendar: %v", err)
+	}
+
+	busy := caldav.ParseBusyRanges(docs)
+	ranges := make([]model.CalendarBusyRange, 0, len(busy))
+	for _, b := range busy {
+		ranges = append(ranges, model.CalendarBusyRange{
+			NamespaceID:   ci.NamespaceID,
+			IntegrationID: ci.ID,
+			ProviderID:    ci.ProviderID,
+			UID:           b.UID,
+			StartsAt:      b.Range.Start,
+			EndsAt:        b.Range.End,
+		})
+	}
+	if err := s.calendarIntegrationRepo.ReplaceBusyRanges(ctx, ci.ID, ranges); err != nil {
+		s.logErr("SyncCalendar", err, "stage", "replace busy ranges", "integration_id", ci.ID.String())
+		return nil, status.Errorf(codes.Internal, "sync calendar: %v", err)
+	}
+
+	ctag, err := client.GetCTag(ctx)
+	if err != nil {
+		s.logErr("SyncCalendar", err, "stage", "get ctag", "integration_id", ci.ID.String())
+	}
+	if err := s.calendarIntegrationRepo.UpdateSyncState(ctx, ci.ID, ctag, time.Now().UTC(), ""); err != nil {
+		s.logErr("SyncCalendar", err, "stage", "update sync state", "integration_id", ci.ID.String())
+	}
+
+	s.logInfo("SyncCalendar", "provider_id", req.GetProviderId(), "integration_id", ci.ID.String(), "busy_ranges", len(ranges))
+	return &calendarpb.SyncCalendarResponse{BusyRangeCount: int32(len(ranges))}, nil
+}
+
+// DisconnectCalendar — отвязать внешний календарь от провайдера. Ранее
+// синхронизированные CalendarBusyRange удаляются каскадно (FK на
+// integration_id с ON DELETE CASCADE, см. model.CalendarBusyRange).
+func (s *CalendarService) DisconnectCalendar(
+	ctx context.Context,
+	req *calendarpb.DisconnectCalendarRequest,
+) (*calendarpb.DisconnectCalendarResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+	if s.calendarIntegrationRepo == nil {
+		return nil, status.Error(codes.Unimplemented, "calendar integrations are not configured")
+	}
+
+	ci, err := s.calendarIntegrationRepo.GetByProviderID(ctx, req.GetProviderId())
+	if err != nil {
+		s.logErr("DisconnectCalendar", err, "stage", "get integration", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.NotFound, "calendar integration not found: %v", err)
+	}
+	if err := s.calendarIntegrationRepo.Delete(ctx, ci.ID); err != nil {
+		s.logErr("DisconnectCalendar", err, "stage", "delete integration", "integration_id", ci.ID.String())
+		return nil, status.Errorf(codes.Internal, "disconnect calendar: %v", err)
+	}
+
+	s.logInfo("DisconnectCalendar", "provider_id", req.GetProviderId(), "integration_id", ci.ID.String())
+	return &calendarpb.DisconnectCalendarResponse{}, nil
+}
+
+// ExportProviderCalendar рендерит .ics провайдера для окна [start, end) так
+// же, как icalendar.Handler (подписанный HTTP-фид для Google/Apple Calendar),
+// но как RPC с явным выбором набора (Include) и без привязки к подписанному
+// токену — для админских инструментов и ручного экспорта/бэкапа.
+func (s *CalendarService) ExportProviderCalendar(
+	ctx context.Context,
+	req *calendarpb.ExportProviderCalendarRequest,
+) (*calendarpb.ExportProviderCalendarResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if req.GetRange() == nil || req.GetRange().GetStart() == nil || req.GetRange().GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "range is required")
+	}
+	start := req.GetRange().GetStart().AsTime()
+	end := req.GetRange().GetEnd().AsTime()
+	if !end.After(start) {
+		return nil, status.Error(codes.InvalidArgument, "end must be after start")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	provider, err := s.providerRepo.GetByID(ctx, req.GetProviderId())
+	if err != nil {
+		s.logErr("ExportProviderCalendar", err, "stage", "get provider", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.NotFound, "provider not found: %v", err)
+	}
+
+	var freeSlots []calendarutils.TimeRange
+	if req.GetIncludeSlots() {
+		slots, _, err := s.slotRepo.ListByProviderRange(ctx, req.GetProviderId(), start, end, 0, 0)
+		if err != nil {
+			s.logErr("ExportProviderCalendar", err, "stage", "list slots", "provider_id", req.GetProviderId())
+			return nil, status.Errorf(codes.Internal, "export provider calendar: %v", err)
+		}
+		freeSlots = make([]calendarutils.TimeRange, 0, len(slots))
+		for i := range slots {
+			if slots[i].Status == model.TimeSlotStatusPlanned {
+				freeSlots = append(freeSlots, calendarutils.TimeRange{Start: slots[i].StartsAt, End: slots[i].EndsAt})
+			}
+		}
+	}
+
+	var bookings []model.Booking
+	if req.GetIncludeBookings() {
+		bookings, err = s.bookingRepo.ListByProviderAndRange(ctx, req.GetProviderId(), start, end)
+		if err != nil {
+			s.logErr("ExportProviderCalendar", err, "stage", "list bookings", "provider_id", req.GetProviderId())
+			return nil, status.Errorf(codes.Internal, "export provider calendar: %v", err)
+		}
+	}
+
+	loc := time.UTC
+	ics, err := icalendar.RenderProviderICS(provider, freeSlots, bookings, loc)
+	if err != nil {
+		s.logErr("ExportProviderCalendar", err, "stage", "render ics", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "export provider calendar: %v", err)
+	}
+
+	if req.GetIncludeSchedules() && s.scheduleRepo != nil {
+		schedules, err := s.scheduleRepo.ListByProvider(ctx, req.GetProviderId())
+		if err != nil {
+			s.logErr("ExportProviderCalendar", err, "stage", "list schedules", "provider_id", req.GetProviderId())
+			return nil, status.Errorf(codes.Internal, "export provider calendar: %v", err)
+		}
+		for i := range schedules {
+			rrule, exdates, rdates, err := scheduleMasterRRULE(&schedules[i])
+			if err != nil {
+				s.logErr("ExportProviderCalendar", err, "stage", "build schedule rrule", "schedule_id", schedules[i].ID.String())
+				continue
+			}
+			if rrule == "" {
+				continue
+			}
+			ics, err = icalendar.AppendScheduleEvent(ics, &schedules[i], rrule, exdates, rdates, loc)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "export provider calendar: %v", err)
+			}
+		}
+	}
+
+	if req.GetIncludeMaintenance() && s.maintenanceRepo != nil {
+		windows, err := s.maintenanceRepo.ListActiveForProvider(ctx, req.GetProviderId(), start, end)
+		if err != nil {
+			s.logErr("ExportProviderCalendar", err, "stage", "list maintenance windows", "provider_id", req.GetProviderId())
+			return nil, status.Errorf(codes.Internal, "export provider calendar: %v", err)
+		}
+		for i := range windows {
+			rrule := ""
+			if windows[i].RRule != nil {
+				rrule = *windows[i].RRule
+			}
+			occurrences, err := calendarutils.ExpandRecurringWindow(windows[i].StartsAt, windows[i].EndsAt, rrule, start, end)
+			if err != nil {
+				s.logErr("ExportProviderCalendar", err, "stage", "expand maintenance window", "window_id", windows[i].ID.String())
+				continue
+			}
+			for _, occ := range occurrences {
+				ics, err = icalendar.AppendMaintenanceEvent(ics, &windows[i], occ, loc)
+				if err != nil {
+					return nil, status.Errorf(codes.Internal, "export provider calendar: %v", err)
+				}
+			}
+		}
+	}
+
+	s.logInfo("ExportProviderCalendar", "provider_id", req.GetProviderId(), "start", start, "end", end, "bytes", len(ics))
+
+	return &calendarpb.ExportProviderCalendarResponse{IcsBytes: ics}, nil
+}
+
+// IssueCalendarFeedToken выдаёт провайдеру новый токен для публичного
+// подписного iCalendar-фида (icalendar.Handler, GET
+// /providers/{id}/calendar.ics?token=...) и отзывает все ранее выданные —
+// так у провайдера в любой момент есть не больше одной действующей ссылки,
+// и утёкшую старую нельзя переиспользовать после перевыпуска.
+func (s *CalendarService) IssueCalendarFeedToken(
+	ctx context.Context,
+	req *calendarpb.IssueCalendarFeedTokenRequest,
+) (*calendarpb.IssueCalendarFeedTokenResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if s.feedTokenRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "calendar feed tokens are not configured")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+
+	if err := s.feedTokenRepo.RevokeAll(ctx, providerID, time.Now().UTC()); err != nil {
+		s.logErr("IssueCalendarFeedToken", err, "stage", "revoke previous", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "issue calendar feed token: %v", err)
+	}
+
+	token, _, err := s.feedTokenRepo.Issue(ctx, providerID)
+	if err != nil {
+		s.logErr("IssueCalendarFeedToken", err, "stage", "issue", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "issue calendar feed token: %v", err)
+	}
+
+	s.logInfo("IssueCalendarFeedToken", "provider_id", req.GetProviderId())
+	return &calendarpb.IssueCalendarFeedTokenResponse{Token: token}, nil
+}
+
+// RevokeCalendarFeedToken отзывает все действующие токены фида провайдера —
+// например, если ссылка утекла, а провайдер ещё не готов выпускать новую
+// через IssueCalendarFeedToken.
+func (s *CalendarService) RevokeCalendarFeedToken(
+	ctx context.Context,
+	req *calendarpb.RevokeCalendarFeedTokenRequest,
+) (*calendarpb.RevokeCalendarFeedTokenResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if s.feedTokenRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "calendar feed tokens are not configured")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+
+	if err := s.feedTokenRepo.RevokeAll(ctx, providerID, time.Now().UTC()); err != nil {
+		s.logErr("RevokeCalendarFeedToken", err, "stage", "revoke", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "revoke calendar feed token: %v", err)
+	}
+
+	s.logInfo("RevokeCalendarFeedToken", "provider_id", req.GetProviderId())
+	return &calendarpb.RevokeCalendarFeedTokenResponse{}, nil
+}
+
+// ImportProviderCalendar разбирает ics_bytes (см. icalendar.ParseEvents) в
+// слоты провайдера: каждый VEVENT с UID становится model.TimeSlot с
+// ExternalID = UID, повторный импорт того же .ics находит уже созданный слот
+// по ExternalID и обновляет его, а не плодит дубликат. SUMMARY сопоставляется
+// с именем услуги провайдера (без учёта регистра); STATUS:CANCELLED переводит
+// уже импортированный слот в TimeSlotStatusCancelled. VEVENT с RRULE
+// дополнительно заводит model.Schedule через encodeScheduleRule — это
+// независимое от слотов правило повторения, материализацией которого
+// по-прежнему занимается обычный фоновый SlotMaterializer.
+//
+// mode=replace, в отличие от merge, дополнительно отменяет ранее
+// импортированные слоты (ExternalID непустой), чьи UID в этом .ics больше не
+// встречаются — так внешний календарь считается источником истины для
+// слотов, которые когда-либо были им материализованы.
+func (s *CalendarService) ImportProviderCalendar(
+	ctx context.Context,
+	req *calendarpb.ImportProviderCalendarRequest,
+) (*calendarpb.ImportProviderCalendarResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if len(req.GetIcsBytes()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "ics_bytes is required")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+
+	events, err := icalendar.ParseEvents(req.GetIcsBytes(), time.UTC)
+	if err != nil {
+		s.logErr("ImportProviderCalendar", err, "stage", "parse ics", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.InvalidArgument, "parse ics: %v", err)
+	}
+
+	services, err := s.serviceRepo.ListByProvider(ctx, providerID)
+	if err != nil {
+		s.logErr("ImportProviderCalendar", err, "stage", "list services", "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "import provider calendar: %v", err)
+	}
+	serviceIDByName := make(map[string]uuid.UUID, len(services))
+	for _, svc := range services {
+		serviceIDByName[strings.ToLower(svc.Name)] = svc.ID
+	}
+
+	var imported, updated, cancelled, skipped, createdSchedules int32
+	seenUIDs := make(map[string]struct{}, len(events))
+
+	for _, ev := range events {
+		if ev.UID == "" {
+			skipped++
+			continue
+		}
+		seenUIDs[ev.UID] = struct{}{}
+
+		existing, getErr := s.slotRepo.GetByExternalID(ctx, req.GetProviderId(), ev.UID)
+
+		if ev.Cancelled {
+			if getErr == nil && existing != nil {
+				if err := s.slotRepo.UpdateStatus(ctx, existing.ID.String(), model.TimeSlotStatusCancelled); err != nil {
+					s.logErr("ImportProviderCalendar", err, "stage", "cancel slot", "external_id", ev.UID)
+					skipped++
+					continue
+				}
+				cancelled++
+			}
+			continue
+		}
+
+		if ev.Start.IsZero() || ev.End.IsZero() || !ev.End.After(ev.Start) {
+			skipped++
+			continue
+		}
+
+		var serviceID *uuid.UUID
+		if id, ok := serviceIDByName[strings.ToLower(ev.Summary)]; ok {
+			serviceID = &id
+		}
+
+		if getErr == nil && existing != nil {
+			existing.StartsAt = ev.Start
+			existing.EndsAt = ev.End
+			existing.ServiceID = serviceID
+			if err := s.slotRepo.Update(ctx, existing); err != nil {
+				s.logErr("ImportProviderCalendar", err, "stage", "update slot", "external_id", ev.UID)
+				skipped++
+				continue
+			}
+			updated++
+		} else {
+			slot := model.TimeSlot{
+				ProviderID: providerID,
+				ServiceID:  serviceID,
+				StartsAt:   ev.Start,
+				EndsAt:     ev.End,
+				Status:     model.TimeSlotStatusPlanned,
+				ExternalID: ev.UID,
+			}
+			if err := s.slotRepo.Create(ctx, &slot); err != nil {
+				if errors.Is(err, errs.ErrSlotOverlap) {
+					skipped++
+					continue
+				}
+				s.logErr("ImportProviderCalendar", err, "stage", "create slot", "external_id", ev.UID)
+				skipped++
+				continue
+			}
+			imported++
+		}
+
+		if ev.RRule != "" {
+			ruleJSON, err := encodeScheduleRule(&commonpb.ScheduleRule{
+				RawRrule: ev.RRule,
+				StartsAt: timestamppb.New(ev.Start),
+			})
+			if err != nil {
+				s.logErr("ImportProviderCalendar", err, "stage", "encode imported rule", "external_id", ev.UID)
+				continue
+			}
+			sched := model.Schedule{
+				ProviderID: providerID,
+				TimeZone:   "UTC",
+				Rules:      ruleJSON,
+			}
+			if err := s.scheduleRepo.Create(ctx, &sched); err != nil {
+				s.logErr("ImportProviderCalendar", err, "stage", "create imported schedule", "external_id", ev.UID)
+				continue
+			}
+			createdSchedules++
+		}
+	}
+
+	if req.GetMode() == calendarpb.ImportMode_IMPORT_MODE_REPLACE {
+		stale, _, err := s.slotRepo.ListByProviderRange(ctx, req.GetProviderId(), time.Time{}, time.Now().AddDate(10, 0, 0), 0, 0)
+		if err != nil {
+			s.logErr("ImportProviderCalendar", err, "stage", "list for replace", "provider_id", req.GetProviderId())
+		} else {
+			for i := range stale {
+				if stale[i].ExternalID == "" || stale[i].Status == model.TimeSlotStatusCancelled {
+					continue
+				}
+				if _, ok := seenUIDs[stale[i].ExternalID]; ok {
+					continue
+				}
+				if err := s.slotRepo.UpdateStatus(ctx, stale[i].ID.String(), model.TimeSlotStatusCancelled); err != nil {
+					s.logErr("ImportProviderCalendar", err, "stage", "cancel stale slot", "external_id", stale[i].ExternalID)
+					continue
+				}
+				cancelled++
+			}
+		}
+	}
+
+	s.logInfo("ImportProviderCalendar", "provider_id", req.GetProviderId(),
+		"imported", imported, "updated", updated, "cancelled", cancelled, "skipped", skipped, "created_schedules", createdSchedules)
+
+	return &calendarpb.ImportProviderCalendarResponse{
+		ImportedSlots:    imported,
+		UpdatedSlots:     updated,
+		CancelledSlots:   cancelled,
+		SkippedEvents:    skipped,
+		CreatedSchedules: createdSchedules,
+	}, nil
+}
+
+// BulkCancelProviderSlots — массовая отмена слотов провайдера в интервале.
+// Отменяет и связанные бронирования (если есть) и возвращает список затронутых записей,
+// чтобы внешний слой мог уведомить клиентов.
+func (s *CalendarService) BulkCancelProviderSlots(
+	ctx context.Context,
+	req *calendarpb.BulkCancelProviderSlotsRequest,
+) (*calendarpb.BulkCancelProviderSlotsResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if req.GetStart() == nil || req.GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start and end are required")
+	}
+	start := req.GetStart().AsTime()
+	end := req.GetEnd().AsTime()
+	if !end.After(start) {
+		return nil, status.Error(codes.InvalidArgument, "end must be after start")
+	}
+	if s.db == nil {
+		return nil, status.Error(codes.FailedPrecondition, "db is not configured")
+	}
+
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+
+	affected, cancelledSlots, cancelledBookings, err := s.cancelSlotsAndBookingsInRange(ctx, providerID, start, end, req.GetReason())
+	if err != nil {
+		return nil, err
+	}
+	if affected == nil {
+		affected = []*calendarpb.AffectedBooking{}
+	}
+
+	s.logInfo("BulkCancelProviderSlots", "provider_id", req.GetProviderId(), "start", start, "end", end, "cancelled_slots", cancelledSlots, "cancelled_bookings", cancelledBookings)
+
+	return &calendarpb.BulkCancelProviderSlotsResponse{
+		CancelledSlots:    int32(cancelledSlots),
+		CancelledBookings: int32(cancelledBookings),
+		AffectedBookings:  affected,
+	}, nil
+}
+
+// BulkCreateSlots — счётчик-противоположность BulkCancelProviderSlots:
+// массовое создание слотов провайдера из списка интервалов в одной
+// транзакции, вместо N отдельных вызовов CreateSlot (чатливо и оставляет
+// частичное состояние при ошибке на полпути). on_conflict управляет
+// реакцией на пересечение входного интервала с уже существующим
+// planned/booked слотом: CONFLICT_MODE_SKIP пропускает только этот интервал,
+// CONFLICT_MODE_FAIL откатывает всю транзакцию, CONFLICT_MODE_MERGE сначала
+// объединяет пересекающиеся/смежные входные интервалы в один (после чего к
+// объединённому интервалу применяется та же проверка конфликтов). Результат
+// — по одной записи на КАЖДЫЙ исходный индекс запроса, даже если несколько
+// индексов были объединены в один созданный слот.
+func (s *CalendarService) BulkCreateSlots(
+	ctx context.Context,
+	req *calendarpb.BulkCreateSlotsRequest,
+) (*calendarpb.BulkCreateSlotsResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if len(req.GetRanges()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "ranges is required")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+	var serviceID *uuid.UUID
+	if req.GetServiceId() != "" {
+		id, err := uuid.Parse(req.GetServiceId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid service_id")
+		}
+		serviceID = &id
+	}
+	var staffID *uuid.UUID
+	if req.GetStaffId() != "" {
+		id, err := uuid.Parse(req.GetStaffId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid staff_id")
+		}
+		staffID = &id
+	}
+	var resourceID *uuid.UUID
+	if req.GetResourceId() != "" {
+		id, err := uuid.Parse(req.GetResourceId())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid resource_id")
+		}
+		resourceID = &id
+	}
+	capacity := int(req.GetCapacity())
+	if capacity <= 0 {
+		capacity = 1
+	}
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type inputRange struct {
+		index int32
+		rng   calendarutils.TimeRange
+	}
+
+	results := make([]*calendarpb.BulkCreateSlotResult, len(req.GetRanges()))
+	var valid []inputRange
+	var minStart, maxEnd time.Time
+	for i, r := range req.GetRanges() {
+		idx := int32(i)
+		if r == nil || r.GetStart() == nil || r.GetEnd() == nil {
+			results[i] = &calendarpb.BulkCreateSlotResult{Index: idx, Error: "range is required"}
+			continue
+		}
+		start := r.GetStart().AsTime().UTC()
+		end := r.GetEnd().AsTime().UTC()
+		if !end.After(start) {
+			results[i] = &calendarpb.BulkCreateSlotResult{Index: idx, Error: "end must be after start"}
+			continue
+		}
+		valid = append(valid, inputRange{index: idx, rng: calendarutils.TimeRange{Start: start, End: end}})
+		if minStart.IsZero() || start.Before(minStart) {
+			minStart = start
+		}
+		if end.After(maxEnd) {
+			maxEnd = end
+		}
+	}
+
+	if len(valid) == 0 {
+		return &calendarpb.BulkCreateSlotsResponse{Results: results}, nil
+	}
+
+	// Группы на вставку: по умолчанию одна на валидный вход; CONFLICT_MODE_MERGE
+	// объединяет пересекающиеся/смежные входы в один union-интервал.
+	type group struct {
+		rng     calendarutils.TimeRange
+		indices []int32
+	}
+	var groups []group
+	if req.GetOnConflict() == calendarpb.ConflictMode_CONFLICT_MODE_MERGE {
+		sort.Slice(valid, func(i, j int) bool { return valid[i].rng.Start.Before(valid[j].rng.Start) })
+		for _, v := range valid {
+			if n := len(groups); n > 0 && !v.rng.Start.After(groups[n-1].rng.End) {
+				if v.rng.End.After(groups[n-1].rng.End) {
+					groups[n-1].rng.End = v.rng.End
+				}
+				groups[n-1].indices = append(groups[n-1].indices, v.index)
+				continue
+			}
+			groups = append(groups, group{rng: v.rng, indices: []int32{v.index}})
+		}
+	} else {
+		for _, v := range valid {
+			groups = append(groups, group{rng: v.rng, indices: []int32{v.index}})
+		}
+	}
+
+	var created, skipped int32
+	err = appdb.WithSerializableRetry(ctx, s.db, func(tx *gorm.DB) error {
+		// Одним запросом поднимаем все активные (planned/booked) слоты
+		// провайдера, пересекающие общий охват входных интервалов, и оставляем
+		// только те, что делят тот же кортеж (provider, service, staff,
+		// resource) — именно в его пределах считается Capacity (см.
+		// model.TimeSlot.Capacity), а не по провайдеру целиком.
+		var existing []model.TimeSlot
+		if err := tx.
+			Where("namespace_id = ? AND provider_id = ?", nsID, providerID).
+			Where("status IN ?", []model.TimeSlotStatus{model.TimeSlotStatusPlanned, model.TimeSlotStatusBooked}).
+			Where("starts_at < ? AND ends_at > ?", maxEnd, minStart).
+			Find(&existing).Error; err != nil {
+			return errs.Wrap(err, "slot")
+		}
+		var tupleExisting []model.TimeSlot
+		for _, sl := range existing {
+			if uuidPtrEqual(sl.ServiceID, serviceID) && uuidPtrEqual(sl.StaffID, staffID) && uuidPtrEqual(sl.ResourceID, resourceID) {
+				tupleExisting = append(tupleExisting, sl)
+			}
+		}
+		tupleRanges := make([]calendarutils.TimeRange, 0, len(tupleExisting))
+		for _, sl := range tupleExisting {
+			tupleRanges = append(tupleRanges, calendarutils.TimeRange{Start: sl.StartsAt, End: sl.EndsAt})
+		}
+
+		for gi := range groups {
+			g := &groups[gi]
+			_, conflicts := calendarutils.HasOverlap(g.rng, tupleRanges, false)
+			if len(conflicts) >= capacity {
+				conflictIDs := make([]string, 0, len(conflicts))
+				for _, c := range conflicts {
+					for _, sl := range tupleExisting {
+						if sl.StartsAt.Equal(c.Start) && sl.EndsAt.Equal(c.End) {
+							conflictIDs = append(conflictIDs, sl.ID.String())
+						}
+					}
+				}
+				if req.GetOnConflict() == calendarpb.ConflictMode_CONFLICT_MODE_FAIL {
+					return status.Errorf(codes.AlreadyExists, "range at index %d conflicts with existing slot(s)", g.indices[0])
+				}
+				for _, idx := range g.indices {
+					results[idx] = &calendarpb.BulkCreateSlotResult{Index: idx, ConflictWithSlotIds: conflictIDs}
+				}
+				skipped += int32(len(g.indices))
+				continue
+			}
+
+			slot := model.TimeSlot{
+				NamespaceID: nsID,
+				ProviderID:  providerID,
+				ServiceID:   serviceID,
+				StaffID:     staffID,
+				ResourceID:  resourceID,
+				Capacity:    capacity,
+				StartsAt:    g.rng.Start,
+				EndsAt:      g.rng.End,
+				Status:      model.TimeSlotStatusPlanned,
+			}
+			if err := tx.Create(&slot).Error; err != nil {
+				return errs.Wrap(err, "slot")
+			}
+			for _, idx := range g.indices {
+				results[idx] = &calendarpb.BulkCreateSlotResult{Index: idx, SlotId: slot.ID.String()}
+			}
+			tupleRanges = append(tupleRanges, g.rng)
+			created++
+		}
+		return nil
+	})
+	if err != nil {
+		s.logErr("BulkCreateSlots", err, "provider_id", req.GetProviderId())
+		if st, ok := status.FromError(err); ok {
+			return nil, st.Err()
+		}
+		return nil, status.Errorf(codes.Internal, "bulk create slots: %v", err)
+	}
+
+	s.logInfo("BulkCreateSlots", "provider_id", req.GetProviderId(), "created", created, "skipped", skipped)
+
+	// Новые planned-слоты могли как раз попасть в желаемое окно кого-то из
+	// листа ожидания провайдера — best-effort, как и в CancelBooking.
+	if created > 0 {
+		if _, perr := s.PromoteWaitlistForProvider(ctx, providerID); perr != nil {
+			s.logErr("BulkCreateSlots", perr, "stage", "promote waitlist", "provider_id", providerID)
+		}
+	}
+
+	return &calendarpb.BulkCreateSlotsResponse{
+		Results:      results,
+		CreatedCount: created,
+		SkippedCount: skipped,
+	}, nil
+}
+
+// CancelSlotsForMaintenanceWindow — отменить слоты/бронирования провайдера в
+// границах одного вхождения MaintenanceWindow. То же, что BulkCancelProviderSlots,
+// но вызывается не по RPC, а scheduler.MaintenanceScheduler на вход окна в
+// активную фазу (см. там же).
+func (s *CalendarService) CancelSlotsForMaintenanceWindow(
+	ctx context.Context,
+	providerID uuid.UUID,
+	start, end time.Time,
+	reason string,
+) (affected []*calendarpb.AffectedBooking, cancelledSlots, cancelledBookings int, err error) {
+	if s.db == nil {
+		return nil, 0, 0, status.Error(codes.FailedPrecondition, "db is not configured")
+	}
+	return s.cancelSlotsAndBookingsInRange(ctx, providerID, start, end, reason)
+}
+
+// cancelSlotsAndBookingsInRange отменяет слоты и активные бронирования
+// провайдера в [start, end), собирая AffectedBooking для уведомлений и, на
+// каждое отменённое бронирование, записывая в notifications_outbox событие
+// model.NotificationEventSlotCancelledBulk той же транзакцией (см.
+// writeOutboxEvent) — доставку клиентам делает отдельно
+// scheduler.OutboxDispatcher. Общая транзакционная логика
+// BulkCancelProviderSlots (админский RPC) и CancelSlotsForMaintenanceWindow
+// (срабатывание scheduler.MaintenanceScheduler на вход окна обслуживания).
+func (s *CalendarService) cancelSlotsAndBookingsInRange(
+	ctx context.Context,
+	providerID uuid.UUID,
+	start, end time.Time,
+	reason string,
+) ([]*calendarpb.AffectedBooking, int, int, error) {
+	type affectedBookingRow struct {
+		BookingID        string    `gorm:"column:booking_id"`
+		SlotID           string    `gorm:"column:slot_id"`
+		ClientID         string    `gorm:"column:client_id"`
+		ClientUserID     string    `gorm:"column:client_user_id"`
+		ClientTelegramID int64     `gorm:"column:client_telegram_id"`
+		ProviderID       string    `gorm:"column:provider_id"`
+		ServiceID        *string   `gorm:"column:service_id"`
+		StartsAt         time.Time `gorm:"column:starts_at"`
+		EndsAt           time.Time `gorm:"column:ends_at"`
+	}
+
+	nsID, err := namespace.Require(ctx)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	var affected []*calendarpb.AffectedBooking
+	var cancelledSlots, cancelledBookings int64
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1) Собрать активные бронирования в окне (для уведомлений) до обновлений.
+		var rows []affectedBookingRow
+		q := tx.Table("bookings").
+			Select(
+				"bookings.id AS booking_id, bookings.slot_id AS slot_id, bookings.client_id AS client_id, "+
+					"clients.user_id AS client_user_id, users.telegram_id AS client_telegram_id, "+
+					"time_slots.provider_id AS provider_id, time_slots.service_id AS service_id, "+
+					"time_slots.starts_at AS starts_at, time_slots.ends_at AS ends_at",
+			).
+			Joins("JOIN time_slots ON time_slots.id = bookings.slot_id").
+			Joins("JOIN clients ON clients.id = bookings.client_id").
+			Joins("JOIN users ON users.id = clients.user_id").
+			Where("time_slots.provider_id = ?", providerID).
+			Where("time_slots.starts_at >= ? AND time_slots.ends_at <= ?", start, end).
+			Where("time_slots.status <> ?", model.TimeSlotStatusCancelled).
+			Where("bookings.status <> ?", model.BookingStatusCancelled)
+
+		if err := q.Scan(&rows).Error; err != nil {
+			s.logErr("cancelSlotsAndBookingsInRange", err, "stage", "list affected bookings")
+			return status.Errorf(codes.Internal, "list affected bookings: %v", err)
+		}
+
+		// 2) Отменить бронирования (если есть).
+		if len(rows) > 0 {
+			bookingIDs := make([]string, 0, len(rows))
+			for i := range rows {
+				bookingIDs = append(bookingIDs, rows[i].BookingID)
+			}
+			now := time.Now().UTC()
+			update := map[string]any{
+				"status":       model.BookingStatusCancelled,
+				"cancelled_at": now,
+			}
+			if reason != "" {
+				update["comment"] = reason
+			}
+			res := tx.Model(&model.Booking{}).
+				Where("id IN ?", bookingIDs).
+				Where("status <> ?", model.BookingStatusCancelled).
+				Updates(update)
+			if res.Error != nil {
+				s.logErr("cancelSlotsAndBookingsInRange", res.Error, "stage", "cancel bookings")
+				return status.Errorf(codes.Internal, "cancel bookings: %v", res.Error)
+			}
+			cancelledBookings = res.RowsAffected
+
+			affected = make([]*calendarpb.AffectedBooking, 0, len(rows))
+			for i := range rows {
+				serviceID := ""
+				if rows[i].ServiceID != nil {
+					serviceID = *rows[i].ServiceID
+				}
+				affected = append(affected, &calendarpb.AffectedBooking{
+					BookingId:        rows[i].BookingID,
+					SlotId:           rows[i].SlotID,
+					ClientId:         rows[i].ClientID,
+					ClientUserId:     rows[i].ClientUserID,
+					ClientTelegramId: rows[i].ClientTelegramID,
+					ProviderId:       rows[i].ProviderID,
+					ServiceId:        serviceID,
+					StartsAt:         timestamppb.New(rows[i].StartsAt),
+					EndsAt:           timestamppb.New(rows[i].EndsAt),
+				})
+
+				bookingID, err := uuid.Parse(rows[i].BookingID)
+				if err != nil {
+					return status.Errorf(codes.Internal, "parse booking id: %v", err)
+				}
+				if err := writeOutboxEvent(tx, nsID, bookingID, model.NotificationEventSlotCancelledBulk, notify.OutboxPayload{
+					ClientUserID:     rows[i].ClientUserID,
+					ClientTelegramID: rows[i].ClientTelegramID,
+					ProviderID:       rows[i].ProviderID,
+					SlotID:           rows[i].SlotID,
+					BookingID:        rows[i].BookingID,
+					StartsAt:         rows[i].StartsAt,
+					EndsAt:           rows[i].EndsAt,
+					Reason:           reason,
+				}); err != nil {
+					return status.Errorf(codes.Internal, "write outbox event: %v", err)
+				}
+			}
+		}
+
+		// 3) Отменить слоты провайдера в окне.
+		res := tx.Model(&model.TimeSlot{}).
+			Where("provider_id = ?", providerID).
+			Where("starts_at >= ? AND ends_at <= ?", start, end).
+			Where("status <> ?", model.TimeSlotStatusCancelled).
+			Update("status", model.TimeSlotStatusCancelled)
+		if res.Error != nil {
+			s.logErr("cancelSlotsAndBookingsInRange", res.Error, "stage", "cancel slots")
+			return status.Errorf(codes.Internal, "cancel slots: %v", res.Error)
+		}
+		cancelledSlots = res.RowsAffected
+
+		return nil
+	})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return affected, int(cancelledSlots), int(cancelledBookings), nil
+}
+
+// CreateMaintenanceWindow регистрирует период недоступности провайдера.
+// Для kind=hard затронутые неотменённые бронирования в окне сразу переводятся
+// в BookingStatusCancelled с системным комментарием; для kind=soft окно только
+// блокирует слоты (см. activeMaintenanceRanges), уже существующие бронирования
+// не трогает.
+func (s *CalendarService) CreateMaintenanceWindow(
+	ctx context.Context,
+	req *calendarpb.CreateMaintenanceWindowRequest,
+) (*calendarpb.CreateMaintenanceWindowResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if req.GetStart() == nil || req.GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start and end are required")
+	}
+	start := req.GetStart().AsTime()
+	end := req.GetEnd().AsTime()
+	if !end.After(start) {
+		return nil, status.Error(codes.InvalidArgument, "end must be after start")
+	}
+	if s.db == nil || s.maintenanceRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "maintenance windows are not configured")
+	}
+
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	kind := model.MaintenanceWindowKindHard
+	if req.GetKind() == calendarpb.MaintenanceWindowKind_MAINTENANCE_WINDOW_KIND_SOFT {
+		kind = model.MaintenanceWindowKindSoft
+	}
+
+	window := &model.MaintenanceWindow{
+		ProviderID:  providerID,
+		StartsAt:    start,
+		EndsAt:      end,
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Reason:      req.GetReason(),
+		Kind:        kind,
+	}
+	if rrule := req.GetRrule(); rrule != "" {
+		window.RRule = &rrule
+	}
+	if sid := req.GetServiceId(); sid != "" {
+		if id, err := uuid.Parse(sid); err == nil {
+			window.ServiceID = &id
+		}
+	}
+	if createdBy := req.GetCreatedBy(); createdBy != "" {
+		if id, err := uuid.Parse(createdBy); err == nil {
+			window.CreatedBy = &id
+		}
+	}
+
+	var cancelledBookings int64
+	var blockedSlots int64
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(window).Error; err != nil {
+			return status.Errorf(codes.Internal, "create maintenance window: %v", err)
+		}
+
+		// Уже материализованные, но ещё не забронированные слоты в пределах
+		// этого (разового) окна сразу переводятся в blocked, чтобы не ждать
+		// следующего прогона scheduler.SlotMaterializer.Rebuild. Для окон с
+		// RRULE будущие вхождения получат статус blocked на очередной
+		// материализации (см. CalendarService.activeMaintenanceRanges).
+		blockRes := tx.Model(&model.TimeSlot{}).
+			Where("provider_id = ?", providerID).
+			Where("starts_at < ? AND ends_at > ?", end, start).
+			Where("status = ?", model.TimeSlotStatusPlanned).
+			Update("status", model.TimeSlotStatusBlocked)
+		if blockRes.Error != nil {
+			return status.Errorf(codes.Internal, "block overlapping slots: %v", blockRes.Error)
+		}
+		blockedSlots = blockRes.RowsAffected
+
+		if kind != model.MaintenanceWindowKindHard {
+			return nil
+		}
+
+		var bookingIDs []string
+		err := tx.Table("bookings").
+			Select("bookings.id").
+			Joins("JOIN time_slots ON time_slots.id = bookings.slot_id").
+			Where("time_slots.provider_id = ?", req.GetProviderId()).
+			Where("time_slots.starts_at < ? AND time_slots.ends_at > ?", end, start).
+			Where("bookings.status <> ?", model.BookingStatusCancelled).
+			Pluck("bookings.id", &bookingIDs).Error
+		if err != nil {
+			return status.Errorf(codes.Internal, "list affected bookings: %v", err)
+		}
+		if len(bookingIDs) == 0 {
+			return nil
+		}
+
+		now := time.Now().UTC()
+		res := tx.Model(&model.Booking{}).
+			Where("id IN ?", bookingIDs).
+			Where("status <> ?", model.BookingStatusCancelled).
+			Updates(map[string]any{
+				"status":       model.BookingStatusCancelled,
+				"cancelled_at": now,
+				"comment":      "Отменено автоматически: техническое обслуживание провайдера.",
+			})
+		if res.Error != nil {
+			return status.Errorf(codes.Internal, "cancel affected bookings: %v", res.Error)
+		}
+		cancelledBookings = res.RowsAffected
+		return nil
+	})
+	if err != nil {
+		s.logErr("CreateMaintenanceWindow", err, "provider_id", req.GetProviderId())
+		return nil, err
+	}
+
+	s.logInfo("CreateMaintenanceWindow", "provider_id", req.GetProviderId(), "kind", kind,
+		"cancelled_bookings", cancelledBookings, "blocked_slots", blockedSlots)
+
+	return &calendarpb.CreateMaintenanceWindowResponse{
+		Id:                window.ID.String(),
+		CancelledBookings: int32(cancelledBookings),
+		BlockedSlots:      int32(blockedSlots),
+	}, nil
+}
+
+// UpdateMaintenanceWindow правит поля существующего окна (расписание/kind/status
+// и т.п.). Немедленных побочных эффектов на слоты/бронирования не делает: их
+// досчитает очередной прогон scheduler.SlotMaterializer.Rebuild
+// (activeMaintenanceRanges/resyncMaintenanceStatusTx) и scheduler.MaintenanceScheduler
+// (для status=paused/active). Пустые строковые поля в запросе означают
+// "оставить как есть" — тот же контракт, что у UpdateSlot/UpdateProviderSchedule.
+func (s *CalendarService) UpdateMaintenanceWindow(
+	ctx context.Context,
+	req *calendarpb.UpdateMaintenanceWindowRequest,
+) (*calendarpb.UpdateMaintenanceWindowResponse, error) {
+	if req.GetWindowId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "window_id is required")
+	}
+	if s.maintenanceRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "maintenance windows are not configured")
+	}
+
+	window, err := s.maintenanceRepo.GetByID(ctx, req.GetWindowId())
+	if err != nil {
+		s.logErr("UpdateMaintenanceWindow", err, "window_id", req.GetWindowId())
+		return nil, status.Errorf(codes.NotFound, "maintenance window not found: %v", err)
+	}
+	if err := s.ensureProviderRole(ctx, window.ProviderID.String()); err != nil {
+		return nil, err
+	}
+
+	if req.GetStart() != nil {
+		window.StartsAt = req.GetStart().AsTime()
+	}
+	if req.GetEnd() != nil {
+		window.EndsAt = req.GetEnd().AsTime()
+	}
+	if !window.EndsAt.After(window.StartsAt) {
+		return nil, status.Error(codes.InvalidArgument, "end must be after start")
+	}
+	if req.GetName() != "" {
+		window.Name = req.GetName()
+	}
+	if req.GetDescription() != "" {
+		window.Description = req.GetDescription()
+	}
+	if req.GetReason() != "" {
+		window.Reason = req.GetReason()
+	}
+	if req.GetRrule() != "" {
+		rrule := req.GetRrule()
+		window.RRule = &rrule
+	}
+	switch req.GetKind() {
+	case calendarpb.MaintenanceWindowKind_MAINTENANCE_WINDOW_KIND_SOFT:
+		window.Kind = model.MaintenanceWindowKindSoft
+	case calendarpb.MaintenanceWindowKind_MAINTENANCE_WINDOW_KIND_HARD:
+		window.Kind = model.MaintenanceWindowKindHard
+	}
+	switch req.GetStatus() {
+	case calendarpb.MaintenanceWindowStatus_MAINTENANCE_WINDOW_STATUS_PAUSED:
+		window.Status = model.MaintenanceWindowStatusPaused
+	case calendarpb.MaintenanceWindowStatus_MAINTENANCE_WINDOW_STATUS_ACTIVE:
+		window.Status = model.MaintenanceWindowStatusActive
+	}
+
+	if err := s.maintenanceRepo.Update(ctx, window); err != nil {
+		s.logErr("UpdateMaintenanceWindow", err, "stage", "update maintenance window", "window_id", req.GetWindowId())
+		return nil, status.Errorf(codes.Internal, "update maintenance window: %v", err)
+	}
+
+	s.logInfo("UpdateMaintenanceWindow", "window_id", req.GetWindowId(), "status", window.Status)
+
+	return &calendarpb.UpdateMaintenanceWindowResponse{Window: mapMaintenanceWindow(window)}, nil
+}
+
+// ListMaintenanceWindows — список окон обслуживания провайдера.
+func (s *CalendarService) ListMaintenanceWindows(
+	ctx context.Context,
+	req *calendarpb.ListMaintenanceWindowsRequest,
+) (*calendarpb.ListMaintenanceWindowsResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if s.maintenanceRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "maintenance windows are not configured")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	from := time.Now().UTC()
+	to := from.AddDate(1, 0, 0)
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+
+	windows, err := s.maintenanceRepo.ListActiveForProvider(ctx, req.GetProviderId(), from, to)
+	if err != nil {
+		s.logErr("ListMaintenanceWindows", err, "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "list maintenance windows: %v", err)
+	}
+
+	out := make([]*calendarpb.MaintenanceWindow, 0, len(windows))
+	for i := range windows {
+		out = append(out, mapMaintenanceWindow(&windows[i]))
+	}
+	return &calendarpb.ListMaintenanceWindowsResponse{Windows: out}, nil
+}
+
+// DeleteMaintenanceWindow — удалить окно обслуживания. Слоты, заблокированные
+// им в рамках разового (без RRULE) диапазона, разблокируются сразу; для окон
+// с RRULE разблокировку будущих вхождений довершит очередной прогон
+// scheduler.SlotMaterializer.Rebuild, когда пересчитает activeMaintenanceRanges
+// уже без удалённого окна.
+func (s *CalendarService) DeleteMaintenanceWindow(
+	ctx context.Context,
+	req *calendarpb.DeleteMaintenanceWindowRequest,
+) (*calendarpb.DeleteMaintenanceWindowResponse, error) {
+	if req.GetWindowId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "window_id is required")
+	}
+	if s.db == nil || s.maintenanceRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "maintenance windows are not configured")
+	}
+
+	window, err := s.maintenanceRepo.GetByID(ctx, req.GetWindowId())
+	if err != nil {
+		s.logErr("DeleteMaintenanceWindow", err, "window_id", req.GetWindowId())
+		return nil, status.Errorf(codes.NotFound, "maintenance window not found: %v", err)
+	}
+	if err := s.ensureProviderRole(ctx, window.ProviderID.String()); err != nil {
+		return nil, err
+	}
+
+	var unblockedSlots int64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.MaintenanceWindow{}, "id = ?", req.GetWindowId()).Error; err != nil {
+			return status.Errorf(codes.Internal, "delete maintenance window: %v", err)
+		}
+		if window.RRule != nil {
+			return nil
+		}
+		res := tx.Model(&model.TimeSlot{}).
+			Where("provider_id = ?", window.ProviderID).
+			Where("starts_at < ? AND ends_at > ?", window.EndsAt, window.StartsAt).
+			Where("status = ?", model.TimeSlotStatusBlocked).
+			Update("status", model.TimeSlotStatusPlanned)
+		if res.Error != nil {
+			return status.Errorf(codes.Internal, "unblock slots: %v", res.Error)
+		}
+		unblockedSlots = res.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logInfo("DeleteMaintenanceWindow", "window_id", req.GetWindowId(), "unblocked_slots", unblockedSlots)
+	return &calendarpb.DeleteMaintenanceWindowResponse{}, nil
+}
+
+// CreateUnavailability регистрирует разовый период недоступности провайдера
+// (отпуск/больничный) — в отличие от CreateMaintenanceWindow не отменяет уже
+// подтверждённые бронирования, а только не даёт material­изатору и
+// CreateBooking считать пересекающиеся слоты свободными (см.
+// activeUnavailabilityRanges). Пересекающиеся или вплотную примыкающие
+// периоды того же провайдера и услуги автоматически сливаются в один (см.
+// repository.GormUnavailabilityRepository.Create).
+func (s *CalendarService) CreateUnavailability(
+	ctx context.Context,
+	req *calendarpb.CreateUnavailabilityRequest,
+) (*calendarpb.CreateUnavailabilityResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if req.GetStart() == nil || req.GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start and end are required")
+	}
+	start := req.GetStart().AsTime()
+	end := req.GetEnd().AsTime()
+	if !end.After(start) {
+		return nil, status.Error(codes.InvalidArgument, "end must be after start")
+	}
+	if s.unavailabilityRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "unavailability periods are not configured")
+	}
+
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	period := &model.UnavailabilityPeriod{
+		ProviderID: providerID,
+		StartsAt:   start,
+		EndsAt:     end,
+		Reason:     req.GetReason(),
+	}
+	if sid := req.GetServiceId(); sid != "" {
+		id, err := uuid.Parse(sid)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid service_id")
+		}
+		period.ServiceID = &id
+	}
+
+	merged, err := s.unavailabilityRepo.Create(ctx, period)
+	if err != nil {
+		s.logErr("CreateUnavailability", err, "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "create unavailability period: %v", err)
+	}
+
+	var blockedSlots int64
+	if s.db != nil {
+		res := s.db.WithContext(ctx).Model(&model.TimeSlot{}).
+			Where("provider_id = ?", providerID).
+			Where("starts_at < ? AND ends_at > ?", merged.EndsAt, merged.StartsAt).
+			Where("status = ?", model.TimeSlotStatusPlanned).
+			Update("status", model.TimeSlotStatusUnavailable)
+		if res.Error != nil {
+			s.logErr("CreateUnavailability", res.Error, "stage", "block overlapping slots", "provider_id", req.GetProviderId())
+			return nil, status.Errorf(codes.Internal, "block overlapping slots: %v", res.Error)
+		}
+		blockedSlots = res.RowsAffected
+	}
+
+	s.logInfo("CreateUnavailability", "provider_id", req.GetProviderId(), "period_id", merged.ID.String(), "blocked_slots", blockedSlots)
+
+	return &calendarpb.CreateUnavailabilityResponse{
+		Period:       mapUnavailabilityPeriod(merged),
+		BlockedSlots: int32(blockedSlots),
+	}, nil
+}
+
+// ListUnavailability — список периодов недоступности провайдера,
+// пересекающихся с [from, to) (по умолчанию — ближайший год).
+func (s *CalendarService) ListUnavailability(
+	ctx context.Context,
+	req *calendarpb.ListUnavailabilityRequest,
+) (*calendarpb.ListUnavailabilityResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if s.unavailabilityRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "unavailability periods are not configured")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
+
+	from := time.Now().UTC()
+	to := from.AddDate(1, 0, 0)
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+
+	periods, err := s.unavailabilityRepo.ListForProvider(ctx, req.GetProviderId(), from, to)
+	if err != nil {
+		s.logErr("ListUnavailability", err, "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "list unavailability periods: %v", err)
+	}
+
+	out := make([]*calendarpb.UnavailabilityPeriod, 0, len(periods))
+	for i := range periods {
+		out = append(out, mapUnavailabilityPeriod(&periods[i]))
+	}
+	return &calendarpb.ListUnavailabilityResponse{Periods: out}, nil
+}
+
+// DeleteUnavailability — удалить период недоступности. Слоты, помеченные им
+// как unavailable, сразу возвращаются в planned; следующий прогон
+// scheduler.SlotMaterializer.Rebuild лишь подтвердит это при пересчёте
+// activeUnavailabilityRanges уже без удалённого периода.
+func (s *CalendarService) DeleteUnavailability(
+	ctx context.Context,
+	req *calendarpb.DeleteUnavailabilityRequest,
+) (*calendarpb.DeleteUnavailabilityResponse, error) {
+	if req.GetPeriodId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "period_id is required")
+	}
+	if s.db == nil || s.unavailabilityRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "unavailability periods are not configured")
+	}
+
+	period, err := s.unavailabilityRepo.GetByID(ctx, req.GetPeriodId())
+	if err != nil {
+		s.logErr("DeleteUnavailability", err, "period_id", req.GetPeriodId())
+		return nil, status.Errorf(codes.NotFound, "unavailability period not found: %v", err)
+	}
+	if err := s.ensureProviderRole(ctx, period.ProviderID.String()); err != nil {
+		return nil, err
+	}
+
+	var unblockedSlots int64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.UnavailabilityPeriod{}, "id = ?", req.GetPeriodId()).Error; err != nil {
+			return status.Errorf(codes.Internal, "delete unavailability period: %v", err)
+		}
+		res := tx.Model(&model.TimeSlot{}).
+			Where("provider_id = ?", period.ProviderID).
+			Where("starts_at < ? AND ends_at > ?", period.EndsAt, period.StartsAt).
+			Where("status = ?", model.TimeSlotStatusUnavailable).
+			Update("status", model.TimeSlotStatusPlanned)
+		if res.Error != nil {
+			return status.Errorf(codes.Internal, "unblock slots: %v", res.Error)
+		}
+		unblockedSlots = res.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logInfo("DeleteUnavailability", "period_id", req.GetPeriodId(), "unblocked_slots", unblockedSlots)
+	return &calendarpb.DeleteUnavailabilityResponse{}, nil
+}
+
+// CreateBlackout заводит период полной недоступности услуги (отпуск
+// мастера по конкретной услуге, праздничный день и т.п.) — в отличие от
+// CreateUnavailability это не провайдер целиком, а необязательно одна
+// услуга (ServiceID); слоты, пересекающиеся с blackout-диапазоном,
+// scheduler.SlotMaterializer затем помечает недоступными (HasOverlap), а не
+// вычитает через calendarutils.BlackoutSet.
+// Запись пишется в events (EventTypeBlackoutAdded) в той же транзакции, что
+// блокировка пересекающихся слотов.
+func (s *CalendarService) CreateBlackout(
+	ctx context.Context,
+	req *calendarpb.CreateBlackoutRequest,
+) (*calendarpb.CreateBlackoutResponse, error) {
+	if req.GetStart() == nil || req.GetEnd() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start and end are required")
+	}
+	start := req.GetStart().AsTime()
+	end := req.GetEnd().AsTime()
+	if !end.After(start) {
+		return nil, status.Error(codes.InvalidArgument, "end must be after start")
+	}
+	if s.db == nil || s.blackoutRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "blackouts are not configured")
+	}
+
+	blackout := &model.Blackout{
+		StartsAt: start,
+		EndsAt:   end,
+		Reason:   req.GetReason(),
+	}
+	if sid := req.GetServiceId(); sid != "" {
+		if s.serviceRepo != nil {
+			if _, err := s.serviceRepo.GetByID(ctx, sid); err != nil {
+				return nil, status.Errorf(codes.NotFound, "service not found: %v", err)
+			}
+		}
+		id, err := uuid.Parse(sid)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid service_id")
+		}
+		blackout.ServiceID = &id
+	}
+
+	created, err := s.blackoutRepo.Create(ctx, blackout)
+	if err != nil {
+		s.logErr("CreateBlackout", err, "service_id", req.GetServiceId())
+		return nil, status.Errorf(codes.Internal, "create blackout: %v", err)
+	}
+
+	var blockedSlots int64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		q := tx.Model(&model.TimeSlot{}).
+			Where("starts_at < ? AND ends_at > ?", created.EndsAt, created.StartsAt).
+			Where("status = ?", model.TimeSlotStatusPlanned)
+		if created.ServiceID != nil {
+			q = q.Where("service_id = ?", *created.ServiceID)
+		}
+		res := q.Update("status", model.TimeSlotStatusUnavailable)
+		if res.Error != nil {
+			return status.Errorf(codes.Internal, "block overlapping slots: %v", res.Error)
+		}
+		blockedSlots = res.RowsAffected
+
+		details, err := json.Marshal(created)
+		if err != nil {
+			return fmt.Errorf("marshal blackout details: %w", err)
+		}
+		return writeAuditEvent(tx, model.EventTypeBlackoutAdded, string(details))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logInfo("CreateBlackout", "blackout_id", created.ID.String(), "blocked_slots", blockedSlots)
+	return &calendarpb.CreateBlackoutResponse{
+		Blackout:     mapBlackout(created),
+		BlockedSlots: int32(blockedSlots),
+	}, nil
+}
+
+// ListBlackouts — список blackout-ов услуги (и общих для всех услуг, с
+// ServiceID == nil), пересекающихся с [from, to) (по умолчанию — ближайший
+// год).
+func (s *CalendarService) ListBlackouts(
+	ctx context.Context,
+	req *calendarpb.ListBlackoutsRequest,
+) (*calendarpb.ListBlackoutsResponse, error) {
+	if s.blackoutRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "blackouts are not configured")
+	}
+
+	from := time.Now().UTC()
+	to := from.AddDate(1, 0, 0)
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+
+	rows, err := s.blackoutRepo.ListForService(ctx, req.GetServiceId(), from, to)
+	if err != nil {
+		s.logErr("ListBlackouts", err, "service_id", req.GetServiceId())
+		return nil, status.Errorf(codes.Internal, "list blackouts: %v", err)
+	}
+
+	out := make([]*calendarpb.Blackout, 0, len(rows))
+	for i := range rows {
+		out = append(out, mapBlackout(&rows[i]))
+	}
+	return &calendarpb.ListBlackoutsResponse{Blackouts: out}, nil
+}
+
+// DeleteBlackout — удалить blackout. Слоты, помеченные им как unavailable,
+// сразу возвращаются в planned; событие EventTypeBlackoutRemoved пишется в
+// той же транзакции.
+func (s *CalendarService) DeleteBlackout(
+	ctx context.Context,
+	req *calendarpb.DeleteBlackoutRequest,
+) (*calendarpb.DeleteBlackoutResponse, error) {
+	if req.GetBlackoutId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "blackout_id is required")
+	}
+	if s.db == nil || s.blackoutRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "blackouts are not configured")
+	}
+
+	blackout, err := s.blackoutRepo.GetByID(ctx, req.GetBlackoutId())
+	if err != nil {
+		s.logErr("DeleteBlackout", err, "blackout_id", req.GetBlackoutId())
+		return nil, status.Errorf(codes.NotFound, "blackout not found: %v", err)
+	}
+
+	var unblockedSlots int64
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.Blackout{}, "id = ?", req.GetBlackoutId()).Error; err != nil {
+			return status.Errorf(codes.Internal, "delete blackout: %v", err)
+		}
+		q := tx.Model(&model.TimeSlot{}).
+			Where("starts_at < ? AND ends_at > ?", blackout.EndsAt, blackout.StartsAt).
+			Where("status = ?", model.TimeSlotStatusUnavailable)
+		if blackout.ServiceID != nil {
+			q = q.Where("service_id = ?", *blackout.ServiceID)
+		}
+		res := q.Update("status", model.TimeSlotStatusPlanned)
+		if res.Error != nil {
+			return status.Errorf(codes.Internal, "unblock slots: %v", res.Error)
+		}
+		unblockedSlots = res.RowsAffected
+
+		return writeAuditEvent(tx, model.EventTypeBlackoutRemoved, blackout.ID.String())
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	s.logInfo("CreateProviderSchedule", "schedule_id", sched.ID.String(), "provider_id", sched.ProviderID.String())
+	s.logInfo("DeleteBlackout", "blackout_id", req.GetBlackoutId(), "unblocked_slots", unblockedSlots)
+	return &calendarpb.DeleteBlackoutResponse{}, nil
+}
 
-	return &calendarpb.CreateProviderScheduleResponse{Schedule: mapProviderSchedule(&sched)}, nil
+// mapBlackout переводит модель blackout-а в protobuf-представление.
+func mapBlackout(b *model.Blackout) *calendarpb.Blackout {
+	out := &calendarpb.Blackout{
+		Id:     b.ID.String(),
+		Start:  timestamppb.New(b.StartsAt),
+		End:    timestamppb.New(b.EndsAt),
+		Reason: b.Reason,
+	}
+	if b.ServiceID != nil {
+		out.ServiceId = b.ServiceID.String()
+	}
+	return out
 }
 
-// UpdateProviderSchedule — обновить расписание.
-func (s *CalendarService) UpdateProviderSchedule(
+// CreateScheduleTemplate заводит именованный шаблон расписания провайдера:
+// дефолтное правило повторения, список услуг и длительность слота, которые
+// ApplyScheduleTemplate затем копирует в конкретные Schedule под любой
+// диапазон дат.
+func (s *CalendarService) CreateScheduleTemplate(
 	ctx context.Context,
-	req *calendarpb.UpdateProviderScheduleRequest,
-) (*calendarpb.UpdateProviderScheduleResponse, error) {
-	if req.GetScheduleId() == "" {
-		return nil, status.Error(codes.InvalidArgument, "schedule_id is required")
+	req *calendarpb.CreateScheduleTemplateRequest,
+) (*calendarpb.CreateScheduleTemplateResponse, error) {
+	if req.GetOwnerId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "owner_id is required")
+	}
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is required")
+	}
+	if s.scheduleTemplateRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "schedule templates are not configured")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetOwnerId()); err != nil {
+		return nil, err
 	}
 
-	ps := req.GetSchedule()
-	if ps == nil {
-		return nil, status.Error(codes.InvalidArgument, "schedule is required")
+	ownerID, err := uuid.Parse(req.GetOwnerId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid owner_id")
 	}
 
-	// обновляем timezone / rule при необходимости
-	schedID, err := uuid.Parse(req.GetScheduleId())
+	ruleJSON, err := encodeScheduleRule(req.GetDefaultRule())
 	if err != nil {
-		return nil, status.Error(codes.InvalidArgument, "invalid schedule_id")
+		s.logErr("CreateScheduleTemplate", err, "stage", "encode default rule")
+		return nil, status.Errorf(codes.InvalidArgument, "invalid default_rule: %v", err)
 	}
 
-	existing, err := s.scheduleRepo.GetByID(ctx, req.GetScheduleId())
+	serviceIDsJSON, err := json.Marshal(req.GetDefaultServiceIds())
 	if err != nil {
-		s.logErr("UpdateProviderSchedule", err, "stage", "get schedule", "schedule_id", req.GetScheduleId())
-		return nil, status.Errorf(codes.NotFound, "schedule not found: %v", err)
+		return nil, status.Errorf(codes.Internal, "encode default_service_ids: %v", err)
 	}
 
-	targetProviderID := existing.ProviderID.String()
-	if ps.GetProviderId() != "" {
-		targetProviderID = ps.GetProviderId()
+	durationMin := req.GetDefaultDurationMin()
+	if durationMin <= 0 {
+		durationMin = 30
 	}
 
-	if err := s.ensureProviderRole(ctx, targetProviderID); err != nil {
+	template := &model.ScheduleTemplate{
+		OwnerID:            ownerID,
+		Name:               req.GetName(),
+		DefaultRule:        ruleJSON,
+		DefaultServiceIDs:  datatypes.JSON(serviceIDsJSON),
+		DefaultDurationMin: durationMin,
+	}
+	if err := s.scheduleTemplateRepo.Create(ctx, template); err != nil {
+		s.logErr("CreateScheduleTemplate", err, "owner_id", req.GetOwnerId())
+		return nil, status.Errorf(codes.Internal, "create schedule template: %v", err)
+	}
+
+	s.logInfo("CreateScheduleTemplate", "template_id", template.ID.String(), "owner_id", template.OwnerID.String())
+
+	return &calendarpb.CreateScheduleTemplateResponse{Template: mapScheduleTemplate(template)}, nil
+}
+
+// ApplyScheduleTemplate материализует шаблон provider-а в конкретные
+// Schedule на [start_date, end_date]: по одному расписанию на каждую услугу
+// из DefaultServiceIDs шаблона (или одно — без привязки к услуге, если
+// список пуст), с таймзоной, унаследованной от последнего расписания
+// провайдера (см. ScheduleRepository.ListByProvider), либо UTC, если их ещё
+// нет. Идемпотентно по (provider_id, template_id, start_date): повторный
+// вызов с теми же аргументами находит уже созданные ApplyScheduleTemplate
+// расписания (см. ScheduleTemplateRepository.FindApplication) и возвращает
+// их вместо дублирования; service_id дополнительно различает расписания,
+// когда шаблон перечисляет несколько услуг.
+func (s *CalendarService) ApplyScheduleTemplate(
+	ctx context.Context,
+	req *calendarpb.ApplyScheduleTemplateRequest,
+) (*calendarpb.ApplyScheduleTemplateResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if req.GetTemplateId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "template_id is required")
+	}
+	if req.GetStartDate() == nil || req.GetEndDate() == nil {
+		return nil, status.Error(codes.InvalidArgument, "start_date and end_date are required")
+	}
+	if s.scheduleTemplateRepo == nil || s.scheduleRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "schedule templates are not configured")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
 		return nil, err
 	}
 
-	// запрещаем менять владельца расписания
-	if ps.GetProviderId() != "" && ps.GetProviderId() != existing.ProviderID.String() {
-		return nil, status.Error(codes.PermissionDenied, "schedule owner cannot be changed")
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
 	}
 
-	ruleJSON, err := encodeScheduleRule(ps.GetRule())
+	template, err := s.scheduleTemplateRepo.GetByID(ctx, req.GetTemplateId())
 	if err != nil {
-		s.logErr("UpdateProviderSchedule", err, "stage", "encode rule")
-		return nil, status.Errorf(codes.InvalidArgument, "invalid rule: %v", err)
+		s.logErr("ApplyScheduleTemplate", err, "template_id", req.GetTemplateId())
+		return nil, status.Errorf(codes.NotFound, "schedule template not found: %v", err)
 	}
 
-	sched := model.Schedule{
-		ID:        schedID,
-		TimeZone:  ps.GetTimeZone(),
-		StartDate: protoDateToDate(ps.GetStartDate()),
-		EndDate:   protoDateToDate(ps.GetEndDate()),
-		Rules:     ruleJSON,
+	startDate := protoDateToDate(req.GetStartDate())
+	endDate := protoDateToDate(req.GetEndDate())
+
+	timeZone := "UTC"
+	if existing, err := s.scheduleRepo.ListByProvider(ctx, req.GetProviderId()); err == nil && len(existing) > 0 {
+		timeZone = existing[0].TimeZone
 	}
 
-	if ps.GetProviderId() != "" {
-		if pid, err := uuid.Parse(ps.GetProviderId()); err == nil {
-			sched.ProviderID = pid
+	var serviceIDs []*string
+	var rawServiceIDs []string
+	if err := json.Unmarshal(template.DefaultServiceIDs, &rawServiceIDs); err == nil && len(rawServiceIDs) > 0 {
+		for i := range rawServiceIDs {
+			serviceIDs = append(serviceIDs, &rawServiceIDs[i])
 		}
+	} else {
+		serviceIDs = []*string{nil}
 	}
 
-	if err := s.scheduleRepo.Update(ctx, &sched); err != nil {
-		s.logErr("UpdateProviderSchedule", err, "stage", "update schedule")
-		return nil, status.Errorf(codes.Internal, "update schedule: %v", err)
+	schedules := make([]*commonpb.ProviderSchedule, 0, len(serviceIDs))
+	var created, skipped int32
+	for _, sidStr := range serviceIDs {
+		existing, err := s.scheduleTemplateRepo.FindApplication(ctx, req.GetProviderId(), req.GetTemplateId(), sidStr, *startDate)
+		if err == nil {
+			schedules = append(schedules, mapProviderSchedule(existing))
+			skipped++
+			continue
+		}
+		if !errors.Is(err, errs.ErrNotFound("")) {
+			s.logErr("ApplyScheduleTemplate", err, "stage", "check existing application", "template_id", req.GetTemplateId())
+			return nil, status.Errorf(codes.Internal, "check existing application: %v", err)
+		}
+
+		sched := &model.Schedule{
+			ProviderID: providerID,
+			TemplateID: &template.ID,
+			TimeZone:   timeZone,
+			StartDate:  startDate,
+			EndDate:    endDate,
+			Rules:      template.DefaultRule,
+			Capacity:   1,
+		}
+		if sidStr != nil {
+			id, err := uuid.Parse(*sidStr)
+			if err != nil {
+				s.logErr("ApplyScheduleTemplate", err, "stage", "parse default service id", "service_id", *sidStr)
+				continue
+			}
+			sched.ServiceID = &id
+		}
+
+		if err := s.scheduleRepo.Create(ctx, sched); err != nil {
+			s.logErr("ApplyScheduleTemplate", err, "stage", "create schedule from template", "template_id", req.GetTemplateId())
+			return nil, status.Errorf(codes.Internal, "apply schedule template: %v", err)
+		}
+		schedules = append(schedules, mapProviderSchedule(sched))
+		created++
 	}
 
-	s.logInfo("UpdateProviderSchedule", "schedule_id", sched.ID.String(), "provider_id", sched.ProviderID.String())
+	s.logInfo("ApplyScheduleTemplate", "template_id", req.GetTemplateId(), "provider_id", req.GetProviderId(), "created", created, "skipped", skipped)
 
-	return &calendarpb.UpdateProviderScheduleResponse{Schedule: mapProviderSchedule(&sched)}, nil
+	return &calendarpb.ApplyScheduleTemplateResponse{
+		Schedules: schedules,
+		Created:   created,
+		Skipped:   skipped,
+	}, nil
 }
 
-// DeleteProviderSchedule — удалить расписание.
-func (s *CalendarService) DeleteProviderSchedule(
-	ctx context.Context,
-	req *calendarpb.DeleteProviderScheduleRequest,
-) (*calendarpb.DeleteProviderScheduleResponse, error) {
-	if req.GetScheduleId() == "" {
-		return nil, status.Error(codes.InvalidArgument, "schedule_id is required")
-	}
-	sched, err := s.scheduleRepo.GetByID(ctx, req.GetScheduleId())
-	if err != nil {
-		s.logErr("DeleteProviderSchedule", err, "stage", "get schedule", "schedule_id", req.GetScheduleId())
-		return nil, status.Errorf(codes.NotFound, "schedule not found: %v", err)
+// mapScheduleTemplate переводит модель шаблона расписания в protobuf-представление.
+func mapScheduleTemplate(t *model.ScheduleTemplate) *commonpb.ScheduleTemplate {
+	var serviceIDs []string
+	_ = json.Unmarshal(t.DefaultServiceIDs, &serviceIDs)
+
+	return &commonpb.ScheduleTemplate{
+		Id:                 t.ID.String(),
+		OwnerId:            t.OwnerID.String(),
+		Name:               t.Name,
+		DefaultRule:        decodeScheduleRule(t.DefaultRule),
+		DefaultServiceIds:  serviceIDs,
+		DefaultDurationMin: t.DefaultDurationMin,
 	}
-	if err := s.ensureProviderRole(ctx, sched.ProviderID.String()); err != nil {
-		return nil, err
+}
+
+// mapUnavailabilityPeriod переводит модель периода недоступности в protobuf-представление.
+func mapUnavailabilityPeriod(p *model.UnavailabilityPeriod) *calendarpb.UnavailabilityPeriod {
+	out := &calendarpb.UnavailabilityPeriod{
+		Id:         p.ID.String(),
+		ProviderId: p.ProviderID.String(),
+		Start:      timestamppb.New(p.StartsAt),
+		End:        timestamppb.New(p.EndsAt),
+		Reason:     p.Reason,
 	}
-	if err := s.scheduleRepo.Delete(ctx, req.GetScheduleId()); err != nil {
-		s.logErr("DeleteProviderSchedule", err, "stage", "delete schedule")
-		return nil, status.Errorf(codes.Internal, "delete schedule: %v", err)
+	if p.ServiceID != nil {
+		out.ServiceId = p.ServiceID.String()
 	}
+	return out
+}
 
-	s.logInfo("DeleteProviderSchedule", "schedule_id", req.GetScheduleId(), "provider_id", sched.ProviderID.String())
-	return &calendarpb.DeleteProviderScheduleResponse{}, nil
+// mapMaintenanceWindow переводит модель окна обслуживания в protobuf-представление.
+func mapMaintenanceWindow(w *model.MaintenanceWindow) *calendarpb.MaintenanceWindow {
+	out := &calendarpb.MaintenanceWindow{
+		Id:          w.ID.String(),
+		ProviderId:  w.ProviderID.String(),
+		Start:       timestamppb.New(w.StartsAt),
+		End:         timestamppb.New(w.EndsAt),
+		Name:        w.Name,
+		Description: w.Description,
+		Reason:      w.Reason,
+	}
+	if w.RRule != nil {
+		out.Rrule = *w.RRule
+	}
+	if w.ServiceID != nil {
+		out.ServiceId = w.ServiceID.String()
+	}
+	if w.CreatedBy != nil {
+		out.CreatedBy = w.CreatedBy.String()
+	}
+	if w.Kind == model.MaintenanceWindowKindSoft {
+		out.Kind = calendarpb.MaintenanceWindowKind_MAINTENANCE_WINDOW_KIND_SOFT
+	} else {
+		out.Kind = calendarpb.MaintenanceWindowKind_MAINTENANCE_WINDOW_KIND_HARD
+	}
+	switch w.Status {
+	case model.MaintenanceWindowStatusPaused:
+		out.Status = calendarpb.MaintenanceWindowStatus_MAINTENANCE_WINDOW_STATUS_PAUSED
+	case model.MaintenanceWindowStatusExpired:
+		out.Status = calendarpb.MaintenanceWindowStatus_MAINTENANCE_WINDOW_STATUS_EXPIRED
+	default:
+		out.Status = calendarpb.MaintenanceWindowStatus_MAINTENANCE_WINDOW_STATUS_ACTIVE
+	}
+	return out
 }
 
-// BulkCancelProviderSlots — массовая отмена слотов провайдера в интервале.
-// Отменяет и связанные бронирования (если есть) и возвращает список затронутых записей,
-// чтобы внешний слой мог уведомить клиентов.
-func (s *CalendarService) BulkCancelProviderSlots(
+// CreateResource — зарегистрировать ресурс провайдера (staff/room/equipment,
+// см. model.Resource), который затем можно закрепить за слотами через
+// resource_ids в CreateSlot и учитывать в ListFreeSlots/CreateBooking.
+func (s *CalendarService) CreateResource(
 	ctx context.Context,
-	req *calendarpb.BulkCancelProviderSlotsRequest,
-) (*calendarpb.BulkCancelProviderSlotsResponse, error) {
-	if req.GetProviderId() == "" {
-		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
-	}
-	if req.GetStart() == nil || req.GetEnd() == nil {
-		return nil, status.Error(codes.InvalidArgument, "start and end are required")
-	}
-	start := req.GetStart().AsTime()
-	end := req.GetEnd().AsTime()
-	if !end.After(start) {
-		return nil, status.Error(codes.InvalidArgument, "end must be after start")
+	req *calendarpb.CreateResourceRequest,
+) (*calendarpb.CreateResourceResponse, error) {
+	if req.GetProviderId() == "" || req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id and name are required")
 	}
-	if s.db == nil {
-		return nil, status.Error(codes.FailedPrecondition, "db is not configured")
+	if s.resourceRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "resources are not configured")
 	}
-
 	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
 		return nil, err
 	}
 
-	type affectedBookingRow struct {
-		BookingID        string    `gorm:"column:booking_id"`
-		SlotID           string    `gorm:"column:slot_id"`
-		ClientID         string    `gorm:"column:client_id"`
-		ClientUserID     string    `gorm:"column:client_user_id"`
-		ClientTelegramID int64     `gorm:"column:client_telegram_id"`
-		ProviderID       string    `gorm:"column:provider_id"`
-		ServiceID        *string   `gorm:"column:service_id"`
-		StartsAt         time.Time `gorm:"column:starts_at"`
-		EndsAt           time.Time `gorm:"column:ends_at"`
+	providerID, err := uuid.Parse(req.GetProviderId())
+	if err != nil {
+		s.logErr("CreateResource", err, "provider_id", req.GetProviderId())
+		return nil, status.Error(codes.InvalidArgument, "invalid provider_id")
 	}
 
-	resp := &calendarpb.BulkCancelProviderSlotsResponse{
-		AffectedBookings: []*calendarpb.AffectedBooking{},
+	kind := model.ResourceKind(req.GetKind())
+	switch kind {
+	case model.ResourceKindStaff, model.ResourceKindRoom, model.ResourceKindEquipment:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown resource kind: %q", req.GetKind())
 	}
 
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 1) Собрать активные бронирования в окне (для уведомлений) до обновлений.
-		var rows []affectedBookingRow
-		q := tx.Table("bookings").
-			Select(
-				"bookings.id AS booking_id, bookings.slot_id AS slot_id, bookings.client_id AS client_id, "+
-					"clients.user_id AS client_user_id, users.telegram_id AS client_telegram_id, "+
-					"time_slots.provider_id AS provider_id, time_slots.service_id AS service_id, "+
-					"time_slots.starts_at AS starts_at, time_slots.ends_at AS ends_at",
-			).
-			Joins("JOIN time_slots ON time_slots.id = bookings.slot_id").
-			Joins("JOIN clients ON clients.id = bookings.client_id").
-			Joins("JOIN users ON users.id = clients.user_id").
-			Where("time_slots.provider_id = ?", req.GetProviderId()).
-			Where("time_slots.starts_at >= ? AND time_slots.ends_at <= ?", start, end).
-			Where("time_slots.status <> ?", model.TimeSlotStatusCancelled).
-			Where("bookings.status <> ?", model.BookingStatusCancelled)
-
-		if err := q.Scan(&rows).Error; err != nil {
-			s.logErr("BulkCancelProviderSlots", err, "stage", "list affected bookings")
-			return status.Errorf(codes.Internal, "list affected bookings: %v", err)
-		}
-
-		// 2) Отменить бронирования (если есть).
-		var cancelledBookings int64
-		if len(rows) > 0 {
-			bookingIDs := make([]string, 0, len(rows))
-			for i := range rows {
-				bookingIDs = append(bookingIDs, rows[i].BookingID)
-			}
-			now := time.Now().UTC()
-			update := map[string]any{
-				"status":       model.BookingStatusCancelled,
-				"cancelled_at": now,
-			}
-			if req.GetReason() != "" {
-				update["comment"] = req.GetReason()
-			}
-			res := tx.Model(&model.Booking{}).
-				Where("id IN ?", bookingIDs).
-				Where("status <> ?", model.BookingStatusCancelled).
-				Updates(update)
-			if res.Error != nil {
-				s.logErr("BulkCancelProviderSlots", res.Error, "stage", "cancel bookings")
-				return status.Errorf(codes.Internal, "cancel bookings: %v", res.Error)
-			}
-			cancelledBookings = res.RowsAffected
+	resource := &model.Resource{
+		ProviderID: providerID,
+		Kind:       kind,
+		Name:       req.GetName(),
+		IsActive:   true,
+	}
+	if err := s.resourceRepo.Create(ctx, resource); err != nil {
+		s.logErr("CreateResource", err, "stage", "create resource")
+		return nil, status.Errorf(codes.Internal, "create resource: %v", err)
+	}
 
-			resp.AffectedBookings = make([]*calendarpb.AffectedBooking, 0, len(rows))
-			for i := range rows {
-				serviceID := ""
-				if rows[i].ServiceID != nil {
-					serviceID = *rows[i].ServiceID
-				}
-				resp.AffectedBookings = append(resp.AffectedBookings, &calendarpb.AffectedBooking{
-					BookingId:        rows[i].BookingID,
-					SlotId:           rows[i].SlotID,
-					ClientId:         rows[i].ClientID,
-					ClientUserId:     rows[i].ClientUserID,
-					ClientTelegramId: rows[i].ClientTelegramID,
-					ProviderId:       rows[i].ProviderID,
-					ServiceId:        serviceID,
-					StartsAt:         timestamppb.New(rows[i].StartsAt),
-					EndsAt:           timestamppb.New(rows[i].EndsAt),
-				})
-			}
-		}
-		resp.CancelledBookings = int32(cancelledBookings)
+	s.logInfo("CreateResource", "resource_id", resource.ID.String(), "provider_id", req.GetProviderId(), "kind", string(kind))
+	return &calendarpb.CreateResourceResponse{Resource: mapResource(resource)}, nil
+}
 
-		// 3) Отменить слоты провайдера в окне.
-		res := tx.Model(&model.TimeSlot{}).
-			Where("provider_id = ?", req.GetProviderId()).
-			Where("starts_at >= ? AND ends_at <= ?", start, end).
-			Where("status <> ?", model.TimeSlotStatusCancelled).
-			Update("status", model.TimeSlotStatusCancelled)
-		if res.Error != nil {
-			s.logErr("BulkCancelProviderSlots", res.Error, "stage", "cancel slots")
-			return status.Errorf(codes.Internal, "cancel slots: %v", res.Error)
-		}
-		resp.CancelledSlots = int32(res.RowsAffected)
+// ListResources — ресурсы провайдера, доступные для закрепления за слотами.
+func (s *CalendarService) ListResources(
+	ctx context.Context,
+	req *calendarpb.ListResourcesRequest,
+) (*calendarpb.ListResourcesResponse, error) {
+	if req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider_id is required")
+	}
+	if s.resourceRepo == nil {
+		return nil, status.Error(codes.FailedPrecondition, "resources are not configured")
+	}
+	if err := s.ensureProviderRole(ctx, req.GetProviderId()); err != nil {
+		return nil, err
+	}
 
-		return nil
-	})
+	resources, err := s.resourceRepo.ListByProvider(ctx, req.GetProviderId())
 	if err != nil {
-		return nil, err
+		s.logErr("ListResources", err, "provider_id", req.GetProviderId())
+		return nil, status.Errorf(codes.Internal, "list resources: %v", err)
 	}
 
-	s.logInfo("BulkCancelProviderSlots", "provider_id", req.GetProviderId(), "start", start, "end", end, "cancelled_slots", resp.GetCancelledSlots(), "cancelled_bookings", resp.GetCancelledBookings())
+	out := make([]*calendarpb.Resource, 0, len(resources))
+	for i := range resources {
+		out = append(out, mapResource(&resources[i]))
+	}
+	return &calendarpb.ListResourcesResponse{Resources: out}, nil
+}
 
-	return resp, nil
+// mapResource переводит модель ресурса в protobuf-представление.
+func mapResource(r *model.Resource) *calendarpb.Resource {
+	return &calendarpb.Resource{
+		Id:         r.ID.String(),
+		ProviderId: r.ProviderID.String(),
+		Kind:       string(r.Kind),
+		Name:       r.Name,
+		IsActive:   r.IsActive,
+	}
 }
 
 // CreateSlot — добавить слот провайдера.
@@ -1237,6 +4477,15 @@ func (s *CalendarService) CreateSlot(
 		return nil, status.Error(codes.InvalidArgument, "end must be after start")
 	}
 
+	maintenanceRanges, err := s.activeMaintenanceRanges(ctx, providerID, start, end)
+	if err != nil {
+		s.logErr("CreateSlot", err, "stage", "list maintenance windows")
+		return nil, status.Errorf(codes.Internal, "list maintenance windows: %v", err)
+	}
+	if has, _ := calendarutils.HasOverlap(calendarutils.TimeRange{Start: start, End: end}, maintenanceRanges, false); has {
+		return nil, status.Error(codes.FailedPrecondition, "provider is under maintenance during this slot")
+	}
+
 	slot := model.TimeSlot{
 		ProviderID: providerID,
 		ServiceID:  serviceID,
@@ -1247,9 +4496,28 @@ func (s *CalendarService) CreateSlot(
 
 	if err := s.slotRepo.Create(ctx, &slot); err != nil {
 		s.logErr("CreateSlot", err, "stage", "create slot")
+		if errors.Is(err, errs.ErrSlotOverlap) {
+			return nil, status.Errorf(codes.AlreadyExists, "create slot: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "create slot: %v", err)
 	}
 
+	if s.resourceRepo != nil && len(req.GetResourceIds()) > 0 {
+		resourceIDs := make([]uuid.UUID, 0, len(req.GetResourceIds()))
+		for _, rid := range req.GetResourceIds() {
+			id, err := uuid.Parse(rid)
+			if err != nil {
+				s.logErr("CreateSlot", err, "resource_id", rid)
+				return nil, status.Error(codes.InvalidArgument, "invalid resource_id")
+			}
+			resourceIDs = append(resourceIDs, id)
+		}
+		if err := s.resourceRepo.AssignToSlot(ctx, slot.ID, resourceIDs); err != nil {
+			s.logErr("CreateSlot", err, "stage", "assign resources")
+			return nil, status.Errorf(codes.Internal, "assign resources: %v", err)
+		}
+	}
+
 	s.logInfo("CreateSlot", "slot_id", slot.ID.String(), "provider_id", req.GetProviderId(), "service_id", req.GetServiceId())
 
 	return &calendarpb.CreateSlotResponse{Slot: mapSlot(&slot)}, nil
@@ -1298,6 +4566,9 @@ func (s *CalendarService) UpdateSlot(
 
 	if err := s.slotRepo.Update(ctx, slot); err != nil {
 		s.logErr("UpdateSlot", err, "stage", "update slot")
+		if errors.Is(err, errs.ErrSlotOverlap) {
+			return nil, status.Errorf(codes.AlreadyExists, "update slot: %v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "update slot: %v", err)
 	}
 
@@ -1512,11 +4783,21 @@ func mapSlot(slot *model.TimeSlot) *commonpb.Slot {
 	if slot.ServiceID != nil {
 		serviceID = slot.ServiceID.String()
 	}
+	staffID := ""
+	if slot.StaffID != nil {
+		staffID = slot.StaffID.String()
+	}
+	resourceID := ""
+	if slot.ResourceID != nil {
+		resourceID = slot.ResourceID.String()
+	}
 
 	return &commonpb.Slot{
 		Id:         slot.ID.String(),
 		ProviderId: slot.ProviderID.String(),
 		ServiceId:  serviceID,
+		StaffId:    staffID,
+		ResourceId: resourceID,
 		StartsAt:   timestamppb.New(slot.StartsAt),
 		EndsAt:     timestamppb.New(slot.EndsAt),
 		Status:     mapSlotStatus(slot.Status),
@@ -1582,6 +4863,21 @@ type scheduleRuleDTO struct {
 	Until       *time.Time                   `json:"until,omitempty"`
 	Count       int32                        `json:"count,omitempty"`
 	Exceptions  []time.Time                  `json:"exceptions,omitempty"`
+
+	// Полный набор RFC 5545, используемый для MONTHLY/YEARLY и для правил,
+	// импортированных из внешних календарей.
+	ByMonth    []int32     `json:"by_month,omitempty"`
+	ByMonthDay []int32     `json:"by_month_day,omitempty"`
+	ByYearDay  []int32     `json:"by_year_day,omitempty"`
+	ByWeekNo   []int32     `json:"by_week_no,omitempty"`
+	ByDay      []string    `json:"by_day,omitempty"`
+	ByHour     []int32     `json:"by_hour,omitempty"`
+	ByMinute   []int32     `json:"by_minute,omitempty"`
+	BySetPos   []int32     `json:"by_set_pos,omitempty"`
+	WeekStart  string      `json:"week_start,omitempty"`
+	RDates     []time.Time `json:"rdates,omitempty"`
+	ExDates    []time.Time `json:"exdates,omitempty"`
+	RawRrule   string      `json:"rrule,omitempty"`
 }
 
 func encodeScheduleRule(rule *commonpb.ScheduleRule) (datatypes.JSON, error) {
@@ -1595,6 +4891,16 @@ func encodeScheduleRule(rule *commonpb.ScheduleRule) (datatypes.JSON, error) {
 		Weekdays:    rule.GetWeekdays(),
 		DurationMin: rule.GetDurationMin(),
 		Count:       rule.GetCount(),
+		ByMonth:     rule.GetByMonth(),
+		ByMonthDay:  rule.GetByMonthDay(),
+		ByYearDay:   rule.GetByYearDay(),
+		ByWeekNo:    rule.GetByWeekNo(),
+		ByDay:       rule.GetByDay(),
+		ByHour:      rule.GetByHour(),
+		ByMinute:    rule.GetByMinute(),
+		BySetPos:    rule.GetBySetPos(),
+		WeekStart:   rule.GetWeekStart(),
+		RawRrule:    rule.GetRawRrule(),
 	}
 
 	if rule.GetStartsAt() != nil {
@@ -1617,6 +4923,26 @@ func encodeScheduleRule(rule *commonpb.ScheduleRule) (datatypes.JSON, error) {
 		}
 		dto.Exceptions = ex
 	}
+	if len(rule.GetRdates()) > 0 {
+		dto.RDates = timestampsIn(rule.GetRdates(), time.UTC)
+	}
+	if len(rule.GetExdates()) > 0 {
+		dto.ExDates = timestampsIn(rule.GetExdates(), time.UTC)
+	}
+
+	// RawRrule обычно приходит из внешнего импорта (см. RawRrule: ev.RRule в
+	// CalendarService.ImportProviderCalendar) — канонизируем её через
+	// DecodeRRULE/EncodeRRULE сразу при сохранении, а не оставляем ошибку на
+	// откуп фоновому материализатору: битая строка тогда тихо ломала бы
+	// построение слотов вместо того, чтобы вернуться вызывающему как
+	// InvalidArgument.
+	if dto.RawRrule != "" {
+		parsed, err := calendarutils.DecodeRRULE(dto.RawRrule)
+		if err != nil {
+			return nil, fmt.Errorf("raw_rrule: %w", err)
+		}
+		dto.RawRrule = calendarutils.EncodeRRULE(parsed)
+	}
 
 	data, err := json.Marshal(dto)
 	if err != nil {
@@ -1660,7 +4986,31 @@ func decodeScheduleRule(raw datatypes.JSON) *commonpb.ScheduleRule {
 		Until:       until,
 		Count:       dto.Count,
 		Exceptions:  exceptions,
+		ByMonth:     dto.ByMonth,
+		ByMonthDay:  dto.ByMonthDay,
+		ByYearDay:   dto.ByYearDay,
+		ByWeekNo:    dto.ByWeekNo,
+		ByDay:       dto.ByDay,
+		ByHour:      dto.ByHour,
+		ByMinute:    dto.ByMinute,
+		BySetPos:    dto.BySetPos,
+		WeekStart:   dto.WeekStart,
+		Rdates:      timestampsToProto(dto.RDates),
+		Exdates:     timestampsToProto(dto.ExDates),
+		RawRrule:    dto.RawRrule,
+	}
+}
+
+// timestampsToProto переводит []time.Time в список protobuf-таймстемпов (UTC).
+func timestampsToProto(values []time.Time) []*timestamppb.Timestamp {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]*timestamppb.Timestamp, 0, len(values))
+	for _, t := range values {
+		result = append(result, timestamppb.New(t.UTC()))
 	}
+	return result
 }
 
 func protoDateToDate(ts *timestamppb.Timestamp) *datatypes.Date {
@@ -1682,19 +5032,28 @@ func dateToProto(d *datatypes.Date) *timestamppb.Timestamp {
 	return timestamppb.New(dateOnly)
 }
 
+// ensureProviderRole — единая точка входа для всех мутаций этого сервиса,
+// требующих прав на управление конкретным провайдером (создание слотов,
+// правка расписания, подтверждение записей и т.п.). Раньше здесь просто
+// сравнивали userRepo.GetRole() == "provider"; теперь это RBAC-проверка через
+// PermissionRepository.HasPermission с правом "provider.manage", заскоупленным
+// на providerID (см. RoleRepository.AssignRole, model.UserRole.ProviderID).
+// Проверяется пользователь-владелец провайдера (provider.UserID), а не
+// вызывающий: права самого вызывающего здесь не смотрятся.
 func (s *CalendarService) ensureProviderRole(ctx context.Context, providerID string) error {
-	if s.providerRepo == nil || s.userRepo == nil {
+	if s.providerRepo == nil || s.permRepo == nil {
 		return nil
 	}
 	provider, err := s.providerRepo.GetByID(ctx, providerID)
 	if err != nil {
 		return status.Errorf(codes.NotFound, "provider not found: %v", err)
 	}
-	role, err := s.userRepo.GetRole(ctx, provider.UserID)
+	scope := provider.ID
+	ok, err := s.permRepo.HasPermission(ctx, provider.UserID, "provider.manage", &scope)
 	if err != nil {
-		return status.Errorf(codes.PermissionDenied, "cannot verify provider role: %v", err)
+		return status.Errorf(codes.PermissionDenied, "cannot verify provider permissions: %v", err)
 	}
-	if role != "provider" {
+	if !ok {
 		return status.Error(codes.PermissionDenied, "only providers can manage schedules and slots")
 	}
 	return nil
@@ -5,15 +5,21 @@ import (
 	"errors"
 	"log"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
-	"gorm.io/gorm"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	commonpb "github.com/Leganyst/appointment-platform/internal/api/common/v1"
 	identitypb "github.com/Leganyst/appointment-platform/internal/api/identity/v1"
+	"github.com/Leganyst/appointment-platform/internal/config"
+	"github.com/Leganyst/appointment-platform/internal/events"
 	"github.com/Leganyst/appointment-platform/internal/model"
 	"github.com/Leganyst/appointment-platform/internal/repository"
+	"github.com/Leganyst/appointment-platform/internal/repository/errs"
+	"github.com/Leganyst/appointment-platform/internal/verification"
 )
 
 // IdentityService реализует регистрацию и управление профилем по Telegram ID.
@@ -23,11 +29,34 @@ type IdentityService struct {
 	userRepo     repository.UserRepository
 	clientRepo   repository.ClientRepository
 	providerRepo repository.ProviderRepository
-	logger       *log.Logger
+	// roleRepo — полный RBAC-список ролей пользователя (с scope), для
+	// identitypb.User.Roles (см. AuthorizationService — основной вход для
+	// назначения/отзыва ролей; здесь только чтение для профиля).
+	roleRepo repository.RoleRepository
+	// identityEventRepo — durable-журнал push-событий профиля (см.
+	// model.IdentityEvent), источник правды для replay по cursor в
+	// SubscribeUserEvents. Может быть nil (события тогда не переживают
+	// переподключение) — см. eventBus.
+	identityEventRepo repository.IdentityEventRepository
+	// eventBus — in-process fan-out тех же событий активным подписчикам
+	// SubscribeUserEvents (см. internal/events.Bus); nil в сборках, где
+	// push-уведомления не настроены (тогда publishIdentityEvent — no-op).
+	eventBus *events.Bus[int64, events.IdentityEvent]
+	cfg      config.IdentityConfig
+	logger   *log.Logger
 }
 
-func NewIdentityService(userRepo repository.UserRepository, clientRepo repository.ClientRepository, providerRepo repository.ProviderRepository) *IdentityService {
-	return &IdentityService{userRepo: userRepo, clientRepo: clientRepo, providerRepo: providerRepo, logger: log.Default()}
+func NewIdentityService(userRepo repository.UserRepository, clientRepo repository.ClientRepository, providerRepo repository.ProviderRepository, roleRepo repository.RoleRepository, identityEventRepo repository.IdentityEventRepository, eventBus *events.Bus[int64, events.IdentityEvent], cfg config.IdentityConfig) *IdentityService {
+	return &IdentityService{
+		userRepo:          userRepo,
+		clientRepo:        clientRepo,
+		providerRepo:      providerRepo,
+		roleRepo:          roleRepo,
+		identityEventRepo: identityEventRepo,
+		eventBus:          eventBus,
+		cfg:               cfg,
+		logger:            log.Default(),
+	}
 }
 
 func (s *IdentityService) logInfo(method string, fields ...any) {
@@ -57,6 +86,12 @@ func (s *IdentityService) RegisterUser(ctx context.Context, req *identitypb.Regi
 	if req.GetTelegramId() <= 0 {
 		return nil, status.Error(codes.InvalidArgument, "telegram_id is required")
 	}
+	if s.cfg.RequireVerification {
+		if !verification.VerifyToken([]byte(s.cfg.VerificationTokenSecret), req.GetVerificationToken(), req.GetTelegramId(), time.Now().UTC()) {
+			s.logInfo("RegisterUser", "stage", "verification rejected", "telegram_id", req.GetTelegramId())
+			return nil, status.Error(codes.PermissionDenied, "telegram account is not verified: complete /start <code> with the bot first")
+		}
+	}
 
 	s.logInfo("RegisterUser", "telegram_id", req.GetTelegramId(), "display_name", req.GetDisplayName(), "username", req.GetUsername(), "contact_phone", req.GetContactPhone())
 
@@ -67,17 +102,17 @@ func (s *IdentityService) RegisterUser(ctx context.Context, req *identitypb.Regi
 	}
 
 	// Любой пользователь (любая роль) может быть "клиентом" для механизма записи,
-	// поэтому гарантируем наличие записи в таблице clients.
-	if s.clientRepo != nil {
-		if _, err := s.clientRepo.EnsureByUserID(ctx, u.ID); err != nil {
-			return nil, status.Errorf(codes.Internal, "ensure client: %v", err)
-		}
+	// поэтому гарантируем наличие записи в таблице clients и базовой RBAC-роли.
+	if err := s.ensureClientRole(ctx, u.ID); err != nil {
+		return nil, err
 	}
 
-	roleCode, _ := s.userRepo.GetRole(ctx, u.ID) // роль может отсутствовать; игнорируем ошибку
+	roleCode, roles := s.lookupRoles(ctx, u.ID)
 	clientID, providerID := s.lookupActorIDs(ctx, u)
 
-	resp := &identitypb.RegisterUserResponse{User: mapUser(u, roleCode, clientID, providerID)}
+	s.publishIdentityEvent(ctx, model.IdentityEventRegistered, u, nil)
+
+	resp := &identitypb.RegisterUserResponse{User: mapUser(u, roleCode, roles, clientID, providerID)}
 	s.logInfo("RegisterUser", "telegram_id", req.GetTelegramId(), "role", roleCode, "client_id", clientID, "provider_id", providerID)
 	return resp, nil
 }
@@ -95,10 +130,12 @@ func (s *IdentityService) UpdateContacts(ctx context.Context, req *identitypb.Up
 		s.logErr("UpdateContacts", err, "stage", "update contacts", "telegram_id", req.GetTelegramId())
 		return nil, status.Errorf(codes.Internal, "update contacts: %v", err)
 	}
-	roleCode, _ := s.userRepo.GetRole(ctx, u.ID)
+	roleCode, roles := s.lookupRoles(ctx, u.ID)
 	clientID, providerID := s.lookupActorIDs(ctx, u)
 
-	resp := &identitypb.UpdateContactsResponse{User: mapUser(u, roleCode, clientID, providerID)}
+	s.publishIdentityEvent(ctx, model.IdentityEventContactsUpdated, u, nil)
+
+	resp := &identitypb.UpdateContactsResponse{User: mapUser(u, roleCode, roles, clientID, providerID)}
 	s.logInfo("UpdateContacts", "telegram_id", req.GetTelegramId(), "role", roleCode, "client_id", clientID, "provider_id", providerID)
 	return resp, nil
 }
@@ -127,12 +164,11 @@ func (s *IdentityService) SetRole(ctx context.Context, req *identitypb.SetRoleRe
 
 	// Вариант A: автосоздание сущностей календарного ядра при смене роли.
 	// Клиентская сущность нужна всегда, т.к. записываться может любой пользователь.
-	if s.clientRepo != nil {
-		if _, err := s.clientRepo.EnsureByUserID(ctx, u.ID); err != nil {
-			return nil, status.Errorf(codes.Internal, "ensure client: %v", err)
-		}
+	if err := s.ensureClientRole(ctx, u.ID); err != nil {
+		return nil, err
 	}
 	roleCode := strings.TrimSpace(req.GetRoleCode())
+	var attachedProvider *model.Provider
 	switch roleCode {
 	case "provider":
 		if s.providerRepo != nil {
@@ -140,17 +176,26 @@ func (s *IdentityService) SetRole(ctx context.Context, req *identitypb.SetRoleRe
 			if dn == "" {
 				dn = strings.TrimSpace(u.Note)
 			}
-			if _, err := s.providerRepo.EnsureByUserID(ctx, u.ID, dn); err != nil {
+			p, err := s.providerRepo.EnsureByUserID(ctx, u.ID, dn)
+			if err != nil {
 				s.logErr("SetRole", err, "stage", "ensure provider", "user_id", u.ID.String())
 				return nil, status.Errorf(codes.Internal, "ensure provider: %v", err)
 			}
+			attachedProvider = p
 			clientID, providerID := s.lookupActorIDs(ctx, u)
 			s.logInfo("SetRole", "stage", "provider ensured", "user_id", u.ID.String(), "client_id", clientID, "provider_id", providerID)
 		}
 	}
 
+	_, roles := s.lookupRoles(ctx, u.ID)
 	clientID, providerID := s.lookupActorIDs(ctx, u)
-	resp := &identitypb.SetRoleResponse{User: mapUser(u, roleCode, clientID, providerID)}
+
+	s.publishIdentityEvent(ctx, model.IdentityEventRoleChanged, u, nil)
+	if attachedProvider != nil {
+		s.publishIdentityEvent(ctx, model.IdentityEventProviderAttached, u, attachedProvider)
+	}
+
+	resp := &identitypb.SetRoleResponse{User: mapUser(u, roleCode, roles, clientID, providerID)}
 	s.logInfo("SetRole", "telegram_id", req.GetTelegramId(), "role", roleCode, "client_id", clientID, "provider_id", providerID)
 	return resp, nil
 }
@@ -168,10 +213,10 @@ func (s *IdentityService) GetProfile(ctx context.Context, req *identitypb.GetPro
 		s.logErr("GetProfile", err, "stage", "find user", "telegram_id", req.GetTelegramId())
 		return nil, status.Errorf(codes.NotFound, "user not found: %v", err)
 	}
-	roleCode, _ := s.userRepo.GetRole(ctx, u.ID)
+	roleCode, roles := s.lookupRoles(ctx, u.ID)
 	clientID, providerID := s.lookupActorIDs(ctx, u)
 
-	resp := &identitypb.GetProfileResponse{User: mapUser(u, roleCode, clientID, providerID)}
+	resp := &identitypb.GetProfileResponse{User: mapUser(u, roleCode, roles, clientID, providerID)}
 	s.logInfo("GetProfile", "telegram_id", req.GetTelegramId(), "role", roleCode, "client_id", clientID, "provider_id", providerID)
 	return resp, nil
 }
@@ -223,17 +268,17 @@ func (s *IdentityService) FindProviderByPhone(ctx context.Context, req *identity
 		if dn == "" {
 			dn = strings.TrimSpace(u.Note)
 		}
-		if _, err := s.providerRepo.EnsureByUserID(ctx, u.ID, dn); err != nil {
+		p, err := s.providerRepo.EnsureByUserID(ctx, u.ID, dn)
+		if err != nil {
 			s.logErr("FindProviderByPhone", err, "stage", "ensure provider", "user_id", u.ID.String())
 			return nil, status.Errorf(codes.Internal, "ensure provider: %v", err)
 		}
+		s.publishIdentityEvent(ctx, model.IdentityEventProviderAttached, u, p)
 	}
 	// Клиентская сущность тоже полезна (например, чтобы провайдер мог сам записываться).
-	if s.clientRepo != nil {
-		if _, err := s.clientRepo.EnsureByUserID(ctx, u.ID); err != nil {
-			s.logErr("FindProviderByPhone", err, "stage", "ensure client", "user_id", u.ID.String())
-			return nil, status.Errorf(codes.Internal, "ensure client: %v", err)
-		}
+	if err := s.ensureClientRole(ctx, u.ID); err != nil {
+		s.logErr("FindProviderByPhone", err, "stage", "ensure client", "user_id", u.ID.String())
+		return nil, err
 	}
 
 	clientID, providerID := s.lookupActorIDs(ctx, u)
@@ -241,7 +286,8 @@ func (s *IdentityService) FindProviderByPhone(ctx context.Context, req *identity
 		return nil, status.Error(codes.NotFound, "provider not found")
 	}
 
-	resp := &identitypb.FindProviderByPhoneResponse{User: mapUser(u, roleCode, clientID, providerID)}
+	_, roles := s.lookupRoles(ctx, u.ID)
+	resp := &identitypb.FindProviderByPhoneResponse{User: mapUser(u, roleCode, roles, clientID, providerID)}
 	s.logInfo("FindProviderByPhone", "phone", contact, "user_id", u.ID.String(), "client_id", clientID, "provider_id", providerID)
 	return resp, nil
 }
@@ -261,7 +307,7 @@ func (s *IdentityService) GetUserContext(ctx context.Context, req *identitypb.Ge
 
 	u, err := s.userRepo.FindByTelegramID(ctx, req.GetTelegramId())
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, errs.ErrNotFound("")) {
 			return nil, status.Errorf(codes.NotFound, "user not found: %v", err)
 		}
 		s.logErr("GetUserContext", err, "stage", "find user", "telegram_id", req.GetTelegramId())
@@ -280,14 +326,16 @@ func (s *IdentityService) GetUserContext(ctx context.Context, req *identitypb.Ge
 			if dn == "" {
 				dn = strings.TrimSpace(u.Note)
 			}
-			if _, err := s.providerRepo.EnsureByUserID(ctx, u.ID, dn); err != nil {
+			p, err := s.providerRepo.EnsureByUserID(ctx, u.ID, dn)
+			if err != nil {
 				s.logErr("GetUserContext", err, "stage", "ensure provider", "user_id", u.ID.String())
 				return nil, status.Errorf(codes.Internal, "ensure provider: %v", err)
 			}
+			s.publishIdentityEvent(ctx, model.IdentityEventProviderAttached, u, p)
 		}
 
 		p, err := s.providerRepo.GetByUserID(ctx, u.ID)
-		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		if err != nil && !errors.Is(err, errs.ErrNotFound("")) {
 			s.logErr("GetUserContext", err, "stage", "get provider", "user_id", u.ID.String())
 			return nil, status.Errorf(codes.Internal, "get provider: %v", err)
 		}
@@ -301,8 +349,9 @@ func (s *IdentityService) GetUserContext(ctx context.Context, req *identitypb.Ge
 		}
 	}
 
+	_, roles := s.lookupRoles(ctx, u.ID)
 	resp := &identitypb.GetUserContextResponse{
-		User:     mapUser(u, roleCode, clientID, providerID),
+		User:     mapUser(u, roleCode, roles, clientID, providerID),
 		Provider: providerProfile,
 	}
 	s.logInfo("GetUserContext", "telegram_id", req.GetTelegramId(), "role", roleCode, "client_id", clientID, "provider_id", providerID, "include_provider_profile", includeProvider)
@@ -323,7 +372,7 @@ func (s *IdentityService) ResetAccount(ctx context.Context, req *identitypb.GetP
 
 	u, err := s.userRepo.FindByTelegramID(ctx, req.GetTelegramId())
 	if err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
+		if !errors.Is(err, errs.ErrNotFound("")) {
 			s.logErr("ResetAccount", err, "stage", "find user", "telegram_id", req.GetTelegramId())
 			return nil, status.Errorf(codes.Internal, "find user: %v", err)
 		}
@@ -346,14 +395,101 @@ func (s *IdentityService) ResetAccount(ctx context.Context, req *identitypb.GetP
 		return nil, status.Errorf(codes.Internal, "reset contacts: %v", err)
 	}
 
-	roleCode, _ := s.userRepo.GetRole(ctx, u.ID)
+	roleCode, roles := s.lookupRoles(ctx, u.ID)
 	clientID, providerID := s.lookupActorIDs(ctx, u)
 
-	resp := &identitypb.RegisterUserResponse{User: mapUser(u, roleCode, clientID, providerID)}
+	s.publishIdentityEvent(ctx, model.IdentityEventReset, u, nil)
+
+	resp := &identitypb.RegisterUserResponse{User: mapUser(u, roleCode, roles, clientID, providerID)}
 	s.logInfo("ResetAccount", "telegram_id", req.GetTelegramId(), "role", roleCode, "client_id", clientID, "provider_id", providerID)
 	return resp, nil
 }
 
+// SubscribeUserEvents — server-streaming RPC, замена поллингу GetProfile/
+// GetUserContext (см. cmd/identity-bot, internal/events.Bus): стрим сперва
+// реплеит всё, что уже случилось с telegram_id после Since (через
+// identityEventRepo.ListSince, если настроен), затем подписывается на
+// eventBus и шлёт новые события, пока вызывающий не отменит ctx. Since == 0
+// означает "реплея не нужно, только новые события".
+func (s *IdentityService) SubscribeUserEvents(req *identitypb.SubscribeUserEventsRequest, stream identitypb.IdentityService_SubscribeUserEventsServer) error {
+	if req.GetTelegramId() <= 0 {
+		return status.Error(codes.InvalidArgument, "telegram_id is required")
+	}
+	if s.eventBus == nil {
+		return status.Error(codes.Unavailable, "user event stream is not configured")
+	}
+
+	ctx := stream.Context()
+	telegramID := req.GetTelegramId()
+
+	// Подписываемся до replay, чтобы не потерять события, случившиеся между
+	// чтением identity_events и регистрацией подписки.
+	ch, unsubscribe := s.eventBus.Subscribe(telegramID)
+	defer unsubscribe()
+
+	lastSent := req.GetSince()
+	if s.identityEventRepo != nil && req.GetSince() > 0 {
+		missed, err := s.identityEventRepo.ListSince(ctx, telegramID, req.GetSince(), 0)
+		if err != nil {
+			s.logErr("SubscribeUserEvents", err, "stage", "replay", "telegram_id", telegramID)
+			return status.Errorf(codes.Internal, "replay missed events: %v", err)
+		}
+		for _, row := range missed {
+			u, err := s.userRepo.FindByTelegramID(ctx, telegramID)
+			if err != nil {
+				return status.Errorf(codes.Internal, "replay missed events: %v", err)
+			}
+			var provider *model.Provider
+			if row.ProviderID != nil && s.providerRepo != nil {
+				provider, _ = s.providerRepo.GetByID(ctx, row.ProviderID.String())
+			}
+			if err := stream.Send(s.mapIdentityEvent(ctx, row.ID, model.IdentityEventType(row.EventType), u, provider, row.CreatedAt)); err != nil {
+				return err
+			}
+			lastSent = row.ID
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if ev.ID != 0 && ev.ID <= lastSent {
+				continue
+			}
+			if err := stream.Send(s.mapIdentityEvent(ctx, ev.ID, model.IdentityEventType(ev.Type), ev.User, ev.Provider, ev.At)); err != nil {
+				return err
+			}
+			if ev.ID != 0 {
+				lastSent = ev.ID
+			}
+		}
+	}
+}
+
+func (s *IdentityService) mapIdentityEvent(ctx context.Context, id uint64, evType model.IdentityEventType, u *model.User, provider *model.Provider, at time.Time) *identitypb.UserEvent {
+	roleCode, roles := s.lookupRoles(ctx, u.ID)
+	clientID, providerID := s.lookupActorIDs(ctx, u)
+	out := &identitypb.UserEvent{
+		Id:   id,
+		Type: string(evType),
+		User: mapUser(u, roleCode, roles, clientID, providerID),
+		At:   timestamppb.New(at),
+	}
+	if provider != nil {
+		out.Provider = &commonpb.Provider{
+			Id:          provider.ID.String(),
+			DisplayName: provider.DisplayName,
+			Description: provider.Description,
+		}
+	}
+	return out
+}
+
 func (s *IdentityService) lookupActorIDs(ctx context.Context, u *model.User) (clientID string, providerID string) {
 	if u == nil {
 		return "", ""
@@ -371,7 +507,126 @@ func (s *IdentityService) lookupActorIDs(ctx context.Context, u *model.User) (cl
 	return clientID, providerID
 }
 
-func mapUser(u *model.User, roleCode, clientID, providerID string) *identitypb.User {
+// ensureClientRole гарантирует наличие записи в clients (любой пользователь,
+// независимо от основной роли, может выступать клиентом записи) и назначает
+// ему базовую RBAC-роль "client" через roleRepo.AssignRole (см.
+// repository.RoleRepository, model.UserRole) — идемпотентно, т.к. AssignRole
+// сам ищет существующее назначение перед созданием. roleRepo здесь всегда
+// глобальный (providerID=nil): клиентский доступ не заскоупен на провайдера.
+func (s *IdentityService) ensureClientRole(ctx context.Context, userID uuid.UUID) error {
+	if s.clientRepo != nil {
+		if _, err := s.clientRepo.EnsureByUserID(ctx, userID); err != nil {
+			return status.Errorf(codes.Internal, "ensure client: %v", err)
+		}
+	}
+	if s.roleRepo != nil {
+		if _, err := s.roleRepo.AssignRole(ctx, userID, "client", nil); err != nil {
+			s.logErr("ensureClientRole", err, "user_id", userID.String())
+		}
+	}
+	return nil
+}
+
+// publishIdentityEvent пишет событие в durable-журнал (см. model.IdentityEvent)
+// и, если получилось, тут же публикует его активным подписчикам
+// SubscribeUserEvents через eventBus. Вызывается после того, как основная
+// мутация уже успешно завершена, поэтому ошибка здесь логируется, но не
+// валит RPC целиком — push-уведомление вторично по отношению к самому
+// изменению профиля. Если Append не удался, событие в журнал не попадает и
+// уходит только текущим подписчикам eventBus с ID 0, так что через replay
+// его потом не получить.
+func (s *IdentityService) publishIdentityEvent(ctx context.Context, evType model.IdentityEventType, u *model.User, provider *model.Provider) {
+	if u == nil {
+		return
+	}
+	now := time.Now().UTC()
+
+	var providerID *uuid.UUID
+	if provider != nil {
+		providerID = &provider.ID
+	}
+
+	var seq uint64
+	if s.identityEventRepo != nil {
+		row, err := s.identityEventRepo.Append(ctx, &model.IdentityEvent{
+			TelegramID: u.TelegramID,
+			UserID:     u.ID,
+			EventType:  evType,
+			ProviderID: providerID,
+			CreatedAt:  now,
+		})
+		if err != nil {
+			s.logErr("publishIdentityEvent", err, "type", string(evType), "telegram_id", u.TelegramID)
+		} else {
+			seq = row.ID
+		}
+	}
+
+	if s.eventBus == nil {
+		return
+	}
+	s.eventBus.Publish(u.TelegramID, events.IdentityEvent{
+		ID:         seq,
+		Type:       events.IdentityEventType(evType),
+		TelegramID: u.TelegramID,
+		User:       u,
+		Provider:   provider,
+		At:         now,
+	})
+}
+
+// rolePriority ранжирует встроенные роли для identitypb.User.RoleCode —
+// устаревшего поля "основная роль", которое мы сохраняем только ради
+// клиентов, ещё не перешедших на Roles (см. AuthorizationService). Выше
+// приоритет — та роль, которую клиент скорее всего ожидает увидеть первой;
+// роли, не входящие в список, считаются ниже любой из них.
+func rolePriority(code string) int {
+	switch code {
+	case "admin":
+		return 3
+	case "provider":
+		return 2
+	case "client":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// lookupRoles возвращает устаревший "основной" код роли (по rolePriority,
+// при равенстве — первая назначенная) и полный список ролей пользователя,
+// включая scope на провайдера (см. model.UserRole.ProviderID). Ошибка
+// самого ListUserRoles игнорируется так же, как раньше игнорировалась
+// ошибка GetRole — отсутствие ролей не должно валить профиль.
+func (s *IdentityService) lookupRoles(ctx context.Context, userID uuid.UUID) (roleCode string, roles []*identitypb.Role) {
+	if s.roleRepo == nil {
+		return "", nil
+	}
+	userRoles, err := s.roleRepo.ListUserRoles(ctx, userID)
+	if err != nil {
+		return "", nil
+	}
+
+	roles = make([]*identitypb.Role, 0, len(userRoles))
+	for _, ur := range userRoles {
+		code := ""
+		if ur.Role != nil {
+			code = ur.Role.Code
+		}
+		providerID := ""
+		if ur.ProviderID != nil {
+			providerID = ur.ProviderID.String()
+		}
+		roles = append(roles, &identitypb.Role{Code: code, ProviderId: providerID})
+
+		if roleCode == "" || rolePriority(code) > rolePriority(roleCode) {
+			roleCode = code
+		}
+	}
+	return roleCode, roles
+}
+
+func mapUser(u *model.User, roleCode string, roles []*identitypb.Role, clientID, providerID string) *identitypb.User {
 	if u == nil {
 		return nil
 	}
@@ -381,7 +636,8 @@ func mapUser(u *model.User, roleCode, clientID, providerID string) *identitypb.U
 		DisplayName:  u.DisplayName,
 		Username:     u.Note, // username сохраняем в поле Note
 		ContactPhone: u.ContactPhone,
-		RoleCode:     roleCode,
+		RoleCode:     roleCode, // deprecated: см. Roles
+		Roles:        roles,
 		ClientId:     clientID,
 		ProviderId:   providerID,
 	}
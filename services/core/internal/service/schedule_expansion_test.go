@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	calendarutils "github.com/Leganyst/appointment-platform/internal/utils"
+)
+
+func TestSliceIntoBookableSlots(t *testing.T) {
+	occ := calendarutils.TimeRange{
+		Start: time.Date(2026, 8, 3, 9, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 8, 3, 10, 30, 0, 0, time.UTC),
+	}
+
+	slots := sliceIntoBookableSlots([]calendarutils.TimeRange{occ}, 30*time.Minute, 10*time.Minute)
+
+	// 09:00-09:30, 09:40-10:10; 10:20-10:50 would overrun the 10:30 block end.
+	if len(slots) != 2 {
+		t.Fatalf("expected 2 slots, got %d", len(slots))
+	}
+	if !slots[0].Start.Equal(occ.Start) {
+		t.Errorf("first slot start = %v, want %v", slots[0].Start, occ.Start)
+	}
+	wantSecondStart := time.Date(2026, 8, 3, 9, 40, 0, 0, time.UTC)
+	if !slots[1].Start.Equal(wantSecondStart) {
+		t.Errorf("second slot start = %v, want %v", slots[1].Start, wantSecondStart)
+	}
+}
+
+func TestSliceIntoBookableSlots_NoBreak(t *testing.T) {
+	occ := calendarutils.TimeRange{
+		Start: time.Date(2026, 8, 3, 9, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 8, 3, 10, 0, 0, 0, time.UTC),
+	}
+
+	slots := sliceIntoBookableSlots([]calendarutils.TimeRange{occ}, 20*time.Minute, 0)
+	if len(slots) != 3 {
+		t.Fatalf("expected 3 back-to-back slots, got %d", len(slots))
+	}
+}
+
+func TestSliceIntoBookableSlots_InvalidDuration(t *testing.T) {
+	occ := calendarutils.TimeRange{
+		Start: time.Date(2026, 8, 3, 9, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 8, 3, 10, 0, 0, 0, time.UTC),
+	}
+	if slots := sliceIntoBookableSlots([]calendarutils.TimeRange{occ}, 0, 0); slots != nil {
+		t.Errorf("expected nil slots for non-positive duration, got %v", slots)
+	}
+}
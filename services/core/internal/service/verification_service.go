@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/datatypes"
+
+	verificationpb "github.com/Leganyst/appointment-platform/internal/api/verification/v1"
+	"github.com/Leganyst/appointment-platform/internal/model"
+	"github.com/Leganyst/appointment-platform/internal/repository"
+	"github.com/Leganyst/appointment-platform/internal/verification"
+)
+
+// VerificationServiceConfig — параметры VerificationService.
+type VerificationServiceConfig struct {
+	// TokenSecret подписывает verification_token, который
+	// ConsumeVerificationCode отдаёт боту, а тот передаёт в RegisterUser
+	// (см. verification.SignToken/VerifyToken).
+	TokenSecret []byte
+	// CodeTTL — срок жизни PIN-кода с момента IssueVerificationCode.
+	CodeTTL time.Duration
+	// TokenTTL — срок жизни verification_token с момента
+	// ConsumeVerificationCode.
+	TokenTTL time.Duration
+	// RateLimitWindow/RateLimitMax — не больше RateLimitMax вызовов
+	// IssueVerificationCode с одного IP или telegram_id_hint за
+	// RateLimitWindow.
+	RateLimitWindow time.Duration
+	RateLimitMax    int
+}
+
+func (c VerificationServiceConfig) withDefaults() VerificationServiceConfig {
+	if c.CodeTTL <= 0 {
+		c.CodeTTL = 10 * time.Minute
+	}
+	if c.TokenTTL <= 0 {
+		c.TokenTTL = 10 * time.Minute
+	}
+	if c.RateLimitWindow <= 0 {
+		c.RateLimitWindow = time.Hour
+	}
+	if c.RateLimitMax <= 0 {
+		c.RateLimitMax = 5
+	}
+	return c
+}
+
+// claimPayload — то, что IssueVerificationCode сохраняет в
+// model.VerificationCode.ClaimPayload.
+type claimPayload struct {
+	TelegramIDHint *int64 `json:"telegram_id_hint,omitempty"`
+	DisplayName    string `json:"display_name,omitempty"`
+}
+
+// VerificationService реализует IssueVerificationCode/ConsumeVerificationCode
+// — PIN-подтверждение владения Telegram-аккаунтом перед RegisterUser (см.
+// internal/verification, cmd/identity-bot). Сам код не трогает users/clients:
+// это забота IdentityService.RegisterUser, вызываемого ботом после успешного
+// ConsumeVerificationCode с полученным verification_token.
+type VerificationService struct {
+	verificationpb.UnimplementedVerificationServiceServer
+
+	repo   repository.VerificationCodeRepository
+	cfg    VerificationServiceConfig
+	logger *log.Logger
+}
+
+func NewVerificationService(repo repository.VerificationCodeRepository, cfg VerificationServiceConfig) *VerificationService {
+	return &VerificationService{repo: repo, cfg: cfg.withDefaults(), logger: log.Default()}
+}
+
+// IssueVerificationCode генерирует одноразовый PIN с TTL
+// VerificationServiceConfig.CodeTTL. Если вызывающий уже передал
+// telegram_id_hint, предыдущие ещё активные коды на этот hint истекают
+// (держим не больше одного активного кода на claim), а рейт-лимит считается
+// и по IP, и по hint.
+func (s *VerificationService) IssueVerificationCode(ctx context.Context, req *verificationpb.IssueVerificationCodeRequest) (*verificationpb.IssueVerificationCodeResponse, error) {
+	now := time.Now().UTC()
+	requesterIP := req.GetRequesterIp()
+
+	if requesterIP != "" {
+		count, err := s.repo.CountRecentByIP(ctx, requesterIP, now.Add(-s.cfg.RateLimitWindow))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "check ip rate limit: %v", err)
+		}
+		if count >= int64(s.cfg.RateLimitMax) {
+			return nil, status.Error(codes.ResourceExhausted, "too many verification codes requested from this IP, try again later")
+		}
+	}
+
+	var telegramIDHint *int64
+	if hint := req.GetTelegramIdHint(); hint != 0 {
+		telegramIDHint = &hint
+		count, err := s.repo.CountRecentByTelegramHint(ctx, hint, now.Add(-s.cfg.RateLimitWindow))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "check telegram rate limit: %v", err)
+		}
+		if count >= int64(s.cfg.RateLimitMax) {
+			return nil, status.Error(codes.ResourceExhausted, "too many verification codes requested for this telegram_id, try again later")
+		}
+		if err := s.repo.InvalidateActiveForTelegramHint(ctx, hint, now); err != nil {
+			return nil, status.Errorf(codes.Internal, "invalidate previous codes: %v", err)
+		}
+	}
+
+	code, err := verification.GenerateCode()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "generate code: %v", err)
+	}
+
+	payload, err := json.Marshal(claimPayload{TelegramIDHint: telegramIDHint, DisplayName: req.GetDisplayName()})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "marshal claim payload: %v", err)
+	}
+
+	expiresAt := now.Add(s.cfg.CodeTTL)
+	vc, err := s.repo.Create(ctx, &model.VerificationCode{
+		Code:           code,
+		ClaimPayload:   datatypes.JSON(payload),
+		RequesterIP:    requesterIP,
+		TelegramIDHint: telegramIDHint,
+		ExpiresAt:      expiresAt,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "issue verification code: %v", err)
+	}
+
+	s.logger.Printf("[VERIFICATION][INFO] IssueVerificationCode | id=%s telegram_id_hint=%v", vc.ID, telegramIDHint)
+	return &verificationpb.IssueVerificationCodeResponse{
+		Code:      vc.Code,
+		ExpiresAt: vc.ExpiresAt.Unix(),
+	}, nil
+}
+
+// ConsumeVerificationCode проверяет code (регистронезависимо), помечает
+// его потреблённым и возвращает verification_token, который бот передаёт
+// в IdentityService.RegisterUser/UpdateContacts. Код одноразовый:
+// конкурентный повторный вызов получит FailedPrecondition от MarkConsumed.
+func (s *VerificationService) ConsumeVerificationCode(ctx context.Context, req *verificationpb.ConsumeVerificationCodeRequest) (*verificationpb.ConsumeVerificationCodeResponse, error) {
+	if req.GetTelegramId() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "telegram_id is required")
+	}
+	code := verification.NormalizeCode(req.GetCode())
+	if code == "" {
+		return nil, status.Error(codes.InvalidArgument, "code is required")
+	}
+
+	now := time.Now().UTC()
+	vc, err := s.repo.FindActiveByCode(ctx, code, now)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "verification code not found or expired")
+	}
+
+	if err := s.repo.MarkConsumed(ctx, vc.ID.String(), now); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, "verification code already used")
+	}
+
+	expiresAt := now.Add(s.cfg.TokenTTL)
+	token := verification.SignToken(s.cfg.TokenSecret, req.GetTelegramId(), expiresAt)
+
+	s.logger.Printf("[VERIFICATION][INFO] ConsumeVerificationCode | id=%s telegram_id=%d", vc.ID, req.GetTelegramId())
+	return &verificationpb.ConsumeVerificationCodeResponse{
+		VerificationToken: token,
+		ExpiresAt:         expiresAt.Unix(),
+	}, nil
+}
@@ -0,0 +1,107 @@
+package calendar
+
+import "sort"
+
+// BlackoutSet — отсортированный набор непересекающихся интервалов
+// недоступности (отпуска, праздники, разовые "не работаем сегодня"), которые
+// нужно вычесть из доступности. Subtract — основной примитив; он же
+// применяется через ApplyBlackouts поверх готовых интервалов
+// (SplitToTimeSlots, ExpandRecurringRule), так же как ApplyMaintenance
+// работает поверх них для MaintenanceWindow. Ranges отдаёт тот же срез,
+// что можно передать прямо в HasOverlap.
+type BlackoutSet struct {
+	ranges []TimeRange
+}
+
+// NewBlackoutSet сортирует ranges по Start и сливает пересекающиеся или
+// вплотную примыкающие интервалы, так что Subtract может полагаться на
+// отсортированность и непересекаемость и использовать двоичный поиск вместо
+// полного перебора.
+func NewBlackoutSet(ranges []TimeRange) BlackoutSet {
+	if len(ranges) == 0 {
+		return BlackoutSet{}
+	}
+	sorted := make([]TimeRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.Before(sorted[j].Start) })
+
+	merged := []TimeRange{sorted[0]}
+	for _, next := range sorted[1:] {
+		cur := &merged[len(merged)-1]
+		if !next.Start.After(cur.End) {
+			if next.End.After(cur.End) {
+				cur.End = next.End
+			}
+			continue
+		}
+		merged = append(merged, next)
+	}
+	return BlackoutSet{ranges: merged}
+}
+
+// Ranges возвращает вычищенные (отсортированные, непересекающиеся)
+// интервалы набора.
+func (b BlackoutSet) Ranges() []TimeRange {
+	return b.ranges
+}
+
+// Subtract вычитает из tr все пересекающиеся интервалы набора, возвращая
+// оставшиеся куски в порядке возрастания Start: полностью перекрытый tr даёт
+// пустой срез, частичное пересечение — один или два оставшихся куска.
+// Интервалы полуоткрытые [Start, End), поэтому только касание границами
+// (tr.End == blackout.Start или наоборот) пересечением не считается и tr
+// возвращается без изменений.
+func (b BlackoutSet) Subtract(tr TimeRange) []TimeRange {
+	if len(b.ranges) == 0 {
+		return []TimeRange{tr}
+	}
+
+	// Всё левее lo заведомо заканчивается не позже начала tr и пересекаться
+	// не может — двоичным поиском пропускаем эту часть набора.
+	lo := sort.Search(len(b.ranges), func(i int) bool {
+		return b.ranges[i].End.After(tr.Start)
+	})
+
+	remaining := []TimeRange{tr}
+	for i := lo; i < len(b.ranges); i++ {
+		w := b.ranges[i]
+		if !w.Start.Before(tr.End) {
+			break // ranges отсортированы — дальше пересечений уже не будет.
+		}
+		var next []TimeRange
+		for _, r := range remaining {
+			next = append(next, subtractRange(r, w)...)
+		}
+		remaining = next
+		if len(remaining) == 0 {
+			break
+		}
+	}
+	return remaining
+}
+
+// ApplyBlackouts вычитает blackouts из каждого интервала ranges (вывод
+// SplitToTimeSlots или ExpandRecurringRule), разбивая или отбрасывая
+// интервалы, которые blackouts перекрывают.
+func ApplyBlackouts(ranges []TimeRange, blackouts BlackoutSet) []TimeRange {
+	if len(blackouts.ranges) == 0 {
+		return ranges
+	}
+	result := make([]TimeRange, 0, len(ranges))
+	for _, r := range ranges {
+		result = append(result, blackouts.Subtract(r)...)
+	}
+	return result
+}
+
+// ExpandRecurringRuleWithBlackouts — ExpandRecurringRule с дополнительным
+// вычитанием blackouts на каждом материализованном вхождении; композиция, а
+// не встроенная в генератор фильтрация, по образцу ApplyMaintenance, которая
+// так же работает поверх готовых слотов.
+func ExpandRecurringRuleWithBlackouts(rule RecurringRule, window TimeRange, blackouts BlackoutSet) ([]TimeRange, error) {
+	occurrences, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		return nil, err
+	}
+	return ApplyBlackouts(occurrences, blackouts), nil
+}
@@ -0,0 +1,87 @@
+package calendar
+
+import (
+	"testing"
+)
+
+func TestBlackoutSet_Subtract_FullyCovered(t *testing.T) {
+	slot := TimeRange{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)}
+	blackouts := NewBlackoutSet([]TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 9, 0), End: mustTime(t, 2025, 1, 1, 12, 0)},
+	})
+
+	got := blackouts.Subtract(slot)
+	if len(got) != 0 {
+		t.Fatalf("expected slot fully covered by blackout to be dropped, got %+v", got)
+	}
+}
+
+func TestBlackoutSet_Subtract_PartialOverlapSplitsInTwo(t *testing.T) {
+	slot := TimeRange{Start: mustTime(t, 2025, 1, 1, 9, 0), End: mustTime(t, 2025, 1, 1, 12, 0)}
+	blackouts := NewBlackoutSet([]TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)},
+	})
+
+	got := blackouts.Subtract(slot)
+	want := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 9, 0), End: mustTime(t, 2025, 1, 1, 10, 0)},
+		{Start: mustTime(t, 2025, 1, 1, 11, 0), End: mustTime(t, 2025, 1, 1, 12, 0)},
+	}
+	if len(got) != 2 || !got[0].Start.Equal(want[0].Start) || !got[0].End.Equal(want[0].End) ||
+		!got[1].Start.Equal(want[1].Start) || !got[1].End.Equal(want[1].End) {
+		t.Fatalf("expected split into two remaining ranges %+v, got %+v", want, got)
+	}
+}
+
+func TestBlackoutSet_Subtract_TouchingBoundaryNoChange(t *testing.T) {
+	slot := TimeRange{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)}
+	blackouts := NewBlackoutSet([]TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 11, 0), End: mustTime(t, 2025, 1, 1, 12, 0)},
+	})
+
+	got := blackouts.Subtract(slot)
+	if len(got) != 1 || !got[0].Start.Equal(slot.Start) || !got[0].End.Equal(slot.End) {
+		t.Fatalf("expected a blackout merely touching the boundary to leave the slot unchanged, got %+v", got)
+	}
+}
+
+func TestBlackoutSet_Subtract_NoOverlap(t *testing.T) {
+	slot := TimeRange{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)}
+	blackouts := NewBlackoutSet([]TimeRange{
+		{Start: mustTime(t, 2025, 2, 1, 0, 0), End: mustTime(t, 2025, 2, 8, 0, 0)},
+	})
+
+	got := blackouts.Subtract(slot)
+	if len(got) != 1 || !got[0].Start.Equal(slot.Start) || !got[0].End.Equal(slot.End) {
+		t.Fatalf("expected an unrelated blackout to leave the slot unchanged, got %+v", got)
+	}
+}
+
+func TestNewBlackoutSet_MergesOverlapping(t *testing.T) {
+	bs := NewBlackoutSet([]TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 12, 0)},
+		{Start: mustTime(t, 2025, 1, 1, 11, 0), End: mustTime(t, 2025, 1, 1, 13, 0)},
+	})
+	if len(bs.Ranges()) != 1 {
+		t.Fatalf("expected overlapping blackouts to merge into one, got %+v", bs.Ranges())
+	}
+}
+
+func TestApplyBlackouts(t *testing.T) {
+	slots := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 9, 0), End: mustTime(t, 2025, 1, 1, 10, 0)},
+		{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)},
+	}
+	blackouts := NewBlackoutSet([]TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 9, 30), End: mustTime(t, 2025, 1, 1, 10, 30)},
+	})
+
+	got := ApplyBlackouts(slots, blackouts)
+	want := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 9, 0), End: mustTime(t, 2025, 1, 1, 9, 30)},
+		{Start: mustTime(t, 2025, 1, 1, 10, 30), End: mustTime(t, 2025, 1, 1, 11, 0)},
+	}
+	if len(got) != 2 || !got[0].Start.Equal(want[0].Start) || !got[1].End.Equal(want[1].End) {
+		t.Fatalf("expected blackout applied across both slots, got %+v", got)
+	}
+}
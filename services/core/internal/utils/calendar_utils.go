@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"sort"
 	"time"
+
+	"github.com/Leganyst/appointment-platform/internal/i18n"
 )
 
 var (
@@ -119,6 +121,20 @@ func SplitToTimeSlots(
 	return slots, nil
 }
 
+// SplitToTimeSlotsWithSchedule — то же самое, что SplitToTimeSlots, но
+// ограниченное рабочими окнами sched (см. WeeklyTemplate): слоты вне окон
+// отбрасываются, а интервалы, пересекающие границу дня недели, уже разрезаны
+// по отдельным DayInterval — делегирует sched.Materialize, которая это
+// умеет для произвольного окна tr.
+func SplitToTimeSlotsWithSchedule(
+	tr TimeRange,
+	slotDuration time.Duration,
+	alignMinutes int,
+	sched WeeklyTemplate,
+) ([]TimeRange, error) {
+	return sched.Materialize(tr, slotDuration, alignMinutes)
+}
+
 // HasOverlap проверяет, пересекается ли newRange с existing.
 // inclusive = true — касание концами считается пересечением.
 func HasOverlap(
@@ -149,29 +165,116 @@ func rangesOverlap(a, b TimeRange, inclusive bool) bool {
 	return a.Start.Before(b.End) && b.Start.Before(a.End)
 }
 
-// ===== Recurring rules =====
+// ApplyMaintenance вычитает интервалы windows из каждого слота в slots.
+// Если слот полностью покрыт окном обслуживания — он отбрасывается; если
+// покрыт частично и остаток короче исходной длительности слота — тоже
+// отбрасывается (частичный слот не бронируем); иначе остаток слота
+// включается как отдельный интервал. Слоты, не пересекающиеся ни с одним
+// окном, возвращаются без изменений. Порядок результата соответствует
+// порядку исходных слотов.
+func ApplyMaintenance(slots []TimeRange, windows []TimeRange) []TimeRange {
+	if len(windows) == 0 {
+		return slots
+	}
+
+	result := make([]TimeRange, 0, len(slots))
+	for _, slot := range slots {
+		duration := slot.End.Sub(slot.Start)
+		remaining := []TimeRange{slot}
+
+		for _, w := range windows {
+			if !rangesOverlap(w, TimeRange{Start: slot.Start, End: slot.End}, false) {
+				continue
+			}
+			var next []TimeRange
+			for _, r := range remaining {
+				next = append(next, subtractRange(r, w)...)
+			}
+			remaining = next
+			if len(remaining) == 0 {
+				break
+			}
+		}
+
+		for _, r := range remaining {
+			if r.End.Sub(r.Start) < duration {
+				continue
+			}
+			result = append(result, r)
+		}
+	}
+
+	return result
+}
+
+// subtractRange вычитает w из r, возвращая 0, 1 или 2 оставшихся интервала.
+func subtractRange(r, w TimeRange) []TimeRange {
+	if !rangesOverlap(r, w, false) {
+		return []TimeRange{r}
+	}
+
+	var leftover []TimeRange
+	if w.Start.After(r.Start) {
+		leftover = append(leftover, TimeRange{Start: r.Start, End: w.Start})
+	}
+	if w.End.Before(r.End) {
+		leftover = append(leftover, TimeRange{Start: w.End, End: r.End})
+	}
+	return leftover
+}
+
+// ===== Recurring rules (RFC 5545-совместимый движок) =====
 
 type RecurrenceFrequency int
 
 const (
 	FreqDaily RecurrenceFrequency = iota
 	FreqWeekly
+	FreqMonthly
+	FreqYearly
 )
 
+// ByDayEntry — элемент BYDAY: день недели с опциональной позицией внутри периода
+// (1 — первый такой день периода, -1 — последний, 0 — без позиции, т.е. "каждый Weekday").
+// Например, BYDAY=-1SU ("последнее воскресенье месяца") это ByDayEntry{Pos: -1, Weekday: time.Sunday}.
+type ByDayEntry struct {
+	Pos     int
+	Weekday time.Weekday
+}
+
 type RecurringRule struct {
 	Freq      RecurrenceFrequency
-	Interval  int            // шаг: каждые Interval дней/недель (>=1)
-	Weekdays  []time.Weekday // для FreqWeekly
-	StartTime time.Time      // начальное начало слота
+	Interval  int            // шаг: каждые Interval дней/недель/месяцев/лет (>=1)
+	Weekdays  []time.Weekday // BYDAY без позиции (сохранено для обратной совместимости с FreqWeekly)
+	StartTime time.Time      // DTSTART: начальное начало слота, задаёт и TZID, и время дня по умолчанию
 	Duration  time.Duration  // длительность слота
-	Until     *time.Time     // опционально: дата/время окончания
-	Count     *int           // опционально: максимальное количество повторений
-	// Исключения по датам (используем дату без времени).
+	Until     *time.Time     // UNTIL: опционально, дата/время окончания
+	Count     *int           // COUNT: опционально, максимальное количество повторений
+	// Исключения по датам (используем дату без времени) — сохранено для обратной совместимости.
 	Exceptions map[time.Time]struct{}
+
+	ByMonth    []int        // BYMONTH, 1-12 (только для FreqYearly)
+	ByMonthDay []int        // BYMONTHDAY, 1-31 либо отрицательные — от конца месяца
+	ByYearDay  []int        // BYYEARDAY, 1-366 либо отрицательные — от конца года (только для FreqYearly)
+	ByWeekNo   []int        // BYWEEKNO, 1-53 либо отрицательные — от конца года, ISO-неделя с началом WKST (только для FreqYearly)
+	ByDay      []ByDayEntry // BYDAY с позицией — для FreqMonthly/FreqYearly ("1MO", "-1SU" и т.п.)
+	ByHour     []int        // BYHOUR, 0-23
+	ByMinute   []int        // BYMINUTE, 0-59
+	BySetPos   []int        // BYSETPOS — выбор N-го вхождения (считая с конца, если отрицательный) внутри периода
+	WeekStart  time.Weekday // WKST; нулевое значение (Sunday) трактуется как понедельник (дефолт RFC 5545)
+
+	RDates  []time.Time // RDATE — дополнительные разовые вхождения, добавляются к развёрнутому набору
+	ExDates []time.Time // EXDATE — точные исключения по времени (в отличие от Exceptions, без усечения до даты)
 }
 
 // ExpandRecurringRule разворачивает правило повторений в набор интервалов
 // внутри окна window. Интервалы, полностью лежащие вне window, отбрасываются.
+//
+// Кандидаты генерируются по периодам (день/неделя/месяц/год в зависимости от Freq),
+// внутри периода применяются BYMONTH/BYWEEKNO/BYYEARDAY → BYMONTHDAY → BYDAY → BYHOUR →
+// BYMINUTE → BYSETPOS в порядке, предписанном RFC 5545 §3.3.10 (BYYEARDAY и BYWEEKNO
+// применимы только к FreqYearly и взаимоисключающи с BYMONTH/BYMONTHDAY), после чего
+// учитываются RDATE/EXDATE и отсекаются значения за пределами COUNT/UNTIL.
 func ExpandRecurringRule(rule RecurringRule, window TimeRange) ([]TimeRange, error) {
 	if rule.Duration <= 0 {
 		return nil, errors.New("recurring rule: duration must be positive")
@@ -186,173 +289,390 @@ func ExpandRecurringRule(rule RecurringRule, window TimeRange) ([]TimeRange, err
 		return []TimeRange{}, nil
 	}
 
-	var result []TimeRange
-	countGenerated := 0
+	wkst := rule.WeekStart
+	if wkst == time.Sunday {
+		wkst = time.Monday
+	}
+
+	exDates := make(map[int64]struct{}, len(rule.ExDates))
+	for _, d := range rule.ExDates {
+		exDates[d.Unix()] = struct{}{}
+	}
 
-	// Weekly with explicit weekdays: generate occurrences for each weekday in each stepped week.
-	if rule.Freq == FreqWeekly && len(rule.Weekdays) > 0 {
-		weekdays := uniqueSortedWeekdays(rule.Weekdays)
-		startLoc := rule.StartTime.Location()
-		startHour, startMin, startSec := rule.StartTime.Clock()
+	var occStarts []time.Time
+	countGenerated := 0
 
-		weekCursor := rule.StartTime
-		for {
-			// Stop by Count.
-			if rule.Count != nil && countGenerated >= *rule.Count {
+	periodStart := periodAnchor(rule.Freq, rule.StartTime, wkst)
+	// Защита от бесконечного цикла, когда ни Until, ни Count не ограничивают правило
+	// и окно находится далеко впереди (например, ошибка в данных): не более 10000 периодов.
+	for periodsVisited := 0; periodsVisited < 10000; periodsVisited++ {
+		if rule.Count != nil && countGenerated >= *rule.Count {
+			break
+		}
+		if rule.Until != nil && periodStart.After(*rule.Until) {
+			break
+		}
+		if periodStart.After(window.End) && !periodStart.Equal(window.End) {
+			// Дальше по периодам будет только позже окна — но у weekly/monthly кандидаты
+			// внутри периода могут быть раньше periodStart (BYDAY с позицией), поэтому
+			// проверяем по следующему периоду только когда разница заведомо больше периода.
+			if periodStart.Sub(window.End) > periodSpan(rule.Freq, rule.Interval) {
 				break
 			}
+		}
+
+		candidates := occurrencesForPeriod(rule, periodStart, wkst)
+		candidates = applyBySetPos(candidates, rule.BySetPos)
 
-			weekStart := weekStartMonday(weekCursor)
-			// Stop once we're clearly past the window.
-			if weekStart.After(window.End) {
+		for _, occStart := range candidates {
+			if occStart.Before(rule.StartTime) {
+				continue
+			}
+			if rule.Until != nil && occStart.After(*rule.Until) {
+				continue
+			}
+			// COUNT ограничивает число вхождений, порождённых самим правилом,
+			// ДО применения EXDATE/Exceptions (RFC 5545 §3.8.5.2) — иначе
+			// исключённая дата "восстанавливала" бы вычеркнутое вхождение.
+			if rule.Count != nil && countGenerated >= *rule.Count {
 				break
 			}
+			countGenerated++
+			if isException(rule, occStart) {
+				continue
+			}
+			if _, excluded := exDates[occStart.Unix()]; excluded {
+				continue
+			}
+			occStarts = append(occStarts, occStart)
+		}
 
-			for _, wd := range weekdays {
-				// Stop by Count.
-				if rule.Count != nil && countGenerated >= *rule.Count {
-					break
-				}
+		periodStart = nextPeriodAnchor(rule.Freq, periodStart, rule.Interval)
+	}
 
-				d := weekStart.AddDate(0, 0, offsetFromMonday(wd))
-				occStart := time.Date(d.Year(), d.Month(), d.Day(), startHour, startMin, startSec, 0, startLoc)
-				// Не генерируем события до исходного якоря.
-				if occStart.Before(rule.StartTime) {
-					continue
-				}
+	// RDATE — добавочные разовые вхождения, не участвуют в COUNT/UNTIL/BYSETPOS.
+	occStarts = append(occStarts, rule.RDates...)
 
-				// Ограничение по Until.
-				if rule.Until != nil && occStart.After(*rule.Until) {
-					// Дальше по дням недели/неделям будет только позже.
-					return result, nil
-				}
+	sort.Slice(occStarts, func(i, j int) bool { return occStarts[i].Before(occStarts[j]) })
+	occStarts = dedupeOccurrences(occStarts)
 
-				// Исключения по дате.
-				if isException(rule, occStart) {
-					continue
-				}
+	result := make([]TimeRange, 0, len(occStarts))
+	for _, occStart := range occStarts {
+		occEnd := occStart.Add(rule.Duration)
+		occRange := TimeRange{Start: occStart, End: occEnd}
+		if rangesOverlap(occRange, window, false) {
+			result = append(result, occRange)
+		}
+	}
 
-				occEnd := occStart.Add(rule.Duration)
-				occRange := TimeRange{Start: occStart, End: occEnd}
+	return result, nil
+}
 
-				if rangesOverlap(occRange, window, false) {
-					result = append(result, occRange)
-					countGenerated++
-				} else if occStart.After(window.End) && occEnd.After(window.End) {
-					// Для текущей недели дальше по дням может быть ещё позже — прерываем внутренний цикл.
-					break
-				}
-			}
+// periodAnchor возвращает начало периода (в смысле FREQ), которому принадлежит StartTime.
+func periodAnchor(freq RecurrenceFrequency, t time.Time, wkst time.Weekday) time.Time {
+	switch freq {
+	case FreqWeekly:
+		return weekStartOn(t, wkst)
+	case FreqMonthly:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case FreqYearly:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	default:
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
 
-			// Переходим к следующей неделе с учётом interval.
-			weekCursor = weekCursor.AddDate(0, 0, 7*rule.Interval)
-			if rule.Until != nil && weekCursor.After(*rule.Until) {
-				break
-			}
-		}
+func nextPeriodAnchor(freq RecurrenceFrequency, cur time.Time, interval int) time.Time {
+	switch freq {
+	case FreqWeekly:
+		return cur.AddDate(0, 0, 7*interval)
+	case FreqMonthly:
+		return cur.AddDate(0, interval, 0)
+	case FreqYearly:
+		return cur.AddDate(interval, 0, 0)
+	default:
+		return cur.AddDate(0, 0, interval)
+	}
+}
 
-		return result, nil
+// periodSpan — примерная длительность одного периода, используется только для
+// ранней остановки цикла развёртки (достаточно верхней оценки).
+func periodSpan(freq RecurrenceFrequency, interval int) time.Duration {
+	day := 24 * time.Hour
+	switch freq {
+	case FreqWeekly:
+		return 7 * day * time.Duration(interval)
+	case FreqMonthly:
+		return 31 * day * time.Duration(interval)
+	case FreqYearly:
+		return 366 * day * time.Duration(interval)
+	default:
+		return day * time.Duration(interval)
 	}
+}
 
-	cur := rule.StartTime
+// occurrencesForPeriod строит неотфильтрованный (до BYSETPOS) список кандидатов
+// внутри одного периода, отсортированный по времени.
+func occurrencesForPeriod(rule RecurringRule, periodStart time.Time, wkst time.Weekday) []time.Time {
+	loc := rule.StartTime.Location()
+	hours := rule.ByHour
+	if len(hours) == 0 {
+		hours = []int{rule.StartTime.Hour()}
+	}
+	minutes := rule.ByMinute
+	if len(minutes) == 0 {
+		minutes = []int{rule.StartTime.Minute()}
+	}
+	sec := rule.StartTime.Second()
 
-	for {
-		// Ограничение по Until
-		if rule.Until != nil && cur.After(*rule.Until) {
-			break
+	var days []time.Time
+
+	switch rule.Freq {
+	case FreqDaily:
+		days = []time.Time{time.Date(periodStart.Year(), periodStart.Month(), periodStart.Day(), 0, 0, 0, 0, loc)}
+
+	case FreqWeekly:
+		byDay := rule.ByDay
+		if len(byDay) == 0 {
+			for _, wd := range rule.Weekdays {
+				byDay = append(byDay, ByDayEntry{Weekday: wd})
+			}
 		}
-		// Ограничение по Count
-		if rule.Count != nil && countGenerated >= *rule.Count {
-			break
+		if len(byDay) == 0 {
+			byDay = []ByDayEntry{{Weekday: rule.StartTime.Weekday()}}
+		}
+		for _, entry := range byDay {
+			offset := weekdayOffset(entry.Weekday, wkst)
+			days = append(days, periodStart.AddDate(0, 0, offset))
 		}
-		occStart := cur
-		occEnd := cur.Add(rule.Duration)
 
-		// Для weekly учитываем только нужные дни недели.
-		if rule.Freq == FreqWeekly && len(rule.Weekdays) > 0 {
-			if !containsWeekday(rule.Weekdays, occStart.Weekday()) {
-				cur = nextOccurrence(rule, cur)
-				continue
+	case FreqMonthly:
+		monthStart := periodStart
+		daysInMonth := daysInMonthOf(monthStart)
+		if len(rule.ByMonthDay) > 0 {
+			for _, md := range rule.ByMonthDay {
+				day := resolveMonthDay(md, daysInMonth)
+				if day < 1 || day > daysInMonth {
+					continue
+				}
+				days = append(days, time.Date(monthStart.Year(), monthStart.Month(), day, 0, 0, 0, 0, loc))
 			}
+		} else if len(rule.ByDay) > 0 {
+			for _, entry := range rule.ByDay {
+				days = append(days, nthWeekdayOfMonth(monthStart, entry)...)
+			}
+		} else {
+			days = append(days, time.Date(monthStart.Year(), monthStart.Month(), rule.StartTime.Day(), 0, 0, 0, 0, loc))
 		}
 
-		// Проверка исключений по дате.
-		if isException(rule, occStart) {
-			cur = nextOccurrence(rule, cur)
-			continue
+	case FreqYearly:
+		if len(rule.ByYearDay) > 0 {
+			daysInYear := daysInYearOf(periodStart)
+			for _, yd := range rule.ByYearDay {
+				day := resolveYearDay(yd, daysInYear)
+				if day < 1 || day > daysInYear {
+					continue
+				}
+				days = append(days, time.Date(periodStart.Year(), time.January, 1, 0, 0, 0, 0, loc).AddDate(0, 0, day-1))
+			}
+			break
 		}
-
-		occRange := TimeRange{Start: occStart, End: occEnd}
-
-		// Если интервал пересекается с окном — включаем.
-		if rangesOverlap(occRange, window, false) {
-			result = append(result, occRange)
-			countGenerated++
-		} else if occEnd.After(window.End) && occStart.After(window.End) {
-			// Дальнейшие повторения точно будут дальше окна.
+		if len(rule.ByWeekNo) > 0 {
+			for _, wn := range rule.ByWeekNo {
+				weekStart, ok := nthISOWeekOfYear(periodStart.Year(), wn, wkst, loc)
+				if !ok {
+					continue
+				}
+				if len(rule.ByDay) > 0 {
+					for _, entry := range rule.ByDay {
+						days = append(days, weekStart.AddDate(0, 0, weekdayOffset(entry.Weekday, wkst)))
+					}
+				} else {
+					for i := 0; i < 7; i++ {
+						days = append(days, weekStart.AddDate(0, 0, i))
+					}
+				}
+			}
 			break
 		}
 
-		cur = nextOccurrence(rule, cur)
+		months := rule.ByMonth
+		if len(months) == 0 {
+			months = []int{int(rule.StartTime.Month())}
+		}
+		for _, mo := range months {
+			monthStart := time.Date(periodStart.Year(), time.Month(mo), 1, 0, 0, 0, 0, loc)
+			daysInMonth := daysInMonthOf(monthStart)
+			if len(rule.ByMonthDay) > 0 {
+				for _, md := range rule.ByMonthDay {
+					day := resolveMonthDay(md, daysInMonth)
+					if day < 1 || day > daysInMonth {
+						continue
+					}
+					days = append(days, time.Date(monthStart.Year(), monthStart.Month(), day, 0, 0, 0, 0, loc))
+				}
+			} else if len(rule.ByDay) > 0 {
+				for _, entry := range rule.ByDay {
+					days = append(days, nthWeekdayOfMonth(monthStart, entry)...)
+				}
+			} else {
+				days = append(days, time.Date(monthStart.Year(), monthStart.Month(), rule.StartTime.Day(), 0, 0, 0, 0, loc))
+			}
+		}
+	}
+
+	var result []time.Time
+	for _, d := range days {
+		for _, h := range hours {
+			for _, m := range minutes {
+				result = append(result, time.Date(d.Year(), d.Month(), d.Day(), h, m, sec, 0, loc))
+			}
+		}
 	}
 
-	return result, nil
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
 }
 
-func uniqueSortedWeekdays(days []time.Weekday) []time.Weekday {
-	seen := make(map[time.Weekday]struct{}, len(days))
-	uniq := make([]time.Weekday, 0, len(days))
-	for _, d := range days {
-		if _, ok := seen[d]; ok {
+// applyBySetPos выбирает подмножество candidates по 1-based позициям (отрицательные — с конца).
+// Пустой setPos означает «без фильтрации».
+func applyBySetPos(candidates []time.Time, setPos []int) []time.Time {
+	if len(setPos) == 0 {
+		return candidates
+	}
+	n := len(candidates)
+	seen := make(map[int]struct{}, len(setPos))
+	var result []time.Time
+	for _, pos := range setPos {
+		idx := pos
+		if idx < 0 {
+			idx = n + idx + 1
+		}
+		if idx < 1 || idx > n {
+			continue
+		}
+		if _, ok := seen[idx]; ok {
 			continue
 		}
-		seen[d] = struct{}{}
-		uniq = append(uniq, d)
+		seen[idx] = struct{}{}
+		result = append(result, candidates[idx-1])
 	}
-	sort.Slice(uniq, func(i, j int) bool { return uniq[i] < uniq[j] })
-	return uniq
+	sort.Slice(result, func(i, j int) bool { return result[i].Before(result[j]) })
+	return result
 }
 
-// weekStartMonday возвращает начало ISO-недели (понедельник 00:00) для даты t в её локации.
-func weekStartMonday(t time.Time) time.Time {
-	loc := t.Location()
-	y, m, d := t.Date()
-	midnight := time.Date(y, m, d, 0, 0, 0, 0, loc)
-	wd := midnight.Weekday()
-	var delta int
-	if wd == time.Sunday {
-		delta = 6
-	} else {
-		delta = int(wd) - 1 // Monday=1 -> 0
+// weekdayOffset — смещение в днях от начала недели (с учётом wkst) до weekday.
+func weekdayOffset(weekday, wkst time.Weekday) int {
+	diff := int(weekday) - int(wkst)
+	if diff < 0 {
+		diff += 7
 	}
+	return diff
+}
+
+// weekStartOn возвращает полночь начала недели (с учётом wkst), которой принадлежит t.
+func weekStartOn(t time.Time, wkst time.Weekday) time.Time {
+	y, m, d := t.Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	delta := weekdayOffset(midnight.Weekday(), wkst)
 	return midnight.AddDate(0, 0, -delta)
 }
 
-func offsetFromMonday(wd time.Weekday) int {
-	if wd == time.Sunday {
-		return 6
+func daysInMonthOf(monthStart time.Time) int {
+	firstOfNext := monthStart.AddDate(0, 1, 0)
+	lastDay := firstOfNext.AddDate(0, 0, -1)
+	return lastDay.Day()
+}
+
+// resolveMonthDay переводит BYMONTHDAY (в т.ч. отрицательный — от конца месяца) в день 1..daysInMonth.
+func resolveMonthDay(monthDay, daysInMonth int) int {
+	if monthDay > 0 {
+		return monthDay
 	}
-	return int(wd) - 1
+	return daysInMonth + monthDay + 1
 }
 
-func nextOccurrence(rule RecurringRule, cur time.Time) time.Time {
-	switch rule.Freq {
-	case FreqDaily:
-		return cur.AddDate(0, 0, rule.Interval)
-	case FreqWeekly:
-		return cur.AddDate(0, 0, 7*rule.Interval)
-	default:
-		return cur.AddDate(0, 0, rule.Interval)
+func daysInYearOf(t time.Time) int {
+	firstOfNextYear := time.Date(t.Year()+1, time.January, 1, 0, 0, 0, 0, t.Location())
+	lastDay := firstOfNextYear.AddDate(0, 0, -1)
+	return lastDay.YearDay()
+}
+
+// resolveYearDay переводит BYYEARDAY (в т.ч. отрицательный — от конца года) в день 1..daysInYear.
+func resolveYearDay(yearDay, daysInYear int) int {
+	if yearDay > 0 {
+		return yearDay
+	}
+	return daysInYear + yearDay + 1
+}
+
+// nthISOWeekOfYear возвращает полночь понедельника (со сдвигом на wkst) недели
+// под номером weekNo (в т.ч. отрицательным — от конца года) внутри year, по правилам
+// RFC 5545 §3.3.10 BYWEEKNO: неделя 1 — первая неделя, содержащая как минимум четыре
+// дня year (эквивалент ISO-8601), отсчитываемая от wkst.
+func nthISOWeekOfYear(year int, weekNo int, wkst time.Weekday, loc *time.Location) (time.Time, bool) {
+	jan1 := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+	firstWeekStart := weekStartOn(jan1, wkst)
+	if weekdayOffset(jan1.Weekday(), wkst) >= 4 {
+		firstWeekStart = firstWeekStart.AddDate(0, 0, 7)
+	}
+
+	dec31 := time.Date(year, time.December, 31, 0, 0, 0, 0, loc)
+	lastWeekStart := weekStartOn(dec31, wkst)
+	if weekdayOffset(dec31.Weekday(), wkst) < 3 {
+		lastWeekStart = lastWeekStart.AddDate(0, 0, -7)
+	}
+	totalWeeks := int(lastWeekStart.Sub(firstWeekStart).Hours()/(24*7)) + 1
+
+	idx := weekNo
+	if idx < 0 {
+		idx = totalWeeks + idx + 1
+	}
+	if idx < 1 || idx > totalWeeks {
+		return time.Time{}, false
 	}
+	return firstWeekStart.AddDate(0, 0, 7*(idx-1)), true
 }
 
-func containsWeekday(list []time.Weekday, w time.Weekday) bool {
-	for _, d := range list {
-		if d == w {
-			return true
+// nthWeekdayOfMonth возвращает все даты заданного дня недели в месяце monthStart,
+// либо только ту, что соответствует позиции entry.Pos (1 — первая, -1 — последняя и т.п.).
+func nthWeekdayOfMonth(monthStart time.Time, entry ByDayEntry) []time.Time {
+	loc := monthStart.Location()
+	daysInMonth := daysInMonthOf(monthStart)
+
+	var occurrences []time.Time
+	for day := 1; day <= daysInMonth; day++ {
+		d := time.Date(monthStart.Year(), monthStart.Month(), day, 0, 0, 0, 0, loc)
+		if d.Weekday() == entry.Weekday {
+			occurrences = append(occurrences, d)
+		}
+	}
+
+	if entry.Pos == 0 {
+		return occurrences
+	}
+	idx := entry.Pos
+	if idx < 0 {
+		idx = len(occurrences) + idx + 1
+	}
+	if idx < 1 || idx > len(occurrences) {
+		return nil
+	}
+	return []time.Time{occurrences[idx-1]}
+}
+
+func dedupeOccurrences(sorted []time.Time) []time.Time {
+	if len(sorted) == 0 {
+		return sorted
+	}
+	result := sorted[:1]
+	for _, t := range sorted[1:] {
+		if !t.Equal(result[len(result)-1]) {
+			result = append(result, t)
 		}
 	}
-	return false
+	return result
 }
 
 func isException(rule RecurringRule, t time.Time) bool {
@@ -383,14 +703,40 @@ var ruWeekdays = map[time.Weekday]string{
 	time.Sunday:    "Воскресенье",
 }
 
+// ruMonthsGenitive — названия месяцев в родительном падеже ("1 января", а
+// не "1 Январь"), как того требует русская запись даты.
+var ruMonthsGenitive = map[time.Month]string{
+	time.January:   "января",
+	time.February:  "февраля",
+	time.March:     "марта",
+	time.April:     "апреля",
+	time.May:       "мая",
+	time.June:      "июня",
+	time.July:      "июля",
+	time.August:    "августа",
+	time.September: "сентября",
+	time.October:   "октября",
+	time.November:  "ноября",
+	time.December:  "декабря",
+}
+
+// formatDateRu форматирует дату по-русски: "Среда, 1 января 2025 г.".
+func formatDateRu(t time.Time) string {
+	return fmt.Sprintf("%s, %d %s %d г.", ruWeekdays[t.Weekday()], t.Day(), ruMonthsGenitive[t.Month()], t.Year())
+}
+
 // FormatSlotForUser форматирует интервал в человекочитаемую строку.
-// Если loc != nil, время переводится в указанный часовой пояс.
-// Если includeID = true, в конце добавляется идентификатор слота в скобках.
+// Если loc != nil, время переводится в указанный часовой пояс. Если
+// includeID = true, в конце добавляется идентификатор слота в скобках.
+// lang выбирает язык даты ("Среда, 1 января 2025 г." для i18n.LangRU,
+// "Wednesday, 01.01.2025" для i18n.LangEN и пустого значения); время суток
+// в обоих случаях — ЧЧ:ММ.
 func FormatSlotForUser(
 	tr TimeRange,
 	loc *time.Location,
 	includeID bool,
 	slotID string,
+	lang i18n.Lang,
 ) string {
 	start := tr.Start
 	end := tr.End
@@ -400,14 +746,17 @@ func FormatSlotForUser(
 		end = end.In(loc)
 	}
 
-	weekday := ruWeekdays[start.Weekday()]
-	// Дата в формате ДД.ММ.ГГГГ
-	dateStr := start.Format("02.01.2006")
-	// Время в формате ЧЧ:ММ
 	startTimeStr := start.Format("15:04")
 	endTimeStr := end.Format("15:04")
 
-	base := fmt.Sprintf("%s, %s, %s–%s", weekday, dateStr, startTimeStr, endTimeStr)
+	var dateStr string
+	if lang == i18n.LangEN {
+		dateStr = fmt.Sprintf("%s, %s", start.Weekday().String(), start.Format("02.01.2006"))
+	} else {
+		dateStr = formatDateRu(start)
+	}
+
+	base := fmt.Sprintf("%s, %s–%s", dateStr, startTimeStr, endTimeStr)
 
 	if includeID && slotID != "" {
 		return fmt.Sprintf("%s (ID: %s)", base, slotID)
@@ -416,6 +765,25 @@ func FormatSlotForUser(
 	return base
 }
 
+// FormatSlotList форматирует список свободных слотов в одну
+// человекочитаемую строку: заголовок с правильно просклонённым числом
+// слотов (см. i18n.Messages.FreeSlotsCount) и каждый слот на отдельной
+// строке через FormatSlotForUser. Пустой список даёт "0 свободных слотов:"
+// без последующих строк.
+func FormatSlotList(slots []TimeRange, loc *time.Location, lang i18n.Lang) string {
+	msgs := i18n.NewMessages(lang)
+	lines := make([]string, 0, len(slots)+1)
+	lines = append(lines, msgs.FreeSlotsCount(len(slots))+":")
+	for _, s := range slots {
+		lines = append(lines, "- "+FormatSlotForUser(s, loc, false, "", lang))
+	}
+	out := lines[0]
+	for _, l := range lines[1:] {
+		out += "\n" + l
+	}
+	return out
+}
+
 // ===== Валидация Telegram-пользователя =====
 
 type UserStatus string
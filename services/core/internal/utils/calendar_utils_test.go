@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Leganyst/appointment-platform/internal/calendar"
+	"github.com/Leganyst/appointment-platform/internal/i18n"
 )
 
 func mustTime(t *testing.T, year int, month time.Month, day, hour, min int) time.Time {
@@ -274,8 +275,8 @@ func TestFormatSlotForUser_Basic(t *testing.T) {
 		End:   mustTime(t, 2025, 1, 1, 11, 0),
 	}
 
-	str := FormatSlotForUser(tr, time.UTC, false, "")
-	// Ожидаем что-то типа "Среда, 01.01.2025, 10:00–11:00"
+	str := FormatSlotForUser(tr, time.UTC, false, "", i18n.LangRU)
+	// Ожидаем что-то типа "Среда, 1 января 2025 г., 10:00–11:00"
 	if str == "" {
 		t.Fatalf("expected non-empty string")
 	}
@@ -290,12 +291,43 @@ func TestFormatSlotForUser_WithID(t *testing.T) {
 		End:   mustTime(t, 2025, 1, 1, 11, 0),
 	}
 
-	str := FormatSlotForUser(tr, time.UTC, true, "slot-123")
+	str := FormatSlotForUser(tr, time.UTC, true, "slot-123", i18n.LangRU)
 	if str == "" || !containsAll(str, []string{"ID", "slot-123"}) {
 		t.Fatalf("expected string with ID, got %q", str)
 	}
 }
 
+func TestFormatSlotForUser_English(t *testing.T) {
+	tr := TimeRange{
+		Start: mustTime(t, 2025, 1, 1, 10, 0),
+		End:   mustTime(t, 2025, 1, 1, 11, 0),
+	}
+
+	str := FormatSlotForUser(tr, time.UTC, false, "", i18n.LangEN)
+	if !containsAll(str, []string{"Wednesday", "2025", "10:00", "11:00"}) {
+		t.Fatalf("unexpected format: %q", str)
+	}
+}
+
+func TestFormatSlotList(t *testing.T) {
+	slots := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)},
+		{Start: mustTime(t, 2025, 1, 1, 12, 0), End: mustTime(t, 2025, 1, 1, 13, 0)},
+	}
+
+	str := FormatSlotList(slots, time.UTC, i18n.LangRU)
+	if !containsAll(str, []string{"2 свободных слота", "10:00", "12:00"}) {
+		t.Fatalf("unexpected list format: %q", str)
+	}
+}
+
+func TestFormatSlotList_Empty(t *testing.T) {
+	str := FormatSlotList(nil, time.UTC, i18n.LangRU)
+	if str != "0 свободных слотов:" {
+		t.Fatalf("unexpected empty list format: %q", str)
+	}
+}
+
 func containsAll(s string, parts []string) bool {
 	for _, p := range parts {
 		if !contains(s, p) {
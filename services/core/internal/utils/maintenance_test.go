@@ -0,0 +1,53 @@
+package calendar
+
+import (
+	"testing"
+)
+
+func TestApplyMaintenance_FullyCovered(t *testing.T) {
+	slot := TimeRange{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 9, 0), End: mustTime(t, 2025, 1, 1, 12, 0)}
+
+	got := ApplyMaintenance([]TimeRange{slot}, []TimeRange{window})
+	if len(got) != 0 {
+		t.Fatalf("expected fully covered slot to be dropped, got %+v", got)
+	}
+}
+
+func TestApplyMaintenance_PartialRemainderTooShort(t *testing.T) {
+	slot := TimeRange{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)}
+	// Окно перекрывает первые 50 минут слота — остаток короче исходной длительности.
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 9, 0), End: mustTime(t, 2025, 1, 1, 10, 50)}
+
+	got := ApplyMaintenance([]TimeRange{slot}, []TimeRange{window})
+	if len(got) != 0 {
+		t.Fatalf("expected remainder shorter than slot duration to be dropped, got %+v", got)
+	}
+}
+
+func TestApplyMaintenance_NoOverlap(t *testing.T) {
+	slot := TimeRange{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 12, 0), End: mustTime(t, 2025, 1, 1, 13, 0)}
+
+	got := ApplyMaintenance([]TimeRange{slot}, []TimeRange{window})
+	if !equalTimeRangeSlices(got, []TimeRange{slot}) {
+		t.Fatalf("expected slot unchanged, got %+v", got)
+	}
+}
+
+func TestApplyMaintenance_MultipleSlotsSomeDropped(t *testing.T) {
+	slots := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 9, 0), End: mustTime(t, 2025, 1, 1, 10, 0)},
+		{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)},
+		{Start: mustTime(t, 2025, 1, 1, 11, 0), End: mustTime(t, 2025, 1, 1, 12, 0)},
+	}
+	windows := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)},
+	}
+
+	got := ApplyMaintenance(slots, windows)
+	want := []TimeRange{slots[0], slots[2]}
+	if !equalTimeRangeSlices(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,355 @@
+package calendar
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var rruleWeekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+var rruleWeekdayNames = map[time.Weekday]string{
+	time.Monday:    "MO",
+	time.Tuesday:   "TU",
+	time.Wednesday: "WE",
+	time.Thursday:  "TH",
+	time.Friday:    "FR",
+	time.Saturday:  "SA",
+	time.Sunday:    "SU",
+}
+
+// ParseRRULE разбирает значение RRULE (RFC 5545 §3.3.10), например
+// "FREQ=MONTHLY;BYDAY=-1SU;INTERVAL=2", и заполняет поля RecurringRule,
+// относящиеся к повторению (Freq, Interval, ByMonth, ByMonthDay, ByDay, ByHour,
+// ByMinute, BySetPos, WeekStart, Until, Count). StartTime и Duration в строке
+// RRULE не описываются — их нужно проставить отдельно (из DTSTART события).
+func ParseRRULE(rrule string) (RecurringRule, error) {
+	var rule RecurringRule
+	freqSet := false
+
+	for _, part := range strings.Split(strings.TrimSpace(rrule), ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return RecurringRule{}, fmt.Errorf("rrule: invalid component %q", part)
+		}
+		key := strings.ToUpper(strings.TrimSpace(kv[0]))
+		value := strings.TrimSpace(kv[1])
+
+		switch key {
+		case "FREQ":
+			freq, err := parseFreq(value)
+			if err != nil {
+				return RecurringRule{}, err
+			}
+			rule.Freq = freq
+			freqSet = true
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid INTERVAL %q", value)
+			}
+			rule.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid COUNT %q", value)
+			}
+			rule.Count = &n
+		case "UNTIL":
+			until, err := parseRRULETime(value)
+			if err != nil {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid UNTIL %q: %w", value, err)
+			}
+			rule.Until = &until
+		case "BYMONTH":
+			months, err := parseIntList(value, 1, 12)
+			if err != nil {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid BYMONTH %q: %w", value, err)
+			}
+			rule.ByMonth = months
+		case "BYMONTHDAY":
+			days, err := parseIntList(value, -31, 31)
+			if err != nil {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid BYMONTHDAY %q: %w", value, err)
+			}
+			rule.ByMonthDay = days
+		case "BYYEARDAY":
+			days, err := parseIntList(value, -366, 366)
+			if err != nil {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid BYYEARDAY %q: %w", value, err)
+			}
+			rule.ByYearDay = days
+		case "BYWEEKNO":
+			weeks, err := parseIntList(value, -53, 53)
+			if err != nil {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid BYWEEKNO %q: %w", value, err)
+			}
+			rule.ByWeekNo = weeks
+		case "BYHOUR":
+			hours, err := parseIntList(value, 0, 23)
+			if err != nil {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid BYHOUR %q: %w", value, err)
+			}
+			rule.ByHour = hours
+		case "BYMINUTE":
+			minutes, err := parseIntList(value, 0, 59)
+			if err != nil {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid BYMINUTE %q: %w", value, err)
+			}
+			rule.ByMinute = minutes
+		case "BYSETPOS":
+			setPos, err := parseIntList(value, -366, 366)
+			if err != nil {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid BYSETPOS %q: %w", value, err)
+			}
+			rule.BySetPos = setPos
+		case "BYDAY":
+			entries, err := parseByDay(value)
+			if err != nil {
+				return RecurringRule{}, err
+			}
+			rule.ByDay = entries
+			for _, e := range entries {
+				if e.Pos == 0 {
+					rule.Weekdays = append(rule.Weekdays, e.Weekday)
+				}
+			}
+		case "WKST":
+			wd, ok := rruleWeekdayCodes[strings.ToUpper(value)]
+			if !ok {
+				return RecurringRule{}, fmt.Errorf("rrule: invalid WKST %q", value)
+			}
+			rule.WeekStart = wd
+		default:
+			// Неизвестные/непринятые компоненты (например, RDATE/EXDATE передаются
+			// отдельным свойством в iCalendar, а не внутри RRULE) — игнорируем.
+		}
+	}
+
+	if !freqSet {
+		return RecurringRule{}, fmt.Errorf("rrule: FREQ is required")
+	}
+	if rule.Interval <= 0 {
+		rule.Interval = 1
+	}
+
+	return rule, nil
+}
+
+// String сериализует относящиеся к повторению поля правила обратно в формат RRULE.
+func (r RecurringRule) String() string {
+	var parts []string
+
+	freqName, ok := freqNames[r.Freq]
+	if !ok {
+		freqName = "DAILY"
+	}
+	parts = append(parts, "FREQ="+freqName)
+
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinInts(r.ByMonth))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinInts(r.ByMonthDay))
+	}
+	if len(r.ByYearDay) > 0 {
+		parts = append(parts, "BYYEARDAY="+joinInts(r.ByYearDay))
+	}
+	if len(r.ByWeekNo) > 0 {
+		parts = append(parts, "BYWEEKNO="+joinInts(r.ByWeekNo))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+joinByDay(r.ByDay))
+	} else if len(r.Weekdays) > 0 {
+		entries := make([]ByDayEntry, 0, len(r.Weekdays))
+		for _, wd := range r.Weekdays {
+			entries = append(entries, ByDayEntry{Weekday: wd})
+		}
+		parts = append(parts, "BYDAY="+joinByDay(entries))
+	}
+	if len(r.ByHour) > 0 {
+		parts = append(parts, "BYHOUR="+joinInts(r.ByHour))
+	}
+	if len(r.ByMinute) > 0 {
+		parts = append(parts, "BYMINUTE="+joinInts(r.ByMinute))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinInts(r.BySetPos))
+	}
+	if r.WeekStart != 0 && r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+rruleWeekdayNames[r.WeekStart])
+	}
+	if r.Count != nil {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", *r.Count))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+
+	return strings.Join(parts, ";")
+}
+
+var freqNames = map[RecurrenceFrequency]string{
+	FreqDaily:   "DAILY",
+	FreqWeekly:  "WEEKLY",
+	FreqMonthly: "MONTHLY",
+	FreqYearly:  "YEARLY",
+}
+
+func parseFreq(value string) (RecurrenceFrequency, error) {
+	switch strings.ToUpper(value) {
+	case "DAILY":
+		return FreqDaily, nil
+	case "WEEKLY":
+		return FreqWeekly, nil
+	case "MONTHLY":
+		return FreqMonthly, nil
+	case "YEARLY":
+		return FreqYearly, nil
+	default:
+		return 0, fmt.Errorf("rrule: unsupported FREQ %q", value)
+	}
+}
+
+// parseRRULETime разбирает UNTIL в одном из двух допустимых форматов iCalendar:
+// "YYYYMMDD" (дата) или "YYYYMMDDTHHMMSSZ" (дата-время в UTC).
+func parseRRULETime(value string) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse("20060102T150405Z", value)
+	}
+	if len(value) == 8 {
+		return time.Parse("20060102", value)
+	}
+	return time.Parse("20060102T150405", value)
+}
+
+func parseIntList(value string, min, max int) ([]int, error) {
+	var result []int
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(raw)
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", raw)
+		}
+		if n < min || n > max || n == 0 {
+			return nil, fmt.Errorf("value %d out of range [%d,%d]", n, min, max)
+		}
+		result = append(result, n)
+	}
+	return result, nil
+}
+
+// ParseByDay — экспортированная обёртка над parseByDay для вызывающих, которые уже
+// получили значение BYDAY (например, из внешнего источника) без остальной строки RRULE.
+func ParseByDay(value string) ([]ByDayEntry, error) {
+	return parseByDay(value)
+}
+
+// WeekdayFromRRULECode переводит двухбуквенный код дня недели RFC 5545 (MO, TU, ...) в time.Weekday.
+func WeekdayFromRRULECode(code string) (time.Weekday, bool) {
+	wd, ok := rruleWeekdayCodes[strings.ToUpper(code)]
+	return wd, ok
+}
+
+// parseByDay разбирает BYDAY=значение, где каждый элемент — опциональный знаковый
+// номер позиции (1, -1, 2 и т.д.) плюс двухбуквенный код дня недели, например "-1SU", "2MO", "FR".
+func parseByDay(value string) ([]ByDayEntry, error) {
+	var entries []ByDayEntry
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(raw)
+		if len(raw) < 2 {
+			return nil, fmt.Errorf("rrule: invalid BYDAY entry %q", raw)
+		}
+		code := raw[len(raw)-2:]
+		wd, ok := rruleWeekdayCodes[strings.ToUpper(code)]
+		if !ok {
+			return nil, fmt.Errorf("rrule: invalid BYDAY weekday %q", code)
+		}
+		pos := 0
+		if posStr := raw[:len(raw)-2]; posStr != "" {
+			n, err := strconv.Atoi(posStr)
+			if err != nil {
+				return nil, fmt.Errorf("rrule: invalid BYDAY position %q", posStr)
+			}
+			pos = n
+		}
+		entries = append(entries, ByDayEntry{Pos: pos, Weekday: wd})
+	}
+	return entries, nil
+}
+
+func joinInts(values []int) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		strs[i] = strconv.Itoa(v)
+	}
+	return strings.Join(strs, ",")
+}
+
+func joinByDay(entries []ByDayEntry) string {
+	strs := make([]string, len(entries))
+	for i, e := range entries {
+		prefix := ""
+		if e.Pos != 0 {
+			prefix = strconv.Itoa(e.Pos)
+		}
+		strs[i] = prefix + rruleWeekdayNames[e.Weekday]
+	}
+	return strings.Join(strs, ",")
+}
+
+// EncodeRRULE и DecodeRRULE — канонические имена (де)сериализации строки
+// RRULE. Сама работа делегирована String()/ParseRRULE — тем же, что
+// использует импорт/экспорт внешних календарей (см. RawRrule в
+// calendar_service.go и ev.RRule в import.go). calendar_service.
+// encodeScheduleRule хранит правило расписания как JSON scheduleRuleDTO с
+// отдельными BYxxx-полями и пропускает через эту пару только поле RawRrule
+// (канонизация при сохранении); decodeScheduleRule её не вызывает.
+func EncodeRRULE(rule RecurringRule) string {
+	return rule.String()
+}
+
+func DecodeRRULE(rrule string) (RecurringRule, error) {
+	return ParseRRULE(rrule)
+}
+
+// ExpandRecurringWindow разворачивает одно окно [start, start+duration), опционально
+// повторяемое по rrule (RFC 5545), в конкретные вхождения внутри [queryFrom, queryTo).
+// Используется для MaintenanceWindow: окно задаётся одной парой start/end плюс
+// необязательным RRULE, в отличие от Schedule/RecurringRule, которые уже несут
+// полный набор BY*-полей отдельно.
+func ExpandRecurringWindow(start, end time.Time, rrule string, queryFrom, queryTo time.Time) ([]TimeRange, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("rrule: window end must be after start")
+	}
+	if rrule == "" {
+		full := TimeRange{Start: start, End: end}
+		if !full.End.After(queryFrom) || !full.Start.Before(queryTo) {
+			return nil, nil
+		}
+		return []TimeRange{full}, nil
+	}
+
+	rule, err := ParseRRULE(rrule)
+	if err != nil {
+		return nil, fmt.Errorf("rrule: %w", err)
+	}
+	rule.StartTime = start
+	rule.Duration = end.Sub(start)
+	return ExpandRecurringRule(rule, TimeRange{Start: queryFrom, End: queryTo})
+}
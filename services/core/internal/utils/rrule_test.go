@@ -0,0 +1,419 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+)
+
+// TestExpandRecurringRule_RFC5545_EveryOtherWeekMWFUntil — канонический пример
+// RFC 5545 §3.8.5.3 ("Every other week on Monday, Wednesday, and Friday
+// until December 24, 1997"), адаптированный на 2025 год (чтобы пользоваться
+// тем же UTC-якорем, что остальные тесты пакета, без привязки к конкретной
+// TZID): FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR;UNTIL=...
+func TestExpandRecurringRule_RFC5545_EveryOtherWeekMWFUntil(t *testing.T) {
+	rule, err := ParseRRULE("FREQ=WEEKLY;INTERVAL=2;UNTIL=20250224T000000Z;WKST=MO;BYDAY=MO,WE,FR")
+	if err != nil {
+		t.Fatalf("ParseRRULE: %v", err)
+	}
+	rule.StartTime = mustTime(t, 2025, 1, 6, 9, 0) // Monday
+	rule.Duration = time.Hour
+
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 0, 0), End: mustTime(t, 2025, 3, 1, 0, 0)}
+	got, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	wantDays := []int{6, 8, 10, 20, 22, 24, 3, 5, 7, 17, 19, 21}
+	wantMonths := []time.Month{
+		time.January, time.January, time.January,
+		time.January, time.January, time.January,
+		time.February, time.February, time.February,
+		time.February, time.February, time.February,
+	}
+	if len(got) != len(wantDays) {
+		t.Fatalf("expected %d occurrences, got %d: %+v", len(wantDays), len(got), got)
+	}
+	for i, occ := range got {
+		want := mustTime(t, 2025, wantMonths[i], wantDays[i], 9, 0)
+		if !occ.Start.Equal(want) {
+			t.Errorf("occurrence %d: expected %v, got %v", i, want, occ.Start)
+		}
+	}
+}
+
+func TestExpandRecurringRule_MonthlyByDayLastSunday(t *testing.T) {
+	start := mustTime(t, 2025, 1, 1, 9, 0)
+	count := 3
+	rule := RecurringRule{
+		Freq:      FreqMonthly,
+		Interval:  1,
+		StartTime: start,
+		Duration:  time.Hour,
+		ByDay:     []ByDayEntry{{Pos: -1, Weekday: time.Sunday}},
+		Count:     &count,
+	}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 0, 0), End: mustTime(t, 2025, 6, 1, 0, 0)}
+
+	got, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 26, 9, 0), End: mustTime(t, 2025, 1, 26, 10, 0)},
+		{Start: mustTime(t, 2025, 2, 23, 9, 0), End: mustTime(t, 2025, 2, 23, 10, 0)},
+		{Start: mustTime(t, 2025, 3, 30, 9, 0), End: mustTime(t, 2025, 3, 30, 10, 0)},
+	}
+	if !equalTimeRangeSlices(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestExpandRecurringRule_YearlyByMonthAndMonthDay(t *testing.T) {
+	start := mustTime(t, 2025, 3, 15, 8, 0)
+	until := mustTime(t, 2027, 1, 1, 0, 0)
+	rule := RecurringRule{
+		Freq:       FreqYearly,
+		Interval:   1,
+		StartTime:  start,
+		Duration:   30 * time.Minute,
+		ByMonth:    []int{3},
+		ByMonthDay: []int{15},
+		Until:      &until,
+	}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 0, 0), End: mustTime(t, 2027, 1, 1, 0, 0)}
+
+	got, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TimeRange{
+		{Start: mustTime(t, 2025, 3, 15, 8, 0), End: mustTime(t, 2025, 3, 15, 8, 30)},
+		{Start: mustTime(t, 2026, 3, 15, 8, 0), End: mustTime(t, 2026, 3, 15, 8, 30)},
+	}
+	if !equalTimeRangeSlices(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestExpandRecurringRule_YearlyByYearDay(t *testing.T) {
+	start := mustTime(t, 2025, 1, 1, 9, 0)
+	until := mustTime(t, 2026, 1, 1, 0, 0)
+	rule := RecurringRule{
+		Freq:      FreqYearly,
+		Interval:  1,
+		StartTime: start,
+		Duration:  time.Hour,
+		ByYearDay: []int{1, -1},
+		Until:     &until,
+	}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 0, 0), End: mustTime(t, 2026, 1, 1, 0, 0)}
+
+	got, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 9, 0), End: mustTime(t, 2025, 1, 1, 10, 0)},
+		{Start: mustTime(t, 2025, 12, 31, 9, 0), End: mustTime(t, 2025, 12, 31, 10, 0)},
+	}
+	if !equalTimeRangeSlices(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestExpandRecurringRule_YearlyByWeekNo(t *testing.T) {
+	start := mustTime(t, 2025, 1, 1, 9, 0)
+	until := mustTime(t, 2026, 1, 1, 0, 0)
+	rule := RecurringRule{
+		Freq:      FreqYearly,
+		Interval:  1,
+		StartTime: start,
+		Duration:  time.Hour,
+		ByWeekNo:  []int{20},
+		ByDay:     []ByDayEntry{{Weekday: time.Monday}},
+		Until:     &until,
+	}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 0, 0), End: mustTime(t, 2026, 1, 1, 0, 0)}
+
+	got, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TimeRange{
+		{Start: mustTime(t, 2025, 5, 12, 9, 0), End: mustTime(t, 2025, 5, 12, 10, 0)},
+	}
+	if !equalTimeRangeSlices(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestExpandRecurringRule_ByDaySetPos(t *testing.T) {
+	// "Второй будний день месяца" — BYDAY=MO,TU,WE,TH,FR;BYSETPOS=2.
+	start := mustTime(t, 2025, 1, 1, 9, 0)
+	count := 2
+	rule := RecurringRule{
+		Freq:      FreqMonthly,
+		Interval:  1,
+		StartTime: start,
+		Duration:  time.Hour,
+		ByDay: []ByDayEntry{
+			{Weekday: time.Monday},
+			{Weekday: time.Tuesday},
+			{Weekday: time.Wednesday},
+			{Weekday: time.Thursday},
+			{Weekday: time.Friday},
+		},
+		BySetPos: []int{2},
+		Count:    &count,
+	}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 0, 0), End: mustTime(t, 2025, 12, 31, 0, 0)}
+
+	got, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 occurrences, got %d: %+v", len(got), got)
+	}
+	// Январь 2025: 1 января — среда, значит второй будний день — 2 января.
+	want0 := TimeRange{Start: mustTime(t, 2025, 1, 2, 9, 0), End: mustTime(t, 2025, 1, 2, 10, 0)}
+	if !equalTimeRange(got[0], want0) {
+		t.Fatalf("expected first occurrence %+v, got %+v", want0, got[0])
+	}
+}
+
+func TestExpandRecurringRule_ExDates(t *testing.T) {
+	start := mustTime(t, 2025, 1, 1, 10, 0)
+	count := 3
+	rule := RecurringRule{
+		Freq:      FreqDaily,
+		Interval:  1,
+		StartTime: start,
+		Duration:  time.Hour,
+		Count:     &count,
+		ExDates:   []time.Time{mustTime(t, 2025, 1, 2, 10, 0)},
+	}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 0, 0), End: mustTime(t, 2025, 1, 10, 0, 0)}
+
+	got, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// COUNT=3 ограничивает вхождения самого правила (1,2,3 января), EXDATE
+	// затем вычёркивает 2 января — восстанавливать его место 4-м числом не нужно.
+	want := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)},
+		{Start: mustTime(t, 2025, 1, 3, 10, 0), End: mustTime(t, 2025, 1, 3, 11, 0)},
+	}
+	if !equalTimeRangeSlices(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestExpandRecurringRule_RDates(t *testing.T) {
+	start := mustTime(t, 2025, 1, 1, 10, 0)
+	count := 1
+	rule := RecurringRule{
+		Freq:      FreqDaily,
+		Interval:  1,
+		StartTime: start,
+		Duration:  time.Hour,
+		Count:     &count,
+		RDates:    []time.Time{mustTime(t, 2025, 1, 5, 14, 0)},
+	}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 0, 0), End: mustTime(t, 2025, 1, 10, 0, 0)}
+
+	got, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TimeRange{
+		{Start: mustTime(t, 2025, 1, 1, 10, 0), End: mustTime(t, 2025, 1, 1, 11, 0)},
+		{Start: mustTime(t, 2025, 1, 5, 14, 0), End: mustTime(t, 2025, 1, 5, 15, 0)},
+	}
+	if !equalTimeRangeSlices(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestParseRRULE_MonthlyLastSunday(t *testing.T) {
+	rule, err := ParseRRULE("FREQ=MONTHLY;BYDAY=-1SU;INTERVAL=2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.Freq != FreqMonthly {
+		t.Fatalf("expected FreqMonthly, got %v", rule.Freq)
+	}
+	if rule.Interval != 2 {
+		t.Fatalf("expected Interval=2, got %d", rule.Interval)
+	}
+	if len(rule.ByDay) != 1 || rule.ByDay[0].Pos != -1 || rule.ByDay[0].Weekday != time.Sunday {
+		t.Fatalf("unexpected ByDay: %+v", rule.ByDay)
+	}
+}
+
+func TestParseRRULE_YearlyByYearDayAndWeekNo(t *testing.T) {
+	rule, err := ParseRRULE("FREQ=YEARLY;BYYEARDAY=1,-1;BYWEEKNO=20")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rule.ByYearDay) != 2 || rule.ByYearDay[0] != 1 || rule.ByYearDay[1] != -1 {
+		t.Fatalf("unexpected ByYearDay: %+v", rule.ByYearDay)
+	}
+	if len(rule.ByWeekNo) != 1 || rule.ByWeekNo[0] != 20 {
+		t.Fatalf("unexpected ByWeekNo: %+v", rule.ByWeekNo)
+	}
+}
+
+func TestParseRRULE_InvalidFreq(t *testing.T) {
+	if _, err := ParseRRULE("FREQ=HOURLY"); err == nil {
+		t.Fatalf("expected error for unsupported FREQ")
+	}
+}
+
+func TestParseRRULE_MissingFreq(t *testing.T) {
+	if _, err := ParseRRULE("INTERVAL=2"); err == nil {
+		t.Fatalf("expected error when FREQ is missing")
+	}
+}
+
+func TestEncodeDecodeRRULE_LastWeekdayOfMonth(t *testing.T) {
+	// "Последний понедельник месяца, каждые 2 месяца" — BYDAY=-1MO, через
+	// EncodeRRULE/DecodeRRULE должен пережить round trip без потерь.
+	rule := RecurringRule{
+		Freq:     FreqMonthly,
+		Interval: 2,
+		ByDay:    []ByDayEntry{{Pos: -1, Weekday: time.Monday}},
+	}
+	encoded := EncodeRRULE(rule)
+	decoded, err := DecodeRRULE(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error decoding %q: %v", encoded, err)
+	}
+	if decoded.Freq != rule.Freq || decoded.Interval != rule.Interval {
+		t.Fatalf("round trip mismatch: %+v vs %+v", rule, decoded)
+	}
+	if len(decoded.ByDay) != 1 || decoded.ByDay[0].Pos != -1 || decoded.ByDay[0].Weekday != time.Monday {
+		t.Fatalf("unexpected ByDay after round trip: %+v", decoded.ByDay)
+	}
+}
+
+func TestEncodeDecodeRRULE_EverySecondTuesdayBySetPos(t *testing.T) {
+	// "Каждый второй вторник месяца" — BYDAY=TU;BYSETPOS=2, а не BYDAY=2TU
+	// (позиция относится к отфильтрованному по BYDAY набору дня, а не к
+	// позиции дня недели внутри месяца напрямую).
+	rule := RecurringRule{
+		Freq:     FreqMonthly,
+		Interval: 1,
+		ByDay:    []ByDayEntry{{Weekday: time.Tuesday}},
+		BySetPos: []int{2},
+	}
+	encoded := EncodeRRULE(rule)
+	decoded, err := DecodeRRULE(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error decoding %q: %v", encoded, err)
+	}
+	if len(decoded.ByDay) != 1 || decoded.ByDay[0].Pos != 0 || decoded.ByDay[0].Weekday != time.Tuesday {
+		t.Fatalf("unexpected ByDay after round trip: %+v", decoded.ByDay)
+	}
+	if len(decoded.BySetPos) != 1 || decoded.BySetPos[0] != 2 {
+		t.Fatalf("unexpected BySetPos after round trip: %+v", decoded.BySetPos)
+	}
+
+	start := mustTime(t, 2025, 1, 1, 9, 0)
+	count := 1
+	decoded.StartTime = start
+	decoded.Duration = time.Hour
+	decoded.Count = &count
+	window := TimeRange{Start: mustTime(t, 2025, 1, 1, 0, 0), End: mustTime(t, 2025, 2, 1, 0, 0)}
+	got, err := ExpandRecurringRule(decoded, window)
+	if err != nil {
+		t.Fatalf("unexpected error expanding: %v", err)
+	}
+	// Январь 2025: вторники — 7, 14, 21, 28 — второй вторник это 14-е.
+	want := []TimeRange{{Start: mustTime(t, 2025, 1, 14, 9, 0), End: mustTime(t, 2025, 1, 14, 10, 0)}}
+	if !equalTimeRangeSlices(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestExpandRecurringRule_ExDateAcrossDSTSpringForward(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("load location: %v", err)
+	}
+	// 2025-03-09 02:00 America/New_York — переход на летнее время: исключаем
+	// именно вхождение, чьё wall-clock время приходится на дату перехода, по
+	// тому же смещению, что рассчитывает RRULE-движок (в отличие от сравнения
+	// по UTC, которое из-за сдвига в день перехода не совпало бы).
+	start := time.Date(2025, 3, 2, 9, 0, 0, 0, loc)
+	count := 3
+	rule := RecurringRule{
+		Freq:      FreqWeekly,
+		Interval:  1,
+		StartTime: start,
+		Duration:  time.Hour,
+		Count:     &count,
+		ExDates:   []time.Time{time.Date(2025, 3, 9, 9, 0, 0, 0, loc)},
+	}
+	window := TimeRange{Start: time.Date(2025, 3, 1, 0, 0, 0, 0, loc), End: time.Date(2025, 4, 1, 0, 0, 0, 0, loc)}
+
+	got, err := ExpandRecurringRule(rule, window)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []TimeRange{
+		{Start: time.Date(2025, 3, 2, 9, 0, 0, 0, loc), End: time.Date(2025, 3, 2, 10, 0, 0, 0, loc)},
+		{Start: time.Date(2025, 3, 16, 9, 0, 0, 0, loc), End: time.Date(2025, 3, 16, 10, 0, 0, 0, loc)},
+	}
+	if !equalTimeRangeSlices(got, want) {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestRecurringRule_StringRoundTrip(t *testing.T) {
+	rule := RecurringRule{
+		Freq:     FreqWeekly,
+		Interval: 2,
+		ByDay:    []ByDayEntry{{Weekday: time.Monday}, {Weekday: time.Wednesday}},
+	}
+	s := rule.String()
+	reparsed, err := ParseRRULE(s)
+	if err != nil {
+		t.Fatalf("unexpected error reparsing %q: %v", s, err)
+	}
+	if reparsed.Freq != rule.Freq || reparsed.Interval != rule.Interval {
+		t.Fatalf("round trip mismatch: %+v vs %+v", rule, reparsed)
+	}
+}
+
+// FuzzParseRRULE_StringRoundTrip проверяет, что для любой строки, которую
+// ParseRRULE способен разобрать, её String()-представление парсится заново
+// с теми же FREQ и INTERVAL (они не "теряются" при повторной сериализации).
+func FuzzParseRRULE_StringRoundTrip(f *testing.F) {
+	seeds := []string{
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE,FR;UNTIL=20250224T000000Z",
+		"FREQ=DAILY;COUNT=5",
+		"FREQ=MONTHLY;BYDAY=-1SU",
+		"FREQ=YEARLY;BYMONTH=12;BYMONTHDAY=25",
+		"FREQ=MONTHLY;BYSETPOS=2;BYDAY=TU,WE,TH",
+	}
+	for _, s := range seeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, rrule string) {
+		rule, err := ParseRRULE(rrule)
+		if err != nil {
+			t.Skip()
+		}
+		s := rule.String()
+		reparsed, err := ParseRRULE(s)
+		if err != nil {
+			t.Fatalf("ParseRRULE(%q) failed to reparse String() output %q: %v", rrule, s, err)
+		}
+		if reparsed.Freq != rule.Freq || reparsed.Interval != rule.Interval {
+			t.Fatalf("round trip mismatch for %q: %+v vs %+v", rrule, rule, reparsed)
+		}
+	})
+}
@@ -0,0 +1,429 @@
+package calendar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	ErrIntervalCrossesMidnight = errors.New("interval must not cross midnight, split it into two")
+	ErrIntervalsOverlap        = errors.New("intervals overlap")
+	ErrInvalidTimeOfDay        = errors.New("invalid time of day")
+)
+
+// TimeOfDay — время суток в рамках одного дня (без даты), с точностью до минуты.
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+}
+
+func (t TimeOfDay) minutes() int { return t.Hour*60 + t.Minute }
+
+func (t TimeOfDay) valid() bool {
+	return t.Hour >= 0 && t.Hour <= 23 && t.Minute >= 0 && t.Minute <= 59
+}
+
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", t.Hour, t.Minute)
+}
+
+func parseTimeOfDay(s string) (TimeOfDay, error) {
+	var t TimeOfDay
+	if _, err := fmt.Sscanf(s, "%d:%d", &t.Hour, &t.Minute); err != nil {
+		return TimeOfDay{}, fmt.Errorf("%w: %q", ErrInvalidTimeOfDay, s)
+	}
+	if !t.valid() {
+		return TimeOfDay{}, fmt.Errorf("%w: %q", ErrInvalidTimeOfDay, s)
+	}
+	return t, nil
+}
+
+// DayInterval — интервал в рамках одного дня, заданный парой времени суток.
+type DayInterval struct {
+	Start TimeOfDay
+	End   TimeOfDay
+}
+
+// DateOverride — переопределение интервалов для конкретной календарной даты
+// (праздник, санитарный день и т.п.), приоритетнее расписания по дню недели.
+type DateOverride struct {
+	Date      time.Time // учитывается только год/месяц/день
+	Intervals []DayInterval
+}
+
+// WeeklyTemplate — недельный шаблон доступности: набор интервалов на каждый
+// день недели плюс точечные переопределения по датам. Интервалы заданы в
+// wall-clock времени внутри Location шаблона.
+type WeeklyTemplate struct {
+	Location  *time.Location
+	Days      map[time.Weekday][]DayInterval
+	Overrides []DateOverride
+}
+
+// Validate проверяет, что интервалы в Days и Overrides не пересекаются внутри
+// одного дня и не пересекают полночь (такой интервал нужно разбить на два).
+func (t WeeklyTemplate) Validate() error {
+	for wd, intervals := range t.Days {
+		if err := validateDayIntervals(intervals); err != nil {
+			return fmt.Errorf("weekday %s: %w", wd, err)
+		}
+	}
+	for _, ov := range t.Overrides {
+		if err := validateDayIntervals(ov.Intervals); err != nil {
+			return fmt.Errorf("override %s: %w", ov.Date.Format("2006-01-02"), err)
+		}
+	}
+	return nil
+}
+
+func validateDayIntervals(intervals []DayInterval) error {
+	for _, iv := range intervals {
+		if !iv.Start.valid() || !iv.End.valid() {
+			return ErrInvalidTimeOfDay
+		}
+		if iv.End.minutes() <= iv.Start.minutes() {
+			return ErrIntervalCrossesMidnight
+		}
+	}
+
+	sorted := make([]DayInterval, len(intervals))
+	copy(sorted, intervals)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start.minutes() < sorted[j].Start.minutes() })
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Start.minutes() < sorted[i-1].End.minutes() {
+			return ErrIntervalsOverlap
+		}
+	}
+	return nil
+}
+
+// Materialize разворачивает шаблон в конкретные TimeRange внутри окна window
+// (которое интерпретируется в Location шаблона): для каждого календарного
+// дня берутся интервалы переопределения, если они заданы, иначе — интервалы
+// дня недели; каждый интервал переводится в абсолютное время и нарезается на
+// слоты через SplitToTimeSlots.
+//
+// DST-переходы обрабатываются на уровне конкретного интервала: если начало
+// или конец интервала приходится на несуществующее wall-clock время (весенний
+// перевод вперёд) — интервал для этого дня пропускается; если wall-clock
+// время неоднозначно (осенний перевод назад) — интервал дублируется для
+// обоих фактических моментов.
+func (t WeeklyTemplate) Materialize(window TimeRange, slotDuration time.Duration, alignMinutes int) ([]TimeRange, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	loc := t.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	if !window.End.After(window.Start) {
+		return []TimeRange{}, nil
+	}
+
+	overridesByDate := make(map[string][]DayInterval, len(t.Overrides))
+	for _, ov := range t.Overrides {
+		overridesByDate[ov.Date.In(loc).Format("2006-01-02")] = ov.Intervals
+	}
+
+	var result []TimeRange
+
+	cur := time.Date(window.Start.In(loc).Year(), window.Start.In(loc).Month(), window.Start.In(loc).Day(), 0, 0, 0, 0, loc)
+	for !cur.After(window.End) {
+		key := cur.Format("2006-01-02")
+		intervals, ok := overridesByDate[key]
+		if !ok {
+			intervals = t.Days[cur.Weekday()]
+		}
+
+		for _, iv := range intervals {
+			ranges, err := resolveDayInterval(loc, cur, iv)
+			if err != nil {
+				return nil, err
+			}
+			for _, r := range ranges {
+				if !rangesOverlap(r, window, false) {
+					continue
+				}
+				slots, err := SplitToTimeSlots(r, slotDuration, alignMinutes)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, slots...)
+			}
+		}
+
+		cur = cur.AddDate(0, 0, 1)
+	}
+
+	return result, nil
+}
+
+// Contains сообщает, попадает ли момент at в одно из рабочих окон шаблона:
+// переопределение по дате (Overrides), если оно задано для этого календарного
+// дня, иначе интервалы дня недели (Days). Тот же DST-учёт, что в Materialize:
+// неоднозначные (осенний перевод часов) wall-clock интервалы проверяются в
+// обоих фактических вхождениях.
+func (t WeeklyTemplate) Contains(at time.Time) bool {
+	loc := t.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	at = at.In(loc)
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc)
+
+	intervals, ok := t.overridesFor(day, loc)
+	if !ok {
+		intervals = t.Days[day.Weekday()]
+	}
+
+	for _, iv := range intervals {
+		ranges, err := resolveDayInterval(loc, day, iv)
+		if err != nil {
+			continue
+		}
+		for _, r := range ranges {
+			if !at.Before(r.Start) && at.Before(r.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NextStart возвращает начало ближайшего рабочего интервала в момент at или
+// позже; если at уже внутри рабочего интервала, возвращает at. Поиск
+// ограничен nextStartSearchDays вперёд (с запасом на годовые Overrides,
+// закрывающие обычный недельный паттерн) — если за это время не нашлось ни
+// одного интервала (например, Days пуст), возвращает нулевое время.
+func (t WeeklyTemplate) NextStart(at time.Time) time.Time {
+	loc := t.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	at = at.In(loc)
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, loc)
+
+	const nextStartSearchDays = 366
+	for i := 0; i <= nextStartSearchDays; i++ {
+		cur := day.AddDate(0, 0, i)
+
+		intervals, ok := t.overridesFor(cur, loc)
+		if !ok {
+			intervals = t.Days[cur.Weekday()]
+		}
+
+		var dayRanges []TimeRange
+		for _, iv := range intervals {
+			ranges, err := resolveDayInterval(loc, cur, iv)
+			if err != nil {
+				continue
+			}
+			dayRanges = append(dayRanges, ranges...)
+		}
+		sort.Slice(dayRanges, func(a, b int) bool { return dayRanges[a].Start.Before(dayRanges[b].Start) })
+
+		for _, r := range dayRanges {
+			if !r.End.After(at) {
+				continue
+			}
+			if r.Start.After(at) {
+				return r.Start
+			}
+			return at
+		}
+	}
+	return time.Time{}
+}
+
+// overridesFor возвращает интервалы переопределения для календарного дня day
+// (должен быть полночью в loc) и true, если для этой даты задан Override —
+// в т.ч. Override с пустым Intervals (явно закрытый день), который отличается
+// от отсутствия Override (откат на Days) тем, что не должен откатываться.
+func (t WeeklyTemplate) overridesFor(day time.Time, loc *time.Location) ([]DayInterval, bool) {
+	key := day.In(loc).Format("2006-01-02")
+	for _, ov := range t.Overrides {
+		if ov.Date.In(loc).Format("2006-01-02") == key {
+			return ov.Intervals, true
+		}
+	}
+	return nil, false
+}
+
+// resolveDayInterval переводит DayInterval в абсолютные TimeRange для
+// календарного дня day (сам day должен быть полночью в loc), учитывая DST.
+func resolveDayInterval(loc *time.Location, day time.Time, iv DayInterval) ([]TimeRange, error) {
+	start, startAlt, startSkip := resolveWallClock(loc, day.Year(), day.Month(), day.Day(), iv.Start.Hour, iv.Start.Minute)
+	end, endAlt, endSkip := resolveWallClock(loc, day.Year(), day.Month(), day.Day(), iv.End.Hour, iv.End.Minute)
+
+	if startSkip || endSkip {
+		return nil, nil
+	}
+
+	ranges := []TimeRange{{Start: start, End: end}}
+	switch {
+	case startAlt != nil && endAlt != nil:
+		// Оба конца интервала неоднозначны — интервал целиком повторяется
+		// дважды (до и после перевода часов назад).
+		ranges = append(ranges, TimeRange{Start: *startAlt, End: *endAlt})
+	case startAlt != nil:
+		ranges = append(ranges, TimeRange{Start: *startAlt, End: end})
+	case endAlt != nil:
+		ranges = append(ranges, TimeRange{Start: start, End: *endAlt})
+	}
+	return ranges, nil
+}
+
+// resolveWallClock строит абсолютный момент для заданного wall-clock времени
+// в loc. skip=true означает, что такого момента не существует (весенний
+// перевод часов). alt, если не nil, — второй момент с тем же wall-clock
+// временем при неоднозначности (осенний перевод часов).
+func resolveWallClock(loc *time.Location, year int, month time.Month, day, hour, minute int) (primary time.Time, alt *time.Time, skip bool) {
+	primary = time.Date(year, month, day, hour, minute, 0, 0, loc)
+	if primary.Hour() != hour || primary.Minute() != minute {
+		return time.Time{}, nil, true
+	}
+
+	candidate := primary.Add(time.Hour)
+	if candidate.Hour() == hour && candidate.Minute() == minute {
+		_, off1 := primary.Zone()
+		_, off2 := candidate.Zone()
+		if off1 != off2 {
+			alt = &candidate
+		}
+	}
+	return primary, alt, false
+}
+
+// ===== JSON (де)сериализация для хранения в model.Provider.BusinessHours =====
+
+type weeklyTemplateJSON struct {
+	Location  string                      `json:"location"`
+	Days      map[string][]dayIntervalDTO `json:"days,omitempty"`
+	Overrides []dateOverrideDTO           `json:"overrides,omitempty"`
+}
+
+type dayIntervalDTO struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type dateOverrideDTO struct {
+	Date      string           `json:"date"`
+	Intervals []dayIntervalDTO `json:"intervals"`
+}
+
+var weekdayNames = map[time.Weekday]string{
+	time.Sunday:    "sunday",
+	time.Monday:    "monday",
+	time.Tuesday:   "tuesday",
+	time.Wednesday: "wednesday",
+	time.Thursday:  "thursday",
+	time.Friday:    "friday",
+	time.Saturday:  "saturday",
+}
+
+var weekdayByName = func() map[string]time.Weekday {
+	m := make(map[string]time.Weekday, len(weekdayNames))
+	for wd, name := range weekdayNames {
+		m[name] = wd
+	}
+	return m
+}()
+
+func (t WeeklyTemplate) MarshalJSON() ([]byte, error) {
+	dto := weeklyTemplateJSON{Location: "UTC"}
+	if t.Location != nil {
+		dto.Location = t.Location.String()
+	}
+
+	if len(t.Days) > 0 {
+		dto.Days = make(map[string][]dayIntervalDTO, len(t.Days))
+		for wd, intervals := range t.Days {
+			name, ok := weekdayNames[wd]
+			if !ok {
+				return nil, fmt.Errorf("weekly template: invalid weekday %d", wd)
+			}
+			dto.Days[name] = toIntervalDTOs(intervals)
+		}
+	}
+
+	for _, ov := range t.Overrides {
+		dto.Overrides = append(dto.Overrides, dateOverrideDTO{
+			Date:      ov.Date.Format("2006-01-02"),
+			Intervals: toIntervalDTOs(ov.Intervals),
+		})
+	}
+
+	return json.Marshal(dto)
+}
+
+func (t *WeeklyTemplate) UnmarshalJSON(data []byte) error {
+	var dto weeklyTemplateJSON
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(dto.Location)
+	if err != nil {
+		loc = time.UTC
+	}
+	t.Location = loc
+
+	if len(dto.Days) > 0 {
+		t.Days = make(map[time.Weekday][]DayInterval, len(dto.Days))
+		for name, intervals := range dto.Days {
+			wd, ok := weekdayByName[name]
+			if !ok {
+				return fmt.Errorf("weekly template: unknown weekday %q", name)
+			}
+			dayIntervals, err := fromIntervalDTOs(intervals)
+			if err != nil {
+				return err
+			}
+			t.Days[wd] = dayIntervals
+		}
+	}
+
+	t.Overrides = nil
+	for _, ov := range dto.Overrides {
+		date, err := time.ParseInLocation("2006-01-02", ov.Date, loc)
+		if err != nil {
+			return fmt.Errorf("weekly template: invalid override date %q: %w", ov.Date, err)
+		}
+		intervals, err := fromIntervalDTOs(ov.Intervals)
+		if err != nil {
+			return err
+		}
+		t.Overrides = append(t.Overrides, DateOverride{Date: date, Intervals: intervals})
+	}
+
+	return nil
+}
+
+func toIntervalDTOs(intervals []DayInterval) []dayIntervalDTO {
+	dtos := make([]dayIntervalDTO, 0, len(intervals))
+	for _, iv := range intervals {
+		dtos = append(dtos, dayIntervalDTO{Start: iv.Start.String(), End: iv.End.String()})
+	}
+	return dtos
+}
+
+func fromIntervalDTOs(dtos []dayIntervalDTO) ([]DayInterval, error) {
+	intervals := make([]DayInterval, 0, len(dtos))
+	for _, dto := range dtos {
+		start, err := parseTimeOfDay(dto.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseTimeOfDay(dto.End)
+		if err != nil {
+			return nil, err
+		}
+		intervals = append(intervals, DayInterval{Start: start, End: end})
+	}
+	return intervals, nil
+}
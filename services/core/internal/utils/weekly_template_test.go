@@ -0,0 +1,252 @@
+package calendar
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestWeeklyTemplate_Validate_OverlapRejected(t *testing.T) {
+	tmpl := WeeklyTemplate{
+		Location: time.UTC,
+		Days: map[time.Weekday][]DayInterval{
+			time.Monday: {
+				{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}},
+				{Start: TimeOfDay{12, 0}, End: TimeOfDay{18, 0}},
+			},
+		},
+	}
+	if err := tmpl.Validate(); !errors.Is(err, ErrIntervalsOverlap) {
+		t.Fatalf("expected ErrIntervalsOverlap, got %v", err)
+	}
+}
+
+func TestWeeklyTemplate_Validate_CrossesMidnightRejected(t *testing.T) {
+	tmpl := WeeklyTemplate{
+		Days: map[time.Weekday][]DayInterval{
+			time.Monday: {{Start: TimeOfDay{22, 0}, End: TimeOfDay{2, 0}}},
+		},
+	}
+	if err := tmpl.Validate(); !errors.Is(err, ErrIntervalCrossesMidnight) {
+		t.Fatalf("expected ErrIntervalCrossesMidnight, got %v", err)
+	}
+}
+
+func TestWeeklyTemplate_Materialize_MonFriAndSaturday(t *testing.T) {
+	tmpl := WeeklyTemplate{
+		Location: time.UTC,
+		Days: map[time.Weekday][]DayInterval{
+			time.Monday:    {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}, {Start: TimeOfDay{14, 0}, End: TimeOfDay{18, 0}}},
+			time.Tuesday:   {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}, {Start: TimeOfDay{14, 0}, End: TimeOfDay{18, 0}}},
+			time.Wednesday: {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}, {Start: TimeOfDay{14, 0}, End: TimeOfDay{18, 0}}},
+			time.Thursday:  {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}, {Start: TimeOfDay{14, 0}, End: TimeOfDay{18, 0}}},
+			time.Friday:    {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}, {Start: TimeOfDay{14, 0}, End: TimeOfDay{18, 0}}},
+			time.Saturday:  {{Start: TimeOfDay{10, 0}, End: TimeOfDay{14, 0}}},
+		},
+	}
+
+	// 2025-01-06 Monday .. 2025-01-11 Saturday.
+	window := TimeRange{Start: mustTime(t, 2025, 1, 6, 0, 0), End: mustTime(t, 2025, 1, 12, 0, 0)}
+
+	got, err := tmpl.Materialize(window, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Mon-Fri: 4h+4h = 8 hourly slots each * 5 days = 40, Saturday: 4 slots.
+	if len(got) != 44 {
+		t.Fatalf("expected 44 slots, got %d", len(got))
+	}
+}
+
+func TestWeeklyTemplate_Materialize_DateOverride(t *testing.T) {
+	tmpl := WeeklyTemplate{
+		Location: time.UTC,
+		Days: map[time.Weekday][]DayInterval{
+			time.Monday: {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}},
+		},
+		Overrides: []DateOverride{
+			{Date: mustTime(t, 2025, 1, 6, 0, 0), Intervals: nil},
+		},
+	}
+	window := TimeRange{Start: mustTime(t, 2025, 1, 6, 0, 0), End: mustTime(t, 2025, 1, 7, 0, 0)}
+
+	got, err := tmpl.Materialize(window, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected override to suppress all slots, got %+v", got)
+	}
+}
+
+func TestWeeklyTemplate_Materialize_DSTSpringForwardSkips(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	tmpl := WeeklyTemplate{
+		Location: loc,
+		Days: map[time.Weekday][]DayInterval{
+			time.Sunday: {{Start: TimeOfDay{2, 15}, End: TimeOfDay{2, 45}}},
+		},
+	}
+	// 2023-03-12 is a Sunday, clocks spring forward at 2:00 AM -> 3:00 AM.
+	window := TimeRange{
+		Start: time.Date(2023, 3, 12, 0, 0, 0, 0, loc),
+		End:   time.Date(2023, 3, 13, 0, 0, 0, 0, loc),
+	}
+
+	got, err := tmpl.Materialize(window, 30*time.Minute, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Fatalf("expected DST gap interval to be skipped, got %+v", got)
+	}
+}
+
+func TestWeeklyTemplate_Materialize_DSTFallBackDuplicates(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata unavailable: %v", err)
+	}
+	tmpl := WeeklyTemplate{
+		Location: loc,
+		Days: map[time.Weekday][]DayInterval{
+			time.Sunday: {{Start: TimeOfDay{1, 15}, End: TimeOfDay{1, 45}}},
+		},
+	}
+	// 2023-11-05 is a Sunday, clocks fall back at 2:00 AM -> 1:00 AM (1:15-1:45 occurs twice).
+	window := TimeRange{
+		Start: time.Date(2023, 11, 5, 0, 0, 0, 0, loc),
+		End:   time.Date(2023, 11, 6, 0, 0, 0, 0, loc),
+	}
+
+	got, err := tmpl.Materialize(window, 30*time.Minute, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected interval to be duplicated for both DST occurrences, got %d: %+v", len(got), got)
+	}
+	if got[0].Start.Equal(got[1].Start) {
+		t.Fatalf("expected the two occurrences to be distinct instants, got %+v", got)
+	}
+}
+
+func TestWeeklyTemplate_JSONRoundTrip(t *testing.T) {
+	loc, _ := time.LoadLocation("UTC")
+	tmpl := WeeklyTemplate{
+		Location: loc,
+		Days: map[time.Weekday][]DayInterval{
+			time.Monday: {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}},
+		},
+		Overrides: []DateOverride{
+			{Date: mustTime(t, 2025, 1, 6, 0, 0), Intervals: []DayInterval{{Start: TimeOfDay{10, 0}, End: TimeOfDay{11, 0}}}},
+		},
+	}
+
+	data, err := json.Marshal(tmpl)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+
+	var reparsed WeeklyTemplate
+	if err := json.Unmarshal(data, &reparsed); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	if len(reparsed.Days[time.Monday]) != 1 || reparsed.Days[time.Monday][0].Start != (TimeOfDay{9, 0}) {
+		t.Fatalf("unexpected round-tripped days: %+v", reparsed.Days)
+	}
+	if len(reparsed.Overrides) != 1 || !reparsed.Overrides[0].Date.Equal(tmpl.Overrides[0].Date) {
+		t.Fatalf("unexpected round-tripped overrides: %+v", reparsed.Overrides)
+	}
+}
+
+func TestWeeklyTemplate_Contains(t *testing.T) {
+	tmpl := WeeklyTemplate{
+		Location: time.UTC,
+		Days: map[time.Weekday][]DayInterval{
+			time.Monday: {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}, {Start: TimeOfDay{14, 0}, End: TimeOfDay{18, 0}}},
+		},
+		Overrides: []DateOverride{
+			{Date: mustTime(t, 2025, 1, 13, 0, 0), Intervals: nil}, // a Monday, closed.
+		},
+	}
+
+	if !tmpl.Contains(mustTime(t, 2025, 1, 6, 9, 30)) {
+		t.Error("expected 09:30 on a Monday to be inside the morning window")
+	}
+	if tmpl.Contains(mustTime(t, 2025, 1, 6, 13, 30)) {
+		t.Error("expected 13:30 on a Monday to fall in the lunch gap")
+	}
+	if tmpl.Contains(mustTime(t, 2025, 1, 7, 9, 30)) {
+		t.Error("expected Tuesday (no Days entry) not to be open")
+	}
+	if tmpl.Contains(mustTime(t, 2025, 1, 13, 9, 30)) {
+		t.Error("expected the overridden Monday to be closed")
+	}
+}
+
+func TestWeeklyTemplate_NextStart(t *testing.T) {
+	tmpl := WeeklyTemplate{
+		Location: time.UTC,
+		Days: map[time.Weekday][]DayInterval{
+			time.Monday: {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}},
+		},
+	}
+
+	if got := tmpl.NextStart(mustTime(t, 2025, 1, 6, 9, 30)); !got.Equal(mustTime(t, 2025, 1, 6, 9, 30)) {
+		t.Errorf("expected NextStart inside a window to return the same instant, got %v", got)
+	}
+	if got := tmpl.NextStart(mustTime(t, 2025, 1, 6, 14, 0)); !got.Equal(mustTime(t, 2025, 1, 13, 9, 0)) {
+		t.Errorf("expected NextStart after Monday's window to roll to the following Monday, got %v", got)
+	}
+	if got := (WeeklyTemplate{}).NextStart(mustTime(t, 2025, 1, 6, 0, 0)); !got.IsZero() {
+		t.Errorf("expected an empty template to report no next start, got %v", got)
+	}
+}
+
+// TestWeeklyTemplate_ContainsMatchesSplitCoverage — свойство: для случайного
+// момента t внутри window Contains(t) истинно тогда и только тогда, когда
+// SplitToTimeSlotsWithSchedule покрывает t одним из слотов.
+func TestWeeklyTemplate_ContainsMatchesSplitCoverage(t *testing.T) {
+	tmpl := WeeklyTemplate{
+		Location: time.UTC,
+		Days: map[time.Weekday][]DayInterval{
+			time.Monday:    {{Start: TimeOfDay{9, 0}, End: TimeOfDay{13, 0}}, {Start: TimeOfDay{14, 0}, End: TimeOfDay{18, 0}}},
+			time.Wednesday: {{Start: TimeOfDay{10, 0}, End: TimeOfDay{16, 30}}},
+			time.Saturday:  {{Start: TimeOfDay{10, 0}, End: TimeOfDay{14, 0}}},
+		},
+	}
+
+	window := TimeRange{Start: mustTime(t, 2025, 1, 6, 0, 0), End: mustTime(t, 2025, 1, 20, 0, 0)}
+	slotDuration := 30 * time.Minute
+
+	slots, err := SplitToTimeSlotsWithSchedule(window, slotDuration, 0, tmpl)
+	if err != nil {
+		t.Fatalf("SplitToTimeSlotsWithSchedule: %v", err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	windowMinutes := int(window.End.Sub(window.Start).Minutes())
+	for i := 0; i < 500; i++ {
+		at := window.Start.Add(time.Duration(rng.Intn(windowMinutes)) * time.Minute)
+
+		covered := false
+		for _, s := range slots {
+			if !at.Before(s.Start) && at.Before(s.End) {
+				covered = true
+				break
+			}
+		}
+
+		if tmpl.Contains(at) != covered {
+			t.Fatalf("Contains(%v) = %v, but slot coverage = %v", at, tmpl.Contains(at), covered)
+		}
+	}
+}
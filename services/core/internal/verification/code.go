@@ -0,0 +1,42 @@
+// Package verification реализует примитивы для привязки Telegram-аккаунта
+// через короткий человекочитаемый PIN (см. model.VerificationCode,
+// service.VerificationService, cmd/identity-bot): генерацию самого кода и
+// подпись/проверку opaque-токена, которым ConsumeVerificationCode
+// удостоверяет IdentityService.RegisterUser, что владение telegram_id уже
+// проверено ботом.
+package verification
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// codeAlphabet — 31 символ: цифры 2–9 и латинские буквы без похожих на
+// цифры I, L и O (0 и 1 тоже исключены),
+// чтобые human не путал буквы и цифры, диктуя код в чат боту.
+const codeAlphabet = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// codeLength — длина PIN, как в запросе ("6 символов, base32").
+const codeLength = 6
+
+// GenerateCode генерирует случайный codeLength-символьный PIN из
+// codeAlphabet, в верхнем регистре.
+func GenerateCode() (string, error) {
+	buf := make([]byte, codeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.Grow(codeLength)
+	for _, b := range buf {
+		sb.WriteByte(codeAlphabet[int(b)%len(codeAlphabet)])
+	}
+	return sb.String(), nil
+}
+
+// NormalizeCode приводит введённый пользователем код к форме, в которой он
+// хранится и сравнивается (верхний регистр, без пробелов) — проверка кода
+// регистронезависима, как того требует запрос.
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
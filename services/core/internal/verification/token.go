@@ -0,0 +1,55 @@
+package verification
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignToken подписывает telegramID+expiresAt секретом secret через
+// HMAC-SHA256 (см. icalendar.SignProviderToken — тот же приём, но с
+// истечением: verification_token одноразово удостоверяет факт недавнего
+// ConsumeVerificationCode, а не постоянный доступ к ресурсу). Формат —
+// "<telegram_id>.<expires_unix>.<hmac_hex>", чтобы RegisterUser мог
+// проверить токен без похода в БД.
+func SignToken(secret []byte, telegramID int64, expiresAt time.Time) string {
+	payload := tokenPayload(telegramID, expiresAt.Unix())
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return fmt.Sprintf("%s.%s", payload, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// VerifyToken проверяет токен, выданный SignToken для telegramID: подпись
+// константным по времени сравнением и срок действия относительно now.
+func VerifyToken(secret []byte, token string, telegramID int64, now time.Time) bool {
+	parts := strings.SplitN(token, ".", 3)
+	if len(parts) != 3 {
+		return false
+	}
+	gotTelegramID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || gotTelegramID != telegramID {
+		return false
+	}
+	expiresUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return false
+	}
+	if now.After(time.Unix(expiresUnix, 0)) {
+		return false
+	}
+
+	payload := tokenPayload(telegramID, expiresUnix)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(parts[2])) == 1
+}
+
+func tokenPayload(telegramID, expiresUnix int64) string {
+	return fmt.Sprintf("%d.%d", telegramID, expiresUnix)
+}
@@ -0,0 +1,69 @@
+package verification
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateCode_LengthAndAlphabet(t *testing.T) {
+	code, err := GenerateCode()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(code) != codeLength {
+		t.Fatalf("expected length %d, got %d (%q)", codeLength, len(code), code)
+	}
+	for _, r := range code {
+		if !strings.ContainsRune(codeAlphabet, r) {
+			t.Fatalf("code %q contains character %q outside codeAlphabet", code, r)
+		}
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	if got := NormalizeCode("  a1b2c3  "); got != "A1B2C3" {
+		t.Errorf("NormalizeCode = %q, want %q", got, "A1B2C3")
+	}
+}
+
+func TestSignVerifyToken_RoundTrip(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Date(2026, 7, 30, 12, 0, 0, 0, time.UTC)
+	token := SignToken(secret, 42, now.Add(10*time.Minute))
+
+	if !VerifyToken(secret, token, 42, now) {
+		t.Fatalf("expected token to verify for telegram_id=42 at issuance time")
+	}
+}
+
+func TestVerifyToken_RejectsExpired(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Date(2026, 7, 30, 12, 0, 0, 0, time.UTC)
+	token := SignToken(secret, 42, now.Add(-time.Second))
+
+	if VerifyToken(secret, token, 42, now) {
+		t.Fatalf("expected expired token to fail verification")
+	}
+}
+
+func TestVerifyToken_RejectsWrongTelegramID(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Date(2026, 7, 30, 12, 0, 0, 0, time.UTC)
+	token := SignToken(secret, 42, now.Add(10*time.Minute))
+
+	if VerifyToken(secret, token, 43, now) {
+		t.Fatalf("expected token issued for telegram_id=42 to fail for telegram_id=43")
+	}
+}
+
+func TestVerifyToken_RejectsTamperedSignature(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Date(2026, 7, 30, 12, 0, 0, 0, time.UTC)
+	token := SignToken(secret, 42, now.Add(10*time.Minute))
+
+	tampered := token[:len(token)-1] + "0"
+	if VerifyToken(secret, tampered, 42, now) {
+		t.Fatalf("expected tampered token to fail verification")
+	}
+}